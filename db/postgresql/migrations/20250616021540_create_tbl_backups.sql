@@ -0,0 +1,30 @@
+-- +goose Up
+-- DATABASE BACKUPS TABLE
+CREATE TABLE tbl_backups (
+    id SERIAL PRIMARY KEY,
+    backup_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    format VARCHAR NOT NULL,
+    spaces TEXT[] NOT NULL DEFAULT '{}',
+    file_path VARCHAR,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    checksum VARCHAR,
+    space_count INTEGER NOT NULL DEFAULT 0,
+    tuple_count BIGINT NOT NULL DEFAULT 0,
+    job_status VARCHAR NOT NULL,
+    error_message TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_backups_db_id ON tbl_backups(db_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_backups;
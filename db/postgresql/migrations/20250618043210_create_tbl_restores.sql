@@ -0,0 +1,31 @@
+-- +goose Up
+-- DATABASE RESTORES TABLE
+CREATE TABLE tbl_restores (
+    id SERIAL PRIMARY KEY,
+    restore_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    backup_id INTEGER REFERENCES tbl_backups(id) ON DELETE SET NULL,
+    file_path VARCHAR NOT NULL,
+    mode VARCHAR NOT NULL,
+    batch_size INTEGER NOT NULL DEFAULT 500,
+    plan JSONB NOT NULL DEFAULT '{}',
+    progress JSONB NOT NULL DEFAULT '[]',
+    total_tuples BIGINT NOT NULL DEFAULT 0,
+    restored_tuples BIGINT NOT NULL DEFAULT 0,
+    job_status VARCHAR NOT NULL,
+    error_message TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_restores_db_id ON tbl_restores(db_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_restores;
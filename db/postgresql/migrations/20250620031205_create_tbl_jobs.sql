@@ -0,0 +1,30 @@
+-- +goose Up
+-- SCHEDULED JOBS TABLE
+CREATE TABLE tbl_jobs (
+    id SERIAL PRIMARY KEY,
+    job_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    job_name VARCHAR NOT NULL,
+    job_type VARCHAR NOT NULL,
+    cron_expr VARCHAR NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_status VARCHAR,
+    last_result JSONB,
+    last_error TEXT,
+    run_count INTEGER NOT NULL DEFAULT 0,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_jobs_next_run_at ON tbl_jobs(next_run_at) WHERE deleted_at IS NULL AND is_active;
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_jobs;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- DATA EXPORTS TABLE
+CREATE TABLE tbl_exports (
+    id SERIAL PRIMARY KEY,
+    export_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    source VARCHAR NOT NULL,
+    space_name VARCHAR,
+    query TEXT,
+    format VARCHAR NOT NULL,
+    gzip BOOLEAN NOT NULL DEFAULT FALSE,
+    file_path VARCHAR,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    row_count BIGINT NOT NULL DEFAULT 0,
+    job_status VARCHAR NOT NULL,
+    error_message TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_exports_db_id ON tbl_exports(db_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_exports;
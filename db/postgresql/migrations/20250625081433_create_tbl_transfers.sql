@@ -0,0 +1,28 @@
+-- +goose Up
+-- DATABASE TO DATABASE TRANSFERS TABLE
+CREATE TABLE tbl_transfers (
+    id SERIAL PRIMARY KEY,
+    transfer_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    source_db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    target_db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    options JSONB NOT NULL DEFAULT '{}',
+    progress JSONB NOT NULL DEFAULT '[]',
+    copied_tuples BIGINT NOT NULL DEFAULT 0,
+    verified BOOLEAN,
+    job_status VARCHAR NOT NULL,
+    error_message TEXT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_transfers_user_id ON tbl_transfers(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_transfers;
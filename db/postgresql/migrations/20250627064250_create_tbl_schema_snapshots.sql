@@ -0,0 +1,21 @@
+-- +goose Up
+-- DATABASE SCHEMA SNAPSHOTS TABLE
+CREATE TABLE tbl_schema_snapshots (
+    id SERIAL PRIMARY KEY,
+    snapshot_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    checksum VARCHAR NOT NULL,
+    schema_data JSONB NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_schema_snapshots_db_id ON tbl_schema_snapshots(db_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_schema_snapshots;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- VERSIONED SCHEMA HISTORY AND DRIFT
+ALTER TABLE tbl_schema_snapshots
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN source VARCHAR NOT NULL DEFAULT 'manual',
+    ADD COLUMN drift BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN checked_at TIMESTAMP;
+
+UPDATE tbl_schema_snapshots ss SET
+    version = numbered.version,
+    checked_at = ss.created_at
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY db_id ORDER BY id) AS version
+    FROM tbl_schema_snapshots
+) numbered
+WHERE numbered.id = ss.id;
+
+CREATE UNIQUE INDEX idx_tbl_schema_snapshots_db_id_version ON tbl_schema_snapshots(db_id, version);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tbl_schema_snapshots_db_id_version;
+
+ALTER TABLE tbl_schema_snapshots
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS source,
+    DROP COLUMN IF EXISTS drift,
+    DROP COLUMN IF EXISTS checked_at;
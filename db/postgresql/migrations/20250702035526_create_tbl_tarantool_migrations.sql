@@ -0,0 +1,24 @@
+-- +goose Up
+-- LUA MIGRATIONS UPLOADED FOR THE MANAGED TARANTOOL DATABASES
+CREATE TABLE tbl_tarantool_migrations (
+    id SERIAL PRIMARY KEY,
+    migration_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    name VARCHAR NOT NULL,
+    checksum VARCHAR NOT NULL,
+    up_script TEXT NOT NULL,
+    down_script TEXT,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_tbl_tarantool_migrations_db_id_version ON tbl_tarantool_migrations(db_id, version) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_tarantool_migrations;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- PERMISSIONS GRANTED ON A DATABASE BY ITS OWNER
+CREATE TABLE tbl_database_permissions (
+    id SERIAL PRIMARY KEY,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    permission VARCHAR NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_tbl_database_permissions_grant ON tbl_database_permissions(db_id, user_id, permission) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_database_permissions;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- STATEMENT MODE OF A DATABASE CONNECTION AND PER USER OVERRIDES
+ALTER TABLE tbl_users_databases
+    ADD COLUMN mode VARCHAR NOT NULL DEFAULT 'read_write';
+
+CREATE TABLE tbl_database_user_modes (
+    id SERIAL PRIMARY KEY,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    mode VARCHAR NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_tbl_database_user_modes_db_id_user_id ON tbl_database_user_modes(db_id, user_id) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_database_user_modes;
+
+ALTER TABLE tbl_users_databases
+    DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- DATABASES WHOSE WRITES NEED A SECOND USER TO APPROVE THEM
+ALTER TABLE tbl_users_databases
+    ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- CHANGES WAITING FOR APPROVAL BEFORE THEY RUN ON A DATABASE
+CREATE TABLE tbl_pending_changes (
+    id SERIAL PRIMARY KEY,
+    change_uuid UUID NOT NULL UNIQUE,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    kind VARCHAR NOT NULL,
+    statement TEXT NOT NULL,
+    statements JSONB NOT NULL DEFAULT '[]',
+    restore_id INTEGER REFERENCES tbl_restores(id) ON DELETE CASCADE,
+    change_status VARCHAR NOT NULL,
+    requested_by INTEGER NOT NULL REFERENCES tbl_users(id),
+    reviewed_by INTEGER REFERENCES tbl_users(id),
+    review_comment TEXT,
+    reviewed_at TIMESTAMP,
+    result JSONB,
+    error_message TEXT,
+    executed_at TIMESTAMP,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_pending_changes_db_id_change_status ON tbl_pending_changes(db_id, change_status);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_pending_changes;
+
+ALTER TABLE tbl_users_databases
+    DROP COLUMN IF EXISTS requires_approval;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- LINK AUDIT ROWS TO THE DATABASE THEY ARE ABOUT
+ALTER TABLE tbl_users_audits
+    ADD COLUMN db_id INTEGER REFERENCES tbl_users_databases(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tbl_users_audits_user_id ON tbl_users_audits(user_id);
+CREATE INDEX idx_tbl_users_audits_db_id ON tbl_users_audits(db_id);
+CREATE INDEX idx_tbl_users_audits_created_at ON tbl_users_audits(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tbl_users_audits_created_at;
+DROP INDEX IF EXISTS idx_tbl_users_audits_db_id;
+DROP INDEX IF EXISTS idx_tbl_users_audits_user_id;
+
+ALTER TABLE tbl_users_audits
+    DROP COLUMN IF EXISTS db_id;
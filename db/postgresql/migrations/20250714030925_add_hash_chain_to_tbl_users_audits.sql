@@ -0,0 +1,33 @@
+-- +goose Up
+-- HASH CHAIN OVER THE AUDIT ROWS, EACH ROW HASH COVERS ITS CONTENT AND THE
+-- HASH OF THE ROW BEFORE IT IN CHAIN ORDER
+ALTER TABLE tbl_users_audits
+    ADD COLUMN chain_seq BIGINT,
+    ADD COLUMN prev_hash VARCHAR(64),
+    ADD COLUMN row_hash VARCHAR(64);
+
+CREATE UNIQUE INDEX idx_tbl_users_audits_chain_seq ON tbl_users_audits(chain_seq);
+
+-- SIGNED CHECKPOINTS OF THE AUDIT CHAIN
+CREATE TABLE tbl_audit_checkpoints (
+    id SERIAL PRIMARY KEY,
+    checkpoint_uuid UUID NOT NULL UNIQUE,
+    chain_seq BIGINT NOT NULL,
+    row_hash VARCHAR(64) NOT NULL,
+    signature VARCHAR(64) NOT NULL,
+    file_path VARCHAR NOT NULL,
+    created_by INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_audit_checkpoints;
+
+DROP INDEX IF EXISTS idx_tbl_users_audits_chain_seq;
+
+ALTER TABLE tbl_users_audits
+    DROP COLUMN IF EXISTS row_hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq;
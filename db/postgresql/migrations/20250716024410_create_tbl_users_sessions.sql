@@ -0,0 +1,24 @@
+-- +goose Up
+-- ONE ROW PER SIGNED IN DEVICE, THE REFRESH TOKEN IS ONLY KEPT AS A HASH AND
+-- IS REPLACED ON EVERY REFRESH
+CREATE TABLE tbl_users_sessions (
+    id SERIAL PRIMARY KEY,
+    session_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    device VARCHAR NOT NULL,
+    user_agent VARCHAR,
+    ip VARCHAR,
+    refresh_token_hash VARCHAR(64) NOT NULL,
+    refresh_expires_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    revoked_by INTEGER,
+    revoked_at TIMESTAMP,
+    revoked_reason VARCHAR,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_users_sessions_user_id ON tbl_users_sessions(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_users_sessions;
@@ -0,0 +1,70 @@
+-- +goose Up
+-- ROLES A USER CAN HOLD
+CREATE TABLE tbl_roles (
+    id SERIAL PRIMARY KEY,
+    role_name VARCHAR NOT NULL UNIQUE,
+    role_desc VARCHAR,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- PERMISSIONS CHECKED BY THE ROUTES OF THE API
+CREATE TABLE tbl_permissions (
+    id SERIAL PRIMARY KEY,
+    permission_name VARCHAR NOT NULL UNIQUE,
+    permission_desc VARCHAR,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- PERMISSIONS GRANTED BY EACH ROLE
+CREATE TABLE tbl_roles_permissions (
+    role_id INTEGER NOT NULL REFERENCES tbl_roles(id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES tbl_permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+ALTER TABLE tbl_users
+    ADD COLUMN role_id INTEGER REFERENCES tbl_roles(id);
+
+-- +goose StatementBegin
+INSERT INTO tbl_roles (role_name, role_desc, created_by, created_at) VALUES
+('owner', 'Owns the installation, holds every permission', 1, NOW()),
+('admin', 'Manages users, connections and every database', 1, NOW()),
+('operator', 'Manages connections and runs any statement', 1, NOW()),
+('analyst', 'Reads data and the audit log', 1, NOW()),
+('viewer', 'Browses connections without running statements', 1, NOW());
+
+INSERT INTO tbl_permissions (permission_name, permission_desc, created_by, created_at) VALUES
+('manage_connections', 'Create, update and delete connections, backups and restores', 1, NOW()),
+('run_select', 'Run read statements and export data', 1, NOW()),
+('run_dml', 'Run statements changing data and import data', 1, NOW()),
+('run_ddl', 'Run statements changing the schema and migrations', 1, NOW()),
+('lua_eval', 'Evaluate lua on a database', 1, NOW()),
+('manage_users', 'Manage users and their roles', 1, NOW()),
+('view_audit', 'Read, verify and export the audit log', 1, NOW());
+
+INSERT INTO tbl_roles_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM tbl_roles r
+INNER JOIN tbl_permissions p ON
+    r.role_name IN ('owner', 'admin')
+    OR (r.role_name = 'operator' AND p.permission_name IN ('manage_connections', 'run_select', 'run_dml', 'run_ddl', 'lua_eval'))
+    OR (r.role_name = 'analyst' AND p.permission_name IN ('run_select', 'view_audit'));
+
+-- existing users could do everything, the first one owns the installation
+UPDATE tbl_users SET role_id = (SELECT id FROM tbl_roles WHERE role_name = 'admin');
+UPDATE tbl_users SET role_id = (SELECT id FROM tbl_roles WHERE role_name = 'owner')
+WHERE id = (SELECT MIN(id) FROM tbl_users);
+-- +goose StatementEnd
+
+ALTER TABLE tbl_users
+    ALTER COLUMN role_id SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE tbl_users
+    DROP COLUMN IF EXISTS role_id;
+
+DROP TABLE IF EXISTS tbl_roles_permissions;
+DROP TABLE IF EXISTS tbl_permissions;
+DROP TABLE IF EXISTS tbl_roles;
@@ -0,0 +1,85 @@
+-- +goose Up
+-- WORKSPACES SHARING DATABASE CONNECTIONS BETWEEN THEIR MEMBERS
+CREATE TABLE tbl_workspaces (
+    id SERIAL PRIMARY KEY,
+    workspace_uuid UUID NOT NULL UNIQUE,
+    workspace_name VARCHAR NOT NULL,
+    workspace_desc VARCHAR,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+-- MEMBERS OF A WORKSPACE AND THEIR ROLE IN IT
+CREATE TABLE tbl_workspaces_members (
+    id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES tbl_workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    member_role VARCHAR NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_tbl_workspaces_members_member ON tbl_workspaces_members(workspace_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_tbl_workspaces_members_user_id ON tbl_workspaces_members(user_id);
+
+-- INVITATIONS TO JOIN A WORKSPACE, SENT TO AN EMAIL ADDRESS
+CREATE TABLE tbl_workspaces_invitations (
+    id SERIAL PRIMARY KEY,
+    invitation_uuid UUID NOT NULL UNIQUE,
+    workspace_id INTEGER NOT NULL REFERENCES tbl_workspaces(id) ON DELETE CASCADE,
+    email VARCHAR NOT NULL,
+    member_role VARCHAR NOT NULL,
+    invitation_status VARCHAR NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    responded_by INTEGER REFERENCES tbl_users(id),
+    responded_at TIMESTAMP,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX idx_tbl_workspaces_invitations_email ON tbl_workspaces_invitations(LOWER(email));
+
+-- CONNECTIONS OWNED BY A WORKSPACE
+ALTER TABLE tbl_users_databases
+    ADD COLUMN workspace_id INTEGER REFERENCES tbl_workspaces(id);
+
+CREATE INDEX idx_tbl_users_databases_workspace_id ON tbl_users_databases(workspace_id);
+
+-- ACCESS LEVEL OF A MEMBER ON A WORKSPACE CONNECTION, OVERRIDING THE LEVEL
+-- GIVEN BY THE MEMBER ROLE
+CREATE TABLE tbl_workspaces_databases_access (
+    id SERIAL PRIMARY KEY,
+    db_id INTEGER NOT NULL REFERENCES tbl_users_databases(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    access_level VARCHAR NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_by INTEGER,
+    updated_at TIMESTAMP,
+    deleted_by INTEGER,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_tbl_workspaces_databases_access_member ON tbl_workspaces_databases_access(db_id, user_id) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_workspaces_databases_access;
+
+DROP INDEX IF EXISTS idx_tbl_users_databases_workspace_id;
+
+ALTER TABLE tbl_users_databases
+    DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS tbl_workspaces_invitations;
+DROP TABLE IF EXISTS tbl_workspaces_members;
+DROP TABLE IF EXISTS tbl_workspaces;
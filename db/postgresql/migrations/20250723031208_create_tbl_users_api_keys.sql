@@ -0,0 +1,22 @@
+-- +goose Up
+-- PERSONAL API KEYS, ONLY THE HASH OF THE KEY IS KEPT
+CREATE TABLE tbl_users_api_keys (
+    id SERIAL PRIMARY KEY,
+    key_uuid UUID NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    key_name VARCHAR NOT NULL,
+    key_prefix VARCHAR NOT NULL,
+    key_hash VARCHAR NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR,
+    revoked_by INTEGER,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_tbl_users_api_keys_user_id ON tbl_users_api_keys(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_users_api_keys;
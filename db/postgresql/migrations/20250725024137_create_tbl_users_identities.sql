@@ -0,0 +1,29 @@
+-- +goose Up
+-- ACCOUNTS OF AN IDENTITY PROVIDER LINKED TO A USER
+CREATE TABLE tbl_users_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    issuer VARCHAR NOT NULL,
+    subject VARCHAR NOT NULL,
+    email VARCHAR,
+    last_login_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_tbl_users_identities_subject ON tbl_users_identities(issuer, subject);
+CREATE INDEX idx_tbl_users_identities_user_id ON tbl_users_identities(user_id);
+
+-- SIGN IN REQUESTS WAITING FOR THE IDENTITY PROVIDER TO CALL BACK, A ROW IS
+-- CONSUMED BY ITS CALLBACK
+CREATE TABLE tbl_oidc_requests (
+    id SERIAL PRIMARY KEY,
+    state VARCHAR NOT NULL UNIQUE,
+    nonce VARCHAR NOT NULL,
+    code_verifier VARCHAR NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_oidc_requests;
+DROP TABLE IF EXISTS tbl_users_identities;
@@ -0,0 +1,46 @@
+-- +goose Up
+-- ADMINS CAN REQUIRE A USER TO SIGN IN WITH A SECOND FACTOR
+ALTER TABLE tbl_users
+    ADD COLUMN mfa_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- TOTP SECRET OF A USER, IT IS ONLY USED ONCE CONFIRMED WITH A CODE
+CREATE TABLE tbl_users_totp (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES tbl_users(id) ON DELETE CASCADE,
+    secret VARCHAR NOT NULL,
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP
+);
+
+-- ONE TIME RECOVERY CODES, ONLY THEIR HASH IS KEPT
+CREATE TABLE tbl_users_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    code_hash VARCHAR NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_tbl_users_recovery_codes_user_id ON tbl_users_recovery_codes(user_id);
+
+-- LOGINS WAITING FOR THE SECOND FACTOR
+CREATE TABLE tbl_users_mfa_challenges (
+    id SERIAL PRIMARY KEY,
+    challenge_hash VARCHAR NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    device VARCHAR,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_users_mfa_challenges;
+DROP TABLE IF EXISTS tbl_users_recovery_codes;
+DROP TABLE IF EXISTS tbl_users_totp;
+
+ALTER TABLE tbl_users
+    DROP COLUMN IF EXISTS mfa_required;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- WHEN THE USER PROVED IT OWNS ITS EMAIL
+ALTER TABLE tbl_users
+    ADD COLUMN email_verified_at TIMESTAMP;
+
+-- SINGLE USE TOKENS MAILED TO USERS, ONLY THEIR HASH IS KEPT
+CREATE TABLE tbl_users_tokens (
+    id SERIAL PRIMARY KEY,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL REFERENCES tbl_users(id) ON DELETE CASCADE,
+    purpose VARCHAR NOT NULL,
+    email VARCHAR NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_tbl_users_tokens_user_id ON tbl_users_tokens(user_id, purpose);
+
+-- +goose Down
+DROP TABLE IF EXISTS tbl_users_tokens;
+
+ALTER TABLE tbl_users
+    DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- THE LANGUAGE THE USER PREFERS FOR THE UI AND ITS MAILS
+ALTER TABLE tbl_users
+    ADD COLUMN language VARCHAR(10);
+
+-- PHOTOS ARE UPLOADED NOW, A USER WITHOUT ONE HAS NONE
+UPDATE tbl_users SET profile_photo = NULL WHERE profile_photo = 'user1.png';
+
+-- +goose Down
+UPDATE tbl_users SET profile_photo = 'user1.png' WHERE profile_photo IS NULL;
+
+ALTER TABLE tbl_users
+    DROP COLUMN IF EXISTS language;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- WHAT A CHANGE THAT IS NOT A QUERY NEEDS TO RUN ONCE APPROVED
+ALTER TABLE tbl_pending_changes
+    ADD COLUMN payload JSONB;
+
+-- +goose Down
+ALTER TABLE tbl_pending_changes
+    DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- EVERY ROLE CAN BROWSE THE CONNECTIONS IT HAS ACCESS TO
+-- +goose StatementBegin
+INSERT INTO tbl_permissions (permission_name, permission_desc, created_by, created_at) VALUES
+('view_connections', 'List connections, their detail and their workspaces', 1, NOW());
+
+INSERT INTO tbl_roles_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM tbl_roles r
+INNER JOIN tbl_permissions p ON p.permission_name = 'view_connections';
+-- +goose StatementEnd
+
+-- +goose Down
+DELETE FROM tbl_permissions
+WHERE permission_name = 'view_connections';
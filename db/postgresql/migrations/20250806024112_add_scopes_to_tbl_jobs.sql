@@ -0,0 +1,8 @@
+-- +goose Up
+-- PERMISSIONS A JOB SAVED WITH AN API KEY RUNS WITH, NULL FOR A LOGIN
+ALTER TABLE tbl_jobs
+    ADD COLUMN scopes TEXT[];
+
+-- +goose Down
+ALTER TABLE tbl_jobs
+    DROP COLUMN IF EXISTS scopes;
@@ -3,6 +3,7 @@ module tarantool-admin-api
 go 1.24.1
 
 require (
+	github.com/MicahParks/keyfunc/v2 v2.1.0
 	github.com/go-playground/validator/v10 v10.26.0
 	github.com/gofiber/contrib/fiberi18n/v2 v2.0.6
 	github.com/gofiber/contrib/jwt v1.1.2
@@ -17,26 +18,20 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/nicksnyder/go-i18n/v2 v2.6.0
 	github.com/redis/go-redis/v9 v9.8.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/rs/zerolog v1.34.0
-	github.com/shopspring/decimal v1.4.0
 	github.com/swaggo/swag v1.16.4
 	github.com/tarantool/go-tarantool/v2 v2.3.2
 	golang.org/x/text v0.25.0
 )
 
 require (
-	github.com/golang/protobuf v1.3.1 // indirect
-	github.com/mattn/go-pointer v0.0.1 // indirect
-	github.com/spacemonkeygo/spacelog v0.0.0-20180420211403-2296661a0572 // indirect
+	github.com/shopspring/decimal v1.4.0 // indirect
 	github.com/tarantool/go-iproto v1.1.0 // indirect
-	github.com/tarantool/go-openssl v0.0.8-0.20230307065445-720eeb389195 // indirect
-	google.golang.org/appengine v1.6.7 // indirect
-	gopkg.in/vmihailenco/msgpack.v2 v2.9.2 // indirect
 )
 
 require (
 	github.com/KyleBanks/depth v1.2.1 // indirect
-	github.com/MicahParks/keyfunc/v2 v2.1.0 // indirect
 	github.com/PuerkitoBio/purell v1.1.1 // indirect
 	github.com/PuerkitoBio/urlesc v0.0.0-20170810143723-de5bf2ad4578 // indirect
 	github.com/andybalholm/brotli v1.1.0 // indirect
@@ -60,11 +55,10 @@ require (
 	github.com/rivo/uniseg v0.4.4 // indirect
 	github.com/savsgio/gotils v0.0.0-20240303185622-093b76447511 // indirect
 	github.com/swaggo/files/v2 v2.0.2 // indirect
-	github.com/tarantool/go-tarantool v1.12.2
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.52.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
-	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/net v0.34.0 // indirect
@@ -1,9 +1,24 @@
 package handler
 
 import (
+	"tarantool-admin-api/internal/front/apikey"
+	"tarantool-admin-api/internal/front/approval"
+	"tarantool-admin-api/internal/front/audit"
 	"tarantool-admin-api/internal/front/auth"
+	"tarantool-admin-api/internal/front/backup"
 	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/internal/front/export"
+	"tarantool-admin-api/internal/front/importer"
+	"tarantool-admin-api/internal/front/job"
+	"tarantool-admin-api/internal/front/mfa"
+	"tarantool-admin-api/internal/front/migration"
+	"tarantool-admin-api/internal/front/profile"
+	"tarantool-admin-api/internal/front/restore"
+	"tarantool-admin-api/internal/front/schema"
+	"tarantool-admin-api/internal/front/session"
+	"tarantool-admin-api/internal/front/transfer"
 	"tarantool-admin-api/internal/front/user"
+	"tarantool-admin-api/internal/front/workspace"
 	"tarantool-admin-api/pkg/middlewares"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,27 +32,114 @@ type ServiceHandler struct {
 
 // register modules route here
 type FrontService struct {
-	AuthRoute     *auth.AuthRoute
-	DatabaseRoute *database.DatabaseRoute
-	UserRoute     *user.UserRoute
+	AuthRoute      *auth.AuthRoute
+	DatabaseRoute  *database.DatabaseRoute
+	UserRoute      *user.UserRoute
+	BackupRoute    *backup.BackupRoute
+	RestoreRoute   *restore.RestoreRoute
+	JobRoute       *job.JobRoute
+	ExportRoute    *export.ExportRoute
+	ImportRoute    *importer.ImportRoute
+	TransferRoute  *transfer.TransferRoute
+	SchemaRoute    *schema.SchemaRoute
+	MigrationRoute *migration.MigrationRoute
+	ApprovalRoute  *approval.ApprovalRoute
+	AuditRoute     *audit.AuditRoute
+	SessionRoute   *session.SessionRoute
+	WorkspaceRoute *workspace.WorkspaceRoute
+	ApiKeyRoute    *apikey.ApiKeyRoute
+	MFARoute       *mfa.MFARoute
+	ProfileRoute   *profile.ProfileRoute
 }
 
 func NewFrontService(app *fiber.App, pool *sqlx.DB) *FrontService {
+	// rate limit the public auth endpoints
+	middlewares.NewAuthRateLimit(app)
+
 	// register auth route
 	au := auth.NewRoute(pool, app).RegisterAuthRoute()
 
 	// middleware
 	middlewares.NewJwtMinddleWare(app, pool)
 
+	front := registerFrontRoutes(app, pool)
+	front.AuthRoute = au
+
+	return front
+}
+
+// accountRoutes act on the account of the user itself, they need no
+// permission of its role but an api key cannot use them
+var accountRoutes = []string{
+	"/api/v1/front/user/info",
+	"/api/v1/front/profile",
+	"/api/v1/front/mfa",
+	"/api/v1/front/session",
+	"/api/v1/front/api-key",
+	"/api/v1/front/workspace/invitation",
+}
+
+// registerFrontRoutes registers the routes behind the jwt middleware, every
+// route declares the permission it needs or is one of the account routes, so
+// an api key only reaches what its scopes grant
+func registerFrontRoutes(app *fiber.App, pool *sqlx.DB) *FrontService {
+	for _, prefix := range accountRoutes {
+		app.Use(prefix, middlewares.RequireLogin())
+	}
+
 	// register database route
 	db := database.NewRoute(pool, app).RegisterDatabaseRoute()
 	// register user route
 	us := user.NewRoute(pool, app).RegisterUserRoute()
+	// register backup route
+	bk := backup.NewRoute(pool, app).RegisterBackupRoute()
+	// register restore route
+	rs := restore.NewRoute(pool, app).RegisterRestoreRoute()
+	// register job route
+	jb := job.NewRoute(pool, app).RegisterJobRoute()
+	// register export route
+	ex := export.NewRoute(pool, app).RegisterExportRoute()
+	// register import route
+	im := importer.NewRoute(pool, app).RegisterImportRoute()
+	// register transfer route
+	tf := transfer.NewRoute(pool, app).RegisterTransferRoute()
+	// register schema route
+	sc := schema.NewRoute(pool, app).RegisterSchemaRoute()
+	// register migration route
+	mg := migration.NewRoute(pool, app).RegisterMigrationRoute()
+	// register approval route
+	ap := approval.NewRoute(pool, app).RegisterApprovalRoute()
+	// register audit route
+	ad := audit.NewRoute(pool, app).RegisterAuditRoute()
+	// register session route
+	ss := session.NewRoute(pool, app).RegisterSessionRoute()
+	// register workspace route
+	ws := workspace.NewRoute(pool, app).RegisterWorkspaceRoute()
+	// register api key route
+	ak := apikey.NewRoute(pool, app).RegisterApiKeyRoute()
+	// register mfa route
+	mf := mfa.NewRoute(pool, app).RegisterMFARoute()
+	// register profile route
+	pf := profile.NewRoute(pool, app).RegisterProfileRoute()
 
 	return &FrontService{
-		AuthRoute:     au,
-		DatabaseRoute: db,
-		UserRoute:     us,
+		DatabaseRoute:  db,
+		UserRoute:      us,
+		BackupRoute:    bk,
+		RestoreRoute:   rs,
+		JobRoute:       jb,
+		ExportRoute:    ex,
+		ImportRoute:    im,
+		TransferRoute:  tf,
+		SchemaRoute:    sc,
+		MigrationRoute: mg,
+		ApprovalRoute:  ap,
+		AuditRoute:     ad,
+		SessionRoute:   ss,
+		WorkspaceRoute: ws,
+		ApiKeyRoute:    ak,
+		MFARoute:       mf,
+		ProfileRoute:   pf,
 	}
 }
 
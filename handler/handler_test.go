@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"tarantool-admin-api/pkg/constants"
+	types "tarantool-admin-api/pkg/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// authenticatedRoutes are the routes a logged in user may call whatever its
+// role, they act on the account of the user itself and are covered by
+// accountRoutes
+var authenticatedRoutes = map[string]bool{
+	"GET /api/v1/front/user/info": true,
+
+	"GET /api/v1/front/profile/":                 true,
+	"PUT /api/v1/front/profile/":                 true,
+	"PUT /api/v1/front/profile/password":         true,
+	"PUT /api/v1/front/profile/language":         true,
+	"POST /api/v1/front/profile/photo":           true,
+	"DELETE /api/v1/front/profile/photo":         true,
+	"GET /api/v1/front/profile/photo/:user_uuid": true,
+
+	"GET /api/v1/front/mfa/":               true,
+	"POST /api/v1/front/mfa/enrol":         true,
+	"POST /api/v1/front/mfa/confirm":       true,
+	"POST /api/v1/front/mfa/recovery-code": true,
+	"DELETE /api/v1/front/mfa/":            true,
+
+	"GET /api/v1/front/session/":                 true,
+	"POST /api/v1/front/session/logout":          true,
+	"POST /api/v1/front/session/logout-all":      true,
+	"DELETE /api/v1/front/session/:session_uuid": true,
+
+	"GET /api/v1/front/api-key/":             true,
+	"POST /api/v1/front/api-key/":            true,
+	"DELETE /api/v1/front/api-key/:key_uuid": true,
+
+	"GET /api/v1/front/workspace/invitation":                           true,
+	"POST /api/v1/front/workspace/invitation/:invitation_uuid/accept":  true,
+	"POST /api/v1/front/workspace/invitation/:invitation_uuid/decline": true,
+}
+
+var routeParam = regexp.MustCompile(`:[a-z_]+`)
+
+// frontRoutes registers the front routes behind a stub of the jwt middleware
+// that logs in the user with us_ctx, a handler reached without a database
+// panics and answers 500
+func frontRoutes(us_ctx types.UserContext) *fiber.App {
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("UserContext", us_ctx)
+		return c.Next()
+	})
+	registerFrontRoutes(app, nil)
+	return app
+}
+
+func TestFrontRoutesRequirePermission(t *testing.T) {
+	app := frontRoutes(types.UserContext{Id: 1, Permissions: []string{}})
+
+	registered := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == http.MethodHead {
+			continue
+		}
+		name := route.Method + " " + route.Path
+		registered[name] = true
+
+		if authenticatedRoutes[name] {
+			continue
+		}
+
+		req := httptest.NewRequest(route.Method, routeParam.ReplaceAllString(route.Path, "x"), nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s answered %d to a user without permission, want %d", name, resp.StatusCode, http.StatusForbidden)
+		}
+	}
+
+	// keep the allow-list in line with the routes
+	for name := range authenticatedRoutes {
+		if !registered[name] {
+			t.Errorf("%s is allowed without permission but is not registered", name)
+		}
+	}
+}
+
+func TestFrontRoutesRefuseApiKeyWithoutScope(t *testing.T) {
+	app := frontRoutes(types.UserContext{Id: 1, Permissions: []string{}, ApiKey: "key-1"})
+
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == http.MethodHead {
+			continue
+		}
+		name := route.Method + " " + route.Path
+
+		req := httptest.NewRequest(route.Method, routeParam.ReplaceAllString(route.Path, "x"), nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s answered %d to an api key without scope, want %d", name, resp.StatusCode, http.StatusForbidden)
+		}
+	}
+}
+
+func TestAccountRoutesRefuseApiKey(t *testing.T) {
+	// every scope an api key can hold
+	permissions := []string{
+		constants.PermissionViewConnections,
+		constants.PermissionManageConnections,
+		constants.PermissionRunSelect,
+		constants.PermissionRunDML,
+		constants.PermissionRunDDL,
+		constants.PermissionLuaEval,
+		constants.PermissionManageUsers,
+		constants.PermissionViewAudit,
+	}
+	app := frontRoutes(types.UserContext{Id: 1, Permissions: permissions, ApiKey: "key-1"})
+
+	for name := range authenticatedRoutes {
+		method, path, _ := strings.Cut(name, " ")
+
+		req := httptest.NewRequest(method, routeParam.ReplaceAllString(path, "x"), nil)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s answered %d to an api key, want %d", name, resp.StatusCode, http.StatusForbidden)
+		}
+	}
+}
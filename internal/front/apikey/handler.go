@@ -0,0 +1,129 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ApiKeyHandler struct {
+	DBPool        *sqlx.DB
+	ApiKeyService func(c *fiber.Ctx) *ApiKeyService
+}
+
+func NewApiKeyHandler(db_pool *sqlx.DB) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		DBPool: db_pool,
+		ApiKeyService: func(c *fiber.Ctx) *ApiKeyService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewApiKeyService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (a *ApiKeyHandler) List(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, apiKeyListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("api_key_list_failed", nil, c),
+				-17000,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := a.ApiKeyService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-17000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("api_key_list_success", nil, c),
+			17000,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (a *ApiKeyHandler) Create(c *fiber.Ctx) error {
+	var api_key_req ApiKeyRequest
+	v := utils.NewValidator()
+
+	if err := api_key_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("api_key_create_failed", nil, c),
+				-17001,
+				err,
+			),
+		)
+	}
+
+	resp, err := a.ApiKeyService(c).Create(api_key_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-17001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("api_key_create_success", nil, c),
+			17001,
+			resp,
+		),
+	)
+}
+
+func (a *ApiKeyHandler) Revoke(c *fiber.Ctx) error {
+	key_uuid := c.Params("key_uuid")
+
+	resp, err := a.ApiKeyService(c).Revoke(key_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-17002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("api_key_revoke_success", nil, c),
+			17002,
+			resp,
+		),
+	)
+}
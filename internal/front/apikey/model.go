@@ -0,0 +1,122 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// keyPrefix starts every api key so a leaked key is easy to recognise
+const keyPrefix = "tak_"
+
+type ApiKey struct {
+	ID         uint64         `json:"-" db:"id"`
+	KeyUUID    string         `json:"key_uuid" db:"key_uuid"`
+	KeyName    string         `json:"key_name" db:"key_name"`
+	KeyPrefix  string         `json:"key_prefix" db:"key_prefix"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	ExpiresAt  time.Time      `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at" db:"last_used_at"`
+	LastUsedIP *string        `json:"last_used_ip" db:"last_used_ip"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+}
+
+// apiKeyListFields are the columns the api key list filters and sorts on
+var apiKeyListFields = postgres.ListFields{
+	Filter:      []string{"key_name", "key_prefix", "expires_at", "last_used_at", "created_at"},
+	Sort:        []string{"key_name", "expires_at", "last_used_at", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type ApiKeyResponse struct {
+	ApiKey ApiKey `json:"api_key"`
+}
+
+// ApiKeyCreateResponse carries the key itself, it is only shown once
+type ApiKeyCreateResponse struct {
+	ApiKey ApiKey `json:"api_key"`
+	Key    string `json:"key"`
+}
+
+type ApiKeyRequest struct {
+	KeyName       string   `json:"key_name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=view_connections manage_connections run_select run_dml run_ddl lua_eval manage_users view_audit"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"required,min=1,max=365"`
+}
+
+func (a *ApiKeyRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(a); err != nil {
+		custom_log.NewCustomLog("api_key_create_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(a, c); err != nil {
+		custom_log.NewCustomLog("api_key_create_failed", err.Error(), "error")
+		return err
+	}
+
+	a.KeyName = strings.TrimSpace(a.KeyName)
+
+	return nil
+}
+
+type ApiKeyNewModel struct {
+	ID        uint64         `db:"id"`
+	KeyUUID   string         `db:"key_uuid"`
+	UserID    int            `db:"user_id"`
+	KeyName   string         `db:"key_name"`
+	Key       string         `db:"-"`
+	KeyPrefix string         `db:"key_prefix"`
+	KeyHash   string         `db:"key_hash"`
+	Scopes    pq.StringArray `db:"scopes"`
+	ExpiresAt time.Time      `db:"expires_at"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (a *ApiKeyNewModel) new(api_key_req ApiKeyRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_users_api_keys_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	// generate the key, only its hash is stored
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("error generate api key : %w", err)
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := utils.Now()
+
+	a.ID = uint64(*id)
+	a.KeyUUID = uuid.String()
+	a.UserID = us_ctx.Id
+	a.KeyName = api_key_req.KeyName
+	a.Key = key
+	a.KeyPrefix = key[:len(keyPrefix)+6]
+	a.KeyHash = utils.HashToken(key)
+	a.Scopes = api_key_req.Scopes
+	a.ExpiresAt = now.Add(time.Duration(api_key_req.ExpiresInDays) * 24 * time.Hour)
+	a.CreatedAt = now
+
+	return nil
+}
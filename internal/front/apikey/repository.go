@@ -0,0 +1,155 @@
+package apikey
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ApiKeyRepo interface {
+	List(list_req types.ListRequest) ([]ApiKey, int, *responses.ErrorResponse)
+	Create(api_key_req ApiKeyRequest) (*ApiKeyCreateResponse, *responses.ErrorResponse)
+	Revoke(key_uuid string) (*ApiKeyResponse, *responses.ErrorResponse)
+}
+
+type ApiKeyRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewApiKeyRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *ApiKeyRepoImpl {
+	return &ApiKeyRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+// List returns the api keys of the current user that are not revoked,
+// expired keys are kept so the user can see why a script stopped working
+func (a *ApiKeyRepoImpl) List(list_req types.ListRequest) ([]ApiKey, int, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			id, key_uuid, key_name, key_prefix, scopes, expires_at, last_used_at,
+			last_used_ip, created_at
+		FROM tbl_users_api_keys
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+	`
+
+	// execute query
+	var api_keys []ApiKey
+	total, err := postgres.SelectList(a.DBPool, &api_keys, query, []interface{}{a.UserContext.Id}, list_req, apiKeyListFields)
+	if err != nil {
+		custom_log.NewCustomLog("api_key_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("api_key_list_failed", fmt.Errorf("get_api_key_error"))
+	}
+
+	if api_keys == nil {
+		api_keys = []ApiKey{}
+	}
+
+	return api_keys, total, nil
+}
+
+// Create issues a new api key, its scopes must be permissions the user holds
+func (a *ApiKeyRepoImpl) Create(api_key_req ApiKeyRequest) (*ApiKeyCreateResponse, *responses.ErrorResponse) {
+	// a key cannot be used to issue other keys
+	if a.UserContext.ApiKey != "" {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("api_key_create_failed", fmt.Errorf("api_key_not_allowed"))
+	}
+
+	for _, scope := range api_key_req.Scopes {
+		if !a.UserContext.HasPermission(scope) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("api_key_create_failed", fmt.Errorf("api_key_scope_not_granted"))
+		}
+	}
+
+	var api_key ApiKeyNewModel
+	if err := api_key.new(api_key_req, a.UserContext, a.DBPool); err != nil {
+		custom_log.NewCustomLog("api_key_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("api_key_create_failed", fmt.Errorf("error_create_api_key"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_users_api_keys (
+			id, key_uuid, user_id, key_name, key_prefix, key_hash, scopes, expires_at,
+			created_at
+		) VALUES (
+			:id, :key_uuid, :user_id, :key_name, :key_prefix, :key_hash, :scopes, :expires_at,
+			:created_at
+		)
+	`
+
+	// execute query
+	if _, err := a.DBPool.NamedExec(query, api_key); err != nil {
+		custom_log.NewCustomLog("api_key_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("api_key_create_failed", fmt.Errorf("error_create_api_key"))
+	}
+
+	a.audit("api_key_create", fmt.Sprintf("User %s created api key %s with scopes %v", a.UserContext.UserName, api_key.KeyName, api_key.Scopes))
+
+	return &ApiKeyCreateResponse{
+		ApiKey: ApiKey{
+			ID:        api_key.ID,
+			KeyUUID:   api_key.KeyUUID,
+			KeyName:   api_key.KeyName,
+			KeyPrefix: api_key.KeyPrefix,
+			Scopes:    api_key.Scopes,
+			ExpiresAt: api_key.ExpiresAt,
+			CreatedAt: api_key.CreatedAt,
+		},
+		Key: api_key.Key,
+	}, nil
+}
+
+// Revoke disables one of the current user's api keys right away
+func (a *ApiKeyRepoImpl) Revoke(key_uuid string) (*ApiKeyResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		UPDATE tbl_users_api_keys SET
+			revoked_by = $1, revoked_at = $2
+		WHERE key_uuid = $3
+		AND user_id = $1
+		AND revoked_at IS NULL
+		RETURNING
+			id, key_uuid, key_name, key_prefix, scopes, expires_at, last_used_at,
+			last_used_ip, created_at
+	`
+
+	// execute query
+	var api_key ApiKey
+	if err := a.DBPool.Get(&api_key, query, a.UserContext.Id, utils.Now(), key_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("api_key_revoke_failed", fmt.Errorf("no_api_key_found"))
+		}
+		custom_log.NewCustomLog("api_key_revoke_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("api_key_revoke_failed", fmt.Errorf("error_revoke_api_key"))
+	}
+
+	a.audit("api_key_revoke", fmt.Sprintf("User %s revoked api key %s", a.UserContext.UserName, api_key.KeyName))
+
+	return &ApiKeyResponse{
+		ApiKey: api_key,
+	}, nil
+}
+
+func (a *ApiKeyRepoImpl) audit(context string, desc string) {
+	utils.AuditUserAction(a.UserContext, context, desc, constants.AuditTypeAuth, nil, a.DBPool)
+}
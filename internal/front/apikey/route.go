@@ -0,0 +1,30 @@
+package apikey
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ApiKeyRoute struct {
+	App           *fiber.App
+	DBPool        *sqlx.DB
+	ApiKeyHandler *ApiKeyHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *ApiKeyRoute {
+	return &ApiKeyRoute{
+		App:           app,
+		DBPool:        db_pool,
+		ApiKeyHandler: NewApiKeyHandler(db_pool),
+	}
+}
+
+func (a *ApiKeyRoute) RegisterApiKeyRoute() *ApiKeyRoute {
+	api_key := a.App.Group("/api/v1/front/api-key")
+
+	api_key.Get("/", a.ApiKeyHandler.List)
+	api_key.Post("/", a.ApiKeyHandler.Create)
+	api_key.Delete("/:key_uuid", a.ApiKeyHandler.Revoke)
+
+	return a
+}
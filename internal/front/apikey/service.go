@@ -0,0 +1,40 @@
+package apikey
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ApiKeyServiceCreator interface {
+	List(list_req types.ListRequest) ([]ApiKey, int, *responses.ErrorResponse)
+	Create(api_key_req ApiKeyRequest) (*ApiKeyCreateResponse, *responses.ErrorResponse)
+	Revoke(key_uuid string) (*ApiKeyResponse, *responses.ErrorResponse)
+}
+
+type ApiKeyService struct {
+	DBPool      *sqlx.DB
+	ApiKeyRepo  *ApiKeyRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewApiKeyService(us_ctx *types.UserContext, db_pool *sqlx.DB) *ApiKeyService {
+	return &ApiKeyService{
+		DBPool:      db_pool,
+		ApiKeyRepo:  NewApiKeyRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (a *ApiKeyService) List(list_req types.ListRequest) ([]ApiKey, int, *responses.ErrorResponse) {
+	return a.ApiKeyRepo.List(list_req)
+}
+
+func (a *ApiKeyService) Create(api_key_req ApiKeyRequest) (*ApiKeyCreateResponse, *responses.ErrorResponse) {
+	return a.ApiKeyRepo.Create(api_key_req)
+}
+
+func (a *ApiKeyService) Revoke(key_uuid string) (*ApiKeyResponse, *responses.ErrorResponse) {
+	return a.ApiKeyRepo.Revoke(key_uuid)
+}
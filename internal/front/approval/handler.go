@@ -0,0 +1,168 @@
+package approval
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ApprovalHandler struct {
+	DBPool          *sqlx.DB
+	ApprovalService func(c *fiber.Ctx) *ApprovalService
+}
+
+func NewApprovalHandler(db_pool *sqlx.DB) *ApprovalHandler {
+	return &ApprovalHandler{
+		DBPool: db_pool,
+		ApprovalService: func(c *fiber.Ctx) *ApprovalService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewApprovalService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (a *ApprovalHandler) List(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req ApprovalListRequest
+	v := utils.NewValidator()
+	if err := list_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("approval_list_failed", nil, c),
+				-12000,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := a.ApprovalService(c).List(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-12000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("approval_list_success", nil, c),
+			12000,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (a *ApprovalHandler) ShowOne(c *fiber.Ctx) error {
+	change_uuid := c.Params("change_uuid")
+
+	resp, err := a.ApprovalService(c).ShowOne(change_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-12001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("approval_show_success", nil, c),
+			12001,
+			resp,
+		),
+	)
+}
+
+func (a *ApprovalHandler) Approve(c *fiber.Ctx) error {
+	change_uuid := c.Params("change_uuid")
+
+	var review_req ApprovalReviewRequest
+	v := utils.NewValidator()
+	if err := review_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("approval_approve_failed", nil, c),
+				-12002,
+				err,
+			),
+		)
+	}
+
+	resp, err := a.ApprovalService(c).Approve(change_uuid, review_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-12002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("approval_approve_success", nil, c),
+			12002,
+			resp,
+		),
+	)
+}
+
+func (a *ApprovalHandler) Reject(c *fiber.Ctx) error {
+	change_uuid := c.Params("change_uuid")
+
+	var review_req ApprovalReviewRequest
+	v := utils.NewValidator()
+	if err := review_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("approval_reject_failed", nil, c),
+				-12003,
+				err,
+			),
+		)
+	}
+
+	resp, err := a.ApprovalService(c).Reject(change_uuid, review_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-12003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("approval_reject_success", nil, c),
+			12003,
+			resp,
+		),
+	)
+}
@@ -0,0 +1,88 @@
+package approval
+
+import (
+	"errors"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+type PendingChange struct {
+	ID            uint64                  `json:"-" db:"id"`
+	ChangeUUID    string                  `json:"change_uuid" db:"change_uuid"`
+	DBID          uint64                  `json:"-" db:"db_id"`
+	DBUUID        string                  `json:"db_uuid" db:"db_uuid"`
+	DBName        string                  `json:"db_name" db:"db_name"`
+	DBUserID      uint64                  `json:"-" db:"db_user_id"`
+	Kind          string                  `json:"kind" db:"kind"`
+	Statement     string                  `json:"statement" db:"statement"`
+	Statements    sqlx_types.JSONText     `json:"statements" db:"statements"`
+	RestoreUUID   *string                 `json:"restore_uuid" db:"restore_uuid"`
+	Payload       sqlx_types.NullJSONText `json:"-" db:"payload"`
+	ChangeStatus  string                  `json:"change_status" db:"change_status"`
+	RequestedBy   uint64                  `json:"-" db:"requested_by"`
+	RequestedName string                  `json:"requested_by" db:"requested_name"`
+	ReviewedBy    *uint64                 `json:"-" db:"reviewed_by"`
+	ReviewedName  *string                 `json:"reviewed_by" db:"reviewed_name"`
+	ReviewComment *string                 `json:"review_comment" db:"review_comment"`
+	ReviewedAt    *time.Time              `json:"reviewed_at" db:"reviewed_at"`
+	Result        sqlx_types.NullJSONText `json:"result" db:"result"`
+	ErrorMessage  *string                 `json:"error_message" db:"error_message"`
+	ExecutedAt    *time.Time              `json:"executed_at" db:"executed_at"`
+	CreatedAt     time.Time               `json:"created_at" db:"created_at"`
+}
+
+type PendingChangeResponse struct {
+	PendingChange PendingChange `json:"pending_change"`
+}
+
+// approvalListFields are the columns the pending change list filters and
+// sorts on
+var approvalListFields = postgres.ListFields{
+	Filter:      []string{"change_uuid", "kind", "change_status", "requested_by", "reviewed_by", "reviewed_at", "executed_at", "created_at"},
+	Sort:        []string{"kind", "change_status", "requested_by", "reviewed_at", "executed_at", "created_at"},
+	Columns:     map[string]string{"requested_by": "requested_name", "reviewed_by": "reviewed_name"},
+	DefaultSort: "id DESC",
+}
+
+type ApprovalListRequest struct {
+	Status            string `query:"status" validate:"omitempty,oneof=pending approved rejected executed failed"`
+	types.ListRequest `query:"-"`
+}
+
+func (a *ApprovalListRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.QueryParser(a); err != nil {
+		custom_log.NewCustomLog("approval_list_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(a, c); err != nil {
+		custom_log.NewCustomLog("approval_list_failed", err.Error(), "error")
+		return err
+	}
+
+	return utils.BindList(c, &a.ListRequest, approvalListFields)
+}
+
+type ApprovalReviewRequest struct {
+	Comment string `json:"comment" validate:"required,max=2000"`
+}
+
+func (a *ApprovalReviewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(a); err != nil {
+		custom_log.NewCustomLog("approval_review_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(a, c); err != nil {
+		custom_log.NewCustomLog("approval_review_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,444 @@
+package approval
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/internal/front/importer"
+	"tarantool-admin-api/internal/front/migration"
+	"tarantool-admin-api/internal/front/restore"
+	"tarantool-admin-api/internal/front/transfer"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+type ApprovalRepo interface {
+	List(db_uuid string, list_req ApprovalListRequest) ([]PendingChange, int, *responses.ErrorResponse)
+	ShowOne(change_uuid string) (*PendingChangeResponse, *responses.ErrorResponse)
+	Approve(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse)
+	Reject(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse)
+}
+
+type ApprovalRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewApprovalRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *ApprovalRepoImpl {
+	return &ApprovalRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectPendingChange = `
+	SELECT
+		pc.id, pc.change_uuid, pc.db_id, db.db_uuid, db.db_name, db.user_id AS db_user_id,
+		pc.kind, pc.statement, pc.statements, rs.restore_uuid, pc.payload, pc.change_status,
+		pc.requested_by, rq.user_name AS requested_name, pc.reviewed_by,
+		rv.user_name AS reviewed_name, pc.review_comment, pc.reviewed_at, pc.result,
+		pc.error_message, pc.executed_at, pc.created_at
+	FROM tbl_pending_changes pc
+	INNER JOIN tbl_users_databases db ON db.id = pc.db_id
+	INNER JOIN tbl_users rq ON rq.id = pc.requested_by
+	LEFT JOIN tbl_users rv ON rv.id = pc.reviewed_by
+	LEFT JOIN tbl_restores rs ON rs.id = pc.restore_id
+`
+
+func (a *ApprovalRepoImpl) List(db_uuid string, list_req ApprovalListRequest) ([]PendingChange, int, *responses.ErrorResponse) {
+	db, err_resp := a.reviewable(db_uuid, "approval_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// prepare query
+	query := selectPendingChange + `
+		WHERE pc.deleted_at IS NULL
+		AND pc.db_id = $1
+		AND ($2 = '' OR pc.change_status = $2)
+	`
+
+	// execute query
+	var changes []PendingChange
+	total, err := postgres.SelectList(a.DBPool, &changes, query, []interface{}{db.ID, list_req.Status}, list_req.ListRequest, approvalListFields)
+	if err != nil {
+		custom_log.NewCustomLog("approval_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("approval_list_failed", fmt.Errorf("get_change_error"))
+	}
+
+	if changes == nil {
+		changes = []PendingChange{}
+	}
+
+	return changes, total, nil
+}
+
+func (a *ApprovalRepoImpl) ShowOne(change_uuid string) (*PendingChangeResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectPendingChange + `
+		WHERE pc.deleted_at IS NULL
+		AND pc.change_uuid = $1
+	`
+
+	// execute query
+	var change PendingChange
+	if err := a.DBPool.Get(&change, query, change_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("approval_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("approval_show_failed", fmt.Errorf("no_change_found"))
+		}
+		custom_log.NewCustomLog("approval_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("approval_show_failed", fmt.Errorf("get_change_error"))
+	}
+
+	if _, err_resp := a.reviewable(change.DBUUID, "approval_show_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return &PendingChangeResponse{
+		PendingChange: change,
+	}, nil
+}
+
+// Approve runs the pending change as the reviewer, the outcome is stored on
+// the change and audited with both the requester and the reviewer
+func (a *ApprovalRepoImpl) Approve(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse) {
+	change, err_resp := a.review(change_uuid, database.ChangeStatusApproved, review_req, "approval_approve_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// execute the change, a failure is recorded on the change itself
+	result, err := a.execute(*change)
+	if err != nil {
+		custom_log.NewCustomLog("approval_execute_failed", err.Error(), "error")
+		a.finish(change.ID, database.ChangeStatusFailed, nil, err.Error())
+		a.audit(*change, "change_failed", fmt.Sprintf("approved by %s but failed: %s", a.UserContext.UserName, err.Error()))
+	} else {
+		a.finish(change.ID, database.ChangeStatusExecuted, result, "")
+		a.audit(*change, "change_approved", fmt.Sprintf("approved and executed by %s", a.UserContext.UserName))
+	}
+
+	return a.ShowOne(change_uuid)
+}
+
+// Reject closes the pending change without running it
+func (a *ApprovalRepoImpl) Reject(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse) {
+	change, err_resp := a.review(change_uuid, database.ChangeStatusRejected, review_req, "approval_reject_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// a rejected restore is closed as well so it cannot be run anymore
+	if change.Kind == database.ChangeKindRestore && change.RestoreUUID != nil {
+		if _, err_resp := restore.NewRestoreRepoImpl(a.UserContext, a.DBPool).Reject(*change.RestoreUUID); err_resp != nil {
+			custom_log.NewCustomLog("approval_reject_failed", err_resp.Err.Error(), "error")
+		}
+	}
+
+	// a rejected transfer is closed as well so it cannot be run anymore
+	if change.Kind == database.ChangeKindTransfer {
+		var payload transfer.TransferPayload
+		if err := decodePayload(*change, &payload); err != nil {
+			custom_log.NewCustomLog("approval_reject_failed", err.Error(), "error")
+		} else if _, err_resp := transfer.NewTransferRepoImpl(a.UserContext, a.DBPool).Reject(payload.TransferUUID); err_resp != nil {
+			custom_log.NewCustomLog("approval_reject_failed", err_resp.Err.Error(), "error")
+		}
+	}
+
+	// a rejected import does not need its file anymore
+	if change.Kind == database.ChangeKindImport {
+		var payload importer.ImportPayload
+		if err := decodePayload(*change, &payload); err != nil {
+			custom_log.NewCustomLog("approval_reject_failed", err.Error(), "error")
+		} else {
+			importer.NewImportRepoImpl(a.UserContext, a.DBPool).Discard(payload)
+		}
+	}
+
+	a.audit(*change, "change_rejected", fmt.Sprintf("rejected by %s", a.UserContext.UserName))
+
+	return a.ShowOne(change_uuid)
+}
+
+// review checks the current user may review the change and moves it from
+// pending to status, only one reviewer can win a concurrent review
+func (a *ApprovalRepoImpl) review(change_uuid string, status string, review_req ApprovalReviewRequest, message_id string) (*PendingChange, *responses.ErrorResponse) {
+	change_resp, err_resp := a.ShowOne(change_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	change := change_resp.PendingChange
+
+	if change.ChangeStatus != database.ChangeStatusPending {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("change_not_pending"))
+	}
+
+	// the second pair of eyes must belong to someone else
+	if change.RequestedBy == uint64(a.UserContext.Id) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("cannot_review_own_change"))
+	}
+
+	// the admins of the database and users granted approve_changes may review
+	if !a.administers(change) {
+		allowed, err := database.NewDatabaseRepoImpl(a.UserContext, a.DBPool).HasPermission(change.DBID, database.PermissionApproveChanges)
+		if err != nil {
+			custom_log.NewCustomLog(message_id, err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_permission_error"))
+		}
+		if !allowed {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("not_an_approver"))
+		}
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_pending_changes SET
+			change_status = $1, reviewed_by = $2, review_comment = $3, reviewed_at = $4,
+			updated_by = $2, updated_at = $4
+		WHERE id = $5 AND change_status = $6
+	`
+
+	// execute request
+	result, err := a.DBPool.Exec(query, status, a.UserContext.Id, review_req.Comment, utils.Now(), change.ID, database.ChangeStatusPending)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_review_change"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("change_not_pending"))
+	}
+
+	return &change, nil
+}
+
+// reviewable returns the database when the current user may see its changes,
+// the users with access to the database and its approvers may
+func (a *ApprovalRepoImpl) reviewable(db_uuid string, message_id string) (*database.Database, *responses.ErrorResponse) {
+	db_repo := database.NewDatabaseRepoImpl(a.UserContext, a.DBPool)
+	db_resp, err_resp := db_repo.ShowOne(db_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	level, err := db_repo.AccessLevel(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_db_access_error"))
+	}
+	if level != "" {
+		return &db_resp.Database, nil
+	}
+
+	approver, err := db_repo.HasPermission(db_resp.Database.ID, database.PermissionApproveChanges)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_permission_error"))
+	}
+	if !approver {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_db_access"))
+	}
+
+	return &db_resp.Database, nil
+}
+
+// administers reports whether the current user has admin access on the
+// database of the change, a workspace database has several admins
+func (a *ApprovalRepoImpl) administers(change PendingChange) bool {
+	db_repo := database.NewDatabaseRepoImpl(a.UserContext, a.DBPool)
+	db_resp, err_resp := db_repo.ShowOne(change.DBUUID)
+	if err_resp != nil {
+		return false
+	}
+
+	level, err := db_repo.AccessLevel(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog("approval_review_failed", err.Error(), "error")
+		return false
+	}
+
+	return level == constants.AccessLevelAdmin
+}
+
+// execute runs an approved change and returns what should be stored as its
+// result
+func (a *ApprovalRepoImpl) execute(change PendingChange) (interface{}, error) {
+	switch change.Kind {
+	case database.ChangeKindQuery:
+		db_repo := database.NewDatabaseRepoImpl(a.UserContext, a.DBPool)
+		db_resp, err_resp := db_repo.ShowOne(change.DBUUID)
+		if err_resp != nil {
+			return nil, err_resp.Err
+		}
+
+		query_resp, err_detail := db_repo.Execute(db_resp.Database, change.Statement)
+		if err_detail != nil {
+			if err_detail.Detail != nil && err_detail.Detail.Error() != "" {
+				return nil, fmt.Errorf("%s: %w", err_detail.Err.Error(), err_detail.Detail)
+			}
+			return nil, err_detail.Err
+		}
+		return query_resp.QueryResult, nil
+
+	case database.ChangeKindRestore:
+		if change.RestoreUUID == nil {
+			return nil, fmt.Errorf("restore of change %s no longer exists", change.ChangeUUID)
+		}
+
+		// the restore runs in the background and tracks its own progress
+		restore_resp, err_resp := restore.NewRestoreRepoImpl(a.UserContext, a.DBPool).Approve(*change.RestoreUUID)
+		if err_resp != nil {
+			return nil, err_resp.Err
+		}
+		return restore_resp.Restore, nil
+
+	case database.ChangeKindImport:
+		var payload importer.ImportPayload
+		if err := decodePayload(change, &payload); err != nil {
+			return nil, err
+		}
+
+		import_resp, err_resp := importer.NewImportRepoImpl(a.UserContext, a.DBPool).Approve(change.DBUUID, payload)
+		if err_resp != nil {
+			return nil, err_resp.Err
+		}
+		return import_resp.Import, nil
+
+	case database.ChangeKindTransfer:
+		var payload transfer.TransferPayload
+		if err := decodePayload(change, &payload); err != nil {
+			return nil, err
+		}
+
+		// the transfer runs in the background and tracks its own progress
+		transfer_resp, err_resp := transfer.NewTransferRepoImpl(a.UserContext, a.DBPool).Approve(payload.TransferUUID)
+		if err_resp != nil {
+			return nil, err_resp.Err
+		}
+		return transfer_resp.Transfer, nil
+
+	case database.ChangeKindMigration:
+		var payload migration.MigrationPayload
+		if err := decodePayload(change, &payload); err != nil {
+			return nil, err
+		}
+
+		run_resp, err_detail := migration.NewMigrationRepoImpl(a.UserContext, a.DBPool).Approve(change.DBUUID, payload)
+		if err_detail != nil {
+			if err_detail.Detail != nil && err_detail.Detail.Error() != "" {
+				return nil, fmt.Errorf("%s: %w", err_detail.Err.Error(), err_detail.Detail)
+			}
+			return nil, err_detail.Err
+		}
+		return run_resp.MigrationRun, nil
+
+	case database.ChangeKindLua:
+		var payload database.DatabaseLuaRequest
+		if err := decodePayload(change, &payload); err != nil {
+			return nil, err
+		}
+		if err := payload.DecodeArgs(); err != nil {
+			return nil, err
+		}
+
+		db_repo := database.NewDatabaseRepoImpl(a.UserContext, a.DBPool)
+		db_resp, err_resp := db_repo.ShowOne(change.DBUUID)
+		if err_resp != nil {
+			return nil, err_resp.Err
+		}
+
+		lua_resp, err_detail := db_repo.ExecuteLua(db_resp.Database, payload)
+		if err_detail != nil {
+			if err_detail.Detail != nil && err_detail.Detail.Error() != "" {
+				return nil, fmt.Errorf("%s: %w", err_detail.Err.Error(), err_detail.Detail)
+			}
+			return nil, err_detail.Err
+		}
+		return lua_resp.LuaResult, nil
+	}
+
+	return nil, fmt.Errorf("unknown change kind %s", change.Kind)
+}
+
+// decodePayload reads what a change that is not a query runs with
+func decodePayload(change PendingChange, payload interface{}) error {
+	if !change.Payload.Valid {
+		return fmt.Errorf("change %s has no payload", change.ChangeUUID)
+	}
+	return json.Unmarshal(change.Payload.JSONText, payload)
+}
+
+// finish stores the outcome of an approved change
+func (a *ApprovalRepoImpl) finish(change_id uint64, status string, result interface{}, error_message string) {
+	var result_data sqlx_types.NullJSONText
+	if result != nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			custom_log.NewCustomLog("approval_execute_failed", err.Error(), "error")
+		} else {
+			result_data = sqlx_types.NullJSONText{JSONText: encoded, Valid: true}
+		}
+	}
+
+	var message *string
+	if error_message != "" {
+		message = &error_message
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_pending_changes SET
+			change_status = $1, result = $2, error_message = $3, executed_at = $4
+		WHERE id = $5
+	`
+
+	// execute request
+	if _, err := a.DBPool.Exec(query, status, result_data, message, utils.Now(), change_id); err != nil {
+		custom_log.NewCustomLog("approval_execute_failed", err.Error(), "error")
+	}
+}
+
+// audit records the review on behalf of the requester, the reviewer is the
+// operator of the row
+func (a *ApprovalRepoImpl) audit(change PendingChange, context string, outcome string) {
+	desc := fmt.Sprintf(
+		"%s change %s on database %s requested by %s, %s: %s",
+		change.Kind, change.ChangeUUID, change.DBName, change.RequestedName, outcome, change.Statement,
+	)
+
+	if _, err := utils.AddUserAuditLog(
+		int(change.RequestedBy),
+		context,
+		desc,
+		constants.AuditTypeApproval,
+		&change.DBID,
+		a.UserContext.UserAgent,
+		a.UserContext.UserName,
+		a.UserContext.Ip,
+		a.UserContext.Id,
+		a.DBPool,
+	); err != nil {
+		custom_log.NewCustomLog("user_audit_create_failed", err.Error(), "error")
+	}
+}
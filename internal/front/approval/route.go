@@ -0,0 +1,34 @@
+package approval
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ApprovalRoute struct {
+	App             *fiber.App
+	DBPool          *sqlx.DB
+	ApprovalHandler *ApprovalHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *ApprovalRoute {
+	return &ApprovalRoute{
+		App:             app,
+		DBPool:          db_pool,
+		ApprovalHandler: NewApprovalHandler(db_pool),
+	}
+}
+
+func (a *ApprovalRoute) RegisterApprovalRoute() *ApprovalRoute {
+	approval := a.App.Group("/api/v1/front/approval")
+
+	approval.Get("/change/:change_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), a.ApprovalHandler.ShowOne)
+	approval.Post("/change/:change_uuid/approve", middlewares.RequirePermission(constants.PermissionRunDML), a.ApprovalHandler.Approve)
+	approval.Post("/change/:change_uuid/reject", middlewares.RequirePermission(constants.PermissionRunDML), a.ApprovalHandler.Reject)
+	approval.Get("/:db_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), a.ApprovalHandler.List)
+
+	return a
+}
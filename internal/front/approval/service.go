@@ -0,0 +1,45 @@
+package approval
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ApprovalServiceCreator interface {
+	List(db_uuid string, list_req ApprovalListRequest) ([]PendingChange, int, *responses.ErrorResponse)
+	ShowOne(change_uuid string) (*PendingChangeResponse, *responses.ErrorResponse)
+	Approve(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse)
+	Reject(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse)
+}
+
+type ApprovalService struct {
+	DBPool       *sqlx.DB
+	ApprovalRepo *ApprovalRepoImpl
+	UserContext  *types.UserContext
+}
+
+func NewApprovalService(us_ctx *types.UserContext, db_pool *sqlx.DB) *ApprovalService {
+	return &ApprovalService{
+		DBPool:       db_pool,
+		ApprovalRepo: NewApprovalRepoImpl(us_ctx, db_pool),
+		UserContext:  us_ctx,
+	}
+}
+
+func (a *ApprovalService) List(db_uuid string, list_req ApprovalListRequest) ([]PendingChange, int, *responses.ErrorResponse) {
+	return a.ApprovalRepo.List(db_uuid, list_req)
+}
+
+func (a *ApprovalService) ShowOne(change_uuid string) (*PendingChangeResponse, *responses.ErrorResponse) {
+	return a.ApprovalRepo.ShowOne(change_uuid)
+}
+
+func (a *ApprovalService) Approve(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse) {
+	return a.ApprovalRepo.Approve(change_uuid, review_req)
+}
+
+func (a *ApprovalService) Reject(change_uuid string, review_req ApprovalReviewRequest) (*PendingChangeResponse, *responses.ErrorResponse) {
+	return a.ApprovalRepo.Reject(change_uuid, review_req)
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/redis"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// redis key holding the id of the replica allowed to take checkpoints
+const checkpointerLockKey = "audit_checkpoint:leader"
+
+type Checkpointer struct {
+	DBPool   *sqlx.DB
+	Owner    string
+	Interval time.Duration
+}
+
+func NewCheckpointer(db_pool *sqlx.DB) *Checkpointer {
+	hostname, _ := os.Hostname()
+
+	return &Checkpointer{
+		DBPool:   db_pool,
+		Owner:    fmt.Sprintf("%s-%s", hostname, uuid.NewString()),
+		Interval: time.Duration(utils.GetenvInt("AUDIT_CHECKPOINT_INTERVAL_MINUTES", 1440)) * time.Minute,
+	}
+}
+
+// Start takes a signed checkpoint of the audit chain periodically, it does
+// nothing while AUDIT_CHECKPOINT_KEY is not set
+func (c *Checkpointer) Start() {
+	if _, err := CheckpointKey(); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_disabled", err.Error(), "warn")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			c.tick()
+		}
+	}()
+}
+
+func (c *Checkpointer) tick() {
+	// the lock expires before the next tick so another replica can take over
+	is_leader, err := redis.AcquireLock(context.Background(), checkpointerLockKey, c.Owner, c.Interval*9/10)
+	if err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_failed", err.Error(), "error")
+		return
+	}
+	if !is_leader {
+		return
+	}
+
+	// scheduled checkpoints are taken by the system, not by a user
+	audit_repo := NewAuditRepoImpl(&types.UserContext{}, c.DBPool)
+
+	if err := utils.SealUserAudits(c.DBPool); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_failed", err.Error(), "error")
+		return
+	}
+
+	// skip the checkpoint when nothing was audited since the last one
+	last_seq, err := audit_repo.lastCheckpointSeq()
+	if err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_failed", err.Error(), "error")
+		return
+	}
+	var chain_seq uint64
+	if err := c.DBPool.Get(&chain_seq, `SELECT COALESCE(MAX(chain_seq), 0) FROM tbl_users_audits`); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_failed", err.Error(), "error")
+		return
+	}
+	if chain_seq == 0 || chain_seq == last_seq {
+		return
+	}
+
+	if _, err_resp := audit_repo.CreateCheckpoint(); err_resp != nil {
+		custom_log.NewCustomLog("audit_checkpoint_failed", err_resp.Err.Error(), "error")
+	}
+}
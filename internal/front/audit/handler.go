@@ -0,0 +1,211 @@
+package audit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditHandler struct {
+	DBPool       *sqlx.DB
+	AuditService func(c *fiber.Ctx) *AuditService
+}
+
+func NewAuditHandler(db_pool *sqlx.DB) *AuditHandler {
+	return &AuditHandler{
+		DBPool: db_pool,
+		AuditService: func(c *fiber.Ctx) *AuditService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewAuditService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (a *AuditHandler) List(c *fiber.Ctx) error {
+	var list_req AuditListRequest
+	v := utils.NewValidator()
+	if err := list_req.bind(c, v, "audit_list_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("audit_list_failed", nil, c),
+				-13000,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := a.AuditService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-13000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("audit_list_success", nil, c),
+			13000,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (a *AuditHandler) Export(c *fiber.Ctx) error {
+	var list_req AuditListRequest
+	v := utils.NewValidator()
+	if err := list_req.bind(c, v, "audit_export_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("audit_export_failed", nil, c),
+				-13001,
+				err,
+			),
+		)
+	}
+
+	resp, err := a.AuditService(c).Export(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-13001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	c.Set(fiber.HeaderContentType, resp.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, resp.FileName))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the status is already sent, failures can only be logged
+		if _, err := resp.Write(w); err != nil {
+			custom_log.NewCustomLog("audit_export_failed", err.Error(), "error")
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+func (a *AuditHandler) Verify(c *fiber.Ctx) error {
+	resp, err := a.AuditService(c).Verify()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-13002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	message := "audit_verify_success"
+	if !resp.Verification.Valid {
+		message = "audit_verify_broken"
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate(message, nil, c),
+			13002,
+			resp,
+		),
+	)
+}
+
+func (a *AuditHandler) ListCheckpoints(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, checkpointListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("audit_checkpoint_list_failed", nil, c),
+				-13003,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := a.AuditService(c).ListCheckpoints(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-13003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("audit_checkpoint_list_success", nil, c),
+			13003,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (a *AuditHandler) CreateCheckpoint(c *fiber.Ctx) error {
+	resp, err := a.AuditService(c).CreateCheckpoint()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-13004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("audit_checkpoint_create_success", nil, c),
+			13004,
+			resp,
+		),
+	)
+}
+
+func (a *AuditHandler) DownloadCheckpoint(c *fiber.Ctx) error {
+	checkpoint_uuid := c.Params("checkpoint_uuid")
+
+	resp, err := a.AuditService(c).ShowCheckpoint(checkpoint_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-13005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Download(resp.Checkpoint.FilePath, fmt.Sprintf("audit_checkpoint_%s.json", resp.Checkpoint.CheckpointUUID))
+}
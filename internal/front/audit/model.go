@@ -0,0 +1,347 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	export_utils "tarantool-admin-api/pkg/export"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserAudit struct {
+	ID          uint64    `json:"-" db:"id"`
+	AuditUUID   string    `json:"audit_uuid" db:"user_audit_uuid"`
+	UserID      int       `json:"-" db:"user_id"`
+	UserUUID    *string   `json:"user_uuid" db:"user_uuid"`
+	UserName    *string   `json:"user_name" db:"user_name"`
+	Action      string    `json:"action" db:"user_audit_context"`
+	Description string    `json:"description" db:"user_audit_desc"`
+	AuditTypeID int       `json:"audit_type_id" db:"audit_type_id"`
+	DBUUID      *string   `json:"db_uuid" db:"db_uuid"`
+	DBName      *string   `json:"db_name" db:"db_name"`
+	Operator    string    `json:"operator" db:"operator"`
+	IP          string    `json:"ip" db:"ip"`
+	UserAgent   string    `json:"user_agent" db:"user_agent"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// exportColumns are the columns of an exported audit log, in the order of
+// UserAudit.row
+var exportColumns = []string{
+	"audit_uuid", "created_at", "user_uuid", "user_name", "action", "audit_type_id",
+	"description", "db_uuid", "db_name", "operator", "ip", "user_agent",
+}
+
+func (u UserAudit) row() []interface{} {
+	return []interface{}{
+		u.AuditUUID, u.CreatedAt.Format(time.RFC3339), u.UserUUID, u.UserName, u.Action, u.AuditTypeID,
+		u.Description, u.DBUUID, u.DBName, u.Operator, u.IP, u.UserAgent,
+	}
+}
+
+var auditListFields = postgres.ListFields{
+	Filter:      []string{"audit_uuid", "user_name", "action", "audit_type_id", "db_uuid", "db_name", "operator", "ip", "created_at"},
+	Sort:        []string{"user_name", "action", "audit_type_id", "db_name", "created_at"},
+	Columns:     map[string]string{"audit_uuid": "user_audit_uuid", "action": "user_audit_context"},
+	DefaultSort: "id DESC",
+}
+
+// AuditExport is an audit log export ready to be streamed to the client
+type AuditExport struct {
+	FileName    string
+	ContentType string
+	Write       func(w io.Writer) (uint64, error)
+}
+
+// AuditListRequest filters the audit log, from and to take a date or an
+// RFC3339 time, a date in to includes the whole day
+type AuditListRequest struct {
+	UserUUID    string    `query:"user_uuid" validate:"omitempty,uuid"`
+	AuditTypeID int       `query:"audit_type_id" validate:"omitempty,min=1"`
+	Action      string    `query:"action"`
+	DBUUID      string    `query:"db_uuid" validate:"omitempty,uuid"`
+	From        string    `query:"from"`
+	To          string    `query:"to"`
+	Search      string    `query:"search"`
+	Format      string    `query:"format" validate:"omitempty,oneof=csv json ndjson"`
+	FromTime    time.Time `query:"-"`
+	ToTime      time.Time `query:"-"`
+
+	types.ListRequest `query:"-"`
+}
+
+func (a *AuditListRequest) bind(c *fiber.Ctx, v *utils.Validator, message_id string) error {
+	if err := c.QueryParser(a); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(a, c); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return err
+	}
+
+	if a.From != "" {
+		from, _, err := parseTime(a.From)
+		if err != nil {
+			custom_log.NewCustomLog(message_id, err.Error(), "error")
+			return errors.New(utils.Translate("invalid", map[string]interface{}{"field": "from"}, c))
+		}
+		a.FromTime = from
+	}
+
+	if a.To != "" {
+		to, date_only, err := parseTime(a.To)
+		if err != nil {
+			custom_log.NewCustomLog(message_id, err.Error(), "error")
+			return errors.New(utils.Translate("invalid", map[string]interface{}{"field": "to"}, c))
+		}
+		if date_only {
+			to = to.AddDate(0, 0, 1)
+		}
+		a.ToTime = to
+	}
+
+	if a.Format == "" {
+		a.Format = export_utils.FormatCSV
+	}
+
+	return utils.BindList(c, &a.ListRequest, auditListFields)
+}
+
+// where builds the filter of the request on top of the rows the user may
+// see, its own actions, the actions done to it and those on its databases
+func (a *AuditListRequest) where(user_id int) (string, []interface{}) {
+	conditions := []string{
+		"au.deleted_at IS NULL",
+		"(au.user_id = $1 OR au.created_by = $1 OR db.user_id = $1)",
+	}
+	args := []interface{}{user_id}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if a.UserUUID != "" {
+		add("us.user_uuid = $%d", a.UserUUID)
+	}
+	if a.AuditTypeID != 0 {
+		add("au.audit_type_id = $%d", a.AuditTypeID)
+	}
+	if a.Action != "" {
+		add("au.user_audit_context = $%d", a.Action)
+	}
+	if a.DBUUID != "" {
+		add("db.db_uuid = $%d", a.DBUUID)
+	}
+	if !a.FromTime.IsZero() {
+		add("au.created_at >= $%d", a.FromTime)
+	}
+	if !a.ToTime.IsZero() {
+		add("au.created_at < $%d", a.ToTime)
+	}
+	if a.Search != "" {
+		args = append(args, "%"+a.Search+"%")
+		conditions = append(conditions, fmt.Sprintf(
+			"(au.user_audit_desc ILIKE $%[1]d OR au.user_audit_context ILIKE $%[1]d OR au.operator ILIKE $%[1]d OR au.ip ILIKE $%[1]d)",
+			len(args),
+		))
+	}
+
+	return "WHERE " + strings.Join(conditions, "\n\t\tAND "), args
+}
+
+// parseTime reads a date or an RFC3339 time as wall clock time of the app
+// timezone, which is how audit rows are stored
+func parseTime(value string) (time.Time, bool, error) {
+	location := utils.Now().Location()
+
+	if t, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return wallClock(t), true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return wallClock(t.In(location)), false, nil
+}
+
+// wallClock drops the location so the time compares as a plain timestamp
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+const (
+	// reasons a link of the audit chain is broken
+	ReasonRowMissing      = "row_missing"
+	ReasonPrevHash        = "prev_hash_mismatch"
+	ReasonRowHash         = "row_hash_mismatch"
+	ReasonSignature       = "signature_mismatch"
+	ReasonCheckpointHash  = "checkpoint_hash_mismatch"
+	ReasonCheckpointAhead = "checkpoint_row_missing"
+)
+
+type AuditBrokenLink struct {
+	ChainSeq  uint64 `json:"chain_seq"`
+	AuditUUID string `json:"audit_uuid,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+type AuditBrokenCheckpoint struct {
+	CheckpointUUID string `json:"checkpoint_uuid"`
+	ChainSeq       uint64 `json:"chain_seq"`
+	Reason         string `json:"reason"`
+}
+
+// AuditVerification is the outcome of walking the audit chain, the walk
+// stops at the first broken link
+type AuditVerification struct {
+	Valid              bool                   `json:"valid"`
+	RowsChecked        uint64                 `json:"rows_checked"`
+	LastChainSeq       uint64                 `json:"last_chain_seq"`
+	LastHash           string                 `json:"last_hash"`
+	BrokenLink         *AuditBrokenLink       `json:"broken_link"`
+	CheckpointsChecked int                    `json:"checkpoints_checked"`
+	BrokenCheckpoint   *AuditBrokenCheckpoint `json:"broken_checkpoint"`
+	VerifiedAt         time.Time              `json:"verified_at"`
+}
+
+type AuditVerificationResponse struct {
+	Verification AuditVerification `json:"verification"`
+}
+
+type AuditCheckpoint struct {
+	ID             uint64    `json:"-" db:"id"`
+	CheckpointUUID string    `json:"checkpoint_uuid" db:"checkpoint_uuid"`
+	ChainSeq       uint64    `json:"chain_seq" db:"chain_seq"`
+	RowHash        string    `json:"row_hash" db:"row_hash"`
+	Signature      string    `json:"signature" db:"signature"`
+	FilePath       string    `json:"-" db:"file_path"`
+	CreatedBy      *uint64   `json:"-" db:"created_by"`
+	CreatedByName  *string   `json:"created_by" db:"created_by_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type AuditCheckpointResponse struct {
+	Checkpoint AuditCheckpoint `json:"checkpoint"`
+}
+
+// checkpointListFields are the columns the checkpoint list filters and sorts on
+var checkpointListFields = postgres.ListFields{
+	Filter:      []string{"checkpoint_uuid", "chain_seq", "row_hash", "created_by", "created_at"},
+	Sort:        []string{"chain_seq", "created_by", "created_at"},
+	Columns:     map[string]string{"created_by": "created_by_name"},
+	DefaultSort: "id DESC",
+}
+
+// AuditCheckpointFile is the exported checkpoint, the signature is an
+// HMAC-SHA256 of the other fields with AUDIT_CHECKPOINT_KEY so holding the
+// file proves the state of the chain at that time
+type AuditCheckpointFile struct {
+	CheckpointUUID string `json:"checkpoint_uuid"`
+	ChainSeq       uint64 `json:"chain_seq"`
+	RowHash        string `json:"row_hash"`
+	CreatedAt      string `json:"created_at"`
+	Algorithm      string `json:"algorithm"`
+	Signature      string `json:"signature"`
+}
+
+type AuditCheckpointNewModel struct {
+	ID             uint64    `db:"id"`
+	CheckpointUUID string    `db:"checkpoint_uuid"`
+	ChainSeq       uint64    `db:"chain_seq"`
+	RowHash        string    `db:"row_hash"`
+	Signature      string    `db:"signature"`
+	FilePath       string    `db:"file_path"`
+	CreatedBy      *uint64   `db:"created_by"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (a *AuditCheckpointNewModel) new(chain_seq uint64, row_hash string, key []byte, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_audit_checkpoints_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	a.ID = uint64(*id)
+	a.CheckpointUUID = uuid.String()
+	a.ChainSeq = chain_seq
+	a.RowHash = row_hash
+	// postgres keeps microseconds, the signature must survive a round trip
+	a.CreatedAt = utils.Now().Truncate(time.Microsecond)
+	a.Signature = signCheckpoint(key, a.CheckpointUUID, a.ChainSeq, a.RowHash, a.CreatedAt)
+	a.FilePath = filepath.Join(CheckpointDir(), a.CheckpointUUID+".json")
+	if us_ctx.Id != 0 {
+		created_by := uint64(us_ctx.Id)
+		a.CreatedBy = &created_by
+	}
+
+	return nil
+}
+
+// file returns the exported form of the checkpoint
+func (a *AuditCheckpointNewModel) file() AuditCheckpointFile {
+	return AuditCheckpointFile{
+		CheckpointUUID: a.CheckpointUUID,
+		ChainSeq:       a.ChainSeq,
+		RowHash:        a.RowHash,
+		CreatedAt:      checkpointTime(a.CreatedAt),
+		Algorithm:      "HMAC-SHA256",
+		Signature:      a.Signature,
+	}
+}
+
+// signCheckpoint signs the checkpoint fields with the checkpoint key
+func signCheckpoint(key []byte, checkpoint_uuid string, chain_seq uint64, row_hash string, created_at time.Time) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s|%d|%s|%s", checkpoint_uuid, chain_seq, row_hash, checkpointTime(created_at))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkpointTime formats the checkpoint time as wall clock time of the app
+// timezone, the way postgres stores it
+func checkpointTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.999999")
+}
+
+// CheckpointKey returns the key signing audit checkpoints
+func CheckpointKey() ([]byte, error) {
+	key := os.Getenv("AUDIT_CHECKPOINT_KEY")
+	if key == "" {
+		return nil, errors.New("AUDIT_CHECKPOINT_KEY is not set")
+	}
+	return []byte(key), nil
+}
+
+// CheckpointDir returns the local directory where checkpoints are exported
+func CheckpointDir() string {
+	dir := os.Getenv("AUDIT_CHECKPOINT_DIR")
+	if dir == "" {
+		dir = "./storage/audit_checkpoints"
+	}
+	return dir
+}
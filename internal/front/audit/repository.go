@@ -0,0 +1,427 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	export_utils "tarantool-admin-api/pkg/export"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditRepo interface {
+	List(list_req AuditListRequest) ([]UserAudit, int, *responses.ErrorResponse)
+	Export(list_req AuditListRequest) (*AuditExport, *responses.ErrorResponse)
+	Verify() (*AuditVerificationResponse, *responses.ErrorResponse)
+	ListCheckpoints(list_req types.ListRequest) ([]AuditCheckpoint, int, *responses.ErrorResponse)
+	ShowCheckpoint(checkpoint_uuid string) (*AuditCheckpointResponse, *responses.ErrorResponse)
+	CreateCheckpoint() (*AuditCheckpointResponse, *responses.ErrorResponse)
+}
+
+type AuditRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewAuditRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *AuditRepoImpl {
+	return &AuditRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectUserAudit = `
+	SELECT
+		au.id, au.user_audit_uuid, au.user_id, us.user_uuid, us.user_name,
+		au.user_audit_context, au.user_audit_desc, au.audit_type_id, db.db_uuid,
+		db.db_name, au.operator, au.ip, au.user_agent, au.created_at
+	FROM tbl_users_audits au
+	LEFT JOIN tbl_users us ON us.id = au.user_id
+	LEFT JOIN tbl_users_databases db ON db.id = au.db_id
+`
+
+// List returns a page of the audit log, newest first unless sorted
+// otherwise, along with the number of rows matching the filters
+func (a *AuditRepoImpl) List(list_req AuditListRequest) ([]UserAudit, int, *responses.ErrorResponse) {
+	where, args := list_req.where(a.UserContext.Id)
+
+	// execute query
+	var audits []UserAudit
+	total, err := postgres.SelectList(a.DBPool, &audits, selectUserAudit+where, args, list_req.ListRequest, auditListFields)
+	if err != nil {
+		custom_log.NewCustomLog("audit_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("audit_list_failed", fmt.Errorf("get_audit_error"))
+	}
+
+	if audits == nil {
+		audits = []UserAudit{}
+	}
+
+	return audits, total, nil
+}
+
+// Export streams every audit row matching the filters, oldest first, the
+// paging and the sorts of the request are ignored
+func (a *AuditRepoImpl) Export(list_req AuditListRequest) (*AuditExport, *responses.ErrorResponse) {
+	where, args := list_req.where(a.UserContext.Id)
+
+	filter, filter_args, err := postgres.BuildSQLFilter(list_req.Filters, auditListFields, len(args)+1)
+	if err != nil {
+		custom_log.NewCustomLog("audit_export_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_export_failed", fmt.Errorf("get_audit_error"))
+	}
+	if filter != "" {
+		filter = "WHERE " + filter
+	}
+	args = append(args, filter_args...)
+
+	// prepare query
+	query := `SELECT * FROM (` + selectUserAudit + where + `) list_rows ` + filter + `
+		ORDER BY id
+	`
+
+	// check the filters before the response starts streaming
+	rows, err := a.DBPool.Queryx(query, args...)
+	if err != nil {
+		custom_log.NewCustomLog("audit_export_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_export_failed", fmt.Errorf("get_audit_error"))
+	}
+
+	return &AuditExport{
+		FileName:    export_utils.FileName("audit_log", list_req.Format, false),
+		ContentType: export_utils.ContentType(list_req.Format, false),
+		Write: func(w io.Writer) (uint64, error) {
+			defer rows.Close()
+
+			writer, err := export_utils.NewRowWriter(w, list_req.Format, exportColumns, false)
+			if err != nil {
+				return 0, err
+			}
+
+			for rows.Next() {
+				var audit UserAudit
+				if err := rows.StructScan(&audit); err != nil {
+					return 0, err
+				}
+				if err := writer.Write(audit.row()); err != nil {
+					return 0, err
+				}
+			}
+			if err := rows.Err(); err != nil {
+				return 0, err
+			}
+
+			return writer.Close()
+		},
+	}, nil
+}
+
+// number of chain rows read at a time while verifying
+const verifyBatchSize = 1000
+
+// Verify walks the whole audit chain from the first row, recomputing every
+// hash, and reports the first broken link, every checkpoint is then checked
+// against its signature and the chain
+func (a *AuditRepoImpl) Verify() (*AuditVerificationResponse, *responses.ErrorResponse) {
+	// rows written since the last seal are part of the walk
+	if err := utils.SealUserAudits(a.DBPool); err != nil {
+		custom_log.NewCustomLog("audit_verify_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_verify_failed", fmt.Errorf("error_seal_audit"))
+	}
+
+	checkpoints, err := a.checkpoints()
+	if err != nil {
+		custom_log.NewCustomLog("audit_verify_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_verify_failed", fmt.Errorf("get_audit_error"))
+	}
+
+	// hashes of the chain at the sequences a checkpoint was taken
+	checkpoint_hashes := map[uint64]string{}
+	for _, checkpoint := range checkpoints {
+		checkpoint_hashes[checkpoint.ChainSeq] = ""
+	}
+
+	verification := AuditVerification{Valid: true}
+
+	// prepare query
+	query := utils.SelectAuditChainRow + `
+		WHERE chain_seq > $1
+		ORDER BY chain_seq
+		LIMIT $2
+	`
+
+walk:
+	for {
+		// execute query
+		var rows []utils.AuditChainRow
+		if err := a.DBPool.Select(&rows, query, verification.LastChainSeq, verifyBatchSize); err != nil {
+			custom_log.NewCustomLog("audit_verify_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("audit_verify_failed", fmt.Errorf("get_audit_error"))
+		}
+
+		for _, row := range rows {
+			chain_seq := verification.LastChainSeq + 1
+			if *row.ChainSeq != chain_seq {
+				verification.BrokenLink = &AuditBrokenLink{ChainSeq: chain_seq, Reason: ReasonRowMissing}
+				break walk
+			}
+			if row.PrevHash == nil || *row.PrevHash != verification.LastHash {
+				verification.BrokenLink = &AuditBrokenLink{ChainSeq: chain_seq, AuditUUID: row.UserAuditUUID, Reason: ReasonPrevHash}
+				break walk
+			}
+			if row.RowHash == nil || *row.RowHash != row.Hash(chain_seq, verification.LastHash) {
+				verification.BrokenLink = &AuditBrokenLink{ChainSeq: chain_seq, AuditUUID: row.UserAuditUUID, Reason: ReasonRowHash}
+				break walk
+			}
+
+			verification.RowsChecked++
+			verification.LastChainSeq = chain_seq
+			verification.LastHash = *row.RowHash
+			if _, ok := checkpoint_hashes[chain_seq]; ok {
+				checkpoint_hashes[chain_seq] = *row.RowHash
+			}
+		}
+
+		if len(rows) < verifyBatchSize {
+			break
+		}
+	}
+
+	// a checkpoint beyond an intact chain means rows were removed from its end
+	if key, err := CheckpointKey(); err == nil {
+		for _, checkpoint := range checkpoints {
+			if verification.BrokenCheckpoint = checkCheckpoint(key, checkpoint, checkpoint_hashes, verification); verification.BrokenCheckpoint != nil {
+				break
+			}
+			verification.CheckpointsChecked++
+		}
+	}
+
+	verification.Valid = verification.BrokenLink == nil && verification.BrokenCheckpoint == nil
+	verification.VerifiedAt = utils.Now()
+
+	return &AuditVerificationResponse{
+		Verification: verification,
+	}, nil
+}
+
+// checkCheckpoint returns why the checkpoint does not match the chain, the
+// part of the chain after a broken link cannot be trusted and is skipped
+func checkCheckpoint(key []byte, checkpoint AuditCheckpoint, checkpoint_hashes map[uint64]string, verification AuditVerification) *AuditBrokenCheckpoint {
+	broken := func(reason string) *AuditBrokenCheckpoint {
+		return &AuditBrokenCheckpoint{
+			CheckpointUUID: checkpoint.CheckpointUUID,
+			ChainSeq:       checkpoint.ChainSeq,
+			Reason:         reason,
+		}
+	}
+
+	if !hmac.Equal([]byte(checkpoint.Signature), []byte(signCheckpoint(key, checkpoint.CheckpointUUID, checkpoint.ChainSeq, checkpoint.RowHash, checkpoint.CreatedAt))) {
+		return broken(ReasonSignature)
+	}
+	if checkpoint.ChainSeq > verification.LastChainSeq {
+		if verification.BrokenLink != nil {
+			return nil
+		}
+		return broken(ReasonCheckpointAhead)
+	}
+	if checkpoint_hashes[checkpoint.ChainSeq] != checkpoint.RowHash {
+		return broken(ReasonCheckpointHash)
+	}
+
+	return nil
+}
+
+const selectAuditCheckpoint = `
+	SELECT
+		cp.id, cp.checkpoint_uuid, cp.chain_seq, cp.row_hash, cp.signature,
+		cp.file_path, cp.created_by, us.user_name AS created_by_name, cp.created_at
+	FROM tbl_audit_checkpoints cp
+	LEFT JOIN tbl_users us ON us.id = cp.created_by
+`
+
+// checkpoints returns every checkpoint, oldest first
+func (a *AuditRepoImpl) checkpoints() ([]AuditCheckpoint, error) {
+	// prepare query
+	query := selectAuditCheckpoint + `
+		WHERE cp.deleted_at IS NULL
+		ORDER BY cp.chain_seq, cp.id
+	`
+
+	// execute query
+	var checkpoints []AuditCheckpoint
+	if err := a.DBPool.Select(&checkpoints, query); err != nil {
+		return nil, err
+	}
+
+	if checkpoints == nil {
+		checkpoints = []AuditCheckpoint{}
+	}
+
+	return checkpoints, nil
+}
+
+// ListCheckpoints returns a page of the checkpoints, newest first unless
+// sorted otherwise
+func (a *AuditRepoImpl) ListCheckpoints(list_req types.ListRequest) ([]AuditCheckpoint, int, *responses.ErrorResponse) {
+	// prepare query
+	query := selectAuditCheckpoint + `
+		WHERE cp.deleted_at IS NULL
+	`
+
+	// execute query
+	var checkpoints []AuditCheckpoint
+	total, err := postgres.SelectList(a.DBPool, &checkpoints, query, nil, list_req, checkpointListFields)
+	if err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("audit_checkpoint_list_failed", fmt.Errorf("get_checkpoint_error"))
+	}
+
+	if checkpoints == nil {
+		checkpoints = []AuditCheckpoint{}
+	}
+
+	return checkpoints, total, nil
+}
+
+func (a *AuditRepoImpl) ShowCheckpoint(checkpoint_uuid string) (*AuditCheckpointResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectAuditCheckpoint + `
+		WHERE cp.deleted_at IS NULL
+		AND cp.checkpoint_uuid = $1
+	`
+
+	// execute query
+	var checkpoint AuditCheckpoint
+	if err := a.DBPool.Get(&checkpoint, query, checkpoint_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("audit_checkpoint_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("audit_checkpoint_show_failed", fmt.Errorf("no_checkpoint_found"))
+		}
+		custom_log.NewCustomLog("audit_checkpoint_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_show_failed", fmt.Errorf("get_checkpoint_error"))
+	}
+
+	return &AuditCheckpointResponse{
+		Checkpoint: checkpoint,
+	}, nil
+}
+
+// CreateCheckpoint signs the current end of the chain and exports it as a
+// file, the file is written before the row so a listed checkpoint always
+// has one
+func (a *AuditRepoImpl) CreateCheckpoint() (*AuditCheckpointResponse, *responses.ErrorResponse) {
+	key, err := CheckpointKey()
+	if err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("audit_checkpoint_key_missing"))
+	}
+
+	if err := utils.SealUserAudits(a.DBPool); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("error_seal_audit"))
+	}
+
+	// get the end of the chain
+	var last struct {
+		ChainSeq uint64 `db:"chain_seq"`
+		RowHash  string `db:"row_hash"`
+	}
+	err = a.DBPool.Get(&last, `
+		SELECT chain_seq, row_hash
+		FROM tbl_users_audits
+		WHERE chain_seq IS NOT NULL
+		ORDER BY chain_seq DESC
+		LIMIT 1
+	`)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("audit_log_empty"))
+		}
+		custom_log.NewCustomLog("audit_checkpoint_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("get_audit_error"))
+	}
+
+	var checkpoint AuditCheckpointNewModel
+	if err := checkpoint.new(last.ChainSeq, last.RowHash, key, a.UserContext, a.DBPool); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("error_create_checkpoint"))
+	}
+
+	if err := writeCheckpoint(checkpoint.FilePath, checkpoint.file()); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("error_create_checkpoint"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_audit_checkpoints (
+			id, checkpoint_uuid, chain_seq, row_hash, signature, file_path,
+			created_by, created_at
+		) VALUES (
+			:id, :checkpoint_uuid, :chain_seq, :row_hash, :signature, :file_path,
+			:created_by, :created_at
+		)
+	`
+
+	// execute query
+	if _, err := a.DBPool.NamedExec(query, checkpoint); err != nil {
+		custom_log.NewCustomLog("audit_checkpoint_create_failed", err.Error(), "error")
+		os.Remove(checkpoint.FilePath)
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("audit_checkpoint_create_failed", fmt.Errorf("error_create_checkpoint"))
+	}
+
+	return a.ShowCheckpoint(checkpoint.CheckpointUUID)
+}
+
+// lastCheckpointSeq returns the chain sequence of the latest checkpoint, 0
+// when there is none
+func (a *AuditRepoImpl) lastCheckpointSeq() (uint64, error) {
+	var chain_seq uint64
+	err := a.DBPool.Get(&chain_seq, `
+		SELECT COALESCE(MAX(chain_seq), 0)
+		FROM tbl_audit_checkpoints
+		WHERE deleted_at IS NULL
+	`)
+	return chain_seq, err
+}
+
+// writeCheckpoint exports the checkpoint into a local json file
+func writeCheckpoint(file_path string, checkpoint AuditCheckpointFile) error {
+	if err := os.MkdirAll(filepath.Dir(file_path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file_path, data, 0644)
+}
@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditRoute struct {
+	App          *fiber.App
+	DBPool       *sqlx.DB
+	AuditHandler *AuditHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *AuditRoute {
+	return &AuditRoute{
+		App:          app,
+		DBPool:       db_pool,
+		AuditHandler: NewAuditHandler(db_pool),
+	}
+}
+
+func (a *AuditRoute) RegisterAuditRoute() *AuditRoute {
+	audit := a.App.Group("/api/v1/front/audit")
+
+	audit.Get("/", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.List)
+	audit.Get("/search", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.List)
+	audit.Get("/export", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.Export)
+	audit.Get("/verify", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.Verify)
+	audit.Get("/checkpoint", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.ListCheckpoints)
+	audit.Post("/checkpoint", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.CreateCheckpoint)
+	audit.Get("/checkpoint/:checkpoint_uuid/download", middlewares.RequirePermission(constants.PermissionViewAudit), a.AuditHandler.DownloadCheckpoint)
+
+	return a
+}
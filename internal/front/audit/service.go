@@ -0,0 +1,55 @@
+package audit
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditServiceCreator interface {
+	List(list_req AuditListRequest) ([]UserAudit, int, *responses.ErrorResponse)
+	Export(list_req AuditListRequest) (*AuditExport, *responses.ErrorResponse)
+	Verify() (*AuditVerificationResponse, *responses.ErrorResponse)
+	ListCheckpoints(list_req types.ListRequest) ([]AuditCheckpoint, int, *responses.ErrorResponse)
+	ShowCheckpoint(checkpoint_uuid string) (*AuditCheckpointResponse, *responses.ErrorResponse)
+	CreateCheckpoint() (*AuditCheckpointResponse, *responses.ErrorResponse)
+}
+
+type AuditService struct {
+	DBPool      *sqlx.DB
+	AuditRepo   *AuditRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewAuditService(us_ctx *types.UserContext, db_pool *sqlx.DB) *AuditService {
+	return &AuditService{
+		DBPool:      db_pool,
+		AuditRepo:   NewAuditRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (a *AuditService) List(list_req AuditListRequest) ([]UserAudit, int, *responses.ErrorResponse) {
+	return a.AuditRepo.List(list_req)
+}
+
+func (a *AuditService) Export(list_req AuditListRequest) (*AuditExport, *responses.ErrorResponse) {
+	return a.AuditRepo.Export(list_req)
+}
+
+func (a *AuditService) Verify() (*AuditVerificationResponse, *responses.ErrorResponse) {
+	return a.AuditRepo.Verify()
+}
+
+func (a *AuditService) ListCheckpoints(list_req types.ListRequest) ([]AuditCheckpoint, int, *responses.ErrorResponse) {
+	return a.AuditRepo.ListCheckpoints(list_req)
+}
+
+func (a *AuditService) ShowCheckpoint(checkpoint_uuid string) (*AuditCheckpointResponse, *responses.ErrorResponse) {
+	return a.AuditRepo.ShowCheckpoint(checkpoint_uuid)
+}
+
+func (a *AuditService) CreateCheckpoint() (*AuditCheckpointResponse, *responses.ErrorResponse) {
+	return a.AuditRepo.CreateCheckpoint()
+}
@@ -1,9 +1,12 @@
 package auth
 
 import (
-	"fmt"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	response "tarantool-admin-api/pkg/http/response"
+	types "tarantool-admin-api/pkg/model"
 	"tarantool-admin-api/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,13 +15,22 @@ import (
 
 type AuthHandler struct {
 	DBPool      *sqlx.DB
-	AuthService *AuthService
+	AuthService func(c *fiber.Ctx) *AuthService
 }
 
 func NewAuthHandler(db_pool *sqlx.DB) *AuthHandler {
 	return &AuthHandler{
-		DBPool:      db_pool,
-		AuthService: NewAuthService(db_pool),
+		DBPool: db_pool,
+		AuthService: func(c *fiber.Ctx) *AuthService {
+			// auth routes are public, only the client is known
+			us_ctx := types.UserContext{
+				UserAgent: string(c.Context().UserAgent()),
+				Ip:        c.Context().RemoteIP().String(),
+				Language:  utils.Language(c),
+			}
+
+			return NewAuthService(&us_ctx, db_pool)
+		},
 	}
 }
 
@@ -46,13 +58,13 @@ func (au *AuthHandler) Login(c *fiber.Ctx) error {
 		)
 	}
 
-	resp, err := au.AuthService.Login(login_request.UserName, login_request.Password)
+	resp, err := au.AuthService(c).Login(login_request.UserName, login_request.Password, login_request.Device)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(
+		return c.Status(loginStatus(c, err.Err, http.StatusBadRequest)).JSON(
 			response.NewResponseError(
 				utils.Translate(err.MessageID, nil, c),
 				-1000,
-				fmt.Errorf(utils.Translate(err.Err.Error(), nil, c)),
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
 			),
 		)
 	}
@@ -80,13 +92,13 @@ func (au *AuthHandler) Register(c *fiber.Ctx) error {
 		)
 	}
 
-	register_resp, err := au.AuthService.Register(register_req)
+	register_resp, err := au.AuthService(c).Register(register_req)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(
 			response.NewResponseError(
 				utils.Translate(err.MessageID, nil, c),
 				-1001,
-				fmt.Errorf(utils.Translate(err.Err.Error(), nil, c)),
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
 			),
 		)
 	}
@@ -99,3 +111,308 @@ func (au *AuthHandler) Register(c *fiber.Ctx) error {
 		),
 	)
 }
+
+func (au *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var refresh_req RefreshRequest
+	v := utils.NewValidator()
+
+	if err := refresh_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("refresh_failed", nil, c),
+				-1002,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).Refresh(refresh_req)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("refresh_success", nil, c),
+			1002,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) OIDCAuthorize(c *fiber.Ctx) error {
+	resp, err := au.AuthService(c).OIDCAuthorize()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("oidc_authorize_success", nil, c),
+			1003,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) OIDCCallback(c *fiber.Ctx) error {
+	var callback_req OIDCCallbackRequest
+	v := utils.NewValidator()
+
+	if err := callback_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("oidc_login_failed", nil, c),
+				-1004,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).OIDCCallback(callback_req)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("login_success", nil, c),
+			1004,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) MFAVerify(c *fiber.Ctx) error {
+	var verify_req MFAVerifyRequest
+	v := utils.NewValidator()
+
+	if err := verify_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("mfa_verify_failed", nil, c),
+				-1005,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).MFAVerify(verify_req)
+	if err != nil {
+		return c.Status(loginStatus(c, err.Err, http.StatusUnauthorized)).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("login_success", nil, c),
+			1005,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) MFAEnrol(c *fiber.Ctx) error {
+	var enrol_req MFAEnrolRequest
+	v := utils.NewValidator()
+
+	if err := enrol_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("mfa_enrol_failed", nil, c),
+				-1006,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).MFAEnrol(enrol_req)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1006,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("mfa_enrol_success", nil, c),
+			1006,
+			resp,
+		),
+	)
+}
+
+// loginStatus answers a login refused by the login guard with 429 and tells
+// the client when to try again
+func loginStatus(c *fiber.Ctx, err error, status int) int {
+	var throttled *loginThrottled
+	if !errors.As(err, &throttled) {
+		return status
+	}
+
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Max(1, math.Ceil(throttled.RetryAfter.Seconds())))))
+	return http.StatusTooManyRequests
+}
+
+func (au *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var forgot_req ForgotPasswordRequest
+	v := utils.NewValidator()
+
+	if err := forgot_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("password_forgot_failed", nil, c),
+				-1007,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).ForgotPassword(forgot_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1007,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("password_forgot_success", nil, c),
+			1007,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var reset_req ResetPasswordRequest
+	v := utils.NewValidator()
+
+	if err := reset_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("password_reset_failed", nil, c),
+				-1008,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).ResetPassword(reset_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1008,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("password_reset_success", nil, c),
+			1008,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var verify_req VerifyEmailRequest
+	v := utils.NewValidator()
+
+	if err := verify_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("email_verify_failed", nil, c),
+				-1009,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).VerifyEmail(verify_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1009,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("email_verify_success", nil, c),
+			1009,
+			resp,
+		),
+	)
+}
+
+func (au *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var resend_req ResendVerificationRequest
+	v := utils.NewValidator()
+
+	if err := resend_req.Bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("email_resend_failed", nil, c),
+				-1010,
+				err,
+			),
+		)
+	}
+
+	resp, err := au.AuthService(c).ResendVerification(resend_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-1010,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("email_resend_success", nil, c),
+			1010,
+			resp,
+		),
+	)
+}
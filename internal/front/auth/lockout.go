@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	custom_log "tarantool-admin-api/pkg/logs"
+	"tarantool-admin-api/pkg/redis"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+)
+
+// loginThrottled refuses a login before the password is checked, the key is
+// the message of the error and the client may try again after RetryAfter
+type loginThrottled struct {
+	key        string
+	RetryAfter time.Duration
+}
+
+func (e *loginThrottled) Error() string {
+	return e.key
+}
+
+// loginGuard slows password guessing down on every account, each failure
+// doubles the wait before the next try and too many failures lock the
+// account for a while
+type loginGuard struct {
+	UserLimit     int
+	UserWindow    time.Duration
+	MaxFailures   int
+	FailureWindow time.Duration
+	Lockout       time.Duration
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+}
+
+func newLoginGuard() loginGuard {
+	return loginGuard{
+		UserLimit:     utils.GetenvInt("LOGIN_USER_RATE_LIMIT", 10),
+		UserWindow:    time.Duration(utils.GetenvInt("LOGIN_USER_RATE_WINDOW_SECONDS", 300)) * time.Second,
+		MaxFailures:   utils.GetenvInt("LOGIN_MAX_FAILURES", 5),
+		FailureWindow: time.Duration(utils.GetenvInt("LOGIN_FAILURE_WINDOW_SECONDS", 900)) * time.Second,
+		Lockout:       time.Duration(utils.GetenvInt("LOGIN_LOCKOUT_SECONDS", 900)) * time.Second,
+		BaseDelay:     time.Duration(utils.GetenvInt("LOGIN_DELAY_BASE_MS", 500)) * time.Millisecond,
+		MaxDelay:      time.Duration(utils.GetenvInt("LOGIN_DELAY_MAX_SECONDS", 30)) * time.Second,
+	}
+}
+
+// loginKeys returns the redis keys of an account, user names are stored in
+// upper case so every spelling of a name shares them
+func loginKeys(user_name string) (rate string, failures string, delay string, lock string) {
+	name := strings.ToUpper(strings.TrimSpace(user_name))
+	return "login:rate:" + name, "login:failures:" + name, "login:delay:" + name, "login:lock:" + name
+}
+
+// check refuses the login while the account is locked or waiting out its
+// delay, or once too many logins were tried on it, redis errors let the
+// login through
+func (g loginGuard) check(user_name string) *loginThrottled {
+	ctx := context.Background()
+	client := redis.NewRedis()
+	rate_key, _, delay_key, lock_key := loginKeys(user_name)
+
+	if ttl, err := client.PTTL(ctx, lock_key).Result(); err != nil {
+		custom_log.NewCustomLog("login_guard_failed", err.Error(), "error")
+	} else if ttl > 0 {
+		return &loginThrottled{key: "account_locked", RetryAfter: ttl}
+	}
+
+	if ttl, err := client.PTTL(ctx, delay_key).Result(); err != nil {
+		custom_log.NewCustomLog("login_guard_failed", err.Error(), "error")
+	} else if ttl > 0 {
+		return &loginThrottled{key: "login_delayed", RetryAfter: ttl}
+	}
+
+	if g.UserLimit > 0 {
+		rate, err := redis.AllowRate(ctx, rate_key, g.UserLimit, g.UserWindow)
+		if err != nil {
+			custom_log.NewCustomLog("login_guard_failed", err.Error(), "error")
+		} else if !rate.Allowed {
+			return &loginThrottled{key: "too_many_login_attempts", RetryAfter: rate.RetryAfter}
+		}
+	}
+
+	return nil
+}
+
+// fail counts a wrong password or second factor, it returns true when the
+// failure locked the account
+func (g loginGuard) fail(user_name string) bool {
+	ctx := context.Background()
+	client := redis.NewRedis()
+	_, failures_key, delay_key, lock_key := loginKeys(user_name)
+
+	failures, err := client.Incr(ctx, failures_key).Result()
+	if err != nil {
+		custom_log.NewCustomLog("login_guard_failed", err.Error(), "error")
+		return false
+	}
+	if failures == 1 {
+		client.PExpire(ctx, failures_key, g.FailureWindow)
+	}
+
+	if g.MaxFailures > 0 && failures >= int64(g.MaxFailures) {
+		if err := client.Set(ctx, lock_key, failures, g.Lockout).Err(); err != nil {
+			custom_log.NewCustomLog("login_guard_failed", err.Error(), "error")
+			return false
+		}
+		client.Del(ctx, failures_key, delay_key)
+		return true
+	}
+
+	// the wait doubles with every failure up to the maximum
+	delay := g.MaxDelay
+	if failures < 20 {
+		delay = min(g.BaseDelay<<(failures-1), g.MaxDelay)
+	}
+	if delay > 0 {
+		client.Set(ctx, delay_key, failures, delay)
+	}
+
+	return false
+}
+
+// reset forgets the failures of an account once its user signed in
+func (g loginGuard) reset(user_name string) {
+	_, failures_key, delay_key, _ := loginKeys(user_name)
+	if err := redis.NewRedis().Del(context.Background(), failures_key, delay_key).Err(); err != nil {
+		custom_log.NewCustomLog("login_guard_failed", err.Error(), "error")
+	}
+}
+
+// UnlockLogin lifts the lockout and every delay of an account
+func UnlockLogin(user_name string) error {
+	rate_key, failures_key, delay_key, lock_key := loginKeys(user_name)
+	if err := redis.NewRedis().Del(context.Background(), rate_key, failures_key, delay_key, lock_key).Err(); err != nil {
+		return fmt.Errorf("error unlock login : %w", err)
+	}
+	return nil
+}
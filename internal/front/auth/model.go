@@ -1,10 +1,17 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
+	"tarantool-admin-api/pkg/constants"
 	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/oidc"
 	"tarantool-admin-api/pkg/postgres"
 	"tarantool-admin-api/pkg/responses"
 	"tarantool-admin-api/pkg/utils"
@@ -13,17 +20,19 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type LoginRequest struct {
 	UserName string `json:"user_name" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	Device   string `json:"device" validate:"omitempty,max=255"`
 }
 
 func (au *LoginRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
 	if err := c.BodyParser(au); err != nil {
 		custom_log.NewCustomLog("login_failed", err.Error(), "error")
-		return fmt.Errorf(utils.Translate("invalid_body", nil, c))
+		return errors.New(utils.Translate("invalid_body", nil, c))
 	}
 
 	if err := v.Validate(au, c); err != nil {
@@ -34,24 +43,436 @@ func (au *LoginRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
 	return nil
 }
 
+// LoginResponse carries the tokens of the new session, or the challenge to
+// answer with a second factor before they are issued
 type LoginResponse struct {
-	Auth Auth `json:"auth"`
+	Auth          *Auth         `json:"auth,omitempty"`
+	Challenge     *MFAChallenge `json:"mfa_challenge,omitempty"`
+	RecoveryCodes []string      `json:"recovery_codes,omitempty"`
 }
 
 type Auth struct {
-	Token     string `json:"token"`
-	TokenType string `json:"token_type"`
+	Token            string    `json:"token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionUUID      string    `json:"session_uuid"`
+}
+
+// MFAChallenge is a login waiting for its second factor, enrolment is
+// required when the user must set up a second factor to finish signing in
+type MFAChallenge struct {
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	EnrolmentRequired bool      `json:"enrolment_required"`
+	Methods           []string  `json:"methods"`
+}
+
+type MFAChallengeNewModel struct {
+	ID             uint64    `db:"id"`
+	ChallengeToken string    `db:"-"`
+	ChallengeHash  string    `db:"challenge_hash"`
+	UserID         int       `db:"user_id"`
+	Device         string    `db:"device"`
+	ExpiresAt      time.Time `db:"expires_at"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+func (au *MFAChallengeNewModel) new(user_id int, device string, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_users_mfa_challenges_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("error generate challenge token : %w", err)
+	}
+	challenge_token := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := utils.Now()
+
+	au.ID = uint64(*id)
+	au.ChallengeToken = challenge_token
+	au.ChallengeHash = utils.HashToken(challenge_token)
+	au.UserID = user_id
+	au.Device = device
+	au.ExpiresAt = now.Add(mfaChallengeTTL)
+	au.CreatedAt = now
+
+	return nil
+}
+
+// MFAChallengeModel is a login challenge as read back when it is answered
+type MFAChallengeModel struct {
+	ID       uint64 `db:"id"`
+	UserID   int    `db:"user_id"`
+	UserName string `db:"user_name"`
+	Device   string `db:"device"`
+}
+
+const (
+	// mfaChallengeTTL is how long a user has to type its second factor
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaChallengeAttempts is how many codes can be tried on one challenge
+	mfaChallengeAttempts = 5
+)
+
+type MFAEnrolRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+func (au *MFAEnrolRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("mfa_enrol_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("mfa_enrol_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// MFAVerifyRequest answers a login challenge with a code of the
+// authenticator app or with one of the recovery codes
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code" validate:"omitempty,max=20"`
+}
+
+func (au *MFAVerifyRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("mfa_verify_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("mfa_verify_failed", err.Error(), "error")
+		return err
+	}
+
+	if (au.Code == "") == (au.RecoveryCode == "") {
+		return errors.New(utils.Translate("mfa_code_required", nil, c))
+	}
+
+	return nil
+}
+
+// ForgotPasswordRequest asks for a password reset link, the answer is the
+// same whether the email is known or not
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (au *ForgotPasswordRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("password_forgot_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("password_forgot_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,min=6,max=100"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,min=6,max=100"`
+}
+
+func (au *ResetPasswordRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		return err
+	}
+
+	if au.Password != au.ConfirmPassword {
+		return errors.New(utils.Translate("confirm_pass_and_pass_dont_match", nil, c))
+	}
+
+	return nil
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (au *VerifyEmailRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("email_verify_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("email_verify_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// ResendVerificationRequest asks for a new verification link, the answer is
+// the same whether the email is known or not
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (au *ResendVerificationRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("email_resend_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("email_resend_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type MailSentResponse struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordResponse struct {
+	UserUUID string `json:"user_uuid" db:"user_uuid"`
+	UserName string `json:"user_name" db:"user_name"`
+}
+
+type VerifyEmailResponse struct {
+	UserUUID        string    `json:"user_uuid" db:"user_uuid"`
+	UserName        string    `json:"user_name" db:"user_name"`
+	Email           string    `json:"email" db:"email"`
+	EmailVerifiedAt time.Time `json:"email_verified_at" db:"email_verified_at"`
+}
+
+// mailed tokens are single use and bound to the purpose they were sent for
+const (
+	tokenPurposeEmailVerification = "email_verification"
+	tokenPurposePasswordReset     = "password_reset"
+)
+
+func emailVerificationTTL() time.Duration {
+	return time.Duration(utils.GetenvInt("EMAIL_VERIFICATION_TTL_HOURS", 48)) * time.Hour
+}
+
+func passwordResetTTL() time.Duration {
+	return time.Duration(utils.GetenvInt("PASSWORD_RESET_TTL_MINUTES", 30)) * time.Minute
+}
+
+// UserToken is a token mailed to a user, the token itself only travels in
+// the mail
+type UserToken struct {
+	ID        uint64    `db:"id"`
+	Token     string    `db:"-"`
+	TokenHash string    `db:"token_hash"`
+	UserID    int       `db:"user_id"`
+	Purpose   string    `db:"purpose"`
+	Email     string    `db:"email"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (au *UserToken) new(user_id int, purpose string, email string, ttl time.Duration, conn sqlx.Ext) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_users_tokens_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("error generate token : %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := utils.Now()
+
+	au.ID = uint64(*id)
+	au.Token = token
+	au.TokenHash = utils.HashToken(token)
+	au.UserID = user_id
+	au.Purpose = purpose
+	au.Email = email
+	au.ExpiresAt = now.Add(ttl)
+	au.CreatedAt = now
+
+	return nil
+}
+
+// tokenLink is the page of the frontend a mailed token opens
+func tokenLink(path string, token string) string {
+	frontend_url := os.Getenv("FRONTEND_URL")
+	if frontend_url == "" {
+		frontend_url = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimRight(frontend_url, "/"), path, url.QueryEscape(token))
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (au *RefreshRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// OIDCCallbackRequest carries what the identity provider redirected the
+// browser back with
+type OIDCCallbackRequest struct {
+	Code   string `json:"code" validate:"required"`
+	State  string `json:"state" validate:"required"`
+	Device string `json:"device" validate:"omitempty,max=255"`
+}
+
+func (au *OIDCCallbackRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(au); err != nil {
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(au, c); err != nil {
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// OIDCRequest is a sign in waiting for the identity provider, the code
+// verifier and nonce never leave the server
+type OIDCRequest struct {
+	ID           uint64    `db:"id"`
+	State        string    `db:"state"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func (au *OIDCRequest) new(conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_oidc_requests_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		if values[i], err = oidc.RandomString(); err != nil {
+			return fmt.Errorf("error generate oidc request : %w", err)
+		}
+	}
+
+	now := utils.Now()
+
+	au.ID = uint64(*id)
+	au.State = values[0]
+	au.Nonce = values[1]
+	au.CodeVerifier = values[2]
+	au.ExpiresAt = now.Add(oidcRequestTTL)
+	au.CreatedAt = now
+
+	return nil
+}
+
+// oidcRequestTTL is how long a user has to sign in at the identity provider
+const oidcRequestTTL = 10 * time.Minute
+
+// ssoUserName derives a user name from the identity provider claims, user
+// names are upper case letters and digits like the registered ones
+func ssoUserName(claims *oidc.Claims) string {
+	name := claims.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	var user_name strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			user_name.WriteRune(r)
+		}
+		if user_name.Len() == 40 {
+			break
+		}
+	}
+	if user_name.Len() < 3 {
+		return "SSOUSER"
+	}
+
+	return user_name.String()
 }
 
 type User struct {
+	ID       int       `json:"-" db:"id"`
 	UserUUID uuid.UUID `json:"user_uuid" db:"user_uuid"`
+	UserName string    `json:"user_name" db:"user_name"`
 }
 type UserInfo struct {
-	ID           int    `json:"id" db:"id"`
-	UserUUID     string `json:"user_uuid" db:"user_uuid"`
-	UserName     string `json:"user_name" db:"user_name"`
-	LoginSession string `json:"login_session" db:"login_session"`
-	StatusID     int    `json:"status_id" db:"status_id"`
+	ID       int    `json:"id" db:"id"`
+	UserUUID string `json:"user_uuid" db:"user_uuid"`
+	UserName string `json:"user_name" db:"user_name"`
+	StatusID int    `json:"status_id" db:"status_id"`
+}
+
+// UserRole is the role of a user along with the permissions it grants
+type UserRole struct {
+	RoleName    string
+	Permissions []string
+}
+
+// Scoped keeps the permissions of the role that the api key scopes allow
+func (ur UserRole) Scoped(scopes []string) []string {
+	permissions := []string{}
+	for _, permission := range ur.Permissions {
+		for _, scope := range scopes {
+			if permission == scope {
+				permissions = append(permissions, permission)
+				break
+			}
+		}
+	}
+	return permissions
+}
+
+// ApiKey is an api key resolved from the header of a request
+type ApiKey struct {
+	KeyUUID   string         `db:"key_uuid"`
+	UserUUID  string         `db:"user_uuid"`
+	Scopes    pq.StringArray `db:"scopes"`
+	ExpiresAt time.Time      `db:"expires_at"`
 }
 
 type RegisterRequest struct {
@@ -61,13 +482,12 @@ type RegisterRequest struct {
 	Password        string `json:"password" validate:"required,min=6,max=100"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,min=6,max=100"`
 	Email           string `json:"email" validate:"required,email"`
-	ProfilePhoto    string `json:"profile_photo" validate:"omitempty"`
 }
 
 func (au *RegisterRequest) Bind(c *fiber.Ctx, v *utils.Validator) error {
 	if err := c.BodyParser(au); err != nil {
 		custom_log.NewCustomLog("register_failed", err.Error(), "error")
-		return fmt.Errorf(utils.Translate("invalid_body", nil, c))
+		return errors.New(utils.Translate("invalid_body", nil, c))
 	}
 
 	if err := v.Validate(au, c); err != nil {
@@ -90,8 +510,9 @@ type RegisterModel struct {
 	Username     string    `db:"user_name" json:"user_name"`
 	Password     string    `db:"password" json:"password"`
 	Email        string    `db:"email" json:"email"`
-	ProfilePhoto string    `db:"profile_photo" json:"profile_photo"`
+	ProfilePhoto *string   `db:"profile_photo" json:"profile_photo"`
 	StatusID     uint64    `db:"status_id" json:"status_id"`
+	RoleID       uint64    `db:"role_id" json:"-"`
 	Order        uint64    `db:"order" json:"order"`
 	CreatedBy    uint64    `db:"created_by" json:"created_by"`
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
@@ -113,9 +534,19 @@ func (au *RegisterModel) New(register_req RegisterRequest, conn *sqlx.DB) *respo
 		}
 	}
 
-	// assign default profile photo if none provided
-	if register_req.ProfilePhoto == "" {
-		register_req.ProfilePhoto = "user1.png"
+	// the first user owns the installation, everyone else starts as a viewer
+	var role_id uint64
+	role_query := `
+		SELECT id
+		FROM tbl_roles
+		WHERE role_name = CASE
+			WHEN EXISTS (SELECT 1 FROM tbl_users WHERE deleted_at IS NULL) THEN $1
+			ELSE $2
+		END
+	`
+	if err := conn.Get(&role_id, role_query, constants.RoleViewer, constants.RoleOwner); err != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return err_msg.NewErrorResponse("register_failed", fmt.Errorf("technical_error"), err)
 	}
 
 	// generate new UUID
@@ -145,8 +576,8 @@ func (au *RegisterModel) New(register_req RegisterRequest, conn *sqlx.DB) *respo
 	au.Username = username
 	au.Password = register_req.Password
 	au.Email = register_req.Email
-	au.ProfilePhoto = register_req.ProfilePhoto
-	au.StatusID = 1
+	au.StatusID = constants.UserStatusActive
+	au.RoleID = role_id
 	au.Order = uint64(*id)
 	au.CreatedBy = uint64(*id)
 	au.CreatedAt = now
@@ -157,3 +588,102 @@ func (au *RegisterModel) New(register_req RegisterRequest, conn *sqlx.DB) *respo
 type RegisterResponse struct {
 	UserInfo RegisterModel `json:"user_info"`
 }
+
+// Session is a signed in device as read back when refreshing its tokens
+type Session struct {
+	ID               uint64     `db:"id"`
+	SessionUUID      string     `db:"session_uuid"`
+	UserID           int        `db:"user_id"`
+	UserUUID         uuid.UUID  `db:"user_uuid"`
+	UserName         string     `db:"user_name"`
+	RefreshTokenHash string     `db:"refresh_token_hash"`
+	RefreshExpiresAt time.Time  `db:"refresh_expires_at"`
+	RevokedAt        *time.Time `db:"revoked_at"`
+}
+
+type SessionNewModel struct {
+	ID               uint64    `db:"id"`
+	SessionUUID      string    `db:"session_uuid"`
+	UserID           int       `db:"user_id"`
+	Device           string    `db:"device"`
+	UserAgent        string    `db:"user_agent"`
+	IP               string    `db:"ip"`
+	RefreshToken     string    `db:"-"`
+	RefreshTokenHash string    `db:"refresh_token_hash"`
+	RefreshExpiresAt time.Time `db:"refresh_expires_at"`
+	LastSeenAt       time.Time `db:"last_seen_at"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+func (au *SessionNewModel) new(user_id int, device string, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_users_sessions_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	refresh_token, err := newRefreshToken(uuid.String())
+	if err != nil {
+		return fmt.Errorf("error generate refresh token : %w", err)
+	}
+
+	// the device defaults to the client that signed in
+	if device == "" {
+		device = us_ctx.UserAgent
+	}
+	if device == "" {
+		device = "unknown"
+	}
+
+	now := utils.Now()
+
+	au.ID = uint64(*id)
+	au.SessionUUID = uuid.String()
+	au.UserID = user_id
+	au.Device = device
+	au.UserAgent = us_ctx.UserAgent
+	au.IP = us_ctx.Ip
+	au.RefreshToken = refresh_token
+	au.RefreshTokenHash = utils.HashToken(refresh_token)
+	au.RefreshExpiresAt = now.Add(refreshTokenTTL())
+	au.LastSeenAt = now
+	au.CreatedAt = now
+
+	return nil
+}
+
+// newRefreshToken returns an opaque refresh token, the session is part of
+// it so a replayed token can be traced back to the session it was issued to
+func newRefreshToken(session_uuid string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return session_uuid + "." + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// sessionOfToken returns the session a refresh token was issued to
+func sessionOfToken(refresh_token string) (string, bool) {
+	session_uuid, _, ok := strings.Cut(refresh_token, ".")
+	if !ok {
+		return "", false
+	}
+	if _, err := uuid.Parse(session_uuid); err != nil {
+		return "", false
+	}
+	return session_uuid, true
+}
+
+func accessTokenTTL() time.Duration {
+	return time.Duration(utils.GetenvInt("JWT_ACCESS_EXP_MINUTES", 15)) * time.Minute
+}
+
+func refreshTokenTTL() time.Duration {
+	return time.Duration(utils.GetenvInt("JWT_REFRESH_EXP_DAYS", 30)) * 24 * time.Hour
+}
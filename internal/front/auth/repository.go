@@ -1,10 +1,20 @@
 package auth
 
 import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"tarantool-admin-api/internal/front/mfa"
+	"tarantool-admin-api/pkg/constants"
 	custom_log "tarantool-admin-api/pkg/logs"
+	"tarantool-admin-api/pkg/mailer"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/oidc"
+	"tarantool-admin-api/pkg/postgres"
 	"tarantool-admin-api/pkg/responses"
 	"tarantool-admin-api/pkg/utils"
 	"time"
@@ -16,27 +26,44 @@ import (
 )
 
 type AuthRepo interface {
-	Login(username string, password string) (*LoginResponse, *responses.ErrorResponse)
+	Login(username string, password string, device string) (*LoginResponse, *responses.ErrorResponse)
+	Refresh(refresh_req RefreshRequest) (*LoginResponse, *responses.ErrorResponse)
 	Register(register_req RegisterRequest) (*RegisterResponse, *responses.ErrorResponse)
+	OIDCAuthorize() (*OIDCAuthorizeResponse, *responses.ErrorResponse)
+	OIDCCallback(callback_req OIDCCallbackRequest) (*LoginResponse, *responses.ErrorResponse)
+	MFAEnrol(enrol_req MFAEnrolRequest) (*mfa.MFAEnrolmentResponse, *responses.ErrorResponse)
+	MFAVerify(verify_req MFAVerifyRequest) (*LoginResponse, *responses.ErrorResponse)
+	ForgotPassword(forgot_req ForgotPasswordRequest) (*MailSentResponse, *responses.ErrorResponse)
+	ResetPassword(reset_req ResetPasswordRequest) (*ResetPasswordResponse, *responses.ErrorResponse)
+	VerifyEmail(verify_req VerifyEmailRequest) (*VerifyEmailResponse, *responses.ErrorResponse)
+	ResendVerification(resend_req ResendVerificationRequest) (*MailSentResponse, *responses.ErrorResponse)
 }
 
 type AuthRepoImpl struct {
-	DBPool *sqlx.DB
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
 }
 
-func NewAuthRepoImpl(db_pool *sqlx.DB) *AuthRepoImpl {
+func NewAuthRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *AuthRepoImpl {
 	return &AuthRepoImpl{
-		DBPool: db_pool,
+		DBPool:      db_pool,
+		UserContext: us_ctx,
 	}
 }
 
-func (au *AuthRepoImpl) Login(username string, password string) (*LoginResponse, *responses.ErrorResponse) {
+func (au *AuthRepoImpl) Login(username string, password string, device string) (*LoginResponse, *responses.ErrorResponse) {
+	guard := newLoginGuard()
+	if throttled := guard.check(username); throttled != nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("login_failed", throttled)
+	}
+
 	var users []User
 
 	// prepare sql
 	sql := `
 		SELECT
-			user_uuid
+			id, user_uuid, user_name
 		FROM tbl_users
 		WHERE deleted_at IS NULL 
 		AND user_name = $1 
@@ -44,29 +71,807 @@ func (au *AuthRepoImpl) Login(username string, password string) (*LoginResponse,
 	`
 
 	// execute request
-	if err := au.DBPool.Select(&users, sql, username, password); err != nil {
-		custom_log.NewCustomLog("login_failed", err.Error(), "error")
+	if err := au.DBPool.Select(&users, sql, username, password); err != nil {
+		custom_log.NewCustomLog("login_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("login_failed", fmt.Errorf("username_or_password_invalid"))
+	}
+
+	if len(users) == 0 {
+		custom_log.NewCustomLog("login_failed", "no_user_found", "error")
+		au.audit(0, username, "login_failed", fmt.Sprintf("Failed login attempt for %s", username))
+		if guard.fail(username) {
+			au.audit(0, username, "account_locked", fmt.Sprintf("Account %s was locked after too many failed logins", username))
+		}
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("login_failed", fmt.Errorf("username_or_password_invalid"))
+	}
+
+	user := users[0]
+
+	if err_resp := au.checkStatus(user, "login_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	challenge, err_resp := au.secondFactor(user, device, "login_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	if challenge != nil {
+		au.audit(user.ID, user.UserName, "login_mfa_challenge", fmt.Sprintf("User %s passed the password check and was asked for a second factor", user.UserName))
+
+		return &LoginResponse{
+			Challenge: challenge,
+		}, nil
+	}
+
+	auth, session, err_resp := au.openSession(user, device, "login_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	guard.reset(user.UserName)
+
+	au.audit(user.ID, user.UserName, "login", fmt.Sprintf("User %s logged in on %s", user.UserName, session.Device))
+
+	return &LoginResponse{
+		Auth: auth,
+	}, nil
+}
+
+// checkStatus refuses to sign in a user that is not active
+func (au *AuthRepoImpl) checkStatus(user User, message_id string) *responses.ErrorResponse {
+	// prepare sql
+	sql_status := `
+		SELECT status_id
+		FROM tbl_users
+		WHERE id = $1
+	`
+
+	// execute request
+	var status_id int
+	if err := au.DBPool.Get(&status_id, sql_status, user.ID); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+
+	switch status_id {
+	case constants.UserStatusActive:
+		return nil
+	case constants.UserStatusSuspended:
+		au.audit(user.ID, user.UserName, "login_refused", fmt.Sprintf("Suspended user %s tried to log in", user.UserName))
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("account_suspended"))
+	default:
+		au.audit(user.ID, user.UserName, "login_refused", fmt.Sprintf("Inactive user %s tried to log in", user.UserName))
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("account_inactive"))
+	}
+}
+
+// openSession signs the user in on a new session, every login opens its own
+// session so other devices stay signed in
+func (au *AuthRepoImpl) openSession(user User, device string, message_id string) (*Auth, *SessionNewModel, *responses.ErrorResponse) {
+	var session SessionNewModel
+	if err := session.new(user.ID, device, au.UserContext, au.DBPool); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+
+	// prepare sql
+	insert_sql := `
+		INSERT INTO tbl_users_sessions (
+			id, session_uuid, user_id, device, user_agent, ip,
+			refresh_token_hash, refresh_expires_at, last_seen_at, created_at
+		) VALUES (
+			:id, :session_uuid, :user_id, :device, :user_agent, :ip,
+			:refresh_token_hash, :refresh_expires_at, :last_seen_at, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := au.DBPool.NamedExec(insert_sql, session); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+
+	auth, err := au.issue(user.UserUUID.String(), session.SessionUUID, session.RefreshToken, session.RefreshExpiresAt)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_create_token"))
+	}
+
+	return auth, &session, nil
+}
+
+// secondFactor opens a challenge when the user has a second factor, or is
+// required to set one up, no token is issued before it is answered. It is nil
+// when the user signs in with its first factor only
+func (au *AuthRepoImpl) secondFactor(user User, device string, message_id string) (*MFAChallenge, *responses.ErrorResponse) {
+	state, err := mfa.NewMFARepoImpl(au.UserContext, au.DBPool).State(user.ID)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+	if !state.Enrolled && !state.Required {
+		return nil, nil
+	}
+
+	return au.openChallenge(user, device, state, message_id)
+}
+
+// openChallenge holds a login back until the second factor is given
+func (au *AuthRepoImpl) openChallenge(user User, device string, state *mfa.MFAState, message_id string) (*MFAChallenge, *responses.ErrorResponse) {
+	var challenge MFAChallengeNewModel
+	if err := challenge.new(user.ID, device, au.DBPool); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+
+	// prepare sql, challenges nobody answered are dropped on the way
+	insert_sql := `
+		WITH expired AS (
+			DELETE FROM tbl_users_mfa_challenges
+			WHERE expires_at < :created_at
+		)
+		INSERT INTO tbl_users_mfa_challenges (
+			id, challenge_hash, user_id, device, expires_at, created_at
+		) VALUES (
+			:id, :challenge_hash, :user_id, :device, :expires_at, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := au.DBPool.NamedExec(insert_sql, challenge); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+
+	methods := []string{"totp"}
+	if state.Enrolled && state.RecoveryCodesLeft > 0 {
+		methods = append(methods, "recovery_code")
+	}
+
+	return &MFAChallenge{
+		ChallengeToken:    challenge.ChallengeToken,
+		ExpiresAt:         challenge.ExpiresAt,
+		EnrolmentRequired: !state.Enrolled,
+		Methods:           methods,
+	}, nil
+}
+
+// MFAEnrol gives a user required to use a second factor its TOTP secret in
+// the middle of its login, the first code confirms it in MFAVerify
+func (au *AuthRepoImpl) MFAEnrol(enrol_req MFAEnrolRequest) (*mfa.MFAEnrolmentResponse, *responses.ErrorResponse) {
+	// prepare sql
+	sql_challenge := `
+		SELECT ch.id, ch.user_id, us.user_name, COALESCE(ch.device, '') AS device
+		FROM tbl_users_mfa_challenges ch
+		INNER JOIN tbl_users us ON us.id = ch.user_id
+		WHERE ch.challenge_hash = $1
+		AND ch.used_at IS NULL
+		AND ch.expires_at > $2
+		AND ch.attempts < $3
+		AND us.deleted_at IS NULL
+	`
+
+	// execute request
+	var challenge MFAChallengeModel
+	if err := au.DBPool.Get(&challenge, sql_challenge, utils.HashToken(enrol_req.ChallengeToken), utils.Now(), mfaChallengeAttempts); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse("mfa_enrol_failed", fmt.Errorf("mfa_challenge_invalid"))
+		}
+		custom_log.NewCustomLog("mfa_enrol_failed", err.Error(), "error")
+		return nil, err_msg.NewErrorResponse("mfa_enrol_failed", fmt.Errorf("error_database"))
+	}
+
+	mfa_repo := mfa.NewMFARepoImpl(au.UserContext, au.DBPool)
+	enrolment, err := mfa_repo.Setup(challenge.UserID, challenge.UserName)
+	if err != nil {
+		return nil, au.mfaError("mfa_enrol_failed", err)
+	}
+
+	return &mfa.MFAEnrolmentResponse{
+		Enrolment: *enrolment,
+	}, nil
+}
+
+// MFAVerify answers a login challenge, the session is only opened once the
+// second factor is right, a user enrolling gets its recovery codes back
+func (au *AuthRepoImpl) MFAVerify(verify_req MFAVerifyRequest) (*LoginResponse, *responses.ErrorResponse) {
+	// prepare sql, every answer counts against the challenge
+	sql_challenge := `
+		UPDATE tbl_users_mfa_challenges ch SET
+			attempts = ch.attempts + 1
+		FROM tbl_users us
+		WHERE us.id = ch.user_id
+		AND ch.challenge_hash = $1
+		AND ch.used_at IS NULL
+		AND ch.expires_at > $2
+		AND ch.attempts < $3
+		AND us.deleted_at IS NULL
+		RETURNING ch.id, ch.user_id, us.user_name, COALESCE(ch.device, '') AS device
+	`
+
+	// execute request
+	var challenge MFAChallengeModel
+	if err := au.DBPool.Get(&challenge, sql_challenge, utils.HashToken(verify_req.ChallengeToken), utils.Now(), mfaChallengeAttempts); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse("mfa_verify_failed", fmt.Errorf("mfa_challenge_invalid"))
+		}
+		custom_log.NewCustomLog("mfa_verify_failed", err.Error(), "error")
+		return nil, err_msg.NewErrorResponse("mfa_verify_failed", fmt.Errorf("error_database"))
+	}
+
+	// guessing the second factor counts against the account like a wrong
+	// password does
+	guard := newLoginGuard()
+	if throttled := guard.check(challenge.UserName); throttled != nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("mfa_verify_failed", throttled)
+	}
+
+	mfa_repo := mfa.NewMFARepoImpl(au.UserContext, au.DBPool)
+	state, err := mfa_repo.State(challenge.UserID)
+	if err != nil {
+		return nil, au.mfaError("mfa_verify_failed", err)
+	}
+
+	var recovery_codes []string
+	switch {
+	case !state.Enrolled:
+		// the first code of a user enrolling confirms its secret
+		if verify_req.Code == "" {
+			err = mfa.ErrNotEnrolled
+		} else {
+			recovery_codes, err = mfa_repo.Confirm(challenge.UserID, verify_req.Code)
+		}
+	case verify_req.RecoveryCode != "":
+		err = mfa_repo.UseRecoveryCode(challenge.UserID, verify_req.RecoveryCode)
+	default:
+		err = mfa_repo.Verify(challenge.UserID, verify_req.Code)
+	}
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			au.audit(challenge.UserID, challenge.UserName, "login_mfa_failed", fmt.Sprintf("Wrong second factor for %s", challenge.UserName))
+			if guard.fail(challenge.UserName) {
+				au.audit(challenge.UserID, challenge.UserName, "account_locked", fmt.Sprintf("Account %s was locked after too many failed logins", challenge.UserName))
+			}
+		}
+		return nil, au.mfaError("mfa_verify_failed", err)
+	}
+
+	// prepare sql, a challenge is answered once
+	sql_used := `
+		UPDATE tbl_users_mfa_challenges SET
+			used_at = $1
+		WHERE id = $2
+		AND used_at IS NULL
+		RETURNING id
+	`
+
+	// execute request
+	var id uint64
+	if err := au.DBPool.Get(&id, sql_used, utils.Now(), challenge.ID); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse("mfa_verify_failed", fmt.Errorf("mfa_challenge_invalid"))
+		}
+		custom_log.NewCustomLog("mfa_verify_failed", err.Error(), "error")
+		return nil, err_msg.NewErrorResponse("mfa_verify_failed", fmt.Errorf("error_database"))
+	}
+
+	user, err := au.userByID(challenge.UserID)
+	if err != nil {
+		custom_log.NewCustomLog("mfa_verify_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("mfa_verify_failed", fmt.Errorf("error_database"))
+	}
+
+	if err_resp := au.checkStatus(*user, "mfa_verify_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	auth, session, err_resp := au.openSession(*user, challenge.Device, "mfa_verify_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	guard.reset(user.UserName)
+
+	switch {
+	case recovery_codes != nil:
+		au.audit(user.ID, user.UserName, "mfa_enable", fmt.Sprintf("User %s enabled two-factor authentication", user.UserName))
+	case verify_req.RecoveryCode != "":
+		au.audit(user.ID, user.UserName, "mfa_recovery_code_used", fmt.Sprintf("User %s used a recovery code", user.UserName))
+	}
+	au.audit(user.ID, user.UserName, "login", fmt.Sprintf("User %s logged in with a second factor on %s", user.UserName, session.Device))
+
+	return &LoginResponse{
+		Auth:          auth,
+		RecoveryCodes: recovery_codes,
+	}, nil
+}
+
+func (au *AuthRepoImpl) userByID(user_id int) (*User, error) {
+	// prepare sql
+	sql_user := `
+		SELECT id, user_uuid, user_name
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND id = $1
+	`
+
+	// execute request
+	var user User
+	if err := au.DBPool.Get(&user, sql_user, user_id); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (au *AuthRepoImpl) mfaError(message_id string, err error) *responses.ErrorResponse {
+	err_msg := &responses.ErrorResponse{}
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_code_invalid"))
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_not_enrolled"))
+	case errors.Is(err, mfa.ErrAlreadyEnrolled):
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_already_enrolled"))
+	}
+
+	custom_log.NewCustomLog(message_id, err.Error(), "error")
+	return err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+}
+
+// OIDCAuthorize starts a sign in at the identity provider, the state sent
+// back by the provider picks the request up again in OIDCCallback
+func (au *AuthRepoImpl) OIDCAuthorize() (*OIDCAuthorizeResponse, *responses.ErrorResponse) {
+	provider, err := oidc.Default()
+	if err != nil {
+		custom_log.NewCustomLog("oidc_authorize_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, oidc.ErrNotConfigured) {
+			return nil, err_msg.NewErrorResponse("oidc_authorize_failed", fmt.Errorf("oidc_not_configured"))
+		}
+		return nil, err_msg.NewErrorResponse("oidc_authorize_failed", fmt.Errorf("oidc_provider_unavailable"))
+	}
+
+	var oidc_req OIDCRequest
+	if err := oidc_req.new(au.DBPool); err != nil {
+		custom_log.NewCustomLog("oidc_authorize_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("oidc_authorize_failed", fmt.Errorf("error_database"))
+	}
+
+	// prepare sql, requests nobody came back for are dropped on the way
+	insert_sql := `
+		WITH expired AS (
+			DELETE FROM tbl_oidc_requests
+			WHERE expires_at < :created_at
+		)
+		INSERT INTO tbl_oidc_requests (
+			id, state, nonce, code_verifier, expires_at, created_at
+		) VALUES (
+			:id, :state, :nonce, :code_verifier, :expires_at, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := au.DBPool.NamedExec(insert_sql, oidc_req); err != nil {
+		custom_log.NewCustomLog("oidc_authorize_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("oidc_authorize_failed", fmt.Errorf("error_database"))
+	}
+
+	return &OIDCAuthorizeResponse{
+		AuthorizationURL: provider.AuthCodeURL(oidc_req.State, oidc_req.Nonce, oidc_req.CodeVerifier),
+		State:            oidc_req.State,
+		ExpiresAt:        oidc_req.ExpiresAt,
+	}, nil
+}
+
+// OIDCCallback finishes a sign in at the identity provider, the user is
+// created on its first sign in and its role follows its groups
+func (au *AuthRepoImpl) OIDCCallback(callback_req OIDCCallbackRequest) (*LoginResponse, *responses.ErrorResponse) {
+	provider, err := oidc.Default()
+	if err != nil {
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, oidc.ErrNotConfigured) {
+			return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_not_configured"))
+		}
+		return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_provider_unavailable"))
+	}
+
+	// prepare sql, a state is good for one callback only
+	sql_request := `
+		DELETE FROM tbl_oidc_requests
+		WHERE state = $1
+		RETURNING id, state, nonce, code_verifier, expires_at, created_at
+	`
+
+	// execute request
+	var oidc_req OIDCRequest
+	if err := au.DBPool.Get(&oidc_req, sql_request, callback_req.State); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_state_invalid"))
+		}
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("error_database"))
+	}
+	if !utils.Now().Before(oidc_req.ExpiresAt) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_state_expired"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	token, err := provider.Exchange(ctx, callback_req.Code, oidc_req.CodeVerifier)
+	if err != nil {
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_exchange_failed"))
+	}
+
+	claims, err := provider.Verify(token.IDToken, oidc_req.Nonce)
+	if err != nil {
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_token_invalid"))
+	}
+
+	user, created, err := au.ssoUser(claims)
+	if err != nil {
+		custom_log.NewCustomLog("oidc_login_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, errSSOEmailMissing) {
+			return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("oidc_email_missing"))
+		}
+		return nil, err_msg.NewErrorResponse("oidc_login_failed", fmt.Errorf("error_database"))
+	}
+
+	if created {
+		au.audit(user.ID, user.UserName, "register", fmt.Sprintf("User %s was created on its first single sign-on", user.UserName))
+	}
+
+	return au.ssoLogin(*user, callback_req.Device)
+}
+
+// ssoLogin signs in the user the identity provider vouched for, the second
+// factor of the user is asked the same way a password login asks it
+func (au *AuthRepoImpl) ssoLogin(user User, device string) (*LoginResponse, *responses.ErrorResponse) {
+	if err_resp := au.checkStatus(user, "oidc_login_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	challenge, err_resp := au.secondFactor(user, device, "oidc_login_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	if challenge != nil {
+		au.audit(user.ID, user.UserName, "login_mfa_challenge", fmt.Sprintf("User %s passed the single sign-on and was asked for a second factor", user.UserName))
+
+		return &LoginResponse{
+			Challenge: challenge,
+		}, nil
+	}
+
+	auth, session, err_resp := au.openSession(user, device, "oidc_login_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	au.audit(user.ID, user.UserName, "login_sso", fmt.Sprintf("User %s logged in with single sign-on on %s", user.UserName, session.Device))
+
+	return &LoginResponse{
+		Auth: auth,
+	}, nil
+}
+
+var errSSOEmailMissing = errors.New("identity provider sent no email")
+
+// ssoUser returns the user linked to the identity provider account, an
+// account is linked to the user with the same verified email or to a new
+// user, the role of the user is synced with its groups when a mapping is set
+func (au *AuthRepoImpl) ssoUser(claims *oidc.Claims) (*User, bool, error) {
+	tx, err := au.DBPool.Beginx()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	now := utils.Now()
+	mapping := oidc.RoleMappingFromEnv()
+	role := mapping.Role(claims.Groups, oidc.DefaultRoleFromEnv())
+
+	// prepare sql
+	identity_sql := `
+		SELECT us.id, us.user_uuid, us.user_name
+		FROM tbl_users_identities ui
+		INNER JOIN tbl_users us ON us.id = ui.user_id
+		WHERE ui.issuer = $1
+		AND ui.subject = $2
+		AND us.deleted_at IS NULL
+	`
+
+	// execute request
+	var user User
+	created := false
+	err = tx.Get(&user, identity_sql, claims.Issuer, claims.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		// an unverified email could belong to someone else
+		err = sql.ErrNoRows
+		if claims.EmailVerified && claims.Email != "" {
+			email_sql := `
+				SELECT id, user_uuid, user_name
+				FROM tbl_users
+				WHERE deleted_at IS NULL
+				AND LOWER(email) = LOWER($1)
+				ORDER BY id
+				LIMIT 1
+			`
+			err = tx.Get(&user, email_sql, claims.Email)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			if claims.Email == "" {
+				return nil, false, errSSOEmailMissing
+			}
+			if err = au.createSSOUser(tx, claims, role, &user); err != nil {
+				return nil, false, err
+			}
+			created = true
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		// prepare sql
+		link_sql := `
+			INSERT INTO tbl_users_identities (
+				user_id, issuer, subject, email, last_login_at, created_at
+			) VALUES (
+				$1, $2, $3, $4, $5, $5
+			)
+		`
+
+		// execute request
+		if _, err := tx.Exec(link_sql, user.ID, claims.Issuer, claims.Subject, claims.Email, now); err != nil {
+			return nil, false, err
+		}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	// the identity provider owns the role once groups are mapped, the owner
+	// keeps its role
+	if len(mapping) > 0 && !created {
+		role_sql := `
+			UPDATE tbl_users SET
+				role_id = (SELECT id FROM tbl_roles WHERE role_name = $1)
+			WHERE id = $2
+			AND role_id <> (SELECT id FROM tbl_roles WHERE role_name = $3)
+		`
+		if _, err := tx.Exec(role_sql, role, user.ID, constants.RoleOwner); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// prepare sql
+	touch_sql := `
+		UPDATE tbl_users_identities SET
+			email = $1, last_login_at = $2
+		WHERE issuer = $3
+		AND subject = $4
+	`
+
+	// execute request
+	if _, err := tx.Exec(touch_sql, claims.Email, now, claims.Issuer, claims.Subject); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	return &user, created, nil
+}
+
+// createSSOUser adds the user of an identity provider account, it has no
+// usable password and signs in through the provider only
+func (au *AuthRepoImpl) createSSOUser(tx *sqlx.Tx, claims *oidc.Claims, role string, user *User) error {
+	// the user name gets a number when it is taken
+	base := ssoUserName(claims)
+	user_name := base
+	for i := 2; ; i++ {
+		var taken bool
+		if err := tx.Get(&taken, `SELECT EXISTS (SELECT 1 FROM tbl_users WHERE user_name = $1)`, user_name); err != nil {
+			return err
+		}
+		if !taken {
+			break
+		}
+		user_name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	password, err := oidc.RandomString()
+	if err != nil {
+		return err
+	}
+
+	id, err := postgres.GetSeqNextVal("tbl_users_id_seq", tx)
+	if err != nil {
+		return err
+	}
+
+	user_uuid, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	var first_name, last_name *string
+	if claims.GivenName != "" {
+		first_name = &claims.GivenName
+	}
+	if claims.FamilyName != "" {
+		last_name = &claims.FamilyName
+	}
+
+	// prepare sql, like a registration the first user owns the installation
+	insert_sql := `
+		INSERT INTO tbl_users (
+			id, user_uuid, first_name, last_name, user_name, password, email,
+			email_verified_at, status_id, role_id, "order", created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7,
+			CASE WHEN $11 THEN $10::TIMESTAMP END, 1,
+			(
+				SELECT id FROM tbl_roles
+				WHERE role_name = CASE
+					WHEN EXISTS (SELECT 1 FROM tbl_users WHERE deleted_at IS NULL) THEN $8
+					ELSE $9
+				END
+			),
+			$1, $1, $10
+		)
+		RETURNING id, user_uuid, user_name
+	`
+
+	// execute request
+	return tx.Get(user, insert_sql, *id, user_uuid, first_name, last_name, user_name, password, claims.Email, role, constants.RoleOwner, utils.Now(), claims.EmailVerified)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token, a refresh token is good for one use only, presenting a used one
+// again revokes the whole session since the token was likely stolen
+func (au *AuthRepoImpl) Refresh(refresh_req RefreshRequest) (*LoginResponse, *responses.ErrorResponse) {
+	session_uuid, ok := sessionOfToken(refresh_req.RefreshToken)
+	if !ok {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("refresh_token_invalid"))
+	}
+
+	// prepare sql
+	query := `
+		SELECT
+			ss.id, ss.session_uuid, ss.user_id, us.user_uuid, us.user_name,
+			ss.refresh_token_hash, ss.refresh_expires_at, ss.revoked_at
+		FROM tbl_users_sessions ss
+		INNER JOIN tbl_users us ON us.id = ss.user_id
+		WHERE us.deleted_at IS NULL
+		AND ss.session_uuid = $1
+	`
+
+	// execute request
+	var session Session
+	if err := au.DBPool.Get(&session, query, session_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("refresh_token_invalid"))
+		}
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("error_database"))
+	}
+
+	if session.RevokedAt != nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("session_revoked"))
+	}
+	if !utils.Now().Before(session.RefreshExpiresAt) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("refresh_token_expired"))
+	}
+
+	old_hash := utils.HashToken(refresh_req.RefreshToken)
+	if subtle.ConstantTimeCompare([]byte(old_hash), []byte(session.RefreshTokenHash)) != 1 {
+		au.revokeReused(session)
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("refresh_token_invalid"))
+	}
+
+	refresh_token, err := newRefreshToken(session.SessionUUID)
+	if err != nil {
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("error_create_token"))
+	}
+	now := utils.Now()
+	refresh_expires_at := now.Add(refreshTokenTTL())
+
+	// prepare sql, the old hash guards against two refreshes racing
+	update_sql := `
+		UPDATE tbl_users_sessions SET
+			refresh_token_hash = $1, refresh_expires_at = $2, last_seen_at = $3,
+			user_agent = $4, ip = $5, updated_at = $3
+		WHERE id = $6
+		AND revoked_at IS NULL
+		AND refresh_token_hash = $7
+	`
+
+	// execute request
+	result, err := au.DBPool.Exec(update_sql, utils.HashToken(refresh_token), refresh_expires_at, now, au.UserContext.UserAgent, au.UserContext.Ip, session.ID, old_hash)
+	if err != nil {
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
 		err_msg := &responses.ErrorResponse{}
-		return nil, err_msg.NewErrorResponse("login_failed", fmt.Errorf("username_or_password_invalid"))
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("error_database"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("refresh_token_invalid"))
 	}
 
-	if len(users) == 0 {
-		custom_log.NewCustomLog("login_failed", "no_user_found", "error")
+	auth, err := au.issue(session.UserUUID.String(), session.SessionUUID, refresh_token, refresh_expires_at)
+	if err != nil {
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
 		err_msg := &responses.ErrorResponse{}
-		return nil, err_msg.NewErrorResponse("login_failed", fmt.Errorf("username_or_password_invalid"))
+		return nil, err_msg.NewErrorResponse("refresh_failed", fmt.Errorf("error_create_token"))
 	}
 
-	user := users[0]
+	return &LoginResponse{
+		Auth: auth,
+	}, nil
+}
+
+// revokeReused closes a session whose refresh token was presented twice
+func (au *AuthRepoImpl) revokeReused(session Session) {
+	// prepare sql
+	query := `
+		UPDATE tbl_users_sessions SET
+			revoked_at = $1, revoked_reason = $2, updated_at = $1
+		WHERE id = $3
+		AND revoked_at IS NULL
+	`
+
+	// execute request
+	if _, err := au.DBPool.Exec(query, utils.Now(), "refresh_token_reused", session.ID); err != nil {
+		custom_log.NewCustomLog("refresh_failed", err.Error(), "error")
+		return
+	}
+
+	au.audit(session.UserID, session.UserName, "refresh_token_reused", fmt.Sprintf("Refresh token of session %s was reused, the session is revoked", session.SessionUUID))
+}
 
-	hours := utils.GetenvInt("JWT_EXP_HOUR", 7)
-	expirationTime := time.Now().Add(time.Duration(hours) * time.Hour)
+// issue signs a short lived access token for the session
+func (au *AuthRepoImpl) issue(user_uuid string, session_uuid string, refresh_token string, refresh_expires_at time.Time) (*Auth, error) {
+	expires_at := time.Now().Add(accessTokenTTL())
 
 	// create the JWT claims
-	login_session, _ := uuid.NewV7()
 	claims := jwt.MapClaims{
-		"user_uuid":     user.UserUUID,
-		"login_session": login_session.String(),
-		"exp":           expirationTime.Unix(),
+		"user_uuid":     user_uuid,
+		"login_session": session_uuid,
+		"exp":           expires_at.Unix(),
 	}
 
 	errs := godotenv.Load()
@@ -75,37 +880,99 @@ func (au *AuthRepoImpl) Login(username string, password string) (*LoginResponse,
 	}
 	secret_key := os.Getenv("JWT_SECRET_KEY")
 
+	// Create the token
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(secret_key))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Auth{
+		Token:            tokenString,
+		TokenType:        "JWT",
+		ExpiresAt:        expires_at,
+		RefreshToken:     refresh_token,
+		RefreshExpiresAt: refresh_expires_at,
+		SessionUUID:      session_uuid,
+	}, nil
+}
+
+// CheckSession fails when the session of an access token was revoked or has
+// expired, the last time the session was seen is refreshed at most once a
+// minute
+func (au *AuthRepoImpl) CheckSession(session_uuid string, user_id int) error {
+	now := utils.Now()
+
 	// prepare sql
-	update_sql := `
-		UPDATE tbl_users SET
-			login_session = $1
-		WHERE deleted_at IS NULL
-		AND user_uuid = $2 
+	query := `
+		SELECT refresh_expires_at
+		FROM tbl_users_sessions
+		WHERE session_uuid = $1
+		AND user_id = $2
+		AND revoked_at IS NULL
 	`
 
 	// execute request
-	_, err := au.DBPool.Exec(update_sql, login_session, user.UserUUID)
-	if err != nil {
-		custom_log.NewCustomLog("login_failed", err.Error(), "error")
-		err_msg := &responses.ErrorResponse{}
-		return nil, err_msg.NewErrorResponse("login_failed", fmt.Errorf("error_database"))
+	var refresh_expires_at time.Time
+	if err := au.DBPool.Get(&refresh_expires_at, query, session_uuid, user_id); err != nil {
+		return err
+	}
+	if !now.Before(refresh_expires_at) {
+		return fmt.Errorf("session %s expired", session_uuid)
 	}
 
-	// Create the token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret_key))
-	if err != nil {
-		custom_log.NewCustomLog("login_failed", err.Error(), "error")
-		err_msg := &responses.ErrorResponse{}
-		return nil, err_msg.NewErrorResponse("login_failed", fmt.Errorf("error_create_token"))
+	// prepare sql
+	touch_sql := `
+		UPDATE tbl_users_sessions SET
+			last_seen_at = $1
+		WHERE session_uuid = $2
+		AND last_seen_at < $3
+	`
+
+	// execute request
+	if _, err := au.DBPool.Exec(touch_sql, now, session_uuid, now.Add(-time.Minute)); err != nil {
+		custom_log.NewCustomLog("session_touch_failed", err.Error(), "error")
 	}
 
-	return &LoginResponse{
-		Auth: Auth{
-			Token:     tokenString,
-			TokenType: "JWT",
-		},
-	}, nil
+	return nil
+}
+
+// CheckApiKey returns the api key matching the key sent by a client, revoked
+// and expired keys are not matched
+func (au *AuthRepoImpl) CheckApiKey(api_key string, ip string) (*ApiKey, error) {
+	now := utils.Now()
+
+	// prepare sql
+	query := `
+		SELECT ak.key_uuid, us.user_uuid, ak.scopes, ak.expires_at
+		FROM tbl_users_api_keys ak
+		INNER JOIN tbl_users us ON us.id = ak.user_id
+		WHERE ak.key_hash = $1
+		AND ak.revoked_at IS NULL
+		AND ak.expires_at > $2
+		AND us.deleted_at IS NULL
+	`
+
+	// execute request
+	var key ApiKey
+	if err := au.DBPool.Get(&key, query, utils.HashToken(api_key), now); err != nil {
+		return nil, err
+	}
+
+	// prepare sql
+	touch_sql := `
+		UPDATE tbl_users_api_keys SET
+			last_used_at = $1, last_used_ip = $2
+		WHERE key_uuid = $3
+		AND (last_used_at IS NULL OR last_used_at < $4)
+	`
+
+	// execute request
+	if _, err := au.DBPool.Exec(touch_sql, now, ip, key.KeyUUID, now.Add(-time.Minute)); err != nil {
+		custom_log.NewCustomLog("api_key_touch_failed", err.Error(), "error")
+	}
+
+	return &key, nil
 }
 
 func (au *AuthRepoImpl) GetUserByUUID(user_uuid string) (*UserInfo, error) {
@@ -114,8 +981,7 @@ func (au *AuthRepoImpl) GetUserByUUID(user_uuid string) (*UserInfo, error) {
 	// prepare sql
 	sql := `
 		SELECT
-			id, user_uuid, user_name,
-			login_session, status_id
+			id, user_uuid, user_name, status_id
 		FROM tbl_users
 		WHERE deleted_at IS NULL 
 		AND user_uuid = $1
@@ -130,6 +996,70 @@ func (au *AuthRepoImpl) GetUserByUUID(user_uuid string) (*UserInfo, error) {
 	return &user_info, nil
 }
 
+// GetUserByID returns the user unless it was deleted, its status tells
+// whether it may still act
+func (au *AuthRepoImpl) GetUserByID(user_id int) (*UserInfo, error) {
+	var user_info UserInfo
+
+	// prepare sql
+	sql := `
+		SELECT
+			id, user_uuid, user_name, status_id
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND id = $1
+	`
+
+	// execute request
+	if err := au.DBPool.Get(&user_info, sql, user_id); err != nil {
+		custom_log.NewCustomLog("get_userinfo_failed", err.Error(), "error")
+		return nil, err
+	}
+
+	return &user_info, nil
+}
+
+// GetUserRole returns the role of the user and the permissions it grants
+func (au *AuthRepoImpl) GetUserRole(user_id int) (*UserRole, error) {
+	var user_role UserRole
+
+	// prepare sql
+	sql := `
+		SELECT ro.role_name
+		FROM tbl_users us
+		INNER JOIN tbl_roles ro ON ro.id = us.role_id
+		WHERE us.id = $1
+	`
+
+	// execute request
+	if err := au.DBPool.Get(&user_role.RoleName, sql, user_id); err != nil {
+		custom_log.NewCustomLog("get_user_role_failed", err.Error(), "error")
+		return nil, err
+	}
+
+	// prepare sql
+	permission_sql := `
+		SELECT pm.permission_name
+		FROM tbl_users us
+		INNER JOIN tbl_roles_permissions rp ON rp.role_id = us.role_id
+		INNER JOIN tbl_permissions pm ON pm.id = rp.permission_id
+		WHERE us.id = $1
+		ORDER BY pm.permission_name
+	`
+
+	// execute request
+	if err := au.DBPool.Select(&user_role.Permissions, permission_sql, user_id); err != nil {
+		custom_log.NewCustomLog("get_user_role_failed", err.Error(), "error")
+		return nil, err
+	}
+
+	if user_role.Permissions == nil {
+		user_role.Permissions = []string{}
+	}
+
+	return &user_role, nil
+}
+
 func (au *AuthRepoImpl) Register(register_req RegisterRequest) (*RegisterResponse, *responses.ErrorResponse) {
 	var register_model RegisterModel
 
@@ -144,11 +1074,11 @@ func (au *AuthRepoImpl) Register(register_req RegisterRequest) (*RegisterRespons
 	query := `
 		INSERT INTO tbl_users (
 			id, user_uuid, first_name, last_name, user_name,
-			password, email, profile_photo, status_id, "order",
+			password, email, profile_photo, status_id, role_id, "order",
 			created_by, created_at
 		) VALUES (
 			:id, :user_uuid, :first_name, :last_name, :user_name,
-			:password, :email, :profile_photo, :status_id, :order,
+			:password, :email, :profile_photo, :status_id, :role_id, :order,
 			:created_by, :created_at
 		)
 	`
@@ -161,7 +1091,355 @@ func (au *AuthRepoImpl) Register(register_req RegisterRequest) (*RegisterRespons
 		return nil, err_msg.NewErrorResponse("register_failed", fmt.Errorf("cannot_insert_db_error"))
 	}
 
+	au.audit(int(register_model.ID), register_model.Username, "register", fmt.Sprintf("User %s registered", register_model.Username))
+
+	// the registration stands even when the mail cannot be sent, the user
+	// can ask for a new one
+	if err := au.sendToken(int(register_model.ID), register_model.FirstName, register_model.Email, "", tokenPurposeEmailVerification); err != nil {
+		custom_log.NewCustomLog("register_failed", err.Error(), "error")
+	}
+
 	return &RegisterResponse{
 		UserInfo: register_model,
 	}, nil
 }
+
+// ForgotPassword mails a password reset link, unknown emails get the same
+// answer so the endpoint does not tell who has an account
+func (au *AuthRepoImpl) ForgotPassword(forgot_req ForgotPasswordRequest) (*MailSentResponse, *responses.ErrorResponse) {
+	user, err := au.userByEmail(forgot_req.Email, false)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		custom_log.NewCustomLog("password_forgot_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_forgot_failed", fmt.Errorf("error_database"))
+	}
+
+	if user != nil {
+		if err := au.sendToken(user.ID, user.FirstName, user.Email, user.Language, tokenPurposePasswordReset); err != nil {
+			// failing here would tell the email is known
+			custom_log.NewCustomLog("password_forgot_failed", err.Error(), "error")
+		}
+
+		au.audit(user.ID, user.UserName, "password_forgot", fmt.Sprintf("User %s asked for a password reset", user.UserName))
+	}
+
+	return &MailSentResponse{
+		Email: forgot_req.Email,
+	}, nil
+}
+
+// ResetPassword sets a new password with a reset token, every session of
+// the user is signed out and a lockout is lifted
+func (au *AuthRepoImpl) ResetPassword(reset_req ResetPasswordRequest) (*ResetPasswordResponse, *responses.ErrorResponse) {
+	tx, err := au.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_reset_failed", fmt.Errorf("error_database"))
+	}
+	defer tx.Rollback()
+
+	token, err_resp := au.useToken(tx, reset_req.Token, tokenPurposePasswordReset, "password_reset_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	now := utils.Now()
+
+	// prepare sql, the reset link went to the email so it is verified too
+	update_sql := `
+		UPDATE tbl_users SET
+			password = $1,
+			email_verified_at = CASE
+				WHEN LOWER(email) = LOWER($2) THEN COALESCE(email_verified_at, $3)
+				ELSE email_verified_at
+			END,
+			updated_by = id, updated_at = $3
+		WHERE deleted_at IS NULL
+		AND id = $4
+		RETURNING user_uuid, user_name
+	`
+
+	// execute request
+	var reset_resp ResetPasswordResponse
+	if err := tx.Get(&reset_resp, update_sql, reset_req.Password, token.Email, now, token.UserID); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse("password_reset_failed", fmt.Errorf("token_invalid"))
+		}
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		return nil, err_msg.NewErrorResponse("password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	// prepare sql, whoever knew the old password is signed out
+	revoke_sql := `
+		UPDATE tbl_users_sessions SET
+			revoked_by = $1, revoked_at = $2, revoked_reason = 'password_reset', updated_at = $2
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+	`
+
+	// execute request
+	if _, err := tx.Exec(revoke_sql, token.UserID, now); err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	// the other reset links sent meanwhile stop working
+	if _, err := tx.Exec(`DELETE FROM tbl_users_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, token.UserID, tokenPurposePasswordReset); err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	if err := UnlockLogin(reset_resp.UserName); err != nil {
+		custom_log.NewCustomLog("password_reset_failed", err.Error(), "error")
+	}
+
+	au.audit(token.UserID, reset_resp.UserName, "password_reset", fmt.Sprintf("User %s reset its password, every session was signed out", reset_resp.UserName))
+
+	return &reset_resp, nil
+}
+
+// VerifyEmail marks the email of the user as verified, the token only
+// verifies the email it was sent to
+func (au *AuthRepoImpl) VerifyEmail(verify_req VerifyEmailRequest) (*VerifyEmailResponse, *responses.ErrorResponse) {
+	tx, err := au.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("email_verify_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("email_verify_failed", fmt.Errorf("error_database"))
+	}
+	defer tx.Rollback()
+
+	token, err_resp := au.useToken(tx, verify_req.Token, tokenPurposeEmailVerification, "email_verify_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare sql
+	update_sql := `
+		UPDATE tbl_users SET
+			email_verified_at = COALESCE(email_verified_at, $1)
+		WHERE deleted_at IS NULL
+		AND id = $2
+		AND LOWER(email) = LOWER($3)
+		RETURNING user_uuid, user_name, email, email_verified_at
+	`
+
+	// execute request
+	var verify_resp VerifyEmailResponse
+	if err := tx.Get(&verify_resp, update_sql, utils.Now(), token.UserID, token.Email); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse("email_verify_failed", fmt.Errorf("token_invalid"))
+		}
+		custom_log.NewCustomLog("email_verify_failed", err.Error(), "error")
+		return nil, err_msg.NewErrorResponse("email_verify_failed", fmt.Errorf("error_database"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("email_verify_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("email_verify_failed", fmt.Errorf("error_database"))
+	}
+
+	au.audit(token.UserID, verify_resp.UserName, "email_verified", fmt.Sprintf("User %s verified its email %s", verify_resp.UserName, verify_resp.Email))
+
+	return &verify_resp, nil
+}
+
+// ResendVerification mails a new verification link to a user whose email
+// is not verified yet, unknown emails get the same answer
+func (au *AuthRepoImpl) ResendVerification(resend_req ResendVerificationRequest) (*MailSentResponse, *responses.ErrorResponse) {
+	user, err := au.userByEmail(resend_req.Email, true)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		custom_log.NewCustomLog("email_resend_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("email_resend_failed", fmt.Errorf("error_database"))
+	}
+
+	if user != nil {
+		if err := au.sendToken(user.ID, user.FirstName, user.Email, user.Language, tokenPurposeEmailVerification); err != nil {
+			// failing here would tell the email is known
+			custom_log.NewCustomLog("email_resend_failed", err.Error(), "error")
+		}
+	}
+
+	return &MailSentResponse{
+		Email: resend_req.Email,
+	}, nil
+}
+
+// SendEmailVerification mails a new verification link to the user
+func (au *AuthRepoImpl) SendEmailVerification(user_id int) error {
+	return au.sendTokenTo(user_id, tokenPurposeEmailVerification)
+}
+
+// SendPasswordReset mails a password reset link to the user
+func (au *AuthRepoImpl) SendPasswordReset(user_id int) error {
+	return au.sendTokenTo(user_id, tokenPurposePasswordReset)
+}
+
+func (au *AuthRepoImpl) sendTokenTo(user_id int, purpose string) error {
+	// prepare sql
+	sql_user := `
+		SELECT id, user_name, COALESCE(first_name, user_name) AS first_name, email, COALESCE(language, '') AS language
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND id = $1
+	`
+
+	// execute request
+	var user mailUser
+	if err := au.DBPool.Get(&user, sql_user, user_id); err != nil {
+		return err
+	}
+
+	return au.sendToken(user.ID, user.FirstName, user.Email, user.Language, purpose)
+}
+
+// mailUser is a user a token is mailed to
+type mailUser struct {
+	ID        int    `db:"id"`
+	UserName  string `db:"user_name"`
+	FirstName string `db:"first_name"`
+	Email     string `db:"email"`
+	Language  string `db:"language"`
+}
+
+// userByEmail returns the user owning the email, unverified only keeps
+// users still to verify it
+func (au *AuthRepoImpl) userByEmail(email string, unverified bool) (*mailUser, error) {
+	// prepare sql
+	sql_user := `
+		SELECT id, user_name, COALESCE(first_name, user_name) AS first_name, email, COALESCE(language, '') AS language
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND LOWER(email) = LOWER($1)
+		AND (NOT $2 OR email_verified_at IS NULL)
+		ORDER BY id
+		LIMIT 1
+	`
+
+	// execute request
+	var user mailUser
+	if err := au.DBPool.Get(&user, sql_user, email, unverified); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// sendToken mails a new token for purpose, the tokens sent before for the
+// same purpose stop working, the mail leaves in the background so the
+// answer takes as long for known and unknown emails
+func (au *AuthRepoImpl) sendToken(user_id int, name string, email string, language string, purpose string) error {
+	ttl, template, path := emailVerificationTTL(), "email_verification", "verify-email"
+	if purpose == tokenPurposePasswordReset {
+		ttl, template, path = passwordResetTTL(), "password_reset", "reset-password"
+	}
+
+	tx, err := au.DBPool.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tbl_users_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, user_id, purpose); err != nil {
+		return err
+	}
+
+	var token UserToken
+	if err := token.new(user_id, purpose, email, ttl, tx); err != nil {
+		return err
+	}
+
+	// prepare sql
+	insert_sql := `
+		INSERT INTO tbl_users_tokens (
+			id, token_hash, user_id, purpose, email, expires_at, created_at
+		) VALUES (
+			:id, :token_hash, :user_id, :purpose, :email, :expires_at, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := tx.NamedExec(insert_sql, token); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// the language the user picked wins over the one of the request
+	if language == "" {
+		language = au.UserContext.Language
+	}
+
+	mail, err := mailer.Render(email, template, language, map[string]interface{}{
+		"name":    name,
+		"link":    tokenLink(path, token.Token),
+		"minutes": int(ttl.Minutes()),
+		"hours":   int(ttl.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := mailer.Default().Send(*mail); err != nil {
+			custom_log.NewCustomLog("mail_send_failed", err.Error(), "error")
+		}
+	}()
+
+	return nil
+}
+
+// useToken spends a mailed token, a token is good once and only for the
+// purpose it was sent for
+func (au *AuthRepoImpl) useToken(tx *sqlx.Tx, token string, purpose string, message_id string) (*UserToken, *responses.ErrorResponse) {
+	// prepare sql
+	use_sql := `
+		UPDATE tbl_users_tokens SET
+			used_at = $1
+		WHERE token_hash = $2
+		AND purpose = $3
+		AND used_at IS NULL
+		AND expires_at > $1
+		RETURNING id, token_hash, user_id, purpose, email, expires_at, created_at
+	`
+
+	// execute request
+	var user_token UserToken
+	if err := tx.Get(&user_token, use_sql, utils.Now(), utils.HashToken(token), purpose); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("token_invalid"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("error_database"))
+	}
+
+	return &user_token, nil
+}
+
+// audit records an auth action, the user is only known once it is resolved
+func (au *AuthRepoImpl) audit(user_id int, user_name string, context string, desc string) {
+	us_ctx := types.UserContext{
+		Id:        user_id,
+		UserName:  user_name,
+		UserAgent: au.UserContext.UserAgent,
+		Ip:        au.UserContext.Ip,
+	}
+
+	utils.AuditUserAction(&us_ctx, context, desc, constants.AuditTypeAuth, nil, au.DBPool)
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	types "tarantool-admin-api/pkg/model"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// stubState is the second factor state of the user the stub database knows
+type stubState struct {
+	enrolled bool
+	required bool
+	codes    int64
+}
+
+// stubDB is a minimal database answering the queries of a login, every
+// statement it executes is recorded
+type stubDB struct {
+	mu    sync.Mutex
+	state stubState
+	execs []string
+}
+
+func (s *stubDB) Connect(context.Context) (driver.Conn, error) { return &stubConn{db: s}, nil }
+func (s *stubDB) Driver() driver.Driver                        { return nil }
+
+func (s *stubDB) executed(fragment string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, query := range s.execs {
+		if strings.Contains(query, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+type stubConn struct {
+	db *stubDB
+}
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported")
+}
+func (c *stubConn) Close() error              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("begin is not supported") }
+
+func (c *stubConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *stubConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	c.db.execs = append(c.db.execs, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *stubConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case strings.Contains(query, "SELECT status_id"):
+		return &stubRows{columns: []string{"status_id"}, values: []driver.Value{int64(1)}}, nil
+	case strings.Contains(query, "AS enrolled"):
+		state := c.db.state
+		return &stubRows{
+			columns: []string{"enrolled", "pending", "required", "confirmed_at", "recovery_codes_left"},
+			values:  []driver.Value{state.enrolled, false, state.required, nil, state.codes},
+		}, nil
+	case strings.Contains(query, "nextval"):
+		return &stubRows{columns: []string{"id"}, values: []driver.Value{int64(1)}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+type stubRows struct {
+	columns []string
+	values  []driver.Value
+	done    bool
+}
+
+func (r *stubRows) Columns() []string { return r.columns }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func newStubRepo(state stubState) (*AuthRepoImpl, *stubDB) {
+	db := &stubDB{state: state}
+	pool := sqlx.NewDb(sql.OpenDB(db), "postgres")
+
+	return NewAuthRepoImpl(&types.UserContext{UserAgent: "test", Ip: "127.0.0.1"}, pool), db
+}
+
+func TestSSOLoginAsksEnrolledUserForSecondFactor(t *testing.T) {
+	repo, db := newStubRepo(stubState{enrolled: true, codes: 3})
+	user := User{ID: 7, UserUUID: uuid.New(), UserName: "alice"}
+
+	resp, err_resp := repo.ssoLogin(user, "laptop")
+	if err_resp != nil {
+		t.Fatalf("sso login failed: %+v", err_resp)
+	}
+	if resp.Auth != nil {
+		t.Fatal("tokens were issued before the second factor was given")
+	}
+	if resp.Challenge == nil {
+		t.Fatal("no challenge was opened for a user with a second factor")
+	}
+	if resp.Challenge.EnrolmentRequired {
+		t.Error("an enrolled user was asked to enrol")
+	}
+	if strings.Join(resp.Challenge.Methods, ",") != "totp,recovery_code" {
+		t.Errorf("methods = %v, want totp and recovery_code", resp.Challenge.Methods)
+	}
+	if !db.executed("tbl_users_mfa_challenges") {
+		t.Error("the challenge was not stored")
+	}
+	if db.executed("tbl_users_sessions") {
+		t.Error("a session was opened before the second factor was given")
+	}
+}
+
+func TestSSOLoginAsksRequiredUserToEnrol(t *testing.T) {
+	repo, db := newStubRepo(stubState{required: true})
+	user := User{ID: 7, UserUUID: uuid.New(), UserName: "alice"}
+
+	resp, err_resp := repo.ssoLogin(user, "laptop")
+	if err_resp != nil {
+		t.Fatalf("sso login failed: %+v", err_resp)
+	}
+	if resp.Auth != nil {
+		t.Fatal("tokens were issued to a user required to set up a second factor")
+	}
+	if resp.Challenge == nil {
+		t.Fatal("no challenge was opened for a user required to set up a second factor")
+	}
+	if !resp.Challenge.EnrolmentRequired {
+		t.Error("the user was not asked to enrol")
+	}
+	if strings.Join(resp.Challenge.Methods, ",") != "totp" {
+		t.Errorf("methods = %v, want totp", resp.Challenge.Methods)
+	}
+	if db.executed("tbl_users_sessions") {
+		t.Error("a session was opened before the second factor was set up")
+	}
+}
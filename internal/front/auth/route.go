@@ -24,6 +24,21 @@ func (au *AuthRoute) RegisterAuthRoute() *AuthRoute {
 
 	auth.Post("/login", au.AuthHandler.Login)
 	auth.Post("/register", au.AuthHandler.Register)
+	auth.Post("/refresh", au.AuthHandler.Refresh)
+
+	// forgotten password and email verification, the links are mailed
+	auth.Post("/password/forgot", au.AuthHandler.ForgotPassword)
+	auth.Post("/password/reset", au.AuthHandler.ResetPassword)
+	auth.Post("/email/verify", au.AuthHandler.VerifyEmail)
+	auth.Post("/email/resend", au.AuthHandler.ResendVerification)
+
+	// second step of a login with two-factor authentication
+	auth.Post("/mfa/enrol", au.AuthHandler.MFAEnrol)
+	auth.Post("/mfa/verify", au.AuthHandler.MFAVerify)
+
+	// single sign-on with the identity provider
+	auth.Get("/oidc/authorize", au.AuthHandler.OIDCAuthorize)
+	auth.Post("/oidc/callback", au.AuthHandler.OIDCCallback)
 
 	return au
 }
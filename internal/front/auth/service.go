@@ -1,32 +1,81 @@
 package auth
 
 import (
+	"tarantool-admin-api/internal/front/mfa"
+	types "tarantool-admin-api/pkg/model"
 	"tarantool-admin-api/pkg/responses"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type AuthServiceCreator interface {
-	Login(username string, password string) (*LoginResponse, *responses.ErrorResponse)
+	Login(username string, password string, device string) (*LoginResponse, *responses.ErrorResponse)
+	Refresh(refresh_req RefreshRequest) (*LoginResponse, *responses.ErrorResponse)
 	Register(register_req RegisterRequest) (*RegisterResponse, *responses.ErrorResponse)
+	OIDCAuthorize() (*OIDCAuthorizeResponse, *responses.ErrorResponse)
+	OIDCCallback(callback_req OIDCCallbackRequest) (*LoginResponse, *responses.ErrorResponse)
+	MFAEnrol(enrol_req MFAEnrolRequest) (*mfa.MFAEnrolmentResponse, *responses.ErrorResponse)
+	MFAVerify(verify_req MFAVerifyRequest) (*LoginResponse, *responses.ErrorResponse)
+	ForgotPassword(forgot_req ForgotPasswordRequest) (*MailSentResponse, *responses.ErrorResponse)
+	ResetPassword(reset_req ResetPasswordRequest) (*ResetPasswordResponse, *responses.ErrorResponse)
+	VerifyEmail(verify_req VerifyEmailRequest) (*VerifyEmailResponse, *responses.ErrorResponse)
+	ResendVerification(resend_req ResendVerificationRequest) (*MailSentResponse, *responses.ErrorResponse)
 }
 
 type AuthService struct {
-	DBPool   *sqlx.DB
-	AuthRepo *AuthRepoImpl
+	DBPool      *sqlx.DB
+	AuthRepo    *AuthRepoImpl
+	UserContext *types.UserContext
 }
 
-func NewAuthService(db_pool *sqlx.DB) *AuthService {
+func NewAuthService(us_ctx *types.UserContext, db_pool *sqlx.DB) *AuthService {
 	return &AuthService{
-		DBPool:   db_pool,
-		AuthRepo: NewAuthRepoImpl(db_pool),
+		DBPool:      db_pool,
+		AuthRepo:    NewAuthRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
 	}
 }
 
-func (au *AuthService) Login(username string, password string) (*LoginResponse, *responses.ErrorResponse) {
-	return au.AuthRepo.Login(username, password)
+func (au *AuthService) Login(username string, password string, device string) (*LoginResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.Login(username, password, device)
+}
+
+func (au *AuthService) Refresh(refresh_req RefreshRequest) (*LoginResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.Refresh(refresh_req)
 }
 
 func (au *AuthService) Register(register_req RegisterRequest) (*RegisterResponse, *responses.ErrorResponse) {
 	return au.AuthRepo.Register(register_req)
 }
+
+func (au *AuthService) OIDCAuthorize() (*OIDCAuthorizeResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.OIDCAuthorize()
+}
+
+func (au *AuthService) OIDCCallback(callback_req OIDCCallbackRequest) (*LoginResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.OIDCCallback(callback_req)
+}
+
+func (au *AuthService) MFAEnrol(enrol_req MFAEnrolRequest) (*mfa.MFAEnrolmentResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.MFAEnrol(enrol_req)
+}
+
+func (au *AuthService) MFAVerify(verify_req MFAVerifyRequest) (*LoginResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.MFAVerify(verify_req)
+}
+
+func (au *AuthService) ForgotPassword(forgot_req ForgotPasswordRequest) (*MailSentResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.ForgotPassword(forgot_req)
+}
+
+func (au *AuthService) ResetPassword(reset_req ResetPasswordRequest) (*ResetPasswordResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.ResetPassword(reset_req)
+}
+
+func (au *AuthService) VerifyEmail(verify_req VerifyEmailRequest) (*VerifyEmailResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.VerifyEmail(verify_req)
+}
+
+func (au *AuthService) ResendVerification(resend_req ResendVerificationRequest) (*MailSentResponse, *responses.ErrorResponse) {
+	return au.AuthRepo.ResendVerification(resend_req)
+}
@@ -0,0 +1,208 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"tarantool-admin-api/pkg/constants"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type BackupHandler struct {
+	DBPool        *sqlx.DB
+	BackupService func(c *fiber.Ctx) *BackupService
+}
+
+func NewBackupHandler(db_pool *sqlx.DB) *BackupHandler {
+	return &BackupHandler{
+		DBPool: db_pool,
+		BackupService: func(c *fiber.Ctx) *BackupService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewBackupService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (b *BackupHandler) Snapshot(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	resp, err := b.BackupService(c).Snapshot(db_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-4000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("snapshot_success", nil, c),
+			4000,
+			resp,
+		),
+	)
+}
+
+func (b *BackupHandler) Checkpoints(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	resp, err := b.BackupService(c).Checkpoints(db_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-4001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("checkpoints_show_success", nil, c),
+			4001,
+			resp,
+		),
+	)
+}
+
+func (b *BackupHandler) Create(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var backup_req BackupNewRequest
+	v := utils.NewValidator()
+	if err := backup_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("backup_create_failed", nil, c),
+				-4002,
+				err,
+			),
+		)
+	}
+
+	resp, err := b.BackupService(c).Create(db_uuid, backup_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-4002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("backup_create_success", nil, c),
+			4002,
+			resp,
+		),
+	)
+}
+
+func (b *BackupHandler) List(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, backupListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("backup_list_failed", nil, c),
+				-4003,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := b.BackupService(c).List(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-4003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("backup_list_success", nil, c),
+			4003,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (b *BackupHandler) ShowOne(c *fiber.Ctx) error {
+	backup_uuid := c.Params("backup_uuid")
+
+	resp, err := b.BackupService(c).ShowOne(backup_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-4004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("backup_show_success", nil, c),
+			4004,
+			resp,
+		),
+	)
+}
+
+func (b *BackupHandler) Download(c *fiber.Ctx) error {
+	backup_uuid := c.Params("backup_uuid")
+
+	resp, err := b.BackupService(c).ShowOne(backup_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-4005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	// only completed backups have a downloadable archive
+	if resp.Backup.JobStatus != constants.JobStatusCompleted || resp.Backup.FilePath == nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("backup_download_failed", nil, c),
+				-4005,
+				errors.New(utils.Translate("backup_not_completed", nil, c)),
+			),
+		)
+	}
+
+	return c.Download(*resp.Backup.FilePath, fmt.Sprintf("%s.tar.gz", resp.Backup.BackupUUID))
+}
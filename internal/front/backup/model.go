@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"tarantool-admin-api/pkg/archive"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Backup struct {
+	ID           uint64         `json:"-" db:"id"`
+	BackupUUID   string         `json:"backup_uuid" db:"backup_uuid"`
+	UserID       uint64         `json:"-" db:"user_id"`
+	DBID         uint64         `json:"-" db:"db_id"`
+	DBUUID       string         `json:"db_uuid" db:"db_uuid"`
+	Format       string         `json:"format" db:"format"`
+	Spaces       pq.StringArray `json:"spaces" db:"spaces"`
+	FilePath     *string        `json:"-" db:"file_path"`
+	FileSize     int64          `json:"file_size" db:"file_size"`
+	Checksum     *string        `json:"checksum" db:"checksum"`
+	SpaceCount   int            `json:"space_count" db:"space_count"`
+	TupleCount   int64          `json:"tuple_count" db:"tuple_count"`
+	JobStatus    string         `json:"job_status" db:"job_status"`
+	ErrorMessage *string        `json:"error_message" db:"error_message"`
+	StartedAt    *time.Time     `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time     `json:"finished_at" db:"finished_at"`
+	CreatedBy    uint64         `json:"-" db:"created_by"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
+
+// backupListFields are the columns the backup list filters and sorts on
+var backupListFields = postgres.ListFields{
+	Filter:      []string{"backup_uuid", "format", "job_status", "file_size", "space_count", "tuple_count", "started_at", "finished_at", "created_at"},
+	Sort:        []string{"format", "job_status", "file_size", "tuple_count", "started_at", "finished_at", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type BackupResponse struct {
+	Backup Backup `json:"backup"`
+}
+
+type BackupNewRequest struct {
+	Format string   `json:"format" validate:"omitempty,oneof=ndjson msgpack"`
+	Spaces []string `json:"spaces" validate:"omitempty,dive,required"`
+}
+
+func (b *BackupNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(b); err != nil {
+		custom_log.NewCustomLog("backup_create_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(b, c); err != nil {
+		custom_log.NewCustomLog("backup_create_failed", err.Error(), "error")
+		return err
+	}
+
+	if b.Format == "" {
+		b.Format = archive.FormatNDJSON
+	}
+
+	return nil
+}
+
+type BackupNewModel struct {
+	ID         uint64         `db:"id"`
+	BackupUUID string         `db:"backup_uuid"`
+	UserID     uint64         `db:"user_id"`
+	DBID       uint64         `db:"db_id"`
+	Format     string         `db:"format"`
+	Spaces     pq.StringArray `db:"spaces"`
+	FilePath   string         `db:"file_path"`
+	JobStatus  string         `db:"job_status"`
+	CreatedBy  uint64         `db:"created_by"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+func (b *BackupNewModel) new(db_id uint64, backup_req BackupNewRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_backups_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	// get current os time
+	time_zone := os.Getenv("APP_TIMEZONE")
+	location, err := time.LoadLocation(time_zone)
+	if err != nil {
+		return fmt.Errorf("error load location : %w", err)
+	}
+	now := time.Now().In(location)
+
+	spaces := backup_req.Spaces
+	if spaces == nil {
+		spaces = []string{}
+	}
+
+	b.ID = uint64(*id)
+	b.BackupUUID = uuid.String()
+	b.UserID = uint64(us_ctx.Id)
+	b.DBID = db_id
+	b.Format = backup_req.Format
+	b.Spaces = spaces
+	b.FilePath = filepath.Join(BackupDir(), fmt.Sprintf("%s.tar.gz", b.BackupUUID))
+	b.JobStatus = constants.JobStatusPending
+	b.CreatedBy = uint64(us_ctx.Id)
+	b.CreatedAt = now
+
+	return nil
+}
+
+// BackupDir returns the local directory where backup archives are stored
+func BackupDir() string {
+	dir := os.Getenv("BACKUP_DIR")
+	if dir == "" {
+		dir = "./storage/backups"
+	}
+	return dir
+}
+
+type Checkpoint struct {
+	Signature  int64       `msgpack:"signature" json:"signature"`
+	Vclock     interface{} `msgpack:"vclock" json:"vclock"`
+	References interface{} `msgpack:"references" json:"references"`
+}
+
+type GCInfo struct {
+	Signature              int64        `msgpack:"signature" json:"signature"`
+	Vclock                 interface{}  `msgpack:"vclock" json:"vclock"`
+	CheckpointIsInProgress bool         `msgpack:"checkpoint_is_in_progress" json:"checkpoint_is_in_progress"`
+	Checkpoints            []Checkpoint `msgpack:"checkpoints" json:"checkpoints"`
+	Consumers              interface{}  `msgpack:"consumers" json:"consumers"`
+}
+
+type CheckpointsResponse struct {
+	GC GCInfo `json:"gc"`
+}
+
+type SnapshotResponse struct {
+	Snapshot Snapshot `json:"snapshot"`
+}
+
+type Snapshot struct {
+	DBUUID    string    `json:"db_uuid"`
+	Result    string    `json:"result"`
+	CreatedAt time.Time `json:"created_at"`
+}
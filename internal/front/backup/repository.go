@@ -0,0 +1,497 @@
+package backup
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/archive"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+type BackupRepo interface {
+	Snapshot(db_uuid string) (*SnapshotResponse, *responses.ErrorResponse)
+	Checkpoints(db_uuid string) (*CheckpointsResponse, *responses.ErrorResponse)
+	Create(db_uuid string, backup_req BackupNewRequest) (*BackupResponse, *responses.ErrorResponse)
+	Execute(db_uuid string, backup_req BackupNewRequest) (*BackupResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]Backup, int, *responses.ErrorResponse)
+	ShowOne(backup_uuid string) (*BackupResponse, *responses.ErrorResponse)
+}
+
+type BackupRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewBackupRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *BackupRepoImpl {
+	return &BackupRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+func (b *BackupRepoImpl) Snapshot(db_uuid string) (*SnapshotResponse, *responses.ErrorResponse) {
+	// get database info, backups are for the admins of the database
+	db_resp, err_resp := database.NewDatabaseRepoImpl(b.UserContext, b.DBPool).Accessible(db_uuid, constants.AccessLevelAdmin, "snapshot_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// connect database
+	conn, err := tarantool_utils.ConnectTarantool(
+		db_resp.Database.Host,
+		int(db_resp.Database.Port),
+		db_resp.Database.Username,
+		db_resp.Database.Password,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("snapshot_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("snapshot_failed", fmt.Errorf("failed_connect_to_target_db"))
+	}
+
+	// close connection after function end
+	defer conn.Close()
+
+	// run box.snapshot() on the target
+	var result []string
+	err = conn.Do(tarantool.NewEvalRequest("return box.snapshot()"), pool.RW).GetTyped(&result)
+	if err != nil {
+		custom_log.NewCustomLog("snapshot_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("snapshot_failed", fmt.Errorf("failed_to_create_snapshot"))
+	}
+
+	snapshot := Snapshot{
+		DBUUID:    db_uuid,
+		CreatedAt: utils.Now(),
+	}
+	if len(result) > 0 {
+		snapshot.Result = result[0]
+	}
+
+	return &SnapshotResponse{
+		Snapshot: snapshot,
+	}, nil
+}
+
+func (b *BackupRepoImpl) Checkpoints(db_uuid string) (*CheckpointsResponse, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(b.UserContext, b.DBPool).Accessible(db_uuid, constants.AccessLevelAdmin, "checkpoints_show_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// connect database
+	conn, err := tarantool_utils.ConnectTarantool(
+		db_resp.Database.Host,
+		int(db_resp.Database.Port),
+		db_resp.Database.Username,
+		db_resp.Database.Password,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("checkpoints_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("checkpoints_show_failed", fmt.Errorf("failed_connect_to_target_db"))
+	}
+
+	// close connection after function end
+	defer conn.Close()
+
+	query := `
+		local gc = box.info.gc()
+		local checkpoints = {}
+		for _, checkpoint in ipairs(gc.checkpoints or {}) do
+			table.insert(checkpoints, {
+				signature = checkpoint.signature,
+				vclock = checkpoint.vclock,
+				references = checkpoint.references,
+			})
+		end
+		return {
+			signature = gc.signature,
+			vclock = gc.vclock,
+			checkpoint_is_in_progress = gc.checkpoint_is_in_progress,
+			checkpoints = checkpoints,
+			consumers = gc.consumers,
+		}
+	`
+
+	var result []GCInfo
+	if err := conn.Do(tarantool.NewEvalRequest(query), pool.ANY).GetTyped(&result); err != nil || len(result) == 0 {
+		if err == nil {
+			err = fmt.Errorf("empty box.info.gc() result")
+		}
+		custom_log.NewCustomLog("checkpoints_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("checkpoints_show_failed", fmt.Errorf("failed_to_get_checkpoints"))
+	}
+
+	// convert msgpack maps so they can be encoded as json
+	gc := result[0]
+	gc.Vclock = tarantool_utils.NormalizeValue(gc.Vclock)
+	gc.Consumers = tarantool_utils.NormalizeValue(gc.Consumers)
+	for i := range gc.Checkpoints {
+		gc.Checkpoints[i].Vclock = tarantool_utils.NormalizeValue(gc.Checkpoints[i].Vclock)
+		gc.Checkpoints[i].References = tarantool_utils.NormalizeValue(gc.Checkpoints[i].References)
+	}
+
+	return &CheckpointsResponse{
+		GC: gc,
+	}, nil
+}
+
+func (b *BackupRepoImpl) Create(db_uuid string, backup_req BackupNewRequest) (*BackupResponse, *responses.ErrorResponse) {
+	backup_new_model, db, err_resp := b.create(db_uuid, backup_req)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// run the backup job in the background
+	go b.run(*backup_new_model, *db)
+
+	return b.ShowOne(backup_new_model.BackupUUID)
+}
+
+// Execute creates a backup job and waits for it to finish, it is used by
+// scheduled jobs which need the outcome of the backup
+func (b *BackupRepoImpl) Execute(db_uuid string, backup_req BackupNewRequest) (*BackupResponse, *responses.ErrorResponse) {
+	backup_new_model, db, err_resp := b.create(db_uuid, backup_req)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	if err := b.run(*backup_new_model, *db); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("backup_create_failed", err)
+	}
+
+	return b.ShowOne(backup_new_model.BackupUUID)
+}
+
+// create records a pending backup job for the database
+func (b *BackupRepoImpl) create(db_uuid string, backup_req BackupNewRequest) (*BackupNewModel, *database.Database, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(b.UserContext, b.DBPool).Accessible(db_uuid, constants.AccessLevelAdmin, "backup_create_failed")
+	if err_resp != nil {
+		return nil, nil, err_resp
+	}
+
+	// create insert model
+	var backup_new_model BackupNewModel
+	if err := backup_new_model.new(db_resp.Database.ID, backup_req, b.UserContext, b.DBPool); err != nil {
+		custom_log.NewCustomLog("backup_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse("backup_create_failed", fmt.Errorf("invalid_info_to_create_backup"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_backups (
+			id, backup_uuid, user_id, db_id, format, spaces, file_path,
+			job_status, created_by, created_at
+		) VALUES (
+			:id, :backup_uuid, :user_id, :db_id, :format, :spaces, :file_path,
+			:job_status, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := b.DBPool.NamedExec(query, backup_new_model); err != nil {
+		custom_log.NewCustomLog("backup_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse("backup_create_failed", fmt.Errorf("error_create_backup"))
+	}
+
+	return &backup_new_model, &db_resp.Database, nil
+}
+
+func (b *BackupRepoImpl) List(db_uuid string, list_req types.ListRequest) ([]Backup, int, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(b.UserContext, b.DBPool).Accessible(db_uuid, constants.AccessLevelAdmin, "backup_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// prepare query
+	query := `
+		SELECT
+			bk.id, bk.backup_uuid, bk.user_id, bk.db_id, db.db_uuid, bk.format, bk.spaces,
+			bk.file_path, bk.file_size, bk.checksum, bk.space_count, bk.tuple_count,
+			bk.job_status, bk.error_message, bk.started_at, bk.finished_at,
+			bk.created_by, bk.created_at
+		FROM tbl_backups bk
+		INNER JOIN tbl_users_databases db ON db.id = bk.db_id
+		WHERE bk.deleted_at IS NULL
+		AND bk.db_id = $1
+	`
+
+	// execute query
+	var backups []Backup
+	total, err := postgres.SelectList(b.DBPool, &backups, query, []interface{}{db_resp.Database.ID}, list_req, backupListFields)
+	if err != nil {
+		custom_log.NewCustomLog("backup_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("backup_list_failed", fmt.Errorf("get_backup_error"))
+	}
+
+	if backups == nil {
+		backups = []Backup{}
+	}
+
+	return backups, total, nil
+}
+
+func (b *BackupRepoImpl) ShowOne(backup_uuid string) (*BackupResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			bk.id, bk.backup_uuid, bk.user_id, bk.db_id, db.db_uuid, bk.format, bk.spaces,
+			bk.file_path, bk.file_size, bk.checksum, bk.space_count, bk.tuple_count,
+			bk.job_status, bk.error_message, bk.started_at, bk.finished_at,
+			bk.created_by, bk.created_at
+		FROM tbl_backups bk
+		INNER JOIN tbl_users_databases db ON db.id = bk.db_id
+		WHERE bk.deleted_at IS NULL
+		AND bk.backup_uuid = $1
+	`
+
+	// execute query
+	var backup Backup
+	if err := b.DBPool.Get(&backup, query, backup_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("backup_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("backup_show_failed", fmt.Errorf("no_backup_found"))
+		}
+		custom_log.NewCustomLog("backup_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("backup_show_failed", fmt.Errorf("get_backup_error"))
+	}
+
+	// the backup holds the data of its database
+	if _, err_resp := database.NewDatabaseRepoImpl(b.UserContext, b.DBPool).Accessible(backup.DBUUID, constants.AccessLevelAdmin, "backup_show_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return &BackupResponse{
+		Backup: backup,
+	}, nil
+}
+
+type backupResult struct {
+	FileSize   int64
+	Checksum   string
+	SpaceCount int
+	TupleCount uint64
+}
+
+// run executes the backup job and records its outcome in tbl_backups
+func (b *BackupRepoImpl) run(backup BackupNewModel, db database.Database) error {
+	// mark the job as running
+	update_running := `
+		UPDATE tbl_backups SET
+			job_status = $1, started_at = $2
+		WHERE id = $3
+	`
+	if _, err := b.DBPool.Exec(update_running, constants.JobStatusRunning, utils.Now(), backup.ID); err != nil {
+		custom_log.NewCustomLog("backup_run_failed", err.Error(), "error")
+	}
+
+	result, err := writeArchive(backup, db)
+	if err != nil {
+		custom_log.NewCustomLog("backup_run_failed", err.Error(), "error")
+		os.Remove(backup.FilePath)
+
+		update_failed := `
+			UPDATE tbl_backups SET
+				job_status = $1, error_message = $2, finished_at = $3
+			WHERE id = $4
+		`
+		if _, err := b.DBPool.Exec(update_failed, constants.JobStatusFailed, err.Error(), utils.Now(), backup.ID); err != nil {
+			custom_log.NewCustomLog("backup_run_failed", err.Error(), "error")
+		}
+		return err
+	}
+
+	update_completed := `
+		UPDATE tbl_backups SET
+			job_status = $1, file_size = $2, checksum = $3,
+			space_count = $4, tuple_count = $5, finished_at = $6
+		WHERE id = $7
+	`
+	if _, err := b.DBPool.Exec(
+		update_completed,
+		constants.JobStatusCompleted,
+		result.FileSize,
+		result.Checksum,
+		result.SpaceCount,
+		result.TupleCount,
+		utils.Now(),
+		backup.ID,
+	); err != nil {
+		custom_log.NewCustomLog("backup_run_failed", err.Error(), "error")
+	}
+
+	return nil
+}
+
+// writeArchive streams every selected space into a compressed archive
+func writeArchive(backup BackupNewModel, db database.Database) (*backupResult, error) {
+	// connect database
+	conn, err := tarantool_utils.ConnectTarantool(db.Host, int(db.Port), db.Username, db.Password)
+	if err != nil {
+		return nil, fmt.Errorf("connect target database: %w", err)
+	}
+	defer conn.Close()
+
+	// read the schema of the user spaces
+	all_spaces, err := tarantool_utils.GetUserSpaces(conn)
+	if err != nil {
+		return nil, fmt.Errorf("read spaces: %w", err)
+	}
+
+	spaces, err := filterSpaces(all_spaces, backup.Spaces)
+	if err != nil {
+		return nil, err
+	}
+
+	// stage every data file in a temporary directory first, the manifest
+	// needs the tuple counts and checksums and is stored first in the archive
+	tmp_dir, err := os.MkdirTemp("", "backup-"+backup.BackupUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp_dir)
+
+	manifest := archive.Manifest{
+		Version:   archive.ManifestVersion,
+		DBUUID:    db.DBUUID,
+		DBName:    db.DBName,
+		Format:    backup.Format,
+		CreatedAt: utils.Now(),
+		Spaces:    []archive.ManifestSpace{},
+	}
+
+	var tuple_count uint64
+	for i, space := range spaces {
+		tmp_path := filepath.Join(tmp_dir, fmt.Sprintf("%d.%s", i, backup.Format))
+		manifest_space, err := dumpSpace(conn, space, tmp_path, backup.Format)
+		if err != nil {
+			return nil, fmt.Errorf("dump space %s: %w", space.Name, err)
+		}
+
+		tuple_count += manifest_space.TupleCount
+		manifest.Spaces = append(manifest.Spaces, *manifest_space)
+	}
+
+	// build the archive
+	if err := os.MkdirAll(filepath.Dir(backup.FilePath), 0755); err != nil {
+		return nil, err
+	}
+
+	writer, err := archive.NewWriter(backup.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writer.WriteManifest(manifest); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	for i, space := range manifest.Spaces {
+		tmp_path := filepath.Join(tmp_dir, fmt.Sprintf("%d.%s", i, backup.Format))
+		if err := writer.AddFile(space.File, tmp_path); err != nil {
+			writer.Close()
+			return nil, err
+		}
+	}
+
+	file_size, checksum, err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return &backupResult{
+		FileSize:   file_size,
+		Checksum:   checksum,
+		SpaceCount: len(manifest.Spaces),
+		TupleCount: tuple_count,
+	}, nil
+}
+
+// dumpSpace writes all tuples of a space to a local data file
+func dumpSpace(conn *pool.ConnectionPool, space tarantool_utils.SpaceSchema, file_path string, format string) (*archive.ManifestSpace, error) {
+	file, err := os.Create(file_path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	writer, err := archive.NewTupleWriter(file, format)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tarantool_utils.ScanSpace(conn, space.Name, 1000, func(tuples [][]interface{}) error {
+		for _, tuple := range tuples {
+			if err := writer.Write(tuple); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	count, checksum, err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	space.TupleCount = count
+
+	return &archive.ManifestSpace{
+		Schema:     space,
+		File:       archive.DataFileName(space.Name, format),
+		TupleCount: count,
+		Checksum:   checksum,
+	}, nil
+}
+
+// filterSpaces keeps the requested spaces, or every space when none requested
+func filterSpaces(spaces []tarantool_utils.SpaceSchema, names []string) ([]tarantool_utils.SpaceSchema, error) {
+	if len(names) == 0 {
+		return spaces, nil
+	}
+
+	by_name := make(map[string]tarantool_utils.SpaceSchema, len(spaces))
+	for _, space := range spaces {
+		by_name[space.Name] = space
+	}
+
+	var filtered []tarantool_utils.SpaceSchema
+	for _, name := range names {
+		space, ok := by_name[name]
+		if !ok {
+			return nil, fmt.Errorf("space %s does not exist", name)
+		}
+		filtered = append(filtered, space)
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,36 @@
+package backup
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type BackupRoute struct {
+	App           *fiber.App
+	DBPool        *sqlx.DB
+	BackupHandler *BackupHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *BackupRoute {
+	return &BackupRoute{
+		App:           app,
+		DBPool:        db_pool,
+		BackupHandler: NewBackupHandler(db_pool),
+	}
+}
+
+func (b *BackupRoute) RegisterBackupRoute() *BackupRoute {
+	backup := b.App.Group("/api/v1/front/backup")
+
+	backup.Post("/:db_uuid/snapshot", middlewares.RequirePermission(constants.PermissionManageConnections), b.BackupHandler.Snapshot)
+	backup.Get("/:db_uuid/checkpoints", middlewares.RequirePermission(constants.PermissionManageConnections), b.BackupHandler.Checkpoints)
+	backup.Post("/:db_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), b.BackupHandler.Create)
+	backup.Get("/:db_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), b.BackupHandler.List)
+	backup.Get("/job/:backup_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), b.BackupHandler.ShowOne)
+	backup.Get("/job/:backup_uuid/download", middlewares.RequirePermission(constants.PermissionManageConnections), b.BackupHandler.Download)
+
+	return b
+}
@@ -0,0 +1,50 @@
+package backup
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type BackupServiceCreator interface {
+	Snapshot(db_uuid string) (*SnapshotResponse, *responses.ErrorResponse)
+	Checkpoints(db_uuid string) (*CheckpointsResponse, *responses.ErrorResponse)
+	Create(db_uuid string, backup_req BackupNewRequest) (*BackupResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]Backup, int, *responses.ErrorResponse)
+	ShowOne(backup_uuid string) (*BackupResponse, *responses.ErrorResponse)
+}
+
+type BackupService struct {
+	DBPool      *sqlx.DB
+	BackupRepo  *BackupRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewBackupService(us_ctx *types.UserContext, db_pool *sqlx.DB) *BackupService {
+	return &BackupService{
+		DBPool:      db_pool,
+		BackupRepo:  NewBackupRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (b *BackupService) Snapshot(db_uuid string) (*SnapshotResponse, *responses.ErrorResponse) {
+	return b.BackupRepo.Snapshot(db_uuid)
+}
+
+func (b *BackupService) Checkpoints(db_uuid string) (*CheckpointsResponse, *responses.ErrorResponse) {
+	return b.BackupRepo.Checkpoints(db_uuid)
+}
+
+func (b *BackupService) Create(db_uuid string, backup_req BackupNewRequest) (*BackupResponse, *responses.ErrorResponse) {
+	return b.BackupRepo.Create(db_uuid, backup_req)
+}
+
+func (b *BackupService) List(db_uuid string, list_req types.ListRequest) ([]Backup, int, *responses.ErrorResponse) {
+	return b.BackupRepo.List(db_uuid, list_req)
+}
+
+func (b *BackupService) ShowOne(backup_uuid string) (*BackupResponse, *responses.ErrorResponse) {
+	return b.BackupRepo.ShowOne(backup_uuid)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	response "tarantool-admin-api/pkg/http/response"
@@ -57,7 +58,7 @@ func (db *DatabaseHandler) Create(c *fiber.Ctx) error {
 			response.NewResponseError(
 				utils.Translate(err.MessageID, nil, c),
 				-2001,
-				fmt.Errorf(utils.Translate(err.Err.Error(), nil, c)),
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
 			),
 		)
 	}
@@ -80,7 +81,7 @@ func (db *DatabaseHandler) GetDBDetail(c *fiber.Ctx) error {
 			response.NewResponseError(
 				utils.Translate(err.MessageID, nil, c),
 				-2000,
-				fmt.Errorf(utils.Translate(err.Err.Error(), nil, c)),
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
 			),
 		)
 	}
@@ -111,16 +112,33 @@ func (db *DatabaseHandler) Query(c *fiber.Ctx) error {
 
 	query_resp, err := db.DatabaseService(c).Query(db_uuid, db_query_req)
 	if err != nil {
+		// statements rejected by the mode get their own code
+		code := -2005
+		if err.MessageID == "query_not_allowed" {
+			code = -2010
+		}
+
 		return c.Status(http.StatusBadRequest).JSON(
 			response.NewResponseErrorWithDetail(
 				utils.Translate(err.MessageID, nil, c),
-				-2005,
-				fmt.Errorf(utils.Translate(err.Err.Error(), nil, c)),
+				code,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
 				err.Detail,
 			),
 		)
 	}
 
+	// the query waits for approval instead of running
+	if query_resp.PendingChange != nil {
+		return c.Status(http.StatusAccepted).JSON(
+			response.NewResponse(
+				utils.Translate("query_pending_approval", nil, c),
+				2005,
+				query_resp,
+			),
+		)
+	}
+
 	return c.Status(http.StatusOK).JSON(
 		response.NewResponse(
 			utils.Translate("query_db_success", nil, c),
@@ -129,3 +147,393 @@ func (db *DatabaseHandler) Query(c *fiber.Ctx) error {
 		),
 	)
 }
+
+func (db *DatabaseHandler) List(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, databaseListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("db_list_failed", nil, c),
+				-2002,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := db.DatabaseService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("db_list_success", nil, c),
+			2002,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (db *DatabaseHandler) Lua(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var db_lua_req DatabaseLuaRequest
+	v := utils.NewValidator()
+	if err := db_lua_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("lua_eval_failed", nil, c),
+				-2006,
+				err,
+			),
+		)
+	}
+
+	resp, err := db.DatabaseService(c).Lua(db_uuid, db_lua_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseErrorWithDetail(
+				utils.Translate(err.MessageID, nil, c),
+				-2006,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+				err.Detail,
+			),
+		)
+	}
+
+	// the evaluation waits for approval instead of running
+	if resp.PendingChange != nil {
+		return c.Status(http.StatusAccepted).JSON(
+			response.NewResponse(
+				utils.Translate("lua_pending_approval", nil, c),
+				2006,
+				resp,
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("lua_eval_success", nil, c),
+			2006,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) ListPermissions(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, permissionListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("db_permission_list_failed", nil, c),
+				-2007,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := db.DatabaseService(c).ListPermissions(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2007,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("db_permission_list_success", nil, c),
+			2007,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (db *DatabaseHandler) GrantPermission(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var permission_req DatabasePermissionRequest
+	v := utils.NewValidator()
+	if err := permission_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("db_permission_grant_failed", nil, c),
+				-2008,
+				err,
+			),
+		)
+	}
+
+	resp, err := db.DatabaseService(c).GrantPermission(db_uuid, permission_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2008,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_permission_grant_success", nil, c),
+			2008,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) RevokePermission(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+	user_uuid := c.Params("user_uuid")
+	permission := c.Params("permission")
+
+	resp, err := db.DatabaseService(c).RevokePermission(db_uuid, user_uuid, permission)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2009,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_permission_revoke_success", nil, c),
+			2009,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) ShowMode(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	resp, err := db.DatabaseService(c).ShowMode(db_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2011,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_mode_show_success", nil, c),
+			2011,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) UpdateMode(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var mode_req DatabaseModeRequest
+	v := utils.NewValidator()
+	if err := mode_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("db_mode_update_failed", nil, c),
+				-2012,
+				err,
+			),
+		)
+	}
+
+	resp, err := db.DatabaseService(c).UpdateMode(db_uuid, mode_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2012,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_mode_update_success", nil, c),
+			2012,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) UpdateUserMode(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+	user_uuid := c.Params("user_uuid")
+
+	var mode_req DatabaseModeRequest
+	v := utils.NewValidator()
+	if err := mode_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("db_mode_update_failed", nil, c),
+				-2013,
+				err,
+			),
+		)
+	}
+
+	resp, err := db.DatabaseService(c).UpdateUserMode(db_uuid, user_uuid, mode_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2013,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_mode_update_success", nil, c),
+			2013,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) DeleteUserMode(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := db.DatabaseService(c).DeleteUserMode(db_uuid, user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2014,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_mode_update_success", nil, c),
+			2014,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) UpdateApproval(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var approval_req DatabaseApprovalRequest
+	v := utils.NewValidator()
+	if err := approval_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("db_approval_update_failed", nil, c),
+				-2015,
+				err,
+			),
+		)
+	}
+
+	resp, err := db.DatabaseService(c).UpdateApproval(db_uuid, approval_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2015,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("db_approval_update_success", nil, c),
+			2015,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) Update(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var update_db_req DatabaseUpdateRequest
+	v := utils.NewValidator()
+	if err := update_db_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("update_db_failed", nil, c),
+				-2016,
+				err,
+			),
+		)
+	}
+
+	resp, err := db.DatabaseService(c).Update(db_uuid, update_db_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2016,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("update_db_success", nil, c),
+			2016,
+			resp,
+		),
+	)
+}
+
+func (db *DatabaseHandler) Delete(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	resp, err := db.DatabaseService(c).Delete(db_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-2017,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("delete_db_success", nil, c),
+			2017,
+			resp,
+		),
+	)
+}
@@ -1,8 +1,12 @@
 package database
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"tarantool-admin-api/pkg/constants"
 	custom_log "tarantool-admin-api/pkg/logs"
 	types "tarantool-admin-api/pkg/model"
 	"tarantool-admin-api/pkg/postgres"
@@ -13,42 +17,170 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
 )
 
+const (
+	// what triggered a schema snapshot
+	SchemaSourceManual   = "manual"
+	SchemaSourceDetail   = "detail"
+	SchemaSourceSchedule = "schedule"
+	SchemaSourceAPI      = "api"
+)
+
+const (
+	// permissions the owner of a database grants explicitly
+	PermissionLuaEval        = "lua_eval"
+	PermissionApproveChanges = "approve_changes"
+)
+
+const (
+	// kinds of changes that wait for approval
+	ChangeKindQuery     = "query"
+	ChangeKindRestore   = "restore"
+	ChangeKindImport    = "import"
+	ChangeKindTransfer  = "transfer"
+	ChangeKindMigration = "migration"
+	ChangeKindLua       = "lua"
+
+	// states of a pending change
+	ChangeStatusPending  = "pending"
+	ChangeStatusApproved = "approved"
+	ChangeStatusRejected = "rejected"
+	ChangeStatusExecuted = "executed"
+	ChangeStatusFailed   = "failed"
+)
+
+// statement kinds that need approval on a database requiring it
+var approvalStatements = map[string]bool{
+	tarantool_utils.StatementDML:     true,
+	tarantool_utils.StatementDDL:     true,
+	tarantool_utils.StatementUnknown: true,
+}
+
+const (
+	// statement modes of a database connection
+	ModeReadOnly   = "read_only"
+	ModeReadWrite  = "read_write"
+	ModeDDLAllowed = "ddl_allowed"
+)
+
+// statement kinds each mode lets through
+var allowedStatements = map[string]map[string]bool{
+	ModeReadOnly: {
+		tarantool_utils.StatementRead:        true,
+		tarantool_utils.StatementPragma:      true,
+		tarantool_utils.StatementTransaction: true,
+	},
+	ModeReadWrite: {
+		tarantool_utils.StatementRead:        true,
+		tarantool_utils.StatementPragma:      true,
+		tarantool_utils.StatementTransaction: true,
+		tarantool_utils.StatementDML:         true,
+	},
+	ModeDDLAllowed: {
+		tarantool_utils.StatementRead:        true,
+		tarantool_utils.StatementPragma:      true,
+		tarantool_utils.StatementTransaction: true,
+		tarantool_utils.StatementDML:         true,
+		tarantool_utils.StatementDDL:         true,
+		tarantool_utils.StatementUnknown:     true,
+	},
+}
+
+// role permission needed to run each statement kind
+var statementPermissions = map[string]string{
+	tarantool_utils.StatementRead:        constants.PermissionRunSelect,
+	tarantool_utils.StatementPragma:      constants.PermissionRunSelect,
+	tarantool_utils.StatementTransaction: constants.PermissionRunSelect,
+	tarantool_utils.StatementDML:         constants.PermissionRunDML,
+	tarantool_utils.StatementDDL:         constants.PermissionRunDDL,
+	tarantool_utils.StatementUnknown:     constants.PermissionRunDDL,
+}
+
+// access level needed to run each statement kind
+var statementAccess = map[string]string{
+	tarantool_utils.StatementRead:        constants.AccessLevelRead,
+	tarantool_utils.StatementPragma:      constants.AccessLevelRead,
+	tarantool_utils.StatementTransaction: constants.AccessLevelRead,
+	tarantool_utils.StatementDML:         constants.AccessLevelWrite,
+	tarantool_utils.StatementDDL:         constants.AccessLevelAdmin,
+	tarantool_utils.StatementUnknown:     constants.AccessLevelAdmin,
+}
+
 type Database struct {
-	ID        uint64     `json:"-" db:"id"`
-	UserID    uint64     `json:"user_id" db:"user_id"`
-	DBUUID    string     `json:"db_uuid" db:"db_uuid"`
-	DBName    string     `json:"db_name" db:"db_name"`
-	Host      string     `json:"host" db:"host"`
-	Port      uint64     `json:"port" db:"port"`
-	Username  string     `json:"username" db:"username"`
-	Password  string     `json:"password" db:"password"`
-	IsActive  bool       `json:"is_active" db:"is_active"`
-	CreatedBy uint64     `json:"-" db:"created_by"`
-	CreatedAt time.Time  `json:"-" db:"created_at"`
-	UpdatedBy *uint64    `json:"-" db:"updated_by"`
-	UpdatedAt *time.Time `json:"-" db:"updated_at"`
-	DeletedBy *uint64    `json:"-" db:"deleted_by"`
-	DeletedAt *time.Time `json:"-" db:"deleted_at"`
+	ID               uint64     `json:"-" db:"id"`
+	UserID           uint64     `json:"user_id" db:"user_id"`
+	DBUUID           string     `json:"db_uuid" db:"db_uuid"`
+	DBName           string     `json:"db_name" db:"db_name"`
+	Host             string     `json:"host" db:"host"`
+	Port             uint64     `json:"port" db:"port"`
+	Username         string     `json:"username" db:"username"`
+	Password         string     `json:"password" db:"password"`
+	IsActive         bool       `json:"is_active" db:"is_active"`
+	Mode             string     `json:"mode" db:"mode"`
+	RequiresApproval bool       `json:"requires_approval" db:"requires_approval"`
+	WorkspaceID      *uint64    `json:"-" db:"workspace_id"`
+	CreatedBy        uint64     `json:"-" db:"created_by"`
+	CreatedAt        time.Time  `json:"-" db:"created_at"`
+	UpdatedBy        *uint64    `json:"-" db:"updated_by"`
+	UpdatedAt        *time.Time `json:"-" db:"updated_at"`
+	DeletedBy        *uint64    `json:"-" db:"deleted_by"`
+	DeletedAt        *time.Time `json:"-" db:"deleted_at"`
 }
 
 type DatabaseResponse struct {
 	Database Database `json:"database"`
 }
 
+// DatabaseSummary is a database in the listing along with the state of its
+// latest schema snapshot, schema_changed_by is only set for api changes
+type DatabaseSummary struct {
+	ID               uint64     `json:"-" db:"id"`
+	DBUUID           string     `json:"db_uuid" db:"db_uuid"`
+	DBName           string     `json:"db_name" db:"db_name"`
+	Host             string     `json:"host" db:"host"`
+	Port             uint64     `json:"port" db:"port"`
+	Username         string     `json:"username" db:"username"`
+	IsActive         bool       `json:"is_active" db:"is_active"`
+	Mode             string     `json:"mode" db:"mode"`
+	RequiresApproval bool       `json:"requires_approval" db:"requires_approval"`
+	WorkspaceUUID    *string    `json:"workspace_uuid" db:"workspace_uuid"`
+	WorkspaceName    *string    `json:"workspace_name" db:"workspace_name"`
+	MemberRole       *string    `json:"-" db:"member_role"`
+	AccessOverride   *string    `json:"-" db:"access_override"`
+	AccessLevel      string     `json:"access_level" db:"-"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	SchemaVersion    *int       `json:"schema_version" db:"schema_version"`
+	SchemaSource     *string    `json:"schema_source" db:"schema_source"`
+	SchemaDrift      bool       `json:"schema_drift" db:"schema_drift"`
+	SchemaChangedBy  *string    `json:"schema_changed_by" db:"schema_changed_by"`
+	SchemaChangedAt  *time.Time `json:"schema_changed_at" db:"schema_changed_at"`
+	SchemaCheckedAt  *time.Time `json:"schema_checked_at" db:"schema_checked_at"`
+}
+
+// databaseListFields are the columns the database list filters and sorts on
+var databaseListFields = postgres.ListFields{
+	Filter:      []string{"db_uuid", "db_name", "host", "port", "username", "is_active", "mode", "requires_approval", "workspace_uuid", "workspace_name", "schema_version", "schema_source", "schema_drift", "created_at"},
+	Sort:        []string{"db_name", "host", "port", "is_active", "mode", "workspace_name", "schema_version", "schema_changed_at", "created_at"},
+	DefaultSort: "id",
+}
+
 type DatabaseNewRequest struct {
-	DBName   string `json:"db_name" validate:"required"`
-	Host     string `json:"host" validate:"required"`
-	Port     uint64 `json:"port" validate:"required"`
-	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	DBName           string `json:"db_name" validate:"required"`
+	Host             string `json:"host" validate:"required"`
+	Port             uint64 `json:"port" validate:"required"`
+	Username         string `json:"username" validate:"required"`
+	Password         string `json:"password" validate:"required"`
+	Mode             string `json:"mode" validate:"omitempty,oneof=read_only read_write ddl_allowed"`
+	RequiresApproval bool   `json:"requires_approval"`
+	WorkspaceUUID    string `json:"workspace_uuid" validate:"omitempty,uuid"`
 }
 
 func (db *DatabaseNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
 	if err := c.BodyParser(db); err != nil {
 		custom_log.NewCustomLog("add_db_failed", err.Error(), "error")
-		return fmt.Errorf(utils.Translate("invalid_body", nil, c))
+		return errors.New(utils.Translate("invalid_body", nil, c))
 	}
 
 	if err := v.Validate(db, c); err != nil {
@@ -60,20 +192,23 @@ func (db *DatabaseNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
 }
 
 type DatabaseNewModel struct {
-	ID        uint64    `db:"id"`
-	UserID    uint64    `db:"user_id"`
-	DBUUID    string    `db:"db_uuid"`
-	DBName    string    `db:"db_name"`
-	Host      string    `db:"host"`
-	Port      int       `db:"port"`
-	Username  string    `db:"username"`
-	Password  string    `db:"password"`
-	IsActive  bool      `db:"is_active"`
-	CreatedBy int       `db:"created_by"`
-	CreatedAt time.Time `db:"created_at"`
-}
-
-func (db *DatabaseNewModel) new(db_new_req DatabaseNewRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	ID               uint64    `db:"id"`
+	UserID           uint64    `db:"user_id"`
+	DBUUID           string    `db:"db_uuid"`
+	DBName           string    `db:"db_name"`
+	Host             string    `db:"host"`
+	Port             int       `db:"port"`
+	Username         string    `db:"username"`
+	Password         string    `db:"password"`
+	IsActive         bool      `db:"is_active"`
+	Mode             string    `db:"mode"`
+	RequiresApproval bool      `db:"requires_approval"`
+	WorkspaceID      *uint64   `db:"workspace_id"`
+	CreatedBy        int       `db:"created_by"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+func (db *DatabaseNewModel) new(db_new_req DatabaseNewRequest, workspace_id *uint64, us_ctx *types.UserContext, conn *sqlx.DB) error {
 	// get sequence next value
 	id, err := postgres.GetSeqNextVal("tbl_users_databases_id_seq", conn)
 	if err != nil {
@@ -103,6 +238,12 @@ func (db *DatabaseNewModel) new(db_new_req DatabaseNewRequest, us_ctx *types.Use
 	db.Username = db_new_req.Username
 	db.Password = db_new_req.Password
 	db.IsActive = true
+	db.Mode = db_new_req.Mode
+	if db.Mode == "" {
+		db.Mode = ModeReadWrite
+	}
+	db.RequiresApproval = db_new_req.RequiresApproval
+	db.WorkspaceID = workspace_id
 	db.CreatedBy = us_ctx.Id
 	db.CreatedAt = now
 
@@ -142,7 +283,7 @@ type DatabaseQueryRequest struct {
 func (db *DatabaseQueryRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
 	if err := c.BodyParser(db); err != nil {
 		custom_log.NewCustomLog("query_db_failed", err.Error(), "error")
-		return fmt.Errorf(utils.Translate("invalid_body", nil, c))
+		return errors.New(utils.Translate("invalid_body", nil, c))
 	}
 
 	if err := v.Validate(db, c); err != nil {
@@ -153,6 +294,306 @@ func (db *DatabaseQueryRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
 	return nil
 }
 
+type DatabaseLuaRequest struct {
+	Code    string          `json:"code" validate:"required"`
+	RawArgs json.RawMessage `json:"args"`
+	Args    []interface{}   `json:"-"`
+}
+
+func (db *DatabaseLuaRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(db); err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(db, c); err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		return err
+	}
+
+	if err := db.DecodeArgs(); err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_lua_args", nil, c))
+	}
+
+	return nil
+}
+
+// DecodeArgs fills Args from the raw json arguments, a queued evaluation only
+// keeps the raw arguments
+func (db *DatabaseLuaRequest) DecodeArgs() error {
+	// keep numbers exact so integers reach lua as integers
+	db.Args = []interface{}{}
+	if len(db.RawArgs) > 0 && string(db.RawArgs) != "null" {
+		decoder := json.NewDecoder(bytes.NewReader(db.RawArgs))
+		decoder.UseNumber()
+		if err := decoder.Decode(&db.Args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type DatabaseLuaResultResponse struct {
+	LuaResult     *tarantool_utils.LuaEvalResult `json:"lua_result,omitempty"`
+	PendingChange *PendingChangeRef              `json:"pending_change,omitempty"`
+}
+
+type DatabasePermission struct {
+	ID         uint64    `json:"-" db:"id"`
+	DBID       uint64    `json:"-" db:"db_id"`
+	UserID     uint64    `json:"-" db:"user_id"`
+	UserUUID   string    `json:"user_uuid" db:"user_uuid"`
+	UserName   string    `json:"user_name" db:"user_name"`
+	Permission string    `json:"permission" db:"permission"`
+	GrantedBy  *string   `json:"granted_by" db:"granted_by"`
+	CreatedAt  time.Time `json:"granted_at" db:"created_at"`
+}
+
+type DatabasePermissionsResponse struct {
+	Permissions []DatabasePermission `json:"permissions"`
+}
+
+// permissionListFields are the columns the permission list filters and sorts on
+var permissionListFields = postgres.ListFields{
+	Filter:      []string{"user_uuid", "user_name", "permission", "granted_by", "created_at"},
+	Sort:        []string{"user_name", "permission", "granted_by", "created_at"},
+	DefaultSort: "id",
+}
+
+type DatabasePermissionRequest struct {
+	UserUUID   string `json:"user_uuid" validate:"required,uuid"`
+	Permission string `json:"permission" validate:"required,oneof=lua_eval approve_changes"`
+}
+
+func (db *DatabasePermissionRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(db); err != nil {
+		custom_log.NewCustomLog("db_permission_grant_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(db, c); err != nil {
+		custom_log.NewCustomLog("db_permission_grant_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type DatabaseUpdateRequest struct {
+	DBName   string `json:"db_name" validate:"required"`
+	Host     string `json:"host" validate:"required"`
+	Port     uint64 `json:"port" validate:"required"`
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password"`
+}
+
+func (db *DatabaseUpdateRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(db); err != nil {
+		custom_log.NewCustomLog("update_db_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(db, c); err != nil {
+		custom_log.NewCustomLog("update_db_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// DatabaseQueryResultResponse holds either the result of the query or, on a
+// database requiring approval, the pending change it was queued as
 type DatabaseQueryResultResponse struct {
-	QueryResult tarantool_utils.QueryResult `json:"query_result"`
+	QueryResult   *tarantool_utils.QueryResult `json:"query_result,omitempty"`
+	PendingChange *PendingChangeRef            `json:"pending_change,omitempty"`
+	Statements    []tarantool_utils.Statement  `json:"statements"`
+}
+
+type PendingChangeRef struct {
+	ChangeUUID   string `json:"change_uuid"`
+	ChangeStatus string `json:"change_status"`
+}
+
+type DatabaseApprovalRequest struct {
+	RequiresApproval *bool `json:"requires_approval" validate:"required"`
+}
+
+func (db *DatabaseApprovalRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(db); err != nil {
+		custom_log.NewCustomLog("db_approval_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(db, c); err != nil {
+		custom_log.NewCustomLog("db_approval_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type DatabaseUserMode struct {
+	ID        uint64    `json:"-" db:"id"`
+	DBID      uint64    `json:"-" db:"db_id"`
+	UserID    uint64    `json:"-" db:"user_id"`
+	UserUUID  string    `json:"user_uuid" db:"user_uuid"`
+	UserName  string    `json:"user_name" db:"user_name"`
+	Mode      string    `json:"mode" db:"mode"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type DatabaseMode struct {
+	DBUUID    string             `json:"db_uuid"`
+	Mode      string             `json:"mode"`
+	Overrides []DatabaseUserMode `json:"overrides"`
+}
+
+type DatabaseModeResponse struct {
+	DatabaseMode DatabaseMode `json:"database_mode"`
+}
+
+type DatabaseModeRequest struct {
+	Mode string `json:"mode" validate:"required,oneof=read_only read_write ddl_allowed"`
+}
+
+func (db *DatabaseModeRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(db); err != nil {
+		custom_log.NewCustomLog("db_mode_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(db, c); err != nil {
+		custom_log.NewCustomLog("db_mode_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type SchemaSnapshotNewModel struct {
+	ID           uint64              `db:"id"`
+	SnapshotUUID string              `db:"snapshot_uuid"`
+	UserID       uint64              `db:"user_id"`
+	DBID         uint64              `db:"db_id"`
+	Version      int                 `db:"version"`
+	Source       string              `db:"source"`
+	Drift        bool                `db:"drift"`
+	Checksum     string              `db:"checksum"`
+	SchemaData   sqlx_types.JSONText `db:"schema_data"`
+	CheckedAt    time.Time           `db:"checked_at"`
+	CreatedBy    uint64              `db:"created_by"`
+	CreatedAt    time.Time           `db:"created_at"`
+}
+
+func (s *SchemaSnapshotNewModel) new(db_id uint64, version int, source string, drift bool, checksum string, schema *tarantool_utils.SchemaSnapshot, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_schema_snapshots_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	schema_data, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("error marshal schema : %w", err)
+	}
+
+	s.ID = uint64(*id)
+	s.SnapshotUUID = uuid.String()
+	s.UserID = uint64(us_ctx.Id)
+	s.DBID = db_id
+	s.Version = version
+	s.Source = source
+	s.Drift = drift
+	s.Checksum = checksum
+	s.SchemaData = sqlx_types.JSONText(schema_data)
+	s.CreatedBy = uint64(us_ctx.Id)
+	s.CreatedAt = utils.Now()
+	s.CheckedAt = s.CreatedAt
+
+	return nil
+}
+
+type PendingChangeNewModel struct {
+	ID           uint64                  `db:"id"`
+	ChangeUUID   string                  `db:"change_uuid"`
+	DBID         uint64                  `db:"db_id"`
+	Kind         string                  `db:"kind"`
+	Statement    string                  `db:"statement"`
+	Statements   sqlx_types.JSONText     `db:"statements"`
+	RestoreID    *uint64                 `db:"restore_id"`
+	Payload      sqlx_types.NullJSONText `db:"payload"`
+	ChangeStatus string                  `db:"change_status"`
+	RequestedBy  uint64                  `db:"requested_by"`
+	CreatedBy    uint64                  `db:"created_by"`
+	CreatedAt    time.Time               `db:"created_at"`
+}
+
+func (p *PendingChangeNewModel) new(db_id uint64, kind string, statement string, statements []tarantool_utils.Statement, restore_id *uint64, payload interface{}, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_pending_changes_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	if statements == nil {
+		statements = []tarantool_utils.Statement{}
+	}
+	statements_data, err := json.Marshal(statements)
+	if err != nil {
+		return fmt.Errorf("error marshal statements : %w", err)
+	}
+
+	// the payload keeps what a change other than a query runs with
+	if payload != nil {
+		payload_data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("error marshal payload : %w", err)
+		}
+		p.Payload = sqlx_types.NullJSONText{JSONText: payload_data, Valid: true}
+	}
+
+	p.ID = uint64(*id)
+	p.ChangeUUID = uuid.String()
+	p.DBID = db_id
+	p.Kind = kind
+	p.Statement = statement
+	p.Statements = sqlx_types.JSONText(statements_data)
+	p.RestoreID = restore_id
+	p.ChangeStatus = ChangeStatusPending
+	p.RequestedBy = uint64(us_ctx.Id)
+	p.CreatedBy = uint64(us_ctx.Id)
+	p.CreatedAt = utils.Now()
+
+	return nil
+}
+
+// accessLevel returns the access level of a member on a workspace
+// connection, the level set for the connection wins over the member role
+func accessLevel(member_role *string, access_override *string) string {
+	if member_role == nil {
+		return ""
+	}
+	if access_override != nil {
+		return *access_override
+	}
+	return constants.WorkspaceRoleAccess[*member_role]
+}
+
+// hasAccess reports whether the level includes the required one
+func hasAccess(level string, required string) bool {
+	return level != "" && constants.AccessLevelRank[level] >= constants.AccessLevelRank[required]
 }
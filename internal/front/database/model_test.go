@@ -0,0 +1,35 @@
+package database
+
+import (
+	"testing"
+
+	"tarantool-admin-api/pkg/constants"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+)
+
+func TestStatementRequirements(t *testing.T) {
+	tests := []struct {
+		kind       string
+		access     string
+		permission string
+	}{
+		{kind: tarantool_utils.StatementRead, access: constants.AccessLevelRead, permission: constants.PermissionRunSelect},
+		{kind: tarantool_utils.StatementPragma, access: constants.AccessLevelRead, permission: constants.PermissionRunSelect},
+		{kind: tarantool_utils.StatementTransaction, access: constants.AccessLevelRead, permission: constants.PermissionRunSelect},
+		{kind: tarantool_utils.StatementDML, access: constants.AccessLevelWrite, permission: constants.PermissionRunDML},
+		{kind: tarantool_utils.StatementDDL, access: constants.AccessLevelAdmin, permission: constants.PermissionRunDDL},
+		// a statement the classifier does not know needs the most of both
+		{kind: tarantool_utils.StatementUnknown, access: constants.AccessLevelAdmin, permission: constants.PermissionRunDDL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if got := statementAccess[tt.kind]; got != tt.access {
+				t.Errorf("statementAccess[%s] = %q, want %q", tt.kind, got, tt.access)
+			}
+			if got := statementPermissions[tt.kind]; got != tt.permission {
+				t.Errorf("statementPermissions[%s] = %q, want %q", tt.kind, got, tt.permission)
+			}
+		})
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"tarantool-admin-api/pkg/constants"
 	custom_log "tarantool-admin-api/pkg/logs"
 	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
 	"tarantool-admin-api/pkg/responses"
 	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/tarantool/go-tarantool/v2"
@@ -18,8 +21,27 @@ import (
 
 type DatabaseRepo interface {
 	Create(new_db_req DatabaseNewRequest) (*DatabaseResponse, *responses.ErrorResponse)
+	Update(db_uuid string, update_db_req DatabaseUpdateRequest) (*DatabaseResponse, *responses.ErrorResponse)
+	Delete(db_uuid string) (*DatabaseResponse, *responses.ErrorResponse)
 	GetDBDetail(db_uuid string) (*DatabaseDetailResponse, *responses.ErrorResponse)
 	Query(db_uuid string, db_query_req DatabaseQueryRequest) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse)
+	ShowMode(db_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse)
+	UpdateMode(db_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse)
+	UpdateUserMode(db_uuid string, user_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse)
+	DeleteUserMode(db_uuid string, user_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse)
+	UpdateApproval(db_uuid string, approval_req DatabaseApprovalRequest) (*DatabaseResponse, *responses.ErrorResponse)
+	QueueChange(database Database, kind string, statement string, statements []tarantool_utils.Statement, restore_id *uint64, payload interface{}) (*PendingChangeRef, error)
+	Execute(database Database, query string) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse)
+	List(list_req types.ListRequest) ([]DatabaseSummary, int, *responses.ErrorResponse)
+	RecordSchema(database Database, source string) (string, error)
+	Lua(db_uuid string, db_lua_req DatabaseLuaRequest) (*DatabaseLuaResultResponse, *responses.ErrorWithDetailResponse)
+	ExecuteLua(database Database, db_lua_req DatabaseLuaRequest) (*DatabaseLuaResultResponse, *responses.ErrorWithDetailResponse)
+	ListPermissions(db_uuid string, list_req types.ListRequest) ([]DatabasePermission, int, *responses.ErrorResponse)
+	GrantPermission(db_uuid string, permission_req DatabasePermissionRequest) (*DatabasePermissionsResponse, *responses.ErrorResponse)
+	RevokePermission(db_uuid string, user_uuid string, permission string) (*DatabasePermissionsResponse, *responses.ErrorResponse)
+	HasPermission(db_id uint64, permission string) (bool, error)
+	Accessible(db_uuid string, required string, message_id string) (*DatabaseResponse, *responses.ErrorResponse)
+	Authorize(database Database, statements []tarantool_utils.Statement) *responses.ErrorWithDetailResponse
 }
 
 type DatabaseRepoImpl struct {
@@ -34,12 +56,14 @@ func NewDatabaseRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *DatabaseR
 	}
 }
 
+// ShowOne loads the database without checking the access of the current
+// user, requests made for a user load it through Accessible instead
 func (db *DatabaseRepoImpl) ShowOne(db_uuid string) (*DatabaseResponse, *responses.ErrorResponse) {
 	// prepare query
 	query := `
 		SELECT 
 			id, user_id, db_uuid, db_name, host, port, username, password, is_active, 
-			created_by, created_at, updated_by, updated_at, deleted_by, deleted_at
+			mode, requires_approval, workspace_id, created_by, created_at, updated_by, updated_at, deleted_by, deleted_at
 		FROM tbl_users_databases
 		WHERE deleted_at IS NULL
 		AND db_uuid = $1
@@ -80,8 +104,18 @@ func (db *DatabaseRepoImpl) Create(new_db_req DatabaseNewRequest) (*DatabaseResp
 		return nil, err_msg.NewErrorResponse("add_db_failed", fmt.Errorf("invalid_connection_settings"))
 	}
 
+	// a workspace connection can only be added by the admins of the workspace
+	var workspace_id *uint64
+	if new_db_req.WorkspaceUUID != "" {
+		id, err_resp := db.adminWorkspace(new_db_req.WorkspaceUUID, "add_db_failed")
+		if err_resp != nil {
+			return nil, err_resp
+		}
+		workspace_id = &id
+	}
+
 	// create insert model
-	if err := database_new_model.new(new_db_req, db.UserContext, db.DBPool); err != nil {
+	if err := database_new_model.new(new_db_req, workspace_id, db.UserContext, db.DBPool); err != nil {
 		custom_log.NewCustomLog("add_db_failed", err.Error(), "error")
 		err_msg := &responses.ErrorResponse{}
 		return nil, err_msg.NewErrorResponse("add_db_failed", fmt.Errorf("invalid_info_to_add_db"))
@@ -91,10 +125,10 @@ func (db *DatabaseRepoImpl) Create(new_db_req DatabaseNewRequest) (*DatabaseResp
 	query := `
 		INSERT INTO tbl_users_databases (
 			id, user_id, db_uuid, db_name, host, port, username, password,
-			is_active, created_by, created_at
+			is_active, mode, requires_approval, workspace_id, created_by, created_at
 		) VALUES (
 			:id, :user_id, :db_uuid, :db_name, :host, :port, :username, :password,
-			:is_active, :created_by, :created_at 
+			:is_active, :mode, :requires_approval, :workspace_id, :created_by, :created_at 
 		)
 	`
 
@@ -106,12 +140,128 @@ func (db *DatabaseRepoImpl) Create(new_db_req DatabaseNewRequest) (*DatabaseResp
 		return nil, err_msg.NewErrorResponse("add_db_failed", fmt.Errorf("error_add_db"))
 	}
 
+	utils.AuditUserAction(
+		db.UserContext,
+		"connection_create",
+		fmt.Sprintf("Added database %s at %s:%d", database_new_model.DBName, database_new_model.Host, database_new_model.Port),
+		constants.AuditTypeConnection,
+		&database_new_model.ID,
+		db.DBPool,
+	)
+
 	return db.ShowOne(database_new_model.DBUUID)
 }
 
+// Update changes the connection settings of the database, the password is
+// kept when it is not given
+func (db *DatabaseRepoImpl) Update(db_uuid string, update_db_req DatabaseUpdateRequest) (*DatabaseResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "update_db_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	password := update_db_req.Password
+	if password == "" {
+		password = database.Password
+	}
+
+	// test connect with the new settings
+	if err := tarantool_utils.TestTarantoolConnection(
+		update_db_req.Host,
+		int(update_db_req.Port),
+		update_db_req.Username,
+		password,
+	); err != nil {
+		custom_log.NewCustomLog("update_db_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("update_db_failed", fmt.Errorf("invalid_connection_settings"))
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users_databases SET
+			db_name = $1, host = $2, port = $3, username = $4, password = $5,
+			updated_by = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	// execute request
+	if _, err := db.DBPool.Exec(
+		query,
+		update_db_req.DBName,
+		update_db_req.Host,
+		update_db_req.Port,
+		update_db_req.Username,
+		password,
+		db.UserContext.Id,
+		utils.Now(),
+		database.ID,
+	); err != nil {
+		custom_log.NewCustomLog("update_db_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("update_db_failed", fmt.Errorf("error_update_db"))
+	}
+
+	utils.AuditUserAction(
+		db.UserContext,
+		"connection_update",
+		fmt.Sprintf(
+			"Updated database %s from %s at %s:%d to %s at %s:%d",
+			database.DBName, database.Username, database.Host, database.Port,
+			update_db_req.Username, update_db_req.Host, update_db_req.Port,
+		),
+		constants.AuditTypeConnection,
+		&database.ID,
+		db.DBPool,
+	)
+
+	return db.ShowOne(db_uuid)
+}
+
+// Delete removes the database connection
+func (db *DatabaseRepoImpl) Delete(db_uuid string) (*DatabaseResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "delete_db_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users_databases SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND id = $3
+	`
+
+	// execute request
+	result, err := db.DBPool.Exec(query, db.UserContext.Id, utils.Now(), database.ID)
+	if err != nil {
+		custom_log.NewCustomLog("delete_db_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("delete_db_failed", fmt.Errorf("error_delete_db"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("delete_db_failed", fmt.Errorf("no_db_found"))
+	}
+
+	utils.AuditUserAction(
+		db.UserContext,
+		"connection_delete",
+		fmt.Sprintf("Deleted database %s at %s:%d", database.DBName, database.Host, database.Port),
+		constants.AuditTypeConnection,
+		&database.ID,
+		db.DBPool,
+	)
+
+	return &DatabaseResponse{
+		Database: *database,
+	}, nil
+}
+
 func (db *DatabaseRepoImpl) GetDBDetail(db_uuid string) (*DatabaseDetailResponse, *responses.ErrorResponse) {
 	// get database info
-	db_resp, err_resp := db.ShowOne(db_uuid)
+	db_resp, err_resp := db.Accessible(db_uuid, constants.AccessLevelRead, "db_detail_show_failed")
 	if err_resp != nil {
 		return nil, err_resp
 	}
@@ -185,6 +335,11 @@ func (db *DatabaseRepoImpl) GetDBDetail(db_uuid string) (*DatabaseDetailResponse
 		spaces = append(spaces, space)
 	}
 
+	// keep the schema history up to date, a failure must not break the detail
+	if _, err := db.recordSchema(conn, db_resp.Database, SchemaSourceDetail); err != nil {
+		custom_log.NewCustomLog("schema_record_failed", err.Error(), "error")
+	}
+
 	return &DatabaseDetailResponse{
 		DatabaseDetail: DatabaseDetail{
 			DBName: db_resp.Database.DBName,
@@ -196,18 +351,132 @@ func (db *DatabaseRepoImpl) GetDBDetail(db_uuid string) (*DatabaseDetailResponse
 
 func (db *DatabaseRepoImpl) Query(db_uuid string, db_query_req DatabaseQueryRequest) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse) {
 	// get database info
-	db_resp, err_resp := db.ShowOne(db_uuid)
+	db_resp, err_resp := db.Accessible(db_uuid, constants.AccessLevelRead, "query_db_failed")
 	if err_resp != nil {
 		err_msg := &responses.ErrorWithDetailResponse{}
 		return nil, err_msg.NewErrorResponse(err_resp.MessageID, err_resp.Err, fmt.Errorf(""))
 	}
 
+	// classify the statements and check them against the mode of the user
+	statements, err := tarantool_utils.ClassifyStatements(db_query_req.Query)
+	if err != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("query_db_failed", fmt.Errorf("invalid_sql_statement"), err)
+	}
+
+	if err_detail := db.Authorize(db_resp.Database, statements); err_detail != nil {
+		return nil, err_detail
+	}
+
+	// writes on a database requiring approval wait for a second user
+	if db_resp.Database.RequiresApproval && needsApproval(statements) {
+		pending_change, err := db.QueueChange(db_resp.Database, ChangeKindQuery, db_query_req.Query, statements, nil, nil)
+		if err != nil {
+			custom_log.NewCustomLog("query_db_failed", err.Error(), "error")
+			err_msg := &responses.ErrorWithDetailResponse{}
+			return nil, err_msg.NewErrorResponse("query_db_failed", fmt.Errorf("error_queue_change"), err)
+		}
+
+		utils.AuditUserAction(
+			db.UserContext,
+			"query_queued",
+			fmt.Sprintf("Queued change %s on %s for approval: %s", pending_change.ChangeUUID, db_resp.Database.DBName, db_query_req.Query),
+			constants.AuditTypeApproval,
+			&db_resp.Database.ID,
+			db.DBPool,
+		)
+
+		return &DatabaseQueryResultResponse{
+			PendingChange: pending_change,
+			Statements:    statements,
+		}, nil
+	}
+
+	query_resp, err_detail := db.execute(db_resp.Database, statements, db_query_req.Query)
+	db.auditQuery(db_resp.Database, statements, db_query_req.Query, err_detail != nil)
+
+	return query_resp, err_detail
+}
+
+// Authorize checks the current user may run the statements on the database,
+// its access level and its role must cover every statement and its mode must
+// let them through. Modules writing to a database without sql describe the
+// write as a statement to get the checks of Query
+func (db *DatabaseRepoImpl) Authorize(database Database, statements []tarantool_utils.Statement) *responses.ErrorWithDetailResponse {
+	// the access level of the user on the connection must cover every statement
+	level, err := db.AccessLevel(database)
+	if err != nil {
+		custom_log.NewCustomLog("query_db_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return err_msg.NewErrorResponse("query_db_failed", fmt.Errorf("get_db_access_error"), err)
+	}
+	for _, statement := range statements {
+		if required := statementAccess[statement.Kind]; !hasAccess(level, required) {
+			err_msg := &responses.ErrorWithDetailResponse{}
+			return err_msg.NewErrorResponse(
+				"query_not_allowed",
+				fmt.Errorf("statement_not_permitted"),
+				fmt.Errorf("%s statement %s requires %s access on the database", statement.Kind, statement.Keyword, required),
+			)
+		}
+	}
+
+	// the role of the user must grant every kind of statement
+	for _, statement := range statements {
+		if permission := statementPermissions[statement.Kind]; !db.UserContext.HasPermission(permission) {
+			err_msg := &responses.ErrorWithDetailResponse{}
+			return err_msg.NewErrorResponse(
+				"query_not_allowed",
+				fmt.Errorf("statement_not_permitted"),
+				fmt.Errorf("%s statement %s requires the %s permission", statement.Kind, statement.Keyword, permission),
+			)
+		}
+	}
+
+	mode, err := db.effectiveMode(database)
+	if err != nil {
+		custom_log.NewCustomLog("query_db_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return err_msg.NewErrorResponse("query_db_failed", fmt.Errorf("get_db_mode_error"), err)
+	}
+
+	for _, statement := range statements {
+		if !allowedStatements[mode][statement.Kind] {
+			err_msg := &responses.ErrorWithDetailResponse{}
+			return err_msg.NewErrorResponse(
+				"query_not_allowed",
+				fmt.Errorf("statement_not_allowed_in_mode"),
+				fmt.Errorf("%s statement %s is not allowed in %s mode", statement.Kind, statement.Keyword, mode),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Execute runs the query without checking the mode or queueing it, it is
+// meant for changes that were already approved
+func (db *DatabaseRepoImpl) Execute(database Database, query string) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse) {
+	statements, err := tarantool_utils.ClassifyStatements(query)
+	if err != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("query_db_failed", fmt.Errorf("invalid_sql_statement"), err)
+	}
+
+	query_resp, err_detail := db.execute(database, statements, query)
+	db.auditQuery(database, statements, query, err_detail != nil)
+
+	return query_resp, err_detail
+}
+
+// execute runs the classified query on the database
+func (db *DatabaseRepoImpl) execute(database Database, statements []tarantool_utils.Statement, sql string) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse) {
 	// connect database to get data
 	conn, err := tarantool_utils.ConnectTarantool(
-		db_resp.Database.Host,
-		int(db_resp.Database.Port),
-		db_resp.Database.Username,
-		db_resp.Database.Password,
+		database.Host,
+		int(database.Port),
+		database.Username,
+		database.Password,
 	)
 	if err != nil {
 		custom_log.NewCustomLog("query_db_failed", err.Error(), "error")
@@ -218,9 +487,10 @@ func (db *DatabaseRepoImpl) Query(db_uuid string, db_query_req DatabaseQueryRequ
 	// close connection after function end
 	defer conn.Close()
 
-	query := fmt.Sprintf(`
+	// the sql is an argument of the eval, it never becomes part of the lua
+	query := `
+		local sql = ...
 		return (function()
-			local sql = [[%s]]
 			local res, err_msg = box.execute(sql)
 
 			if res == nil then
@@ -251,10 +521,10 @@ func (db *DatabaseRepoImpl) Query(db_uuid string, db_query_req DatabaseQueryRequ
 			res.rows = processed_rows
 			return res
 		end)()
-	`, db_query_req.Query)
+	`
 
 	// run query string
-	row_result, err := conn.Do(tarantool.NewEvalRequest(query), pool.ANY).Get()
+	row_result, err := conn.Do(tarantool.NewEvalRequest(query).Args([]interface{}{sql}), pool.ANY).Get()
 	if err != nil {
 		custom_log.NewCustomLog("query_db_failed", err.Error(), "error")
 		err_msg := &responses.ErrorWithDetailResponse{}
@@ -269,7 +539,807 @@ func (db *DatabaseRepoImpl) Query(db_uuid string, db_query_req DatabaseQueryRequ
 		return nil, err_msg.NewErrorResponse("query_db_failed", fmt.Errorf("failed_to_query_db"), err)
 	}
 
+	// schema statements are expected changes, record who made them
+	if hasKind(statements, tarantool_utils.StatementDDL) {
+		if _, err := db.recordSchema(conn, database, SchemaSourceAPI); err != nil {
+			custom_log.NewCustomLog("schema_record_failed", err.Error(), "error")
+		}
+	}
+
 	return &DatabaseQueryResultResponse{
-		QueryResult: *result,
+		QueryResult: result,
+		Statements:  statements,
+	}, nil
+}
+
+func (db *DatabaseRepoImpl) List(list_req types.ListRequest) ([]DatabaseSummary, int, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			db.id, db.db_uuid, db.db_name, db.host, db.port, db.username, db.is_active, db.mode,
+			db.requires_approval, ws.workspace_uuid, ws.workspace_name, wm.member_role,
+			da.access_level AS access_override, db.created_at,
+			ss.version AS schema_version, ss.source AS schema_source,
+			COALESCE(ss.drift, FALSE) AS schema_drift,
+			CASE WHEN ss.source = 'api' THEN us.user_name END AS schema_changed_by,
+			ss.created_at AS schema_changed_at, ss.checked_at AS schema_checked_at
+		FROM tbl_users_databases db
+		LEFT JOIN LATERAL (
+			SELECT version, source, drift, created_by, created_at, checked_at
+			FROM tbl_schema_snapshots
+			WHERE db_id = db.id
+			AND deleted_at IS NULL
+			ORDER BY version DESC
+			LIMIT 1
+		) ss ON TRUE
+		LEFT JOIN tbl_users us ON us.id = ss.created_by
+		LEFT JOIN tbl_workspaces ws ON ws.id = db.workspace_id AND ws.deleted_at IS NULL
+		LEFT JOIN tbl_workspaces_members wm ON wm.workspace_id = ws.id AND wm.user_id = $1 AND wm.deleted_at IS NULL
+		LEFT JOIN tbl_workspaces_databases_access da ON da.db_id = db.id AND da.user_id = $1 AND da.deleted_at IS NULL
+		WHERE db.deleted_at IS NULL
+		AND (
+			(db.workspace_id IS NULL AND db.user_id = $1)
+			OR wm.id IS NOT NULL
+		)
+	`
+
+	// execute query
+	var databases []DatabaseSummary
+	total, err := postgres.SelectList(db.DBPool, &databases, query, []interface{}{db.UserContext.Id}, list_req, databaseListFields)
+	if err != nil {
+		custom_log.NewCustomLog("db_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("db_list_failed", fmt.Errorf("get_db_error"))
+	}
+
+	if databases == nil {
+		databases = []DatabaseSummary{}
+	}
+	for i := range databases {
+		databases[i].AccessLevel = constants.AccessLevelAdmin
+		if databases[i].WorkspaceUUID != nil {
+			databases[i].AccessLevel = accessLevel(databases[i].MemberRole, databases[i].AccessOverride)
+		}
+	}
+
+	return databases, total, nil
+}
+
+// RecordSchema connects the database and records its current schema, see
+// recordSchema
+func (db *DatabaseRepoImpl) RecordSchema(database Database, source string) (string, error) {
+	conn, err := tarantool_utils.ConnectTarantool(database.Host, int(database.Port), database.Username, database.Password)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return db.recordSchema(conn, database, source)
+}
+
+// recordSchema stores a new version of the schema when it differs from the
+// latest snapshot, otherwise it only marks the latest one as checked. A change
+// that did not go through the api is flagged as drift. It returns the uuid of
+// the snapshot matching the current schema
+func (db *DatabaseRepoImpl) recordSchema(conn *pool.ConnectionPool, database Database, source string) (string, error) {
+	schema, err := tarantool_utils.GetSchema(conn)
+	if err != nil {
+		return "", fmt.Errorf("read schema: %w", err)
+	}
+
+	checksum, err := schema.Checksum()
+	if err != nil {
+		return "", fmt.Errorf("checksum schema: %w", err)
+	}
+
+	// get the latest snapshot
+	latest_query := `
+		SELECT id, snapshot_uuid, version, checksum
+		FROM tbl_schema_snapshots
+		WHERE deleted_at IS NULL
+		AND db_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+	var latest struct {
+		ID           uint64 `db:"id"`
+		SnapshotUUID string `db:"snapshot_uuid"`
+		Version      int    `db:"version"`
+		Checksum     string `db:"checksum"`
+	}
+	found := true
+	if err := db.DBPool.Get(&latest, latest_query, database.ID); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("get latest snapshot: %w", err)
+		}
+		found = false
+	}
+
+	if found && latest.Checksum == checksum {
+		update_checked := `
+			UPDATE tbl_schema_snapshots SET
+				checked_at = $1
+			WHERE id = $2
+		`
+		if _, err := db.DBPool.Exec(update_checked, utils.Now(), latest.ID); err != nil {
+			return "", fmt.Errorf("update snapshot: %w", err)
+		}
+		return latest.SnapshotUUID, nil
+	}
+
+	// the first snapshot is the baseline, it cannot be a drift
+	drift := found && source != SchemaSourceAPI
+
+	// create insert model
+	var snapshot_new_model SchemaSnapshotNewModel
+	if err := snapshot_new_model.new(database.ID, latest.Version+1, source, drift, checksum, schema, db.UserContext, db.DBPool); err != nil {
+		return "", err
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_schema_snapshots (
+			id, snapshot_uuid, user_id, db_id, version, source, drift, checksum,
+			schema_data, checked_at, created_by, created_at
+		) VALUES (
+			:id, :snapshot_uuid, :user_id, :db_id, :version, :source, :drift, :checksum,
+			:schema_data, :checked_at, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := db.DBPool.NamedExec(query, snapshot_new_model); err != nil {
+		return "", fmt.Errorf("insert snapshot: %w", err)
+	}
+
+	return snapshot_new_model.SnapshotUUID, nil
+}
+
+// Lua evaluates a lua chunk on the database, only users holding the lua_eval
+// permission on it may do so
+func (db *DatabaseRepoImpl) Lua(db_uuid string, db_lua_req DatabaseLuaRequest) (*DatabaseLuaResultResponse, *responses.ErrorWithDetailResponse) {
+	// lua can do anything on the database, it needs admin access
+	db_resp, err_resp := db.Accessible(db_uuid, constants.AccessLevelAdmin, "lua_eval_failed")
+	if err_resp != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse(err_resp.MessageID, err_resp.Err, fmt.Errorf(""))
+	}
+
+	// check the permission
+	permitted, err := db.HasPermission(db_resp.Database.ID, PermissionLuaEval)
+	if err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("get_permission_error"), fmt.Errorf(""))
+	}
+	if !permitted {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("lua_eval_not_permitted"), fmt.Errorf(""))
+	}
+
+	// lua may write, a read only user cannot evaluate it
+	mode, err := db.effectiveMode(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("get_db_mode_error"), err)
+	}
+	if mode == ModeReadOnly {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("lua_eval_not_allowed_in_mode"), fmt.Errorf("lua is not allowed in %s mode", mode))
+	}
+
+	// lua on a database requiring approval waits for a second user
+	if db_resp.Database.RequiresApproval {
+		statements := []tarantool_utils.Statement{{Keyword: "LUA", Kind: tarantool_utils.StatementUnknown}}
+		pending_change, err := db.QueueChange(db_resp.Database, ChangeKindLua, db_lua_req.Code, statements, nil, db_lua_req)
+		if err != nil {
+			custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+			err_msg := &responses.ErrorWithDetailResponse{}
+			return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("error_queue_change"), err)
+		}
+
+		utils.AuditUserAction(
+			db.UserContext,
+			"lua_queued",
+			fmt.Sprintf("Queued change %s on %s for approval: %s", pending_change.ChangeUUID, db_resp.Database.DBName, db_lua_req.Code),
+			constants.AuditTypeApproval,
+			&db_resp.Database.ID,
+			db.DBPool,
+		)
+
+		return &DatabaseLuaResultResponse{
+			PendingChange: pending_change,
+		}, nil
+	}
+
+	return db.ExecuteLua(db_resp.Database, db_lua_req)
+}
+
+// ExecuteLua evaluates the code without checking the user or queueing it, it
+// is meant for evaluations that were already approved
+func (db *DatabaseRepoImpl) ExecuteLua(database Database, db_lua_req DatabaseLuaRequest) (*DatabaseLuaResultResponse, *responses.ErrorWithDetailResponse) {
+	// connect database
+	conn, err := tarantool_utils.ConnectTarantool(
+		database.Host,
+		int(database.Port),
+		database.Username,
+		database.Password,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("failed_connect_to_target_db"), err)
+	}
+
+	// close connection after function end
+	defer conn.Close()
+
+	result, err := tarantool_utils.EvalLua(conn, db_lua_req.Code, db_lua_req.Args)
+	utils.AuditUserAction(
+		db.UserContext,
+		"lua_eval",
+		fmt.Sprintf("Evaluated lua on %s: %s", database.DBName, db_lua_req.Code),
+		constants.AuditTypeQuery,
+		&database.ID,
+		db.DBPool,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("lua_eval_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("lua_eval_failed", fmt.Errorf("failed_to_eval_lua"), err)
+	}
+
+	return &DatabaseLuaResultResponse{
+		LuaResult: result,
+	}, nil
+}
+
+func (db *DatabaseRepoImpl) ListPermissions(db_uuid string, list_req types.ListRequest) ([]DatabasePermission, int, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_permission_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// execute query
+	var permissions []DatabasePermission
+	total, err := postgres.SelectList(db.DBPool, &permissions, selectPermission, []interface{}{database.ID}, list_req, permissionListFields)
+	if err != nil {
+		custom_log.NewCustomLog("db_permission_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("db_permission_list_failed", fmt.Errorf("get_permission_error"))
+	}
+
+	if permissions == nil {
+		permissions = []DatabasePermission{}
+	}
+
+	return permissions, total, nil
+}
+
+// GrantPermission gives a user a permission on a database of the current user
+func (db *DatabaseRepoImpl) GrantPermission(db_uuid string, permission_req DatabasePermissionRequest) (*DatabasePermissionsResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_permission_grant_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	user_id, err_resp := db.userID(permission_req.UserUUID, "db_permission_grant_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_database_permissions (
+			db_id, user_id, permission, created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (db_id, user_id, permission) WHERE deleted_at IS NULL DO NOTHING
+	`
+
+	// execute request
+	if _, err := db.DBPool.Exec(query, database.ID, user_id, permission_req.Permission, db.UserContext.Id, utils.Now()); err != nil {
+		custom_log.NewCustomLog("db_permission_grant_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_permission_grant_failed", fmt.Errorf("error_grant_permission"))
+	}
+
+	return db.permissions(database.ID, "db_permission_grant_failed")
+}
+
+// RevokePermission takes a permission back from a user
+func (db *DatabaseRepoImpl) RevokePermission(db_uuid string, user_uuid string, permission string) (*DatabasePermissionsResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_permission_revoke_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	user_id, err_resp := db.userID(user_uuid, "db_permission_revoke_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_database_permissions SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND db_id = $3
+		AND user_id = $4
+		AND permission = $5
+	`
+
+	// execute request
+	result, err := db.DBPool.Exec(query, db.UserContext.Id, utils.Now(), database.ID, user_id, permission)
+	if err != nil {
+		custom_log.NewCustomLog("db_permission_revoke_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_permission_revoke_failed", fmt.Errorf("error_revoke_permission"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_permission_revoke_failed", fmt.Errorf("no_permission_found"))
+	}
+
+	return db.permissions(database.ID, "db_permission_revoke_failed")
+}
+
+// HasPermission reports whether the current user holds the permission on the
+// database
+func (db *DatabaseRepoImpl) HasPermission(db_id uint64, permission string) (bool, error) {
+	// prepare query
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM tbl_database_permissions
+			WHERE deleted_at IS NULL
+			AND db_id = $1
+			AND user_id = $2
+			AND permission = $3
+		)
+	`
+
+	// execute query
+	var exists bool
+	if err := db.DBPool.Get(&exists, query, db_id, db.UserContext.Id, permission); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// owned returns the database when it belongs to the current user, a
+// workspace database belongs to the members with admin access on it
+func (db *DatabaseRepoImpl) owned(db_uuid string, message_id string) (*Database, *responses.ErrorResponse) {
+	db_resp, err_resp := db.ShowOne(db_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	level, err := db.AccessLevel(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_db_access_error"))
+	}
+	if !hasAccess(level, constants.AccessLevelAdmin) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("db_not_owned"))
+	}
+
+	return &db_resp.Database, nil
+}
+
+// Accessible returns the database when the current user has at least the
+// required access level on it, every module acting on a database of the user
+// loads it through here
+func (db *DatabaseRepoImpl) Accessible(db_uuid string, required string, message_id string) (*DatabaseResponse, *responses.ErrorResponse) {
+	db_resp, err_resp := db.ShowOne(db_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	level, err := db.AccessLevel(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_db_access_error"))
+	}
+	if !hasAccess(level, required) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_db_access"))
+	}
+
+	return db_resp, nil
+}
+
+// AccessLevel returns the access level of the current user on the database,
+// personal databases are only reachable by their owner, workspace databases
+// by the members of the workspace
+func (db *DatabaseRepoImpl) AccessLevel(database Database) (string, error) {
+	if database.WorkspaceID == nil {
+		if database.UserID == uint64(db.UserContext.Id) {
+			return constants.AccessLevelAdmin, nil
+		}
+		return "", nil
+	}
+
+	// prepare query
+	query := `
+		SELECT wm.member_role, da.access_level
+		FROM tbl_workspaces_members wm
+		INNER JOIN tbl_workspaces ws ON ws.id = wm.workspace_id
+		LEFT JOIN tbl_workspaces_databases_access da ON da.db_id = $1 AND da.user_id = wm.user_id AND da.deleted_at IS NULL
+		WHERE wm.deleted_at IS NULL
+		AND ws.deleted_at IS NULL
+		AND wm.workspace_id = $2
+		AND wm.user_id = $3
+	`
+
+	// execute query
+	var member struct {
+		MemberRole  *string `db:"member_role"`
+		AccessLevel *string `db:"access_level"`
+	}
+	if err := db.DBPool.Get(&member, query, database.ID, *database.WorkspaceID, db.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return accessLevel(member.MemberRole, member.AccessLevel), nil
+}
+
+// adminWorkspace returns the id of the workspace when the current user
+// administers it
+func (db *DatabaseRepoImpl) adminWorkspace(workspace_uuid string, message_id string) (uint64, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT ws.id, wm.member_role
+		FROM tbl_workspaces ws
+		INNER JOIN tbl_workspaces_members wm ON wm.workspace_id = ws.id
+		WHERE ws.deleted_at IS NULL
+		AND wm.deleted_at IS NULL
+		AND ws.workspace_uuid = $1
+		AND wm.user_id = $2
+	`
+
+	// execute query
+	var member struct {
+		ID         uint64 `db:"id"`
+		MemberRole string `db:"member_role"`
+	}
+	if err := db.DBPool.Get(&member, query, workspace_uuid, db.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_workspace_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_workspace_error"))
+	}
+	if member.MemberRole == constants.WorkspaceRoleMember {
+		err_msg := &responses.ErrorResponse{}
+		return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("not_workspace_admin"))
+	}
+
+	return member.ID, nil
+}
+
+// userID resolves a user uuid to its id
+func (db *DatabaseRepoImpl) userID(user_uuid string, message_id string) (uint64, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT id
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND user_uuid = $1
+	`
+
+	// execute query
+	var user_id uint64
+	if err := db.DBPool.Get(&user_id, query, user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_user_error"))
+	}
+
+	return user_id, nil
+}
+
+// selectPermission reads the permissions granted on the database $1
+const selectPermission = `
+	SELECT
+		dp.id, dp.db_id, dp.user_id, us.user_uuid, us.user_name, dp.permission,
+		gr.user_name AS granted_by, dp.created_at
+	FROM tbl_database_permissions dp
+	INNER JOIN tbl_users us ON us.id = dp.user_id
+	LEFT JOIN tbl_users gr ON gr.id = dp.created_by
+	WHERE dp.deleted_at IS NULL
+	AND dp.db_id = $1
+`
+
+// permissions lists the permissions granted on the database
+func (db *DatabaseRepoImpl) permissions(db_id uint64, message_id string) (*DatabasePermissionsResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectPermission + `
+		ORDER BY dp.id
+	`
+
+	// execute query
+	var permissions []DatabasePermission
+	if err := db.DBPool.Select(&permissions, query, db_id); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_permission_error"))
+	}
+
+	if permissions == nil {
+		permissions = []DatabasePermission{}
+	}
+
+	return &DatabasePermissionsResponse{
+		Permissions: permissions,
 	}, nil
 }
+
+// ShowMode returns the statement mode of the database and its user overrides
+func (db *DatabaseRepoImpl) ShowMode(db_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_mode_show_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	return db.mode(*database, "db_mode_show_failed")
+}
+
+// UpdateMode changes the statement mode of the database
+func (db *DatabaseRepoImpl) UpdateMode(db_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_mode_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users_databases SET
+			mode = $1, updated_by = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	// execute request
+	if _, err := db.DBPool.Exec(query, mode_req.Mode, db.UserContext.Id, utils.Now(), database.ID); err != nil {
+		custom_log.NewCustomLog("db_mode_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_mode_update_failed", fmt.Errorf("error_update_db_mode"))
+	}
+	database.Mode = mode_req.Mode
+
+	return db.mode(*database, "db_mode_update_failed")
+}
+
+// UpdateUserMode sets the mode a user gets on the database instead of the
+// database mode
+func (db *DatabaseRepoImpl) UpdateUserMode(db_uuid string, user_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_mode_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	user_id, err_resp := db.userID(user_uuid, "db_mode_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_database_user_modes (
+			db_id, user_id, mode, created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (db_id, user_id) WHERE deleted_at IS NULL DO UPDATE SET
+			mode = EXCLUDED.mode, updated_by = EXCLUDED.created_by, updated_at = EXCLUDED.created_at
+	`
+
+	// execute request
+	if _, err := db.DBPool.Exec(query, database.ID, user_id, mode_req.Mode, db.UserContext.Id, utils.Now()); err != nil {
+		custom_log.NewCustomLog("db_mode_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_mode_update_failed", fmt.Errorf("error_update_db_mode"))
+	}
+
+	return db.mode(*database, "db_mode_update_failed")
+}
+
+// DeleteUserMode removes the override, the user gets the database mode again
+func (db *DatabaseRepoImpl) DeleteUserMode(db_uuid string, user_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_mode_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	user_id, err_resp := db.userID(user_uuid, "db_mode_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_database_user_modes SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND db_id = $3
+		AND user_id = $4
+	`
+
+	// execute request
+	result, err := db.DBPool.Exec(query, db.UserContext.Id, utils.Now(), database.ID, user_id)
+	if err != nil {
+		custom_log.NewCustomLog("db_mode_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_mode_update_failed", fmt.Errorf("error_update_db_mode"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_mode_update_failed", fmt.Errorf("no_mode_override_found"))
+	}
+
+	return db.mode(*database, "db_mode_update_failed")
+}
+
+// mode builds the mode response of the database
+func (db *DatabaseRepoImpl) mode(database Database, message_id string) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			um.id, um.db_id, um.user_id, us.user_uuid, us.user_name, um.mode, um.created_at
+		FROM tbl_database_user_modes um
+		INNER JOIN tbl_users us ON us.id = um.user_id
+		WHERE um.deleted_at IS NULL
+		AND um.db_id = $1
+		ORDER BY um.id
+	`
+
+	// execute query
+	var overrides []DatabaseUserMode
+	if err := db.DBPool.Select(&overrides, query, database.ID); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_db_mode_error"))
+	}
+
+	if overrides == nil {
+		overrides = []DatabaseUserMode{}
+	}
+
+	return &DatabaseModeResponse{
+		DatabaseMode: DatabaseMode{
+			DBUUID:    database.DBUUID,
+			Mode:      database.Mode,
+			Overrides: overrides,
+		},
+	}, nil
+}
+
+// effectiveMode returns the mode of the current user on the database, an
+// override wins over the database mode
+func (db *DatabaseRepoImpl) effectiveMode(database Database) (string, error) {
+	// prepare query
+	query := `
+		SELECT mode
+		FROM tbl_database_user_modes
+		WHERE deleted_at IS NULL
+		AND db_id = $1
+		AND user_id = $2
+	`
+
+	// execute query
+	var mode string
+	if err := db.DBPool.Get(&mode, query, database.ID, db.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Mode, nil
+		}
+		return "", err
+	}
+
+	return mode, nil
+}
+
+// UpdateApproval turns the approval of writes on the database on or off
+func (db *DatabaseRepoImpl) UpdateApproval(db_uuid string, approval_req DatabaseApprovalRequest) (*DatabaseResponse, *responses.ErrorResponse) {
+	database, err_resp := db.owned(db_uuid, "db_approval_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users_databases SET
+			requires_approval = $1, updated_by = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	// execute request
+	if _, err := db.DBPool.Exec(query, *approval_req.RequiresApproval, db.UserContext.Id, utils.Now(), database.ID); err != nil {
+		custom_log.NewCustomLog("db_approval_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("db_approval_update_failed", fmt.Errorf("error_update_db_approval"))
+	}
+
+	return db.ShowOne(db_uuid)
+}
+
+// QueueChange stores a change of the current user that has to be approved
+// before it runs on the database, statement describes the change to the
+// reviewer and payload is what a change that is not a query runs with
+func (db *DatabaseRepoImpl) QueueChange(database Database, kind string, statement string, statements []tarantool_utils.Statement, restore_id *uint64, payload interface{}) (*PendingChangeRef, error) {
+	var pending_change_new_model PendingChangeNewModel
+	if err := pending_change_new_model.new(database.ID, kind, statement, statements, restore_id, payload, db.UserContext, db.DBPool); err != nil {
+		return nil, err
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_pending_changes (
+			id, change_uuid, db_id, kind, statement, statements, restore_id, payload,
+			change_status, requested_by, created_by, created_at
+		) VALUES (
+			:id, :change_uuid, :db_id, :kind, :statement, :statements, :restore_id, :payload,
+			:change_status, :requested_by, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := db.DBPool.NamedExec(query, pending_change_new_model); err != nil {
+		return nil, err
+	}
+
+	return &PendingChangeRef{
+		ChangeUUID:   pending_change_new_model.ChangeUUID,
+		ChangeStatus: pending_change_new_model.ChangeStatus,
+	}, nil
+}
+
+// needsApproval reports whether any statement writes data or schema
+func needsApproval(statements []tarantool_utils.Statement) bool {
+	for _, statement := range statements {
+		if approvalStatements[statement.Kind] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKind reports whether any statement is of the kind
+func hasKind(statements []tarantool_utils.Statement, kind string) bool {
+	for _, statement := range statements {
+		if statement.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// auditQuery records a query run on the database, a query changing the
+// schema is recorded as ddl
+func (db *DatabaseRepoImpl) auditQuery(database Database, statements []tarantool_utils.Statement, sql string, failed bool) {
+	context, audit_type := "query_execute", constants.AuditTypeQuery
+	if hasKind(statements, tarantool_utils.StatementDDL) {
+		context, audit_type = "ddl_execute", constants.AuditTypeDDL
+	}
+
+	desc := fmt.Sprintf("Executed on %s: %s", database.DBName, sql)
+	if failed {
+		desc = fmt.Sprintf("Failed on %s: %s", database.DBName, sql)
+	}
+
+	utils.AuditUserAction(db.UserContext, context, desc, audit_type, &database.ID, db.DBPool)
+}
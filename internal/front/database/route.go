@@ -1,6 +1,9 @@
 package database
 
 import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 )
@@ -22,9 +25,21 @@ func NewRoute(db_pool *sqlx.DB, app *fiber.App) *DatabaseRoute {
 func (db *DatabaseRoute) RegisterDatabaseRoute() *DatabaseRoute {
 	database := db.App.Group("/api/v1/front/database")
 
-	database.Post("/", db.DatabaseHandler.Create)
-	database.Get("/:db_uuid/detail", db.DatabaseHandler.GetDBDetail)
-	database.Post("/:db_uuid/query", db.DatabaseHandler.Query)
+	database.Post("/", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.Create)
+	database.Get("/", middlewares.RequirePermission(constants.PermissionViewConnections), db.DatabaseHandler.List)
+	database.Put("/:db_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.Update)
+	database.Delete("/:db_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.Delete)
+	database.Get("/:db_uuid/detail", middlewares.RequirePermission(constants.PermissionViewConnections), db.DatabaseHandler.GetDBDetail)
+	database.Post("/:db_uuid/query", middlewares.RequirePermission(constants.PermissionRunSelect), middlewares.QueryRateLimit(), db.DatabaseHandler.Query)
+	database.Post("/:db_uuid/lua", middlewares.RequirePermission(constants.PermissionLuaEval), middlewares.QueryRateLimit(), db.DatabaseHandler.Lua)
+	database.Get("/:db_uuid/mode", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.ShowMode)
+	database.Put("/:db_uuid/mode", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.UpdateMode)
+	database.Put("/:db_uuid/mode/:user_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.UpdateUserMode)
+	database.Delete("/:db_uuid/mode/:user_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.DeleteUserMode)
+	database.Put("/:db_uuid/approval", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.UpdateApproval)
+	database.Get("/:db_uuid/permission", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.ListPermissions)
+	database.Post("/:db_uuid/permission", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.GrantPermission)
+	database.Delete("/:db_uuid/permission/:user_uuid/:permission", middlewares.RequirePermission(constants.PermissionManageConnections), db.DatabaseHandler.RevokePermission)
 
 	return db
 }
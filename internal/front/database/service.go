@@ -9,8 +9,20 @@ import (
 
 type DatabaseServiceCreator interface {
 	Create(new_db_req DatabaseNewRequest) (*DatabaseResponse, *responses.ErrorResponse)
+	Update(db_uuid string, update_db_req DatabaseUpdateRequest) (*DatabaseResponse, *responses.ErrorResponse)
+	Delete(db_uuid string) (*DatabaseResponse, *responses.ErrorResponse)
 	GetDBDetail(db_uuid string) (*DatabaseDetailResponse, *responses.ErrorResponse)
 	Query(db_uuid string, db_query_req DatabaseQueryRequest) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse)
+	List(list_req types.ListRequest) ([]DatabaseSummary, int, *responses.ErrorResponse)
+	ShowMode(db_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse)
+	UpdateMode(db_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse)
+	UpdateUserMode(db_uuid string, user_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse)
+	DeleteUserMode(db_uuid string, user_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse)
+	UpdateApproval(db_uuid string, approval_req DatabaseApprovalRequest) (*DatabaseResponse, *responses.ErrorResponse)
+	Lua(db_uuid string, db_lua_req DatabaseLuaRequest) (*DatabaseLuaResultResponse, *responses.ErrorWithDetailResponse)
+	ListPermissions(db_uuid string, list_req types.ListRequest) ([]DatabasePermission, int, *responses.ErrorResponse)
+	GrantPermission(db_uuid string, permission_req DatabasePermissionRequest) (*DatabasePermissionsResponse, *responses.ErrorResponse)
+	RevokePermission(db_uuid string, user_uuid string, permission string) (*DatabasePermissionsResponse, *responses.ErrorResponse)
 }
 
 type DatabaseService struct {
@@ -31,6 +43,14 @@ func (db *DatabaseService) Create(new_db_req DatabaseNewRequest) (*DatabaseRespo
 	return db.DatabaseRepo.Create(new_db_req)
 }
 
+func (db *DatabaseService) Update(db_uuid string, update_db_req DatabaseUpdateRequest) (*DatabaseResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.Update(db_uuid, update_db_req)
+}
+
+func (db *DatabaseService) Delete(db_uuid string) (*DatabaseResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.Delete(db_uuid)
+}
+
 func (db *DatabaseService) GetDBDetail(db_uuid string) (*DatabaseDetailResponse, *responses.ErrorResponse) {
 	return db.DatabaseRepo.GetDBDetail(db_uuid)
 }
@@ -38,3 +58,43 @@ func (db *DatabaseService) GetDBDetail(db_uuid string) (*DatabaseDetailResponse,
 func (db *DatabaseService) Query(db_uuid string, db_query_req DatabaseQueryRequest) (*DatabaseQueryResultResponse, *responses.ErrorWithDetailResponse) {
 	return db.DatabaseRepo.Query(db_uuid, db_query_req)
 }
+
+func (db *DatabaseService) List(list_req types.ListRequest) ([]DatabaseSummary, int, *responses.ErrorResponse) {
+	return db.DatabaseRepo.List(list_req)
+}
+
+func (db *DatabaseService) Lua(db_uuid string, db_lua_req DatabaseLuaRequest) (*DatabaseLuaResultResponse, *responses.ErrorWithDetailResponse) {
+	return db.DatabaseRepo.Lua(db_uuid, db_lua_req)
+}
+
+func (db *DatabaseService) ListPermissions(db_uuid string, list_req types.ListRequest) ([]DatabasePermission, int, *responses.ErrorResponse) {
+	return db.DatabaseRepo.ListPermissions(db_uuid, list_req)
+}
+
+func (db *DatabaseService) GrantPermission(db_uuid string, permission_req DatabasePermissionRequest) (*DatabasePermissionsResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.GrantPermission(db_uuid, permission_req)
+}
+
+func (db *DatabaseService) RevokePermission(db_uuid string, user_uuid string, permission string) (*DatabasePermissionsResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.RevokePermission(db_uuid, user_uuid, permission)
+}
+
+func (db *DatabaseService) ShowMode(db_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.ShowMode(db_uuid)
+}
+
+func (db *DatabaseService) UpdateMode(db_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.UpdateMode(db_uuid, mode_req)
+}
+
+func (db *DatabaseService) UpdateUserMode(db_uuid string, user_uuid string, mode_req DatabaseModeRequest) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.UpdateUserMode(db_uuid, user_uuid, mode_req)
+}
+
+func (db *DatabaseService) DeleteUserMode(db_uuid string, user_uuid string) (*DatabaseModeResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.DeleteUserMode(db_uuid, user_uuid)
+}
+
+func (db *DatabaseService) UpdateApproval(db_uuid string, approval_req DatabaseApprovalRequest) (*DatabaseResponse, *responses.ErrorResponse) {
+	return db.DatabaseRepo.UpdateApproval(db_uuid, approval_req)
+}
@@ -0,0 +1,220 @@
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"tarantool-admin-api/pkg/constants"
+	export_utils "tarantool-admin-api/pkg/export"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ExportHandler struct {
+	DBPool        *sqlx.DB
+	ExportService func(c *fiber.Ctx) *ExportService
+}
+
+func NewExportHandler(db_pool *sqlx.DB) *ExportHandler {
+	return &ExportHandler{
+		DBPool: db_pool,
+		ExportService: func(c *fiber.Ctx) *ExportService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewExportService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (e *ExportHandler) Stream(c *fiber.Ctx) error {
+	return e.stream(c, -7000)
+}
+
+func (e *ExportHandler) StreamQuery(c *fiber.Ctx) error {
+	return e.stream(c, -7001)
+}
+
+// stream sends the export to the client while it is read from the target
+func (e *ExportHandler) stream(c *fiber.Ctx, code int) error {
+	db_uuid := c.Params("db_uuid")
+
+	var export_req ExportNewRequest
+	if c.Method() == fiber.MethodPost {
+		export_req.Source = SourceQuery
+	}
+	v := utils.NewValidator()
+	if err := export_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("export_failed", nil, c),
+				code,
+				err,
+			),
+		)
+	}
+
+	resp, err := e.ExportService(c).Stream(db_uuid, export_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				code,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	c.Set(fiber.HeaderContentType, resp.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, resp.FileName))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the status is already sent, failures can only be logged
+		if _, err := resp.Write(w); err != nil {
+			custom_log.NewCustomLog("export_failed", err.Error(), "error")
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+func (e *ExportHandler) Create(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var export_req ExportNewRequest
+	v := utils.NewValidator()
+	if err := export_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("export_create_failed", nil, c),
+				-7002,
+				err,
+			),
+		)
+	}
+
+	resp, err := e.ExportService(c).Create(db_uuid, export_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-7002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("export_create_success", nil, c),
+			7002,
+			resp,
+		),
+	)
+}
+
+func (e *ExportHandler) List(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, exportListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("export_list_failed", nil, c),
+				-7003,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := e.ExportService(c).List(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-7003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("export_list_success", nil, c),
+			7003,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (e *ExportHandler) ShowOne(c *fiber.Ctx) error {
+	export_uuid := c.Params("export_uuid")
+
+	resp, err := e.ExportService(c).ShowOne(export_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-7004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("export_show_success", nil, c),
+			7004,
+			resp,
+		),
+	)
+}
+
+func (e *ExportHandler) Download(c *fiber.Ctx) error {
+	export_uuid := c.Params("export_uuid")
+
+	resp, err := e.ExportService(c).ShowOne(export_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-7005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	// only completed exports have a downloadable file
+	if resp.Export.JobStatus != constants.JobStatusCompleted || resp.Export.FilePath == nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("export_download_failed", nil, c),
+				-7005,
+				errors.New(utils.Translate("export_not_completed", nil, c)),
+			),
+		)
+	}
+
+	name := "query"
+	if resp.Export.SpaceName != nil {
+		name = *resp.Export.SpaceName
+	}
+
+	return c.Download(*resp.Export.FilePath, export_utils.FileName(name, resp.Export.Format, resp.Export.Gzip))
+}
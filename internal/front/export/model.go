@@ -0,0 +1,179 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"tarantool-admin-api/pkg/constants"
+	export_utils "tarantool-admin-api/pkg/export"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	SourceSpace = "space"
+	SourceQuery = "query"
+)
+
+type Export struct {
+	ID           uint64     `json:"-" db:"id"`
+	ExportUUID   string     `json:"export_uuid" db:"export_uuid"`
+	UserID       uint64     `json:"-" db:"user_id"`
+	DBID         uint64     `json:"-" db:"db_id"`
+	DBUUID       string     `json:"db_uuid" db:"db_uuid"`
+	Source       string     `json:"source" db:"source"`
+	SpaceName    *string    `json:"space_name" db:"space_name"`
+	Query        *string    `json:"query" db:"query"`
+	Format       string     `json:"format" db:"format"`
+	Gzip         bool       `json:"gzip" db:"gzip"`
+	FilePath     *string    `json:"-" db:"file_path"`
+	FileSize     int64      `json:"file_size" db:"file_size"`
+	RowCount     int64      `json:"row_count" db:"row_count"`
+	JobStatus    string     `json:"job_status" db:"job_status"`
+	ErrorMessage *string    `json:"error_message" db:"error_message"`
+	StartedAt    *time.Time `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at" db:"finished_at"`
+	CreatedBy    uint64     `json:"-" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// exportListFields are the columns the export list filters and sorts on
+var exportListFields = postgres.ListFields{
+	Filter:      []string{"export_uuid", "source", "space_name", "format", "gzip", "job_status", "file_size", "row_count", "started_at", "finished_at", "created_at"},
+	Sort:        []string{"source", "space_name", "format", "job_status", "file_size", "row_count", "started_at", "finished_at", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type ExportResponse struct {
+	Export Export `json:"export"`
+}
+
+// ExportStream is an export ready to be streamed to the client
+type ExportStream struct {
+	FileName    string
+	ContentType string
+	Write       func(w io.Writer) (uint64, error)
+}
+
+type ExportNewRequest struct {
+	Source    string `json:"source" query:"source" validate:"required,oneof=space query"`
+	SpaceName string `json:"space_name" query:"space_name" validate:"required_if=Source space"`
+	Query     string `json:"query" query:"query" validate:"required_if=Source query"`
+	Format    string `json:"format" query:"format" validate:"omitempty,oneof=csv json ndjson"`
+	Gzip      bool   `json:"gzip" query:"gzip"`
+	BatchSize int    `json:"batch_size" query:"batch_size" validate:"omitempty,min=1,max=10000"`
+}
+
+// bind reads the request from the body, or from the query string for GET
+// requests, source and space_name may be preset from the route
+func (e *ExportNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	var err error
+	if c.Method() == fiber.MethodGet {
+		err = c.QueryParser(e)
+	} else {
+		err = c.BodyParser(e)
+	}
+	if err != nil {
+		custom_log.NewCustomLog("export_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if space_name := c.Params("space_name"); space_name != "" {
+		e.Source = SourceSpace
+		e.SpaceName = space_name
+	}
+
+	if err := v.Validate(e, c); err != nil {
+		custom_log.NewCustomLog("export_failed", err.Error(), "error")
+		return err
+	}
+
+	e.SetDefaults()
+
+	return nil
+}
+
+// SetDefaults fills the format and batch size when they are not given
+func (e *ExportNewRequest) SetDefaults() {
+	if e.Format == "" {
+		e.Format = export_utils.FormatCSV
+	}
+	if e.BatchSize == 0 {
+		e.BatchSize = 1000
+	}
+}
+
+// Name returns the base name of the exported file
+func (e *ExportNewRequest) Name() string {
+	if e.Source == SourceSpace {
+		return e.SpaceName
+	}
+	return "query"
+}
+
+type ExportNewModel struct {
+	ID         uint64    `db:"id"`
+	ExportUUID string    `db:"export_uuid"`
+	UserID     uint64    `db:"user_id"`
+	DBID       uint64    `db:"db_id"`
+	Source     string    `db:"source"`
+	SpaceName  *string   `db:"space_name"`
+	Query      *string   `db:"query"`
+	Format     string    `db:"format"`
+	Gzip       bool      `db:"gzip"`
+	FilePath   string    `db:"file_path"`
+	JobStatus  string    `db:"job_status"`
+	CreatedBy  uint64    `db:"created_by"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (e *ExportNewModel) new(db_id uint64, export_req ExportNewRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_exports_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	e.ID = uint64(*id)
+	e.ExportUUID = uuid.String()
+	e.UserID = uint64(us_ctx.Id)
+	e.DBID = db_id
+	e.Source = export_req.Source
+	if export_req.Source == SourceSpace {
+		e.SpaceName = &export_req.SpaceName
+	} else {
+		e.Query = &export_req.Query
+	}
+	e.Format = export_req.Format
+	e.Gzip = export_req.Gzip
+	e.FilePath = filepath.Join(ExportDir(), export_utils.FileName(e.ExportUUID, e.Format, e.Gzip))
+	e.JobStatus = constants.JobStatusPending
+	e.CreatedBy = uint64(us_ctx.Id)
+	e.CreatedAt = utils.Now()
+
+	return nil
+}
+
+// ExportDir returns the local directory where background exports are stored
+func ExportDir() string {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = "./storage/exports"
+	}
+	return dir
+}
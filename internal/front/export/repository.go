@@ -0,0 +1,380 @@
+package export
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/constants"
+	export_utils "tarantool-admin-api/pkg/export"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+type ExportRepo interface {
+	Stream(db_uuid string, export_req ExportNewRequest) (*ExportStream, *responses.ErrorResponse)
+	Create(db_uuid string, export_req ExportNewRequest) (*ExportResponse, *responses.ErrorResponse)
+	Execute(db_uuid string, export_req ExportNewRequest) (*ExportResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]Export, int, *responses.ErrorResponse)
+	ShowOne(export_uuid string) (*ExportResponse, *responses.ErrorResponse)
+}
+
+type ExportRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewExportRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *ExportRepoImpl {
+	return &ExportRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+func (e *ExportRepoImpl) Stream(db_uuid string, export_req ExportNewRequest) (*ExportStream, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(e.UserContext, e.DBPool).Accessible(db_uuid, constants.AccessLevelRead, "export_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// open the source before streaming so errors are still reported
+	source, err := openSource(db_resp.Database, export_req)
+	if err != nil {
+		custom_log.NewCustomLog("export_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("export_failed", fmt.Errorf("failed_to_open_export_source"))
+	}
+
+	return &ExportStream{
+		FileName:    export_utils.FileName(export_req.Name(), export_req.Format, export_req.Gzip),
+		ContentType: export_utils.ContentType(export_req.Format, export_req.Gzip),
+		Write: func(w io.Writer) (uint64, error) {
+			return source.writeTo(w, export_req.Format, export_req.Gzip)
+		},
+	}, nil
+}
+
+func (e *ExportRepoImpl) Create(db_uuid string, export_req ExportNewRequest) (*ExportResponse, *responses.ErrorResponse) {
+	export_new_model, db, err_resp := e.create(db_uuid, export_req)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// run the export job in the background
+	go e.run(*export_new_model, *db, export_req)
+
+	return e.ShowOne(export_new_model.ExportUUID)
+}
+
+// Execute creates an export job and waits for it to finish, it is used by
+// scheduled jobs which need the outcome of the export
+func (e *ExportRepoImpl) Execute(db_uuid string, export_req ExportNewRequest) (*ExportResponse, *responses.ErrorResponse) {
+	export_new_model, db, err_resp := e.create(db_uuid, export_req)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	if err := e.run(*export_new_model, *db, export_req); err != nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("export_create_failed", err)
+	}
+
+	return e.ShowOne(export_new_model.ExportUUID)
+}
+
+func (e *ExportRepoImpl) List(db_uuid string, list_req types.ListRequest) ([]Export, int, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(e.UserContext, e.DBPool).Accessible(db_uuid, constants.AccessLevelRead, "export_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// prepare query
+	query := `
+		SELECT
+			ex.id, ex.export_uuid, ex.user_id, ex.db_id, db.db_uuid, ex.source, ex.space_name,
+			ex.query, ex.format, ex.gzip, ex.file_path, ex.file_size, ex.row_count,
+			ex.job_status, ex.error_message, ex.started_at, ex.finished_at,
+			ex.created_by, ex.created_at
+		FROM tbl_exports ex
+		INNER JOIN tbl_users_databases db ON db.id = ex.db_id
+		WHERE ex.deleted_at IS NULL
+		AND ex.db_id = $1
+	`
+
+	// execute query
+	var exports []Export
+	total, err := postgres.SelectList(e.DBPool, &exports, query, []interface{}{db_resp.Database.ID}, list_req, exportListFields)
+	if err != nil {
+		custom_log.NewCustomLog("export_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("export_list_failed", fmt.Errorf("get_export_error"))
+	}
+
+	if exports == nil {
+		exports = []Export{}
+	}
+
+	return exports, total, nil
+}
+
+func (e *ExportRepoImpl) ShowOne(export_uuid string) (*ExportResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			ex.id, ex.export_uuid, ex.user_id, ex.db_id, db.db_uuid, ex.source, ex.space_name,
+			ex.query, ex.format, ex.gzip, ex.file_path, ex.file_size, ex.row_count,
+			ex.job_status, ex.error_message, ex.started_at, ex.finished_at,
+			ex.created_by, ex.created_at
+		FROM tbl_exports ex
+		INNER JOIN tbl_users_databases db ON db.id = ex.db_id
+		WHERE ex.deleted_at IS NULL
+		AND ex.export_uuid = $1
+	`
+
+	// execute query
+	var export Export
+	if err := e.DBPool.Get(&export, query, export_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("export_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("export_show_failed", fmt.Errorf("no_export_found"))
+		}
+		custom_log.NewCustomLog("export_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("export_show_failed", fmt.Errorf("get_export_error"))
+	}
+
+	// the export holds the data of its database
+	if _, err_resp := database.NewDatabaseRepoImpl(e.UserContext, e.DBPool).Accessible(export.DBUUID, constants.AccessLevelRead, "export_show_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return &ExportResponse{
+		Export: export,
+	}, nil
+}
+
+// create records a pending export job for the database
+func (e *ExportRepoImpl) create(db_uuid string, export_req ExportNewRequest) (*ExportNewModel, *database.Database, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(e.UserContext, e.DBPool).Accessible(db_uuid, constants.AccessLevelRead, "export_create_failed")
+	if err_resp != nil {
+		return nil, nil, err_resp
+	}
+
+	// create insert model
+	var export_new_model ExportNewModel
+	if err := export_new_model.new(db_resp.Database.ID, export_req, e.UserContext, e.DBPool); err != nil {
+		custom_log.NewCustomLog("export_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse("export_create_failed", fmt.Errorf("invalid_info_to_create_export"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_exports (
+			id, export_uuid, user_id, db_id, source, space_name, query, format, gzip,
+			file_path, job_status, created_by, created_at
+		) VALUES (
+			:id, :export_uuid, :user_id, :db_id, :source, :space_name, :query, :format, :gzip,
+			:file_path, :job_status, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := e.DBPool.NamedExec(query, export_new_model); err != nil {
+		custom_log.NewCustomLog("export_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, nil, err_msg.NewErrorResponse("export_create_failed", fmt.Errorf("error_create_export"))
+	}
+
+	return &export_new_model, &db_resp.Database, nil
+}
+
+// run executes the export job and records its outcome in tbl_exports
+func (e *ExportRepoImpl) run(export ExportNewModel, db database.Database, export_req ExportNewRequest) error {
+	// mark the job as running
+	update_running := `
+		UPDATE tbl_exports SET
+			job_status = $1, started_at = $2
+		WHERE id = $3
+	`
+	if _, err := e.DBPool.Exec(update_running, constants.JobStatusRunning, utils.Now(), export.ID); err != nil {
+		custom_log.NewCustomLog("export_run_failed", err.Error(), "error")
+	}
+
+	row_count, file_size, err := writeFile(export.FilePath, db, export_req)
+	if err != nil {
+		custom_log.NewCustomLog("export_run_failed", err.Error(), "error")
+		os.Remove(export.FilePath)
+
+		update_failed := `
+			UPDATE tbl_exports SET
+				job_status = $1, error_message = $2, finished_at = $3
+			WHERE id = $4
+		`
+		if _, err := e.DBPool.Exec(update_failed, constants.JobStatusFailed, err.Error(), utils.Now(), export.ID); err != nil {
+			custom_log.NewCustomLog("export_run_failed", err.Error(), "error")
+		}
+		return err
+	}
+
+	update_completed := `
+		UPDATE tbl_exports SET
+			job_status = $1, row_count = $2, file_size = $3, finished_at = $4
+		WHERE id = $5
+	`
+	if _, err := e.DBPool.Exec(update_completed, constants.JobStatusCompleted, row_count, file_size, utils.Now(), export.ID); err != nil {
+		custom_log.NewCustomLog("export_run_failed", err.Error(), "error")
+	}
+
+	return nil
+}
+
+// writeFile exports the source into a local file
+func writeFile(file_path string, db database.Database, export_req ExportNewRequest) (uint64, int64, error) {
+	source, err := openSource(db, export_req)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file_path), 0755); err != nil {
+		source.conn.Close()
+		return 0, 0, err
+	}
+
+	file, err := os.Create(file_path)
+	if err != nil {
+		source.conn.Close()
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	row_count, err := source.writeTo(file, export_req.Format, export_req.Gzip)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return row_count, info.Size(), nil
+}
+
+// exportSource reads the rows of a space or an sql select page by page
+type exportSource struct {
+	conn    *pool.ConnectionPool
+	columns []string
+	scan    func(fn func(rows [][]interface{}) error) error
+}
+
+// openSource connects the target database and resolves the export columns,
+// the connection is closed once the source has been written
+func openSource(db database.Database, export_req ExportNewRequest) (*exportSource, error) {
+	conn, err := tarantool_utils.ConnectTarantool(db.Host, int(db.Port), db.Username, db.Password)
+	if err != nil {
+		return nil, fmt.Errorf("connect target database: %w", err)
+	}
+
+	source := &exportSource{conn: conn}
+
+	switch export_req.Source {
+	case SourceSpace:
+		spaces, err := tarantool_utils.GetUserSpaces(conn)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("read spaces: %w", err)
+		}
+
+		var schema *tarantool_utils.SpaceSchema
+		for i := range spaces {
+			if spaces[i].Name == export_req.SpaceName {
+				schema = &spaces[i]
+				break
+			}
+		}
+		if schema == nil {
+			conn.Close()
+			return nil, fmt.Errorf("space %s does not exist", export_req.SpaceName)
+		}
+
+		for _, field := range schema.Format {
+			source.columns = append(source.columns, field.Name)
+		}
+		source.scan = func(fn func(rows [][]interface{}) error) error {
+			return tarantool_utils.ScanSpace(conn, export_req.SpaceName, export_req.BatchSize, fn)
+		}
+	case SourceQuery:
+		// the first page validates the query and gives the column names
+		columns, first_rows, err := tarantool_utils.QueryPage(conn, export_req.Query, export_req.BatchSize, 0)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("run query: %w", err)
+		}
+
+		for _, column := range columns {
+			source.columns = append(source.columns, column.Name)
+		}
+		source.scan = func(fn func(rows [][]interface{}) error) error {
+			rows, offset := first_rows, 0
+			for len(rows) > 0 {
+				if err := fn(rows); err != nil {
+					return err
+				}
+				if len(rows) < export_req.BatchSize {
+					return nil
+				}
+
+				offset += len(rows)
+				if _, rows, err = tarantool_utils.QueryPage(conn, export_req.Query, export_req.BatchSize, offset); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("unsupported export source: %s", export_req.Source)
+	}
+
+	return source, nil
+}
+
+// writeTo encodes every row of the source into w and closes the connection
+func (s *exportSource) writeTo(w io.Writer, format string, compressed bool) (uint64, error) {
+	defer s.conn.Close()
+
+	writer, err := export_utils.NewRowWriter(w, format, s.columns, compressed)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.scan(func(rows [][]interface{}) error {
+		for _, row := range rows {
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		// push every page to the client as soon as it is encoded
+		return writer.Flush()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return writer.Close()
+}
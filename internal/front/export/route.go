@@ -0,0 +1,36 @@
+package export
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ExportRoute struct {
+	App           *fiber.App
+	DBPool        *sqlx.DB
+	ExportHandler *ExportHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *ExportRoute {
+	return &ExportRoute{
+		App:           app,
+		DBPool:        db_pool,
+		ExportHandler: NewExportHandler(db_pool),
+	}
+}
+
+func (e *ExportRoute) RegisterExportRoute() *ExportRoute {
+	export := e.App.Group("/api/v1/front/export")
+
+	export.Get("/:db_uuid/space/:space_name", middlewares.RequirePermission(constants.PermissionRunSelect), e.ExportHandler.Stream)
+	export.Post("/:db_uuid/query", middlewares.RequirePermission(constants.PermissionRunSelect), middlewares.QueryRateLimit(), e.ExportHandler.StreamQuery)
+	export.Post("/:db_uuid/job", middlewares.RequirePermission(constants.PermissionRunSelect), e.ExportHandler.Create)
+	export.Get("/:db_uuid/job", middlewares.RequirePermission(constants.PermissionRunSelect), e.ExportHandler.List)
+	export.Get("/job/:export_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), e.ExportHandler.ShowOne)
+	export.Get("/job/:export_uuid/download", middlewares.RequirePermission(constants.PermissionRunSelect), e.ExportHandler.Download)
+
+	return e
+}
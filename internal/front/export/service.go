@@ -0,0 +1,45 @@
+package export
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ExportServiceCreator interface {
+	Stream(db_uuid string, export_req ExportNewRequest) (*ExportStream, *responses.ErrorResponse)
+	Create(db_uuid string, export_req ExportNewRequest) (*ExportResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]Export, int, *responses.ErrorResponse)
+	ShowOne(export_uuid string) (*ExportResponse, *responses.ErrorResponse)
+}
+
+type ExportService struct {
+	DBPool      *sqlx.DB
+	ExportRepo  *ExportRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewExportService(us_ctx *types.UserContext, db_pool *sqlx.DB) *ExportService {
+	return &ExportService{
+		DBPool:      db_pool,
+		ExportRepo:  NewExportRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (e *ExportService) Stream(db_uuid string, export_req ExportNewRequest) (*ExportStream, *responses.ErrorResponse) {
+	return e.ExportRepo.Stream(db_uuid, export_req)
+}
+
+func (e *ExportService) Create(db_uuid string, export_req ExportNewRequest) (*ExportResponse, *responses.ErrorResponse) {
+	return e.ExportRepo.Create(db_uuid, export_req)
+}
+
+func (e *ExportService) List(db_uuid string, list_req types.ListRequest) ([]Export, int, *responses.ErrorResponse) {
+	return e.ExportRepo.List(db_uuid, list_req)
+}
+
+func (e *ExportService) ShowOne(export_uuid string) (*ExportResponse, *responses.ErrorResponse) {
+	return e.ExportRepo.ShowOne(export_uuid)
+}
@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ImportHandler struct {
+	DBPool        *sqlx.DB
+	ImportService func(c *fiber.Ctx) *ImportService
+}
+
+func NewImportHandler(db_pool *sqlx.DB) *ImportHandler {
+	return &ImportHandler{
+		DBPool: db_pool,
+		ImportService: func(c *fiber.Ctx) *ImportService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewImportService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (i *ImportHandler) Import(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+	space_name := c.Params("space_name")
+
+	var import_req ImportNewRequest
+	v := utils.NewValidator()
+	if err := import_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("import_failed", nil, c),
+				-8000,
+				err,
+			),
+		)
+	}
+
+	file, _ := c.FormFile("file")
+
+	resp, err := i.ImportService(c).Import(db_uuid, space_name, import_req, file)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-8000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	// the import waits for approval instead of running
+	if resp.PendingChange != nil {
+		return c.Status(http.StatusAccepted).JSON(
+			response.NewResponse(
+				utils.Translate("import_pending_approval", nil, c),
+				8000,
+				resp,
+			),
+		)
+	}
+
+	message_id := "import_success"
+	if import_req.DryRun {
+		message_id = "import_validate_success"
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate(message_id, nil, c),
+			8000,
+			resp,
+		),
+	)
+}
@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"tarantool-admin-api/internal/front/database"
+	custom_log "tarantool-admin-api/pkg/logs"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+
+	// keep the report bounded on large files with many failing rows
+	MaxReportedErrors = 1000
+)
+
+type ImportNewRequest struct {
+	Format    string `form:"format" validate:"omitempty,oneof=csv json ndjson"`
+	Mode      string `form:"mode" validate:"omitempty,oneof=insert replace upsert"`
+	Mapping   string `form:"mapping" validate:"omitempty"`
+	BatchSize int    `form:"batch_size" validate:"omitempty,min=1,max=10000"`
+	DryRun    bool   `form:"dry_run"`
+
+	// space field name -> source column name
+	FieldMapping map[string]string `form:"-"`
+}
+
+func (i *ImportNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(i); err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(i, c); err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		return err
+	}
+
+	if i.Mapping != "" {
+		if err := json.Unmarshal([]byte(i.Mapping), &i.FieldMapping); err != nil {
+			custom_log.NewCustomLog("import_failed", err.Error(), "error")
+			return errors.New(utils.Translate("invalid_import_mapping", nil, c))
+		}
+	}
+
+	if i.Mode == "" {
+		i.Mode = tarantool_utils.WriteModeInsert
+	}
+	if i.BatchSize == 0 {
+		i.BatchSize = 500
+	}
+
+	return nil
+}
+
+// FormatOf returns the requested format, or the one matching the file extension
+func (i *ImportNewRequest) FormatOf(file_name string) string {
+	if i.Format != "" {
+		return i.Format
+	}
+
+	switch strings.ToLower(filepath.Ext(file_name)) {
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	default:
+		return FormatCSV
+	}
+}
+
+type ImportColumn struct {
+	Field  database.SpaceFormatField `json:"field"`
+	Column *string                   `json:"column"`
+}
+
+type ImportReport struct {
+	SpaceName      string                     `json:"space_name"`
+	Format         string                     `json:"format"`
+	Mode           string                     `json:"mode"`
+	DryRun         bool                       `json:"dry_run"`
+	Mapping        []ImportColumn             `json:"mapping"`
+	IgnoredColumns []string                   `json:"ignored_columns"`
+	TotalRows      int                        `json:"total_rows"`
+	ValidRows      int                        `json:"valid_rows"`
+	WrittenRows    int                        `json:"written_rows"`
+	FailedRows     int                        `json:"failed_rows"`
+	Errors         []tarantool_utils.RowError `json:"errors"`
+}
+
+type ImportResponse struct {
+	Import        *ImportReport              `json:"import,omitempty"`
+	PendingChange *database.PendingChangeRef `json:"pending_change,omitempty"`
+}
+
+// ImportPayload is what a queued import runs with once it is approved
+type ImportPayload struct {
+	SpaceName string           `json:"space_name"`
+	FileName  string           `json:"file_name"`
+	FilePath  string           `json:"file_path"`
+	Request   ImportNewRequest `json:"request"`
+}
+
+func (p *ImportPayload) new(space_name string, import_req ImportNewRequest, file_name string) error {
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	p.SpaceName = space_name
+	p.FileName = file_name
+	p.FilePath = filepath.Join(ImportDir(), uuid.String()+filepath.Ext(file_name))
+	p.Request = import_req
+
+	return nil
+}
+
+// ImportDir returns the local directory where imports waiting for approval
+// keep their file
+func ImportDir() string {
+	dir := os.Getenv("IMPORT_DIR")
+	if dir == "" {
+		dir = "./storage/imports"
+	}
+	return dir
+}
@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// recordReader reads the uploaded file one record at a time, records are
+// keyed by column name
+type recordReader interface {
+	// Columns lists the column names known so far
+	Columns() []string
+	// Read returns the next record or io.EOF
+	Read() (map[string]interface{}, error)
+}
+
+func newRecordReader(r io.Reader, format string) (recordReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSON:
+		return newJSONReader(r)
+	case FormatNDJSON:
+		return &ndjsonReader{scanner: newLineScanner(r), columns: &columnSet{index: map[string]bool{}}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	// drop the utf-8 bom some spreadsheets put in front of the header
+	if len(header) > 0 {
+		header[0] = string(bytes.TrimPrefix([]byte(header[0]), []byte("\xef\xbb\xbf")))
+	}
+
+	return &csvReader{reader: reader, columns: header}, nil
+}
+
+func (c *csvReader) Columns() []string {
+	return c.columns
+}
+
+func (c *csvReader) Read() (map[string]interface{}, error) {
+	values, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	record := make(map[string]interface{}, len(c.columns))
+	for i, column := range c.columns {
+		if i < len(values) {
+			record[column] = values[i]
+		}
+	}
+	return record, nil
+}
+
+// jsonReader streams the objects of a top level json array
+type jsonReader struct {
+	decoder *json.Decoder
+	columns *columnSet
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("read json array: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("json import must be an array of objects")
+	}
+
+	return &jsonReader{decoder: decoder, columns: &columnSet{index: map[string]bool{}}}, nil
+}
+
+func (j *jsonReader) Columns() []string {
+	return j.columns.names
+}
+
+func (j *jsonReader) Read() (map[string]interface{}, error) {
+	if !j.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var record map[string]interface{}
+	if err := j.decoder.Decode(&record); err != nil {
+		return nil, err
+	}
+	j.columns.add(record)
+	return record, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	columns *columnSet
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
+
+func (n *ndjsonReader) Columns() []string {
+	return n.columns.names
+}
+
+func (n *ndjsonReader) Read() (map[string]interface{}, error) {
+	for n.scanner.Scan() {
+		line := bytes.TrimSpace(n.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+		n.columns.add(record)
+		return record, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// columnSet keeps the column names in the order they were first seen
+type columnSet struct {
+	names []string
+	index map[string]bool
+}
+
+func (s *columnSet) add(record map[string]interface{}) {
+	for name := range record {
+		if !s.index[name] {
+			s.index[name] = true
+			s.names = append(s.names, name)
+		}
+	}
+}
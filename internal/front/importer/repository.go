@@ -0,0 +1,472 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+type ImportRepo interface {
+	Import(db_uuid string, space_name string, import_req ImportNewRequest, file *multipart.FileHeader) (*ImportResponse, *responses.ErrorResponse)
+	Approve(db_uuid string, payload ImportPayload) (*ImportResponse, *responses.ErrorResponse)
+	Discard(payload ImportPayload)
+}
+
+type ImportRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewImportRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *ImportRepoImpl {
+	return &ImportRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+func (i *ImportRepoImpl) Import(db_uuid string, space_name string, import_req ImportNewRequest, file *multipart.FileHeader) (*ImportResponse, *responses.ErrorResponse) {
+	if file == nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("import_file_required"))
+	}
+
+	// get database info
+	db_repo := database.NewDatabaseRepoImpl(i.UserContext, i.DBPool)
+	db_resp, err_resp := db_repo.Accessible(db_uuid, constants.AccessLevelWrite, "import_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// an import writes tuples, it gets the checks of a dml query
+	statements := []tarantool_utils.Statement{{Keyword: "IMPORT", Kind: tarantool_utils.StatementDML}}
+	if err_detail := db_repo.Authorize(db_resp.Database, statements); err_detail != nil {
+		custom_log.NewCustomLog("import_failed", err_detail.Detail.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", err_detail.Err)
+	}
+
+	// an import on a database requiring approval waits for a second user,
+	// a dry run writes nothing and runs right away
+	if db_resp.Database.RequiresApproval && !import_req.DryRun {
+		return i.queue(db_resp.Database, space_name, import_req, file, statements)
+	}
+
+	// open the uploaded file
+	src, err := file.Open()
+	if err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("invalid_import_file"))
+	}
+	defer src.Close()
+
+	return i.run(db_resp.Database, space_name, import_req, import_req.FormatOf(file.Filename), src)
+}
+
+// Approve runs an import that was waiting for approval, the checks were made
+// when it was queued
+func (i *ImportRepoImpl) Approve(db_uuid string, payload ImportPayload) (*ImportResponse, *responses.ErrorResponse) {
+	// the file is not needed anymore whatever the outcome
+	defer i.Discard(payload)
+
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(i.UserContext, i.DBPool).ShowOne(db_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	src, err := os.Open(payload.FilePath)
+	if err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("invalid_import_file"))
+	}
+	defer src.Close()
+
+	return i.run(db_resp.Database, payload.SpaceName, payload.Request, payload.Request.FormatOf(payload.FileName), src)
+}
+
+// Discard removes the file of an import that will not run anymore
+func (i *ImportRepoImpl) Discard(payload ImportPayload) {
+	if err := os.Remove(payload.FilePath); err != nil && !os.IsNotExist(err) {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+	}
+}
+
+// queue keeps the uploaded file on the local disk and stores the import as a
+// pending change of the database
+func (i *ImportRepoImpl) queue(db database.Database, space_name string, import_req ImportNewRequest, file *multipart.FileHeader, statements []tarantool_utils.Statement) (*ImportResponse, *responses.ErrorResponse) {
+	var payload ImportPayload
+	if err := payload.new(space_name, import_req, file.Filename); err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("error_queue_change"))
+	}
+
+	if err := saveUpload(file, payload.FilePath); err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("error_save_file"))
+	}
+
+	statement := fmt.Sprintf("import %s into %s in %s mode", file.Filename, space_name, import_req.Mode)
+	pending_change, err := database.NewDatabaseRepoImpl(i.UserContext, i.DBPool).QueueChange(db, database.ChangeKindImport, statement, statements, nil, payload)
+	if err != nil {
+		i.Discard(payload)
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("error_queue_change"))
+	}
+
+	utils.AuditUserAction(
+		i.UserContext,
+		"import_queued",
+		fmt.Sprintf("Queued change %s on %s for approval: %s", pending_change.ChangeUUID, db.DBName, statement),
+		constants.AuditTypeApproval,
+		&db.ID,
+		i.DBPool,
+	)
+
+	return &ImportResponse{
+		PendingChange: pending_change,
+	}, nil
+}
+
+// run writes the records of src into the space of the database
+func (i *ImportRepoImpl) run(db database.Database, space_name string, import_req ImportNewRequest, file_format string, src io.Reader) (*ImportResponse, *responses.ErrorResponse) {
+	// connect database
+	conn, err := tarantool_utils.ConnectTarantool(
+		db.Host,
+		int(db.Port),
+		db.Username,
+		db.Password,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("failed_connect_to_target_db"))
+	}
+
+	// close connection after function end
+	defer conn.Close()
+
+	// read the target space format
+	spaces, err := tarantool_utils.GetUserSpaces(conn)
+	if err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("failed_to_get_db_detail"))
+	}
+
+	var format []database.SpaceFormatField
+	found := false
+	for _, space := range spaces {
+		if space.Name == space_name {
+			found = true
+			for _, field := range space.Format {
+				format = append(format, database.SpaceFormatField{
+					Name:       field.Name,
+					Type:       field.Type,
+					IsNullable: field.IsNullable,
+				})
+			}
+			break
+		}
+	}
+	if !found {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("no_space_found"))
+	}
+	if len(format) == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("space_has_no_format"))
+	}
+
+	report, err := importRecords(conn, space_name, format, import_req, file_format, src)
+	if err != nil {
+		custom_log.NewCustomLog("import_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		var mapping_err *mappingError
+		if errors.As(err, &mapping_err) {
+			return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("invalid_import_mapping"))
+		}
+		return nil, err_msg.NewErrorResponse("import_failed", fmt.Errorf("invalid_import_file"))
+	}
+
+	return &ImportResponse{
+		Import: report,
+	}, nil
+}
+
+type mappingError struct {
+	message string
+}
+
+func (m *mappingError) Error() string {
+	return m.message
+}
+
+// pendingTuple is a coerced tuple waiting for its batch to be written
+type pendingTuple struct {
+	row   int
+	tuple []interface{}
+}
+
+// importRecords validates every record against the space format and writes
+// them in batches, rows that fail are collected in the report
+func importRecords(conn *pool.ConnectionPool, space_name string, format []database.SpaceFormatField, import_req ImportNewRequest, file_format string, src io.Reader) (*ImportReport, error) {
+	reader, err := newRecordReader(src, file_format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		SpaceName:      space_name,
+		Format:         file_format,
+		Mode:           import_req.Mode,
+		DryRun:         import_req.DryRun,
+		IgnoredColumns: []string{},
+		Errors:         []tarantool_utils.RowError{},
+	}
+
+	// json columns are only known once the first record has been read
+	record, read_err := reader.Read()
+	if read_err != nil && read_err != io.EOF {
+		return nil, read_err
+	}
+
+	columns, err := resolveMapping(format, reader.Columns(), import_req.FieldMapping, file_format == FormatCSV)
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range format {
+		column := columns[i]
+		report.Mapping = append(report.Mapping, ImportColumn{Field: field, Column: column})
+	}
+
+	batch := make([]pendingTuple, 0, import_req.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+
+		if import_req.DryRun {
+			return nil
+		}
+
+		tuples := make([][]interface{}, len(batch))
+		for i, pending := range batch {
+			tuples[i] = pending.tuple
+		}
+
+		result, err := tarantool_utils.WriteTuples(conn, space_name, tuples, import_req.Mode, false)
+		if err != nil {
+			return err
+		}
+
+		report.WrittenRows += result.Written
+		for _, row_err := range result.Errors {
+			// map the position in the batch back to the row of the file
+			row := row_err.Row
+			if row >= 1 && row <= len(batch) {
+				row = batch[row-1].row
+			}
+			report.addError(row, row_err.Error)
+		}
+		return nil
+	}
+
+	for row := 1; read_err != io.EOF; row++ {
+		report.TotalRows++
+
+		tuple, row_err := buildTuple(record, format, columns, file_format)
+		if row_err != nil {
+			report.addError(row, row_err.Error())
+		} else {
+			report.ValidRows++
+			batch = append(batch, pendingTuple{row: row, tuple: tuple})
+			if len(batch) >= import_req.BatchSize {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		record, read_err = reader.Read()
+		if read_err != nil && read_err != io.EOF {
+			// a record that cannot be parsed ends the import
+			report.addError(row+1, read_err.Error())
+			break
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	mapped := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if column != nil {
+			mapped[*column] = true
+		}
+	}
+	for _, column := range reader.Columns() {
+		if !mapped[column] {
+			report.IgnoredColumns = append(report.IgnoredColumns, column)
+		}
+	}
+	sort.Strings(report.IgnoredColumns)
+
+	return report, nil
+}
+
+func (r *ImportReport) addError(row int, message string) {
+	r.FailedRows++
+	if len(r.Errors) < MaxReportedErrors {
+		r.Errors = append(r.Errors, tarantool_utils.RowError{Row: row, Error: message})
+	}
+}
+
+// resolveMapping returns the source column of every space field, a field is
+// mapped to the column given by the user or else to the column with the same
+// name (case insensitive), fields without a column must be nullable. User
+// columns are only checked when the columns are fixed (csv header)
+func resolveMapping(format []database.SpaceFormatField, columns []string, mapping map[string]string, fixed_columns bool) ([]*string, error) {
+	by_name := make(map[string]string, len(columns))
+	for _, column := range columns {
+		by_name[strings.ToLower(column)] = column
+	}
+
+	known_fields := make(map[string]bool, len(format))
+	for _, field := range format {
+		known_fields[field.Name] = true
+	}
+	for field := range mapping {
+		if !known_fields[field] {
+			return nil, &mappingError{message: fmt.Sprintf("field %s does not exist in the space", field)}
+		}
+	}
+
+	resolved := make([]*string, len(format))
+	for i, field := range format {
+		if column, ok := mapping[field.Name]; ok {
+			if fixed_columns && by_name[strings.ToLower(column)] == "" {
+				return nil, &mappingError{message: fmt.Sprintf("column %s does not exist in the file", column)}
+			}
+			resolved[i] = &column
+			continue
+		}
+
+		if column, ok := by_name[strings.ToLower(field.Name)]; ok {
+			resolved[i] = &column
+			continue
+		}
+
+		// json objects may omit keys, later records can still hold the field
+		if !fixed_columns {
+			name := field.Name
+			resolved[i] = &name
+			continue
+		}
+
+		if !field.IsNullable {
+			return nil, &mappingError{message: fmt.Sprintf("field %s is not mapped to any column", field.Name)}
+		}
+	}
+
+	return resolved, nil
+}
+
+// buildTuple coerces the mapped values of a record into a tuple
+func buildTuple(record map[string]interface{}, format []database.SpaceFormatField, columns []*string, file_format string) ([]interface{}, error) {
+	tuple := make([]interface{}, len(format))
+	for i, field := range format {
+		var value interface{}
+		if columns[i] != nil {
+			value = record[*columns[i]]
+		}
+
+		// csv has no null, an empty cell of a nullable field is null
+		if str, ok := value.(string); ok && file_format == FormatCSV {
+			if str == "" && field.IsNullable {
+				value = nil
+			} else if isContainerType(field.Type) {
+				var decoded interface{}
+				decoder := json.NewDecoder(strings.NewReader(str))
+				decoder.UseNumber()
+				if err := decoder.Decode(&decoded); err != nil {
+					return nil, fmt.Errorf("field %s: value is not valid json", field.Name)
+				}
+				value = decoded
+			}
+		}
+
+		if value == nil {
+			if !field.IsNullable {
+				return nil, fmt.Errorf("field %s is not nullable", field.Name)
+			}
+			continue
+		}
+
+		coerced, err := tarantool_utils.CoerceValue(value, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		tuple[i] = coerced
+	}
+
+	// trailing nulls are left out so the tuple matches spaces whose nullable
+	// fields at the end are optional
+	for len(tuple) > 0 && tuple[len(tuple)-1] == nil {
+		tuple = tuple[:len(tuple)-1]
+	}
+
+	return tuple, nil
+}
+
+func isContainerType(field_type string) bool {
+	switch strings.ToLower(field_type) {
+	case "map", "array":
+		return true
+	}
+	return false
+}
+
+// saveUpload stores an uploaded file on the local disk
+func saveUpload(file *multipart.FileHeader, file_path string) error {
+	if err := os.MkdirAll(filepath.Dir(file_path), 0755); err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(file_path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ImportRoute struct {
+	App           *fiber.App
+	DBPool        *sqlx.DB
+	ImportHandler *ImportHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *ImportRoute {
+	return &ImportRoute{
+		App:           app,
+		DBPool:        db_pool,
+		ImportHandler: NewImportHandler(db_pool),
+	}
+}
+
+func (i *ImportRoute) RegisterImportRoute() *ImportRoute {
+	importer := i.App.Group("/api/v1/front/import")
+
+	importer.Post("/:db_uuid/space/:space_name", middlewares.RequirePermission(constants.PermissionRunDML), i.ImportHandler.Import)
+
+	return i
+}
@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"mime/multipart"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ImportServiceCreator interface {
+	Import(db_uuid string, space_name string, import_req ImportNewRequest, file *multipart.FileHeader) (*ImportResponse, *responses.ErrorResponse)
+}
+
+type ImportService struct {
+	DBPool      *sqlx.DB
+	ImportRepo  *ImportRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewImportService(us_ctx *types.UserContext, db_pool *sqlx.DB) *ImportService {
+	return &ImportService{
+		DBPool:      db_pool,
+		ImportRepo:  NewImportRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (i *ImportService) Import(db_uuid string, space_name string, import_req ImportNewRequest, file *multipart.FileHeader) (*ImportResponse, *responses.ErrorResponse) {
+	return i.ImportRepo.Import(db_uuid, space_name, import_req, file)
+}
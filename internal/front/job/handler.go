@@ -0,0 +1,235 @@
+package job
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type JobHandler struct {
+	DBPool     *sqlx.DB
+	JobService func(c *fiber.Ctx) *JobService
+}
+
+func NewJobHandler(db_pool *sqlx.DB) *JobHandler {
+	return &JobHandler{
+		DBPool: db_pool,
+		JobService: func(c *fiber.Ctx) *JobService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewJobService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (j *JobHandler) Create(c *fiber.Ctx) error {
+	var job_req JobNewRequest
+	v := utils.NewValidator()
+	if err := job_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("job_create_failed", nil, c),
+				-6000,
+				err,
+			),
+		)
+	}
+
+	resp, err := j.JobService(c).Create(job_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-6000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("job_create_success", nil, c),
+			6000,
+			resp,
+		),
+	)
+}
+
+func (j *JobHandler) List(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, jobListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("job_list_failed", nil, c),
+				-6001,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := j.JobService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-6001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("job_list_success", nil, c),
+			6001,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (j *JobHandler) ShowOne(c *fiber.Ctx) error {
+	job_uuid := c.Params("job_uuid")
+
+	resp, err := j.JobService(c).ShowOne(job_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-6002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("job_show_success", nil, c),
+			6002,
+			resp,
+		),
+	)
+}
+
+func (j *JobHandler) Update(c *fiber.Ctx) error {
+	job_uuid := c.Params("job_uuid")
+
+	var job_req JobUpdateRequest
+	v := utils.NewValidator()
+	if err := job_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("job_update_failed", nil, c),
+				-6003,
+				err,
+			),
+		)
+	}
+
+	// the payload is validated against the type of the existing job
+	if len(job_req.Payload) > 0 {
+		job_resp, err := j.JobService(c).ShowOne(job_uuid)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(
+				response.NewResponseError(
+					utils.Translate(err.MessageID, nil, c),
+					-6003,
+					errors.New(utils.Translate(err.Err.Error(), nil, c)),
+				),
+			)
+		}
+
+		payload, bind_err := bindPayload(job_resp.Job.JobType, job_req.Payload, c, v)
+		if bind_err != nil {
+			custom_log.NewCustomLog("job_update_failed", bind_err.Error(), "error")
+			return c.Status(http.StatusBadRequest).JSON(
+				response.NewResponseError(
+					utils.Translate("job_update_failed", nil, c),
+					-6003,
+					bind_err,
+				),
+			)
+		}
+		job_req.Payload = payload
+	}
+
+	resp, err := j.JobService(c).Update(job_uuid, job_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-6003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("job_update_success", nil, c),
+			6003,
+			resp,
+		),
+	)
+}
+
+func (j *JobHandler) Delete(c *fiber.Ctx) error {
+	job_uuid := c.Params("job_uuid")
+
+	if err := j.JobService(c).Delete(job_uuid); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-6004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("job_delete_success", nil, c),
+			6004,
+			nil,
+		),
+	)
+}
+
+func (j *JobHandler) Run(c *fiber.Ctx) error {
+	job_uuid := c.Params("job_uuid")
+
+	resp, err := j.JobService(c).Run(job_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-6005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("job_run_success", nil, c),
+			6005,
+			resp,
+		),
+	)
+}
@@ -0,0 +1,267 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/internal/front/backup"
+	"tarantool-admin-api/internal/front/export"
+	"tarantool-admin-api/pkg/archive"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	JobTypeQuery          = "query"
+	JobTypeBackup         = "backup"
+	JobTypeExport         = "export"
+	JobTypeSchemaSnapshot = "schema_snapshot"
+)
+
+// access level the owner of a job needs on its database, the statements of
+// a query job are checked again when it runs
+var jobAccess = map[string]string{
+	JobTypeQuery:          constants.AccessLevelRead,
+	JobTypeBackup:         constants.AccessLevelAdmin,
+	JobTypeExport:         constants.AccessLevelRead,
+	JobTypeSchemaSnapshot: constants.AccessLevelRead,
+}
+
+// permission the role of the owner needs for each type of job, the same one
+// the routes doing the work by hand require
+var jobPermissions = map[string]string{
+	JobTypeQuery:          constants.PermissionRunSelect,
+	JobTypeBackup:         constants.PermissionManageConnections,
+	JobTypeExport:         constants.PermissionRunSelect,
+	JobTypeSchemaSnapshot: constants.PermissionRunSelect,
+}
+
+type Job struct {
+	ID         uint64                  `json:"-" db:"id"`
+	JobUUID    string                  `json:"job_uuid" db:"job_uuid"`
+	UserID     uint64                  `json:"-" db:"user_id"`
+	DBID       uint64                  `json:"-" db:"db_id"`
+	DBUUID     string                  `json:"db_uuid" db:"db_uuid"`
+	JobName    string                  `json:"job_name" db:"job_name"`
+	JobType    string                  `json:"job_type" db:"job_type"`
+	CronExpr   string                  `json:"cron_expr" db:"cron_expr"`
+	Payload    sqlx_types.JSONText     `json:"payload" db:"payload"`
+	IsActive   bool                    `json:"is_active" db:"is_active"`
+	NextRunAt  *time.Time              `json:"next_run_at" db:"next_run_at"`
+	LastRunAt  *time.Time              `json:"last_run_at" db:"last_run_at"`
+	LastStatus *string                 `json:"last_status" db:"last_status"`
+	LastResult sqlx_types.NullJSONText `json:"last_result" db:"last_result"`
+	LastError  *string                 `json:"last_error" db:"last_error"`
+	RunCount   int                     `json:"run_count" db:"run_count"`
+	Scopes     pq.StringArray          `json:"-" db:"scopes"`
+	CreatedBy  uint64                  `json:"-" db:"created_by"`
+	CreatedAt  time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt  *time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// jobListFields are the columns the job list filters and sorts on
+var jobListFields = postgres.ListFields{
+	Filter:      []string{"job_uuid", "db_uuid", "job_name", "job_type", "is_active", "next_run_at", "last_run_at", "last_status", "run_count", "created_at"},
+	Sort:        []string{"job_name", "job_type", "is_active", "next_run_at", "last_run_at", "last_status", "run_count", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type JobResponse struct {
+	Job Job `json:"job"`
+}
+
+// QueryJobPayload runs a saved sql query against the target database
+type QueryJobPayload struct {
+	Query string `json:"query" validate:"required"`
+}
+
+type JobNewRequest struct {
+	JobName  string          `json:"job_name" validate:"required"`
+	JobType  string          `json:"job_type" validate:"required,oneof=query backup export schema_snapshot"`
+	CronExpr string          `json:"cron_expr" validate:"required"`
+	DBUUID   string          `json:"db_uuid" validate:"required,uuid"`
+	Payload  json.RawMessage `json:"payload"`
+	IsActive *bool           `json:"is_active"`
+}
+
+func (j *JobNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(j); err != nil {
+		custom_log.NewCustomLog("job_create_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(j, c); err != nil {
+		custom_log.NewCustomLog("job_create_failed", err.Error(), "error")
+		return err
+	}
+
+	if _, err := ParseSchedule(j.CronExpr); err != nil {
+		custom_log.NewCustomLog("job_create_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_cron_expr", nil, c))
+	}
+
+	payload, err := bindPayload(j.JobType, j.Payload, c, v)
+	if err != nil {
+		custom_log.NewCustomLog("job_create_failed", err.Error(), "error")
+		return err
+	}
+	j.Payload = payload
+
+	return nil
+}
+
+type JobUpdateRequest struct {
+	JobName  *string         `json:"job_name" validate:"omitempty,min=1"`
+	CronExpr *string         `json:"cron_expr" validate:"omitempty,min=1"`
+	Payload  json.RawMessage `json:"payload"`
+	IsActive *bool           `json:"is_active"`
+}
+
+func (j *JobUpdateRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(j); err != nil {
+		custom_log.NewCustomLog("job_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(j, c); err != nil {
+		custom_log.NewCustomLog("job_update_failed", err.Error(), "error")
+		return err
+	}
+
+	if j.CronExpr != nil {
+		if _, err := ParseSchedule(*j.CronExpr); err != nil {
+			custom_log.NewCustomLog("job_update_failed", err.Error(), "error")
+			return errors.New(utils.Translate("invalid_cron_expr", nil, c))
+		}
+	}
+
+	return nil
+}
+
+// bindPayload validates the payload against the job type and returns it
+// re-encoded so unknown fields are dropped
+func bindPayload(job_type string, raw json.RawMessage, c *fiber.Ctx, v *utils.Validator) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+
+	var payload interface{}
+	switch job_type {
+	case JobTypeQuery:
+		payload = &QueryJobPayload{}
+	case JobTypeBackup:
+		payload = &backup.BackupNewRequest{}
+	case JobTypeExport:
+		payload = &export.ExportNewRequest{}
+	case JobTypeSchemaSnapshot:
+		payload = &struct{}{}
+	default:
+		return nil, errors.New(utils.Translate("invalid_job_type", nil, c))
+	}
+
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, errors.New(utils.Translate("invalid_job_payload", nil, c))
+	}
+
+	if err := v.Validate(payload, c); err != nil {
+		return nil, err
+	}
+
+	switch req := payload.(type) {
+	case *backup.BackupNewRequest:
+		if req.Format == "" {
+			req.Format = archive.FormatNDJSON
+		}
+	case *export.ExportNewRequest:
+		req.SetDefaults()
+	}
+
+	return json.Marshal(payload)
+}
+
+type JobNewModel struct {
+	ID        uint64              `db:"id"`
+	JobUUID   string              `db:"job_uuid"`
+	UserID    uint64              `db:"user_id"`
+	DBID      uint64              `db:"db_id"`
+	JobName   string              `db:"job_name"`
+	JobType   string              `db:"job_type"`
+	CronExpr  string              `db:"cron_expr"`
+	Payload   sqlx_types.JSONText `db:"payload"`
+	IsActive  bool                `db:"is_active"`
+	NextRunAt *time.Time          `db:"next_run_at"`
+	Scopes    pq.StringArray      `db:"scopes"`
+	CreatedBy uint64              `db:"created_by"`
+	CreatedAt time.Time           `db:"created_at"`
+}
+
+func (j *JobNewModel) new(db_id uint64, job_req JobNewRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_jobs_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	schedule, err := ParseSchedule(job_req.CronExpr)
+	if err != nil {
+		return fmt.Errorf("error parse cron expression : %w", err)
+	}
+
+	now := utils.Now()
+	next_run_at := schedule.Next(now)
+
+	is_active := true
+	if job_req.IsActive != nil {
+		is_active = *job_req.IsActive
+	}
+
+	j.ID = uint64(*id)
+	j.JobUUID = uuid.String()
+	j.UserID = uint64(us_ctx.Id)
+	j.DBID = db_id
+	j.JobName = job_req.JobName
+	j.JobType = job_req.JobType
+	j.CronExpr = job_req.CronExpr
+	j.Payload = sqlx_types.JSONText(job_req.Payload)
+	j.IsActive = is_active
+	j.NextRunAt = &next_run_at
+	j.Scopes = jobScopes(us_ctx)
+	j.CreatedBy = uint64(us_ctx.Id)
+	j.CreatedAt = now
+
+	return nil
+}
+
+// ParseSchedule parses a standard 5 field cron expression, descriptors
+// such as @daily or @every 1h are accepted as well
+func ParseSchedule(cron_expr string) (cron.Schedule, error) {
+	return cron.ParseStandard(cron_expr)
+}
+
+// jobScopes returns the permissions a job saved by the user runs with, a job
+// saved with an api key keeps the scopes of the key and one saved with a
+// login runs with the whole role of its owner
+func jobScopes(us_ctx *types.UserContext) pq.StringArray {
+	if us_ctx.ApiKey == "" {
+		return nil
+	}
+
+	return pq.StringArray(us_ctx.Permissions)
+}
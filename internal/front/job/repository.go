@@ -0,0 +1,282 @@
+package job
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/internal/front/database"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+type JobRepo interface {
+	Create(job_req JobNewRequest) (*JobResponse, *responses.ErrorResponse)
+	List(list_req types.ListRequest) ([]Job, int, *responses.ErrorResponse)
+	ShowOne(job_uuid string) (*JobResponse, *responses.ErrorResponse)
+	Update(job_uuid string, job_req JobUpdateRequest) (*JobResponse, *responses.ErrorResponse)
+	Delete(job_uuid string) *responses.ErrorResponse
+	Run(job_uuid string) (*JobResponse, *responses.ErrorResponse)
+}
+
+type JobRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewJobRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *JobRepoImpl {
+	return &JobRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectJobQuery = `
+	SELECT
+		jb.id, jb.job_uuid, jb.user_id, jb.db_id, db.db_uuid, jb.job_name, jb.job_type,
+		jb.cron_expr, jb.payload, jb.is_active, jb.next_run_at, jb.last_run_at,
+		jb.last_status, jb.last_result, jb.last_error, jb.run_count, jb.scopes,
+		jb.created_by, jb.created_at, jb.updated_at
+	FROM tbl_jobs jb
+	INNER JOIN tbl_users_databases db ON db.id = jb.db_id
+	WHERE jb.deleted_at IS NULL
+	AND db.deleted_at IS NULL
+`
+
+func (j *JobRepoImpl) Create(job_req JobNewRequest) (*JobResponse, *responses.ErrorResponse) {
+	// get database info, the owner runs the job with its own access
+	db, err_resp := j.authorize(job_req.JobType, job_req.DBUUID, job_req.Payload, "job_create_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// create insert model
+	var job_new_model JobNewModel
+	if err := job_new_model.new(db.ID, job_req, j.UserContext, j.DBPool); err != nil {
+		custom_log.NewCustomLog("job_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("job_create_failed", fmt.Errorf("invalid_info_to_create_job"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_jobs (
+			id, job_uuid, user_id, db_id, job_name, job_type, cron_expr, payload,
+			is_active, next_run_at, scopes, created_by, created_at
+		) VALUES (
+			:id, :job_uuid, :user_id, :db_id, :job_name, :job_type, :cron_expr, :payload,
+			:is_active, :next_run_at, :scopes, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := j.DBPool.NamedExec(query, job_new_model); err != nil {
+		custom_log.NewCustomLog("job_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("job_create_failed", fmt.Errorf("error_create_job"))
+	}
+
+	return j.ShowOne(job_new_model.JobUUID)
+}
+
+func (j *JobRepoImpl) List(list_req types.ListRequest) ([]Job, int, *responses.ErrorResponse) {
+	// prepare query
+	query := selectJobQuery + `
+		AND jb.user_id = $1
+	`
+
+	// execute query
+	var jobs []Job
+	total, err := postgres.SelectList(j.DBPool, &jobs, query, []interface{}{j.UserContext.Id}, list_req, jobListFields)
+	if err != nil {
+		custom_log.NewCustomLog("job_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("job_list_failed", fmt.Errorf("get_job_error"))
+	}
+
+	if jobs == nil {
+		jobs = []Job{}
+	}
+
+	return jobs, total, nil
+}
+
+func (j *JobRepoImpl) ShowOne(job_uuid string) (*JobResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectJobQuery + `
+		AND jb.job_uuid = $1
+		AND jb.user_id = $2
+	`
+
+	// execute query
+	var job Job
+	if err := j.DBPool.Get(&job, query, job_uuid, j.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("job_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("job_show_failed", fmt.Errorf("no_job_found"))
+		}
+		custom_log.NewCustomLog("job_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("job_show_failed", fmt.Errorf("get_job_error"))
+	}
+
+	return &JobResponse{
+		Job: job,
+	}, nil
+}
+
+func (j *JobRepoImpl) Update(job_uuid string, job_req JobUpdateRequest) (*JobResponse, *responses.ErrorResponse) {
+	// get job info
+	job_resp, err_resp := j.ShowOne(job_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	job := job_resp.Job
+
+	if job_req.JobName != nil {
+		job.JobName = *job_req.JobName
+	}
+	if job_req.CronExpr != nil {
+		job.CronExpr = *job_req.CronExpr
+	}
+	if len(job_req.Payload) > 0 {
+		job.Payload = sqlx_types.JSONText(job_req.Payload)
+	}
+	if job_req.IsActive != nil {
+		job.IsActive = *job_req.IsActive
+	}
+
+	// the job runs with the permissions of whoever saved it last
+	if _, err_resp := j.authorize(job.JobType, job.DBUUID, job.Payload, "job_update_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	// reschedule from now, the expression or the active flag may have changed
+	schedule, err := ParseSchedule(job.CronExpr)
+	if err != nil {
+		custom_log.NewCustomLog("job_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("job_update_failed", fmt.Errorf("invalid_cron_expr"))
+	}
+	now := utils.Now()
+	next_run_at := schedule.Next(now)
+
+	// prepare query
+	query := `
+		UPDATE tbl_jobs SET
+			job_name = $1, cron_expr = $2, payload = $3, is_active = $4,
+			next_run_at = $5, scopes = $6, updated_by = $7, updated_at = $8
+		WHERE id = $9
+	`
+
+	// execute request
+	if _, err := j.DBPool.Exec(
+		query,
+		job.JobName,
+		job.CronExpr,
+		job.Payload,
+		job.IsActive,
+		next_run_at,
+		jobScopes(j.UserContext),
+		j.UserContext.Id,
+		now,
+		job.ID,
+	); err != nil {
+		custom_log.NewCustomLog("job_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("job_update_failed", fmt.Errorf("error_update_job"))
+	}
+
+	return j.ShowOne(job_uuid)
+}
+
+func (j *JobRepoImpl) Delete(job_uuid string) *responses.ErrorResponse {
+	// get job info
+	job_resp, err_resp := j.ShowOne(job_uuid)
+	if err_resp != nil {
+		return err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_jobs SET
+			is_active = FALSE, deleted_by = $1, deleted_at = $2
+		WHERE id = $3
+	`
+
+	// execute request
+	if _, err := j.DBPool.Exec(query, j.UserContext.Id, utils.Now(), job_resp.Job.ID); err != nil {
+		custom_log.NewCustomLog("job_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse("job_delete_failed", fmt.Errorf("error_delete_job"))
+	}
+
+	return nil
+}
+
+func (j *JobRepoImpl) Run(job_uuid string) (*JobResponse, *responses.ErrorResponse) {
+	// get job info
+	job_resp, err_resp := j.ShowOne(job_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	if _, err_resp := j.authorize(job_resp.Job.JobType, job_resp.Job.DBUUID, job_resp.Job.Payload, "job_run_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	// run the job in the background, outside of its schedule
+	go execute(j.DBPool, job_resp.Job)
+
+	return job_resp, nil
+}
+
+// authorize checks the current user may manage the type of job on the
+// database, its role must grant the work the job does and its access level
+// on the database must cover it. The statements of a query job go through
+// the checks of a query run by hand
+func (j *JobRepoImpl) authorize(job_type string, db_uuid string, payload []byte, message_id string) (*database.Database, *responses.ErrorResponse) {
+	if !j.UserContext.HasPermission(jobPermissions[job_type]) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("job_type_not_permitted"))
+	}
+
+	db_repo := database.NewDatabaseRepoImpl(j.UserContext, j.DBPool)
+	db_resp, err_resp := db_repo.Accessible(db_uuid, jobAccess[job_type], message_id)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	if job_type != JobTypeQuery {
+		return &db_resp.Database, nil
+	}
+
+	var query_payload QueryJobPayload
+	if err := json.Unmarshal(payload, &query_payload); err != nil || query_payload.Query == "" {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("invalid_job_payload"))
+	}
+
+	statements, err := tarantool_utils.ClassifyStatements(query_payload.Query)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("invalid_sql_statement"))
+	}
+
+	if err_detail := db_repo.Authorize(db_resp.Database, statements); err_detail != nil {
+		custom_log.NewCustomLog(message_id, err_detail.Detail.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, err_detail.Err)
+	}
+
+	return &db_resp.Database, nil
+}
@@ -0,0 +1,36 @@
+package job
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type JobRoute struct {
+	App        *fiber.App
+	DBPool     *sqlx.DB
+	JobHandler *JobHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *JobRoute {
+	return &JobRoute{
+		App:        app,
+		DBPool:     db_pool,
+		JobHandler: NewJobHandler(db_pool),
+	}
+}
+
+func (j *JobRoute) RegisterJobRoute() *JobRoute {
+	job := j.App.Group("/api/v1/front/job")
+
+	job.Post("/", middlewares.RequirePermission(constants.PermissionRunSelect), j.JobHandler.Create)
+	job.Get("/", middlewares.RequirePermission(constants.PermissionRunSelect), j.JobHandler.List)
+	job.Get("/:job_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), j.JobHandler.ShowOne)
+	job.Put("/:job_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), j.JobHandler.Update)
+	job.Delete("/:job_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), j.JobHandler.Delete)
+	job.Post("/:job_uuid/run", middlewares.RequirePermission(constants.PermissionRunSelect), j.JobHandler.Run)
+
+	return j
+}
@@ -0,0 +1,283 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"tarantool-admin-api/internal/front/auth"
+	"tarantool-admin-api/internal/front/backup"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/internal/front/export"
+	"tarantool-admin-api/internal/front/schema"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/redis"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+// redis key holding the id of the replica allowed to run scheduled jobs
+const runnerLockKey = "job_runner:leader"
+
+var errOwnerInactive = errors.New("job_owner_inactive")
+
+// executor runs one job on behalf of its owner and returns a json encodable result
+type executor func(db_pool *sqlx.DB, us_ctx *types.UserContext, job Job) (interface{}, error)
+
+var executors = map[string]executor{
+	JobTypeQuery:          executeQuery,
+	JobTypeBackup:         executeBackup,
+	JobTypeExport:         executeExport,
+	JobTypeSchemaSnapshot: executeSchemaSnapshot,
+}
+
+type Runner struct {
+	DBPool   *sqlx.DB
+	Owner    string
+	Interval time.Duration
+}
+
+func NewRunner(db_pool *sqlx.DB) *Runner {
+	hostname, _ := os.Hostname()
+
+	return &Runner{
+		DBPool:   db_pool,
+		Owner:    fmt.Sprintf("%s-%s", hostname, uuid.NewString()),
+		Interval: time.Duration(utils.GetenvInt("JOB_RUNNER_INTERVAL_SECONDS", 30)) * time.Second,
+	}
+}
+
+// Start polls for due jobs in the background, only the replica holding the
+// redis leader lock runs them so jobs are not executed twice
+func (r *Runner) Start() {
+	go func() {
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			r.tick()
+		}
+	}()
+}
+
+func (r *Runner) tick() {
+	// the lock outlives a few ticks so a missed tick does not lose leadership
+	is_leader, err := redis.AcquireLock(context.Background(), runnerLockKey, r.Owner, 3*r.Interval)
+	if err != nil {
+		custom_log.NewCustomLog("job_runner_failed", err.Error(), "error")
+		return
+	}
+	if !is_leader {
+		return
+	}
+
+	// prepare query
+	query := selectJobQuery + `
+		AND jb.is_active
+		AND jb.next_run_at <= $1
+		ORDER BY jb.next_run_at
+	`
+
+	// execute query
+	now := utils.Now()
+	var jobs []Job
+	if err := r.DBPool.Select(&jobs, query, now); err != nil {
+		custom_log.NewCustomLog("job_runner_failed", err.Error(), "error")
+		return
+	}
+
+	for _, job := range jobs {
+		schedule, err := ParseSchedule(job.CronExpr)
+		if err != nil {
+			custom_log.NewCustomLog("job_runner_failed", err.Error(), "error")
+			continue
+		}
+
+		// claim the run by moving the job to its next slot, runs missed while
+		// the service was down are not replayed
+		claim := `
+			UPDATE tbl_jobs SET
+				next_run_at = $1
+			WHERE id = $2
+			AND next_run_at = $3
+		`
+		result, err := r.DBPool.Exec(claim, schedule.Next(now), job.ID, job.NextRunAt)
+		if err != nil {
+			custom_log.NewCustomLog("job_runner_failed", err.Error(), "error")
+			continue
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			continue
+		}
+
+		go execute(r.DBPool, job)
+	}
+}
+
+// execute runs the job and records its outcome in tbl_jobs
+func execute(db_pool *sqlx.DB, job Job) {
+	// mark the job as running
+	update_running := `
+		UPDATE tbl_jobs SET
+			last_status = $1, last_run_at = $2
+		WHERE id = $3
+	`
+	if _, err := db_pool.Exec(update_running, constants.JobStatusRunning, utils.Now(), job.ID); err != nil {
+		custom_log.NewCustomLog("job_run_failed", err.Error(), "error")
+	}
+
+	var result interface{}
+	us_ctx, err := ownerContext(db_pool, job)
+	if errors.Is(err, errOwnerInactive) {
+		// the owner was deleted or may no longer log in, its jobs stop with it
+		deactivate(db_pool, job)
+	} else if err == nil {
+		// the owner may have lost its access or its permission since the job
+		// was created, the job is then stopped instead of failing on every run
+		if !us_ctx.HasPermission(jobPermissions[job.JobType]) {
+			err = fmt.Errorf("job_type_not_permitted")
+			deactivate(db_pool, job)
+		} else if _, err_resp := database.NewDatabaseRepoImpl(us_ctx, db_pool).Accessible(job.DBUUID, jobAccess[job.JobType], "job_run_failed"); err_resp != nil {
+			err = err_resp.Err
+			deactivate(db_pool, job)
+		} else if run, ok := executors[job.JobType]; ok {
+			result, err = run(db_pool, us_ctx, job)
+		} else {
+			err = fmt.Errorf("unsupported job type: %s", job.JobType)
+		}
+	}
+
+	status := constants.JobStatusCompleted
+	var last_error *string
+	var last_result sqlx_types.NullJSONText
+	if err != nil {
+		custom_log.NewCustomLog("job_run_failed", err.Error(), "error")
+		status = constants.JobStatusFailed
+		err_str := err.Error()
+		last_error = &err_str
+	} else if encoded, err := json.Marshal(result); err != nil {
+		custom_log.NewCustomLog("job_run_failed", err.Error(), "error")
+	} else {
+		last_result = sqlx_types.NullJSONText{JSONText: encoded, Valid: true}
+	}
+
+	update_finished := `
+		UPDATE tbl_jobs SET
+			last_status = $1, last_result = $2, last_error = $3, run_count = run_count + 1
+		WHERE id = $4
+	`
+	if _, err := db_pool.Exec(update_finished, status, last_result, last_error, job.ID); err != nil {
+		custom_log.NewCustomLog("job_run_failed", err.Error(), "error")
+	}
+}
+
+// ownerContext returns the context jobs run with, the permissions of their
+// owner, and errOwnerInactive once the owner is deleted or not active
+func ownerContext(db_pool *sqlx.DB, job Job) (*types.UserContext, error) {
+	auth_repo := auth.NewAuthRepoImpl(nil, db_pool)
+
+	user_info, err := auth_repo.GetUserByID(int(job.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errOwnerInactive
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user_info.StatusID != constants.UserStatusActive {
+		return nil, errOwnerInactive
+	}
+
+	user_role, err := auth_repo.GetUserRole(user_info.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	us_ctx := &types.UserContext{
+		Id:          user_info.ID,
+		UserUuid:    user_info.UserUUID,
+		UserName:    user_info.UserName,
+		StatusId:    user_info.StatusID,
+		Role:        user_role.RoleName,
+		Permissions: user_role.Permissions,
+	}
+	// a job saved with an api key never gets more than the key allowed
+	if job.Scopes != nil {
+		us_ctx.Permissions = user_role.Scoped(job.Scopes)
+	}
+
+	return us_ctx, nil
+}
+
+// deactivate stops the schedule of a job
+func deactivate(db_pool *sqlx.DB, job Job) {
+	query := `
+		UPDATE tbl_jobs SET
+			is_active = FALSE
+		WHERE id = $1
+	`
+	if _, err := db_pool.Exec(query, job.ID); err != nil {
+		custom_log.NewCustomLog("job_run_failed", err.Error(), "error")
+	}
+}
+
+func executeQuery(db_pool *sqlx.DB, us_ctx *types.UserContext, job Job) (interface{}, error) {
+	var payload QueryJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	resp, err_resp := database.NewDatabaseRepoImpl(us_ctx, db_pool).Query(
+		job.DBUUID,
+		database.DatabaseQueryRequest{Query: payload.Query},
+	)
+	if err_resp != nil {
+		return nil, fmt.Errorf("%v: %v", err_resp.Err, err_resp.Detail)
+	}
+
+	return resp, nil
+}
+
+func executeBackup(db_pool *sqlx.DB, us_ctx *types.UserContext, job Job) (interface{}, error) {
+	var payload backup.BackupNewRequest
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	resp, err_resp := backup.NewBackupRepoImpl(us_ctx, db_pool).Execute(job.DBUUID, payload)
+	if err_resp != nil {
+		return nil, err_resp.Err
+	}
+
+	return resp, nil
+}
+
+func executeExport(db_pool *sqlx.DB, us_ctx *types.UserContext, job Job) (interface{}, error) {
+	var payload export.ExportNewRequest
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	resp, err_resp := export.NewExportRepoImpl(us_ctx, db_pool).Execute(job.DBUUID, payload)
+	if err_resp != nil {
+		return nil, err_resp.Err
+	}
+
+	return resp, nil
+}
+
+func executeSchemaSnapshot(db_pool *sqlx.DB, us_ctx *types.UserContext, job Job) (interface{}, error) {
+	resp, err_resp := schema.NewSchemaRepoImpl(us_ctx, db_pool).Snapshot(job.DBUUID, database.SchemaSourceSchedule)
+	if err_resp != nil {
+		return nil, err_resp.Err
+	}
+
+	return resp, nil
+}
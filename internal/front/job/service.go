@@ -0,0 +1,55 @@
+package job
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type JobServiceCreator interface {
+	Create(job_req JobNewRequest) (*JobResponse, *responses.ErrorResponse)
+	List(list_req types.ListRequest) ([]Job, int, *responses.ErrorResponse)
+	ShowOne(job_uuid string) (*JobResponse, *responses.ErrorResponse)
+	Update(job_uuid string, job_req JobUpdateRequest) (*JobResponse, *responses.ErrorResponse)
+	Delete(job_uuid string) *responses.ErrorResponse
+	Run(job_uuid string) (*JobResponse, *responses.ErrorResponse)
+}
+
+type JobService struct {
+	DBPool      *sqlx.DB
+	JobRepo     *JobRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewJobService(us_ctx *types.UserContext, db_pool *sqlx.DB) *JobService {
+	return &JobService{
+		DBPool:      db_pool,
+		JobRepo:     NewJobRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (j *JobService) Create(job_req JobNewRequest) (*JobResponse, *responses.ErrorResponse) {
+	return j.JobRepo.Create(job_req)
+}
+
+func (j *JobService) List(list_req types.ListRequest) ([]Job, int, *responses.ErrorResponse) {
+	return j.JobRepo.List(list_req)
+}
+
+func (j *JobService) ShowOne(job_uuid string) (*JobResponse, *responses.ErrorResponse) {
+	return j.JobRepo.ShowOne(job_uuid)
+}
+
+func (j *JobService) Update(job_uuid string, job_req JobUpdateRequest) (*JobResponse, *responses.ErrorResponse) {
+	return j.JobRepo.Update(job_uuid, job_req)
+}
+
+func (j *JobService) Delete(job_uuid string) *responses.ErrorResponse {
+	return j.JobRepo.Delete(job_uuid)
+}
+
+func (j *JobService) Run(job_uuid string) (*JobResponse, *responses.ErrorResponse) {
+	return j.JobRepo.Run(job_uuid)
+}
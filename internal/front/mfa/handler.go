@@ -0,0 +1,181 @@
+package mfa
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type MFAHandler struct {
+	DBPool     *sqlx.DB
+	MFAService func(c *fiber.Ctx) *MFAService
+}
+
+func NewMFAHandler(db_pool *sqlx.DB) *MFAHandler {
+	return &MFAHandler{
+		DBPool: db_pool,
+		MFAService: func(c *fiber.Ctx) *MFAService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewMFAService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (m *MFAHandler) Status(c *fiber.Ctx) error {
+	resp, err := m.MFAService(c).Status()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-18000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("mfa_status_success", nil, c),
+			18000,
+			resp,
+		),
+	)
+}
+
+func (m *MFAHandler) Enrol(c *fiber.Ctx) error {
+	resp, err := m.MFAService(c).Enrol()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-18001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("mfa_enrol_success", nil, c),
+			18001,
+			resp,
+		),
+	)
+}
+
+func (m *MFAHandler) ConfirmEnrolment(c *fiber.Ctx) error {
+	var code_req MFACodeRequest
+	v := utils.NewValidator()
+
+	if err := code_req.bind(c, v, "mfa_confirm_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("mfa_confirm_failed", nil, c),
+				-18002,
+				err,
+			),
+		)
+	}
+
+	resp, err := m.MFAService(c).ConfirmEnrolment(code_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-18002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("mfa_confirm_success", nil, c),
+			18002,
+			resp,
+		),
+	)
+}
+
+func (m *MFAHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var code_req MFACodeRequest
+	v := utils.NewValidator()
+
+	if err := code_req.bind(c, v, "mfa_recovery_codes_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("mfa_recovery_codes_failed", nil, c),
+				-18003,
+				err,
+			),
+		)
+	}
+
+	resp, err := m.MFAService(c).RegenerateRecoveryCodes(code_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-18003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("mfa_recovery_codes_success", nil, c),
+			18003,
+			resp,
+		),
+	)
+}
+
+func (m *MFAHandler) Disable(c *fiber.Ctx) error {
+	var code_req MFACodeRequest
+	v := utils.NewValidator()
+
+	if err := code_req.bind(c, v, "mfa_disable_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("mfa_disable_failed", nil, c),
+				-18004,
+				err,
+			),
+		)
+	}
+
+	resp, err := m.MFAService(c).Disable(code_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-18004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("mfa_disable_success", nil, c),
+			18004,
+			resp,
+		),
+	)
+}
@@ -0,0 +1,101 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"os"
+	"strings"
+	custom_log "tarantool-admin-api/pkg/logs"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recoveryCodeCount is how many recovery codes a user gets at once
+const recoveryCodeCount = 10
+
+// MFAState is where a user stands with its second factor
+type MFAState struct {
+	Enrolled          bool       `json:"enrolled" db:"enrolled"`
+	Pending           bool       `json:"-" db:"pending"`
+	Required          bool       `json:"required" db:"required"`
+	ConfirmedAt       *time.Time `json:"confirmed_at" db:"confirmed_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left" db:"recovery_codes_left"`
+}
+
+type MFAStateResponse struct {
+	MFA MFAState `json:"mfa"`
+}
+
+// MFAEnrolment is what an authenticator app needs, the provisioning uri is
+// meant to be shown as a QR code
+type MFAEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAEnrolmentResponse struct {
+	Enrolment MFAEnrolment `json:"enrolment"`
+}
+
+// MFARecoveryCodesResponse carries the recovery codes, they are only shown
+// once
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+func (m *MFACodeRequest) bind(c *fiber.Ctx, v *utils.Validator, message_id string) error {
+	if err := c.BodyParser(m); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(m, c); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type totpSecret struct {
+	ID           uint64     `db:"id"`
+	Secret       string     `db:"secret"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+}
+
+// newRecoveryCode returns a code like "k7m2q-x9rtp", easy to read out and
+// type
+func newRecoveryCode() (string, error) {
+	secret := make([]byte, 7)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(secret))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode accepts a recovery code typed in any case, with or
+// without its dash
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+// issuer is the name authenticator apps list the account under
+func issuer() string {
+	if name := os.Getenv("MFA_ISSUER"); name != "" {
+		return name
+	}
+	return "Tarantool Admin"
+}
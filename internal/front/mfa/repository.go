@@ -0,0 +1,407 @@
+package mfa
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/totp"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrInvalidCode     = errors.New("invalid second factor code")
+	ErrNotEnrolled     = errors.New("no totp enrolment")
+	ErrAlreadyEnrolled = errors.New("totp already enrolled")
+)
+
+type MFARepo interface {
+	Status() (*MFAStateResponse, *responses.ErrorResponse)
+	Enrol() (*MFAEnrolmentResponse, *responses.ErrorResponse)
+	ConfirmEnrolment(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse)
+	RegenerateRecoveryCodes(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse)
+	Disable(code_req MFACodeRequest) (*MFAStateResponse, *responses.ErrorResponse)
+}
+
+type MFARepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewMFARepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *MFARepoImpl {
+	return &MFARepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+func (m *MFARepoImpl) Status() (*MFAStateResponse, *responses.ErrorResponse) {
+	state, err := m.State(m.UserContext.Id)
+	if err != nil {
+		custom_log.NewCustomLog("mfa_status_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("mfa_status_failed", fmt.Errorf("get_mfa_error"))
+	}
+
+	return &MFAStateResponse{
+		MFA: *state,
+	}, nil
+}
+
+// Enrol starts a TOTP enrolment, it only takes effect once confirmed
+func (m *MFARepoImpl) Enrol() (*MFAEnrolmentResponse, *responses.ErrorResponse) {
+	if err_resp := m.interactive("mfa_enrol_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	enrolment, err := m.Setup(m.UserContext.Id, m.UserContext.UserName)
+	if err != nil {
+		return nil, m.codeError("mfa_enrol_failed", err)
+	}
+
+	return &MFAEnrolmentResponse{
+		Enrolment: *enrolment,
+	}, nil
+}
+
+func (m *MFARepoImpl) ConfirmEnrolment(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse) {
+	if err_resp := m.interactive("mfa_confirm_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	recovery_codes, err := m.Confirm(m.UserContext.Id, code_req.Code)
+	if err != nil {
+		return nil, m.codeError("mfa_confirm_failed", err)
+	}
+
+	m.audit("mfa_enable", fmt.Sprintf("User %s enabled two-factor authentication", m.UserContext.UserName))
+
+	return &MFARecoveryCodesResponse{
+		RecoveryCodes: recovery_codes,
+	}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, the old ones stop
+// working
+func (m *MFARepoImpl) RegenerateRecoveryCodes(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse) {
+	if err_resp := m.interactive("mfa_recovery_codes_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	if err := m.Verify(m.UserContext.Id, code_req.Code); err != nil {
+		return nil, m.codeError("mfa_recovery_codes_failed", err)
+	}
+
+	tx, err := m.DBPool.Beginx()
+	if err != nil {
+		return nil, m.codeError("mfa_recovery_codes_failed", err)
+	}
+	defer tx.Rollback()
+
+	recovery_codes, err := newRecoveryCodes(tx, m.UserContext.Id)
+	if err != nil {
+		return nil, m.codeError("mfa_recovery_codes_failed", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, m.codeError("mfa_recovery_codes_failed", err)
+	}
+
+	m.audit("mfa_recovery_codes", fmt.Sprintf("User %s generated new recovery codes", m.UserContext.UserName))
+
+	return &MFARecoveryCodesResponse{
+		RecoveryCodes: recovery_codes,
+	}, nil
+}
+
+// Disable turns two-factor authentication off, users it is required for
+// cannot turn it off
+func (m *MFARepoImpl) Disable(code_req MFACodeRequest) (*MFAStateResponse, *responses.ErrorResponse) {
+	if err_resp := m.interactive("mfa_disable_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	state, err := m.State(m.UserContext.Id)
+	if err != nil {
+		return nil, m.codeError("mfa_disable_failed", err)
+	}
+	if state.Required {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("mfa_disable_failed", fmt.Errorf("mfa_enforced"))
+	}
+
+	if err := m.Verify(m.UserContext.Id, code_req.Code); err != nil {
+		return nil, m.codeError("mfa_disable_failed", err)
+	}
+
+	tx, err := m.DBPool.Beginx()
+	if err != nil {
+		return nil, m.codeError("mfa_disable_failed", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tbl_users_totp WHERE user_id = $1`, m.UserContext.Id); err != nil {
+		return nil, m.codeError("mfa_disable_failed", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM tbl_users_recovery_codes WHERE user_id = $1`, m.UserContext.Id); err != nil {
+		return nil, m.codeError("mfa_disable_failed", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, m.codeError("mfa_disable_failed", err)
+	}
+
+	m.audit("mfa_disable", fmt.Sprintf("User %s disabled two-factor authentication", m.UserContext.UserName))
+
+	return m.Status()
+}
+
+// State returns the second factor state of a user
+func (m *MFARepoImpl) State(user_id int) (*MFAState, error) {
+	// prepare query
+	query := `
+		SELECT
+			tt.confirmed_at IS NOT NULL AS enrolled,
+			tt.id IS NOT NULL AND tt.confirmed_at IS NULL AS pending,
+			us.mfa_required AS required,
+			tt.confirmed_at,
+			(
+				SELECT COUNT(*) FROM tbl_users_recovery_codes
+				WHERE user_id = us.id AND used_at IS NULL
+			) AS recovery_codes_left
+		FROM tbl_users us
+		LEFT JOIN tbl_users_totp tt ON tt.user_id = us.id
+		WHERE us.id = $1
+	`
+
+	// execute query
+	var state MFAState
+	if err := m.DBPool.Get(&state, query, user_id); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// Setup gives the user a new TOTP secret waiting to be confirmed, a secret
+// that was never confirmed is replaced
+func (m *MFARepoImpl) Setup(user_id int, account string) (*MFAEnrolment, error) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_users_totp (
+			user_id, secret, created_at
+		) VALUES (
+			$1, $2, $3
+		)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret, last_used_step = 0, updated_at = EXCLUDED.created_at
+		WHERE tbl_users_totp.confirmed_at IS NULL
+	`
+
+	// execute query
+	result, err := m.DBPool.Exec(query, user_id, secret, utils.Now())
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrAlreadyEnrolled
+	}
+
+	return &MFAEnrolment{
+		Secret:          secret,
+		ProvisioningURI: totp.URI(issuer(), account, secret),
+	}, nil
+}
+
+// Confirm turns a pending TOTP secret on once the user proves its app
+// produces the right codes, the user gets its recovery codes
+func (m *MFARepoImpl) Confirm(user_id int, code string) ([]string, error) {
+	tx, err := m.DBPool.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	secret, err := lockSecret(tx, user_id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.Now()
+	step, ok := totp.Validate(secret.Secret, code, now, secret.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users_totp SET
+			confirmed_at = $1, last_used_step = $2, updated_at = $1
+		WHERE id = $3
+	`
+
+	// execute query
+	if _, err := tx.Exec(query, now, step, secret.ID); err != nil {
+		return nil, err
+	}
+
+	recovery_codes, err := newRecoveryCodes(tx, user_id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return recovery_codes, nil
+}
+
+// Verify checks a code of the confirmed TOTP secret, a code is good once
+func (m *MFARepoImpl) Verify(user_id int, code string) error {
+	tx, err := m.DBPool.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	secret, err := lockSecret(tx, user_id, true)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret.Secret, code, utils.Now(), secret.LastUsedStep)
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users_totp SET
+			last_used_step = $1
+		WHERE id = $2
+	`
+
+	// execute query
+	if _, err := tx.Exec(query, step, secret.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode spends one of the recovery codes of the user
+func (m *MFARepoImpl) UseRecoveryCode(user_id int, code string) error {
+	// prepare query
+	query := `
+		UPDATE tbl_users_recovery_codes SET
+			used_at = $1
+		WHERE user_id = $2
+		AND code_hash = $3
+		AND used_at IS NULL
+	`
+
+	// execute query
+	result, err := m.DBPool.Exec(query, utils.Now(), user_id, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+// interactive refuses second factor changes made with an api key
+func (m *MFARepoImpl) interactive(message_id string) *responses.ErrorResponse {
+	if m.UserContext.ApiKey != "" {
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_api_key_not_allowed"))
+	}
+	return nil
+}
+
+func (m *MFARepoImpl) codeError(message_id string, err error) *responses.ErrorResponse {
+	err_msg := &responses.ErrorResponse{}
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_code_invalid"))
+	case errors.Is(err, ErrNotEnrolled):
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_not_enrolled"))
+	case errors.Is(err, ErrAlreadyEnrolled):
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("mfa_already_enrolled"))
+	}
+
+	custom_log.NewCustomLog(message_id, err.Error(), "error")
+	return err_msg.NewErrorResponse(message_id, fmt.Errorf("error_update_mfa"))
+}
+
+func (m *MFARepoImpl) audit(context string, desc string) {
+	utils.AuditUserAction(m.UserContext, context, desc, constants.AuditTypeAuth, nil, m.DBPool)
+}
+
+// lockSecret returns the TOTP secret of the user locked for the check of a
+// code, so two requests cannot spend the same code
+func lockSecret(tx *sqlx.Tx, user_id int, confirmed bool) (*totpSecret, error) {
+	// prepare query
+	query := `
+		SELECT id, secret, confirmed_at, last_used_step
+		FROM tbl_users_totp
+		WHERE user_id = $1
+		AND (confirmed_at IS NOT NULL) = $2
+		FOR UPDATE
+	`
+
+	// execute query
+	var secret totpSecret
+	if err := tx.Get(&secret, query, user_id, confirmed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotEnrolled
+		}
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// newRecoveryCodes replaces the recovery codes of the user
+func newRecoveryCodes(tx *sqlx.Tx, user_id int) ([]string, error) {
+	if _, err := tx.Exec(`DELETE FROM tbl_users_recovery_codes WHERE user_id = $1`, user_id); err != nil {
+		return nil, err
+	}
+
+	now := utils.Now()
+	recovery_codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		// prepare query
+		query := `
+			INSERT INTO tbl_users_recovery_codes (
+				user_id, code_hash, created_at
+			) VALUES (
+				$1, $2, $3
+			)
+		`
+
+		// execute query
+		if _, err := tx.Exec(query, user_id, utils.HashToken(code), now); err != nil {
+			return nil, err
+		}
+		recovery_codes = append(recovery_codes, code)
+	}
+
+	return recovery_codes, nil
+}
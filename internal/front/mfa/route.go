@@ -0,0 +1,32 @@
+package mfa
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type MFARoute struct {
+	App        *fiber.App
+	DBPool     *sqlx.DB
+	MFAHandler *MFAHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *MFARoute {
+	return &MFARoute{
+		App:        app,
+		DBPool:     db_pool,
+		MFAHandler: NewMFAHandler(db_pool),
+	}
+}
+
+func (m *MFARoute) RegisterMFARoute() *MFARoute {
+	mfa := m.App.Group("/api/v1/front/mfa")
+
+	mfa.Get("/", m.MFAHandler.Status)
+	mfa.Post("/enrol", m.MFAHandler.Enrol)
+	mfa.Post("/confirm", m.MFAHandler.ConfirmEnrolment)
+	mfa.Post("/recovery-code", m.MFAHandler.RegenerateRecoveryCodes)
+	mfa.Delete("/", m.MFAHandler.Disable)
+
+	return m
+}
@@ -0,0 +1,50 @@
+package mfa
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MFAServiceCreator interface {
+	Status() (*MFAStateResponse, *responses.ErrorResponse)
+	Enrol() (*MFAEnrolmentResponse, *responses.ErrorResponse)
+	ConfirmEnrolment(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse)
+	RegenerateRecoveryCodes(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse)
+	Disable(code_req MFACodeRequest) (*MFAStateResponse, *responses.ErrorResponse)
+}
+
+type MFAService struct {
+	DBPool      *sqlx.DB
+	MFARepo     *MFARepoImpl
+	UserContext *types.UserContext
+}
+
+func NewMFAService(us_ctx *types.UserContext, db_pool *sqlx.DB) *MFAService {
+	return &MFAService{
+		DBPool:      db_pool,
+		MFARepo:     NewMFARepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (m *MFAService) Status() (*MFAStateResponse, *responses.ErrorResponse) {
+	return m.MFARepo.Status()
+}
+
+func (m *MFAService) Enrol() (*MFAEnrolmentResponse, *responses.ErrorResponse) {
+	return m.MFARepo.Enrol()
+}
+
+func (m *MFAService) ConfirmEnrolment(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse) {
+	return m.MFARepo.ConfirmEnrolment(code_req)
+}
+
+func (m *MFAService) RegenerateRecoveryCodes(code_req MFACodeRequest) (*MFARecoveryCodesResponse, *responses.ErrorResponse) {
+	return m.MFARepo.RegenerateRecoveryCodes(code_req)
+}
+
+func (m *MFAService) Disable(code_req MFACodeRequest) (*MFAStateResponse, *responses.ErrorResponse) {
+	return m.MFARepo.Disable(code_req)
+}
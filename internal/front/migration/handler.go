@@ -0,0 +1,199 @@
+package migration
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type MigrationHandler struct {
+	DBPool           *sqlx.DB
+	MigrationService func(c *fiber.Ctx) *MigrationService
+}
+
+func NewMigrationHandler(db_pool *sqlx.DB) *MigrationHandler {
+	return &MigrationHandler{
+		DBPool: db_pool,
+		MigrationService: func(c *fiber.Ctx) *MigrationService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewMigrationService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (m *MigrationHandler) Upload(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	// migration files are sent in the multipart "files" field
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["files"]
+	}
+
+	resp, err := m.MigrationService(c).Upload(db_uuid, files)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-11000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("migration_upload_success", nil, c),
+			11000,
+			resp,
+		),
+	)
+}
+
+func (m *MigrationHandler) Status(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, migrationStatusListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("migration_status_failed", nil, c),
+				-11001,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := m.MigrationService(c).Status(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-11001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("migration_status_success", nil, c),
+			11001,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (m *MigrationHandler) Up(c *fiber.Ctx) error {
+	return m.run(c, DirectionUp, 11002)
+}
+
+func (m *MigrationHandler) Down(c *fiber.Ctx) error {
+	return m.run(c, DirectionDown, 11003)
+}
+
+func (m *MigrationHandler) run(c *fiber.Ctx, direction string, code int) error {
+	db_uuid := c.Params("db_uuid")
+
+	var run_req MigrationRunRequest
+	v := utils.NewValidator()
+	if err := run_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("migration_run_failed", nil, c),
+				-code,
+				err,
+			),
+		)
+	}
+
+	service := m.MigrationService(c)
+	run := service.Up
+	if direction == DirectionDown {
+		run = service.Down
+	}
+
+	resp, err := run(db_uuid, run_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseErrorWithDetail(
+				utils.Translate(err.MessageID, nil, c),
+				-code,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+				err.Detail,
+			),
+		)
+	}
+
+	// the run waits for approval instead of running
+	if resp.PendingChange != nil {
+		return c.Status(http.StatusAccepted).JSON(
+			response.NewResponse(
+				utils.Translate("migration_pending_approval", nil, c),
+				code,
+				resp,
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("migration_run_success", nil, c),
+			code,
+			resp,
+		),
+	)
+}
+
+func (m *MigrationHandler) Delete(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	version, parse_err := strconv.ParseUint(c.Params("version"), 10, 64)
+	if parse_err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("migration_delete_failed", nil, c),
+				-11004,
+				errors.New(utils.Translate("invalid_migration_version", nil, c)),
+			),
+		)
+	}
+
+	if err := m.MigrationService(c).Delete(db_uuid, version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-11004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("migration_delete_success", nil, c),
+			11004,
+			nil,
+		),
+	)
+}
@@ -0,0 +1,230 @@
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"tarantool-admin-api/internal/front/database"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// section markers of a migration file, a file without markers is all up
+	markerUp   = "-- +migrate up"
+	markerDown = "-- +migrate down"
+)
+
+// migration files are named <version>_<name>.lua like 20250701120000_add_users.lua
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([\w\-]+)\.lua$`)
+
+type Migration struct {
+	ID            uint64    `json:"-" db:"id"`
+	MigrationUUID string    `json:"migration_uuid" db:"migration_uuid"`
+	UserID        uint64    `json:"-" db:"user_id"`
+	DBID          uint64    `json:"-" db:"db_id"`
+	DBUUID        string    `json:"db_uuid" db:"db_uuid"`
+	Version       uint64    `json:"version" db:"version"`
+	Name          string    `json:"name" db:"name"`
+	Checksum      string    `json:"checksum" db:"checksum"`
+	UpScript      string    `json:"up_script" db:"up_script"`
+	DownScript    *string   `json:"down_script" db:"down_script"`
+	CreatedBy     uint64    `json:"-" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+type MigrationsResponse struct {
+	Migrations []Migration `json:"migrations"`
+}
+
+// MigrationState is an uploaded migration along with its state on the database
+type MigrationState struct {
+	ID               uint64    `json:"-" db:"id"`
+	Version          uint64    `json:"version" db:"version"`
+	Name             string    `json:"name" db:"name"`
+	Checksum         string    `json:"checksum" db:"checksum"`
+	HasDown          bool      `json:"has_down" db:"has_down"`
+	Applied          bool      `json:"applied" db:"applied"`
+	AppliedAt        *string   `json:"applied_at" db:"-"`
+	AppliedBy        *string   `json:"applied_by" db:"-"`
+	ChecksumMismatch bool      `json:"checksum_mismatch" db:"-"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// migrationStatusListFields are the columns the migration status filters and
+// sorts on, applied is read from the database before the list is paged
+var migrationStatusListFields = postgres.ListFields{
+	Filter:      []string{"version", "name", "checksum", "has_down", "applied", "created_at"},
+	Sort:        []string{"version", "name", "applied", "created_at"},
+	DefaultSort: "version",
+}
+
+type MigrationRunRequest struct {
+	ToVersion *uint64 `json:"to_version"`
+}
+
+func (m *MigrationRunRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if len(c.Body()) == 0 {
+		return nil
+	}
+
+	if err := c.BodyParser(m); err != nil {
+		custom_log.NewCustomLog("migration_run_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(m, c); err != nil {
+		custom_log.NewCustomLog("migration_run_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type MigrationRunResult struct {
+	Direction      string   `json:"direction"`
+	Versions       []uint64 `json:"versions"`
+	CurrentVersion uint64   `json:"current_version"`
+}
+
+type MigrationRunResponse struct {
+	MigrationRun  *MigrationRunResult        `json:"migration_run,omitempty"`
+	PendingChange *database.PendingChangeRef `json:"pending_change,omitempty"`
+}
+
+// MigrationPayload is what a queued migration run runs with once it is
+// approved
+type MigrationPayload struct {
+	Direction string              `json:"direction"`
+	Request   MigrationRunRequest `json:"request"`
+}
+
+// MigrationFile is a parsed migration file
+type MigrationFile struct {
+	Version    uint64
+	Name       string
+	UpScript   string
+	DownScript *string
+}
+
+// ParseMigrationFile reads the version and name from the file name and splits
+// the content in up and down scripts on the "-- +migrate up/down" markers
+func ParseMigrationFile(file_name string, content []byte) (*MigrationFile, error) {
+	match := fileNamePattern.FindStringSubmatch(filepath.Base(file_name))
+	if match == nil {
+		return nil, fmt.Errorf("%s: file name must look like <version>_<name>.lua", file_name)
+	}
+
+	version, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid version: %w", file_name, err)
+	}
+
+	var up, down strings.Builder
+	has_markers, has_down := false, false
+	current := &up
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case markerUp:
+			has_markers = true
+			current = &up
+			continue
+		case markerDown:
+			has_markers = true
+			has_down = true
+			current = &down
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file_name, err)
+	}
+
+	file := &MigrationFile{
+		Version:  version,
+		Name:     match[2],
+		UpScript: strings.TrimSpace(up.String()),
+	}
+	if file.UpScript == "" {
+		return nil, fmt.Errorf("%s: the up script is empty", file_name)
+	}
+	if has_markers && has_down {
+		if down_script := strings.TrimSpace(down.String()); down_script != "" {
+			file.DownScript = &down_script
+		}
+	}
+
+	return file, nil
+}
+
+// Checksum identifies the content of the migration
+func (m *MigrationFile) Checksum() string {
+	content := m.UpScript
+	if m.DownScript != nil {
+		content += "\n" + markerDown + "\n" + *m.DownScript
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+type MigrationNewModel struct {
+	ID            uint64    `db:"id"`
+	MigrationUUID string    `db:"migration_uuid"`
+	UserID        uint64    `db:"user_id"`
+	DBID          uint64    `db:"db_id"`
+	Version       uint64    `db:"version"`
+	Name          string    `db:"name"`
+	Checksum      string    `db:"checksum"`
+	UpScript      string    `db:"up_script"`
+	DownScript    *string   `db:"down_script"`
+	CreatedBy     uint64    `db:"created_by"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (m *MigrationNewModel) new(db_id uint64, file *MigrationFile, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_tarantool_migrations_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	m.ID = uint64(*id)
+	m.MigrationUUID = uuid.String()
+	m.UserID = uint64(us_ctx.Id)
+	m.DBID = db_id
+	m.Version = file.Version
+	m.Name = file.Name
+	m.Checksum = file.Checksum()
+	m.UpScript = file.UpScript
+	m.DownScript = file.DownScript
+	m.CreatedBy = uint64(us_ctx.Id)
+	m.CreatedAt = utils.Now()
+
+	return nil
+}
@@ -0,0 +1,562 @@
+package migration
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"sort"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// a migration run changes the schema of the database
+var migrationStatements = []tarantool_utils.Statement{{Keyword: "MIGRATION", Kind: tarantool_utils.StatementDDL}}
+
+type MigrationRepo interface {
+	Upload(db_uuid string, files []*multipart.FileHeader) (*MigrationsResponse, *responses.ErrorResponse)
+	Status(db_uuid string, list_req types.ListRequest) ([]MigrationState, int, *responses.ErrorResponse)
+	Up(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse)
+	Down(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse)
+	Delete(db_uuid string, version uint64) *responses.ErrorResponse
+	Approve(db_uuid string, payload MigrationPayload) (*MigrationRunResponse, *responses.ErrorWithDetailResponse)
+}
+
+type MigrationRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewMigrationRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *MigrationRepoImpl {
+	return &MigrationRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectMigrationQuery = `
+	SELECT
+		mg.id, mg.migration_uuid, mg.user_id, mg.db_id, db.db_uuid, mg.version, mg.name,
+		mg.checksum, mg.up_script, mg.down_script, mg.created_by, mg.created_at
+	FROM tbl_tarantool_migrations mg
+	INNER JOIN tbl_users_databases db ON db.id = mg.db_id
+	WHERE mg.deleted_at IS NULL
+	AND db.deleted_at IS NULL
+	AND db.db_uuid = $1
+`
+
+// Upload stores migration files for the database, a version that is already
+// uploaded is kept when its content did not change and rejected otherwise
+func (m *MigrationRepoImpl) Upload(db_uuid string, files []*multipart.FileHeader) (*MigrationsResponse, *responses.ErrorResponse) {
+	if len(files) == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("migration_upload_failed", fmt.Errorf("migration_file_required"))
+	}
+
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool).Accessible(db_uuid, constants.AccessLevelAdmin, "migration_upload_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	existing, err_resp := m.list(db_uuid, "migration_upload_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	existing_by_version := make(map[uint64]Migration, len(existing))
+	for _, migration := range existing {
+		existing_by_version[migration.Version] = migration
+	}
+
+	// parse and check every file before storing any of them
+	var new_files []*MigrationFile
+	seen := make(map[uint64]bool, len(files))
+	for _, file_header := range files {
+		file, err := readMigrationFile(file_header)
+		if err != nil {
+			custom_log.NewCustomLog("migration_upload_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("migration_upload_failed", fmt.Errorf("invalid_migration_file"))
+		}
+
+		if seen[file.Version] {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("migration_upload_failed", fmt.Errorf("duplicate_migration_version"))
+		}
+		seen[file.Version] = true
+
+		if migration, ok := existing_by_version[file.Version]; ok {
+			if migration.Checksum != file.Checksum() {
+				err_msg := &responses.ErrorResponse{}
+				return nil, err_msg.NewErrorResponse("migration_upload_failed", fmt.Errorf("migration_version_exists"))
+			}
+			continue
+		}
+		new_files = append(new_files, file)
+	}
+
+	for _, file := range new_files {
+		// create insert model
+		var migration_new_model MigrationNewModel
+		if err := migration_new_model.new(db_resp.Database.ID, file, m.UserContext, m.DBPool); err != nil {
+			custom_log.NewCustomLog("migration_upload_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("migration_upload_failed", fmt.Errorf("invalid_info_to_upload_migration"))
+		}
+
+		// prepare query
+		query := `
+			INSERT INTO tbl_tarantool_migrations (
+				id, migration_uuid, user_id, db_id, version, name, checksum,
+				up_script, down_script, created_by, created_at
+			) VALUES (
+				:id, :migration_uuid, :user_id, :db_id, :version, :name, :checksum,
+				:up_script, :down_script, :created_by, :created_at
+			)
+		`
+
+		// execute request
+		if _, err := m.DBPool.NamedExec(query, migration_new_model); err != nil {
+			custom_log.NewCustomLog("migration_upload_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("migration_upload_failed", fmt.Errorf("error_upload_migration"))
+		}
+	}
+
+	migrations, err_resp := m.list(db_uuid, "migration_upload_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	return &MigrationsResponse{
+		Migrations: migrations,
+	}, nil
+}
+
+// Status lists the uploaded migrations with their state on the database
+func (m *MigrationRepoImpl) Status(db_uuid string, list_req types.ListRequest) ([]MigrationState, int, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool).Accessible(db_uuid, constants.AccessLevelRead, "migration_status_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// connect database to read the applied versions
+	conn, err := connect(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog("migration_status_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("migration_status_failed", fmt.Errorf("failed_connect_to_target_db"))
+	}
+	defer conn.Close()
+
+	applied, err := tarantool_utils.GetAppliedMigrations(conn)
+	if err != nil {
+		custom_log.NewCustomLog("migration_status_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("migration_status_failed", fmt.Errorf("failed_to_read_migrations"))
+	}
+
+	applied_by_version := make(map[uint64]tarantool_utils.AppliedMigration, len(applied))
+	versions := make([]int64, 0, len(applied))
+	for _, migration := range applied {
+		applied_by_version[migration.Version] = migration
+		versions = append(versions, int64(migration.Version))
+	}
+
+	// prepare query, the applied versions are passed along so the client
+	// can filter and sort on them
+	query := `
+		SELECT
+			mg.id, mg.version, mg.name, mg.checksum,
+			mg.down_script IS NOT NULL AS has_down,
+			mg.version = ANY($2) AS applied, mg.created_at
+		FROM tbl_tarantool_migrations mg
+		INNER JOIN tbl_users_databases db ON db.id = mg.db_id
+		WHERE mg.deleted_at IS NULL
+		AND db.deleted_at IS NULL
+		AND db.db_uuid = $1
+	`
+
+	// execute query
+	var states []MigrationState
+	total, err := postgres.SelectList(m.DBPool, &states, query, []interface{}{db_uuid, pq.Array(versions)}, list_req, migrationStatusListFields)
+	if err != nil {
+		custom_log.NewCustomLog("migration_status_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("migration_status_failed", fmt.Errorf("get_migration_error"))
+	}
+
+	if states == nil {
+		states = []MigrationState{}
+	}
+
+	for i := range states {
+		applied_migration, ok := applied_by_version[states[i].Version]
+		if !ok {
+			continue
+		}
+		states[i].AppliedAt = &applied_migration.AppliedAt
+		states[i].AppliedBy = &applied_migration.AppliedBy
+		states[i].ChecksumMismatch = applied_migration.Checksum != states[i].Checksum
+	}
+
+	return states, total, nil
+}
+
+// Up applies the pending migrations in version order, up to to_version when
+// given, and stops at the first failure
+func (m *MigrationRepoImpl) Up(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	db, err_resp := m.authorize(db_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// a run on a database requiring approval waits for a second user
+	if db.RequiresApproval {
+		return m.queue(*db, DirectionUp, run_req)
+	}
+
+	return m.up(*db, run_req)
+}
+
+// Down rolls back the latest applied migration, or every applied migration
+// above to_version when given, newest first
+func (m *MigrationRepoImpl) Down(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	db, err_resp := m.authorize(db_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// a run on a database requiring approval waits for a second user
+	if db.RequiresApproval {
+		return m.queue(*db, DirectionDown, run_req)
+	}
+
+	return m.down(*db, run_req)
+}
+
+// Approve runs migrations that were waiting for approval, the checks were
+// made when they were queued
+func (m *MigrationRepoImpl) Approve(db_uuid string, payload MigrationPayload) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool).ShowOne(db_uuid)
+	if err_resp != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse(err_resp.MessageID, err_resp.Err, fmt.Errorf(""))
+	}
+
+	if payload.Direction == DirectionDown {
+		return m.down(db_resp.Database, payload.Request)
+	}
+	return m.up(db_resp.Database, payload.Request)
+}
+
+// authorize checks the current user may run migrations on the database, a
+// migration changes the schema and gets the checks of a ddl query
+func (m *MigrationRepoImpl) authorize(db_uuid string) (*database.Database, *responses.ErrorWithDetailResponse) {
+	// get database info
+	db_repo := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool)
+	db_resp, err_resp := db_repo.Accessible(db_uuid, constants.AccessLevelAdmin, "migration_run_failed")
+	if err_resp != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse(err_resp.MessageID, err_resp.Err, fmt.Errorf(""))
+	}
+
+	if err_detail := db_repo.Authorize(db_resp.Database, migrationStatements); err_detail != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("migration_run_failed", err_detail.Err, err_detail.Detail)
+	}
+
+	return &db_resp.Database, nil
+}
+
+// queue stores the run as a pending change of the database
+func (m *MigrationRepoImpl) queue(db database.Database, direction string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	statement := fmt.Sprintf("migrate %s", direction)
+	if run_req.ToVersion != nil {
+		statement = fmt.Sprintf("%s to version %d", statement, *run_req.ToVersion)
+	}
+	payload := MigrationPayload{Direction: direction, Request: run_req}
+
+	pending_change, err := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool).QueueChange(db, database.ChangeKindMigration, statement, migrationStatements, nil, payload)
+	if err != nil {
+		custom_log.NewCustomLog("migration_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("migration_run_failed", fmt.Errorf("error_queue_change"), err)
+	}
+
+	utils.AuditUserAction(
+		m.UserContext,
+		"migration_queued",
+		fmt.Sprintf("Queued change %s on %s for approval: %s", pending_change.ChangeUUID, db.DBName, statement),
+		constants.AuditTypeApproval,
+		&db.ID,
+		m.DBPool,
+	)
+
+	return &MigrationRunResponse{
+		PendingChange: pending_change,
+	}, nil
+}
+
+// up applies the pending migrations without checking the user
+func (m *MigrationRepoImpl) up(db database.Database, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	migrations, conn, applied, err_resp := m.prepare(db)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	defer conn.Close()
+
+	applied_versions := make(map[uint64]bool, len(applied))
+	for _, migration := range applied {
+		applied_versions[migration.Version] = true
+	}
+
+	result := MigrationRunResult{
+		Direction: DirectionUp,
+		Versions:  []uint64{},
+	}
+
+	var run_err error
+	for _, migration := range migrations {
+		if applied_versions[migration.Version] {
+			continue
+		}
+		if run_req.ToVersion != nil && migration.Version > *run_req.ToVersion {
+			break
+		}
+
+		if err := tarantool_utils.ApplyMigration(conn, migration.Version, migration.Name, migration.Checksum, migration.UpScript, m.UserContext.UserName); err != nil {
+			run_err = err
+			break
+		}
+		result.Versions = append(result.Versions, migration.Version)
+	}
+
+	return m.finish(conn, db, result, run_err)
+}
+
+// down rolls back the applied migrations without checking the user
+func (m *MigrationRepoImpl) down(db database.Database, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	migrations, conn, applied, err_resp := m.prepare(db)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	defer conn.Close()
+
+	migrations_by_version := make(map[uint64]Migration, len(migrations))
+	for _, migration := range migrations {
+		migrations_by_version[migration.Version] = migration
+	}
+
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Version > applied[j].Version })
+
+	var rollbacks []Migration
+	for _, applied_migration := range applied {
+		if run_req.ToVersion == nil && len(rollbacks) == 1 {
+			break
+		}
+		if run_req.ToVersion != nil && applied_migration.Version <= *run_req.ToVersion {
+			break
+		}
+
+		// every rollback needs a down script, check them all before running any
+		migration, ok := migrations_by_version[applied_migration.Version]
+		if !ok || migration.DownScript == nil {
+			err_msg := &responses.ErrorWithDetailResponse{}
+			return nil, err_msg.NewErrorResponse("migration_run_failed", fmt.Errorf("migration_has_no_down"), fmt.Errorf("migration %d", applied_migration.Version))
+		}
+		rollbacks = append(rollbacks, migration)
+	}
+
+	result := MigrationRunResult{
+		Direction: DirectionDown,
+		Versions:  []uint64{},
+	}
+
+	var run_err error
+	for _, migration := range rollbacks {
+		if err := tarantool_utils.RollbackMigration(conn, migration.Version, migration.Name, *migration.DownScript); err != nil {
+			run_err = err
+			break
+		}
+		result.Versions = append(result.Versions, migration.Version)
+	}
+
+	return m.finish(conn, db, result, run_err)
+}
+
+// Delete removes an uploaded migration that is not applied on the database
+func (m *MigrationRepoImpl) Delete(db_uuid string, version uint64) *responses.ErrorResponse {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool).Accessible(db_uuid, constants.AccessLevelAdmin, "migration_delete_failed")
+	if err_resp != nil {
+		return err_resp
+	}
+
+	conn, err := connect(db_resp.Database)
+	if err != nil {
+		custom_log.NewCustomLog("migration_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse("migration_delete_failed", fmt.Errorf("failed_connect_to_target_db"))
+	}
+	defer conn.Close()
+
+	applied, err := tarantool_utils.GetAppliedMigrations(conn)
+	if err != nil {
+		custom_log.NewCustomLog("migration_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse("migration_delete_failed", fmt.Errorf("failed_to_read_migrations"))
+	}
+	for _, migration := range applied {
+		if migration.Version == version {
+			err_msg := &responses.ErrorResponse{}
+			return err_msg.NewErrorResponse("migration_delete_failed", fmt.Errorf("migration_is_applied"))
+		}
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_tarantool_migrations SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND db_id = $3
+		AND version = $4
+	`
+
+	// execute request
+	result, err := m.DBPool.Exec(query, m.UserContext.Id, utils.Now(), db_resp.Database.ID, version)
+	if err != nil {
+		custom_log.NewCustomLog("migration_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse("migration_delete_failed", fmt.Errorf("error_delete_migration"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse("migration_delete_failed", fmt.Errorf("no_migration_found"))
+	}
+
+	return nil
+}
+
+// list returns the uploaded migrations of the database by version
+func (m *MigrationRepoImpl) list(db_uuid string, message_id string) ([]Migration, *responses.ErrorResponse) {
+	// prepare query
+	query := selectMigrationQuery + `
+		ORDER BY mg.version
+	`
+
+	// execute query
+	var migrations []Migration
+	if err := m.DBPool.Select(&migrations, query, db_uuid); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_migration_error"))
+	}
+
+	if migrations == nil {
+		migrations = []Migration{}
+	}
+
+	return migrations, nil
+}
+
+// prepare loads what a migration run needs, the caller closes the connection
+func (m *MigrationRepoImpl) prepare(db database.Database) ([]Migration, *pool.ConnectionPool, []tarantool_utils.AppliedMigration, *responses.ErrorWithDetailResponse) {
+	migrations, err_resp := m.list(db.DBUUID, "migration_run_failed")
+	if err_resp != nil {
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, nil, nil, err_msg.NewErrorResponse(err_resp.MessageID, err_resp.Err, fmt.Errorf(""))
+	}
+
+	conn, err := connect(db)
+	if err != nil {
+		custom_log.NewCustomLog("migration_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, nil, nil, err_msg.NewErrorResponse("migration_run_failed", fmt.Errorf("failed_connect_to_target_db"), err)
+	}
+
+	applied, err := tarantool_utils.GetAppliedMigrations(conn)
+	if err != nil {
+		conn.Close()
+		custom_log.NewCustomLog("migration_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, nil, nil, err_msg.NewErrorResponse("migration_run_failed", fmt.Errorf("failed_to_read_migrations"), err)
+	}
+
+	return migrations, conn, applied, nil
+}
+
+// finish records the schema change made by a run and builds its result, the
+// versions run before a failure stay applied
+func (m *MigrationRepoImpl) finish(conn *pool.ConnectionPool, db database.Database, result MigrationRunResult, run_err error) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	if len(result.Versions) > 0 {
+		if _, err := database.NewDatabaseRepoImpl(m.UserContext, m.DBPool).RecordSchema(db, database.SchemaSourceAPI); err != nil {
+			custom_log.NewCustomLog("schema_record_failed", err.Error(), "error")
+		}
+	}
+
+	if len(result.Versions) > 0 || run_err != nil {
+		desc := fmt.Sprintf("Ran migrations %s on %s: %v", result.Direction, db.DBName, result.Versions)
+		if run_err != nil {
+			desc = fmt.Sprintf("%s, failed: %s", desc, run_err.Error())
+		}
+		utils.AuditUserAction(m.UserContext, "migration_"+result.Direction, desc, constants.AuditTypeDDL, &db.ID, m.DBPool)
+	}
+
+	if run_err != nil {
+		custom_log.NewCustomLog("migration_run_failed", run_err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("migration_run_failed", fmt.Errorf("failed_to_run_migration"), run_err)
+	}
+
+	applied, err := tarantool_utils.GetAppliedMigrations(conn)
+	if err != nil {
+		custom_log.NewCustomLog("migration_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorWithDetailResponse{}
+		return nil, err_msg.NewErrorResponse("migration_run_failed", fmt.Errorf("failed_to_read_migrations"), err)
+	}
+	for _, migration := range applied {
+		if migration.Version > result.CurrentVersion {
+			result.CurrentVersion = migration.Version
+		}
+	}
+
+	return &MigrationRunResponse{
+		MigrationRun: &result,
+	}, nil
+}
+
+func connect(db database.Database) (*pool.ConnectionPool, error) {
+	return tarantool_utils.ConnectTarantool(db.Host, int(db.Port), db.Username, db.Password)
+}
+
+func readMigrationFile(file_header *multipart.FileHeader) (*MigrationFile, error) {
+	file, err := file_header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseMigrationFile(file_header.Filename, content)
+}
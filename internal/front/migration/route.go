@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type MigrationRoute struct {
+	App              *fiber.App
+	DBPool           *sqlx.DB
+	MigrationHandler *MigrationHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *MigrationRoute {
+	return &MigrationRoute{
+		App:              app,
+		DBPool:           db_pool,
+		MigrationHandler: NewMigrationHandler(db_pool),
+	}
+}
+
+func (m *MigrationRoute) RegisterMigrationRoute() *MigrationRoute {
+	migration := m.App.Group("/api/v1/front/migration")
+
+	migration.Post("/:db_uuid", middlewares.RequirePermission(constants.PermissionRunDDL), m.MigrationHandler.Upload)
+	migration.Get("/:db_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), m.MigrationHandler.Status)
+	migration.Post("/:db_uuid/up", middlewares.RequirePermission(constants.PermissionRunDDL), m.MigrationHandler.Up)
+	migration.Post("/:db_uuid/down", middlewares.RequirePermission(constants.PermissionRunDDL), m.MigrationHandler.Down)
+	migration.Delete("/:db_uuid/:version", middlewares.RequirePermission(constants.PermissionRunDDL), m.MigrationHandler.Delete)
+
+	return m
+}
@@ -0,0 +1,51 @@
+package migration
+
+import (
+	"mime/multipart"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MigrationServiceCreator interface {
+	Upload(db_uuid string, files []*multipart.FileHeader) (*MigrationsResponse, *responses.ErrorResponse)
+	Status(db_uuid string, list_req types.ListRequest) ([]MigrationState, int, *responses.ErrorResponse)
+	Up(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse)
+	Down(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse)
+	Delete(db_uuid string, version uint64) *responses.ErrorResponse
+}
+
+type MigrationService struct {
+	DBPool        *sqlx.DB
+	MigrationRepo *MigrationRepoImpl
+	UserContext   *types.UserContext
+}
+
+func NewMigrationService(us_ctx *types.UserContext, db_pool *sqlx.DB) *MigrationService {
+	return &MigrationService{
+		DBPool:        db_pool,
+		MigrationRepo: NewMigrationRepoImpl(us_ctx, db_pool),
+		UserContext:   us_ctx,
+	}
+}
+
+func (m *MigrationService) Upload(db_uuid string, files []*multipart.FileHeader) (*MigrationsResponse, *responses.ErrorResponse) {
+	return m.MigrationRepo.Upload(db_uuid, files)
+}
+
+func (m *MigrationService) Status(db_uuid string, list_req types.ListRequest) ([]MigrationState, int, *responses.ErrorResponse) {
+	return m.MigrationRepo.Status(db_uuid, list_req)
+}
+
+func (m *MigrationService) Up(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	return m.MigrationRepo.Up(db_uuid, run_req)
+}
+
+func (m *MigrationService) Down(db_uuid string, run_req MigrationRunRequest) (*MigrationRunResponse, *responses.ErrorWithDetailResponse) {
+	return m.MigrationRepo.Down(db_uuid, run_req)
+}
+
+func (m *MigrationService) Delete(db_uuid string, version uint64) *responses.ErrorResponse {
+	return m.MigrationRepo.Delete(db_uuid, version)
+}
@@ -0,0 +1,221 @@
+package profile
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ProfileHandler struct {
+	DBPool         *sqlx.DB
+	ProfileService func(c *fiber.Ctx) *ProfileService
+}
+
+func NewProfileHandler(db_pool *sqlx.DB) *ProfileHandler {
+	return &ProfileHandler{
+		DBPool: db_pool,
+		ProfileService: func(c *fiber.Ctx) *ProfileService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewProfileService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (p *ProfileHandler) Show(c *fiber.Ctx) error {
+	resp, err := p.ProfileService(c).Show()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("profile_show_success", nil, c),
+			19000,
+			resp,
+		),
+	)
+}
+
+func (p *ProfileHandler) Update(c *fiber.Ctx) error {
+	var update_req ProfileUpdateRequest
+	v := utils.NewValidator()
+
+	if err := update_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("profile_update_failed", nil, c),
+				-19001,
+				err,
+			),
+		)
+	}
+
+	resp, err := p.ProfileService(c).Update(update_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("profile_update_success", nil, c),
+			19001,
+			resp,
+		),
+	)
+}
+
+func (p *ProfileHandler) ChangePassword(c *fiber.Ctx) error {
+	var password_req PasswordChangeRequest
+	v := utils.NewValidator()
+
+	if err := password_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("password_change_failed", nil, c),
+				-19002,
+				err,
+			),
+		)
+	}
+
+	resp, err := p.ProfileService(c).ChangePassword(password_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("password_change_success", nil, c),
+			19002,
+			resp,
+		),
+	)
+}
+
+func (p *ProfileHandler) UpdateLanguage(c *fiber.Ctx) error {
+	var language_req LanguageRequest
+	v := utils.NewValidator()
+
+	if err := language_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("language_update_failed", nil, c),
+				-19003,
+				err,
+			),
+		)
+	}
+
+	resp, err := p.ProfileService(c).UpdateLanguage(language_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("language_update_success", nil, c),
+			19003,
+			resp,
+		),
+	)
+}
+
+func (p *ProfileHandler) UploadPhoto(c *fiber.Ctx) error {
+	file, _ := c.FormFile("photo")
+
+	resp, err := p.ProfileService(c).UploadPhoto(file)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("profile_photo_upload_success", nil, c),
+			19004,
+			resp,
+		),
+	)
+}
+
+func (p *ProfileHandler) DeletePhoto(c *fiber.Ctx) error {
+	resp, err := p.ProfileService(c).DeletePhoto()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("profile_photo_delete_success", nil, c),
+			19005,
+			resp,
+		),
+	)
+}
+
+func (p *ProfileHandler) Photo(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := p.ProfileService(c).Photo(user_uuid, c.Query("size") == "thumb")
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-19006,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.SendFile(resp.FilePath)
+}
@@ -0,0 +1,156 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	custom_log "tarantool-admin-api/pkg/logs"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Profile is what a user can see and change about itself
+type Profile struct {
+	ID              int        `db:"id" json:"-"`
+	UserUUID        string     `db:"user_uuid" json:"user_uuid"`
+	FirstName       *string    `db:"first_name" json:"first_name"`
+	LastName        *string    `db:"last_name" json:"last_name"`
+	UserName        string     `db:"user_name" json:"user_name"`
+	Email           string     `db:"email" json:"email"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at"`
+	ProfilePhoto    *string    `db:"profile_photo" json:"profile_photo"`
+	Language        *string    `db:"language" json:"language"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       *time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type ProfileResponse struct {
+	Profile Profile `json:"profile"`
+}
+
+// ProfileUpdateRequest changes the fields it carries, a new email has to be
+// verified again
+type ProfileUpdateRequest struct {
+	FirstName *string `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName  *string `json:"last_name" validate:"omitempty,min=2,max=100"`
+	Email     *string `json:"email" validate:"omitempty,email"`
+}
+
+func (p *ProfileUpdateRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(p); err != nil {
+		custom_log.NewCustomLog("profile_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(p, c); err != nil {
+		custom_log.NewCustomLog("profile_update_failed", err.Error(), "error")
+		return err
+	}
+
+	if p.FirstName == nil && p.LastName == nil && p.Email == nil {
+		return errors.New(utils.Translate("profile_nothing_to_update", nil, c))
+	}
+
+	if p.Email != nil {
+		email := strings.TrimSpace(*p.Email)
+		p.Email = &email
+	}
+
+	return nil
+}
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=100"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,min=6,max=100"`
+}
+
+func (p *PasswordChangeRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(p); err != nil {
+		custom_log.NewCustomLog("password_change_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(p, c); err != nil {
+		custom_log.NewCustomLog("password_change_failed", err.Error(), "error")
+		return err
+	}
+
+	if p.NewPassword != p.ConfirmPassword {
+		return errors.New(utils.Translate("confirm_pass_and_pass_dont_match", nil, c))
+	}
+
+	if p.NewPassword == p.CurrentPassword {
+		return errors.New(utils.Translate("password_unchanged", nil, c))
+	}
+
+	return nil
+}
+
+type PasswordChangeResponse struct {
+	Revoked int64 `json:"revoked_sessions"`
+}
+
+// LanguageRequest picks one of the languages the translations exist in
+type LanguageRequest struct {
+	Language string `json:"language" validate:"required,oneof=en km zh"`
+}
+
+func (l *LanguageRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(l); err != nil {
+		custom_log.NewCustomLog("language_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(l, c); err != nil {
+		custom_log.NewCustomLog("language_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// ProfilePhotoFile is a stored photo ready to be sent
+type ProfilePhotoFile struct {
+	FilePath string
+}
+
+// photoDir is where the photos are kept, one folder per user
+func photoDir() string {
+	dir := os.Getenv("PROFILE_PHOTO_DIR")
+	if dir == "" {
+		dir = "./storage/photos"
+	}
+	return dir
+}
+
+// photoMaxBytes is the largest photo accepted
+func photoMaxBytes() int64 {
+	return int64(utils.GetenvInt("PROFILE_PHOTO_MAX_KB", 2048)) * 1024
+}
+
+// thumbnailSize is the side of the square thumbnails
+func thumbnailSize() int {
+	return utils.GetenvInt("PROFILE_PHOTO_THUMB_SIZE", 128)
+}
+
+// photoPath returns where a photo or its thumbnail is stored
+func photoPath(user_uuid string, name string, thumbnail bool) string {
+	if thumbnail {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + "_thumb" + ext
+	}
+	return filepath.Join(photoDir(), user_uuid, name)
+}
+
+// removePhoto deletes a photo and its thumbnail from the disk
+func removePhoto(user_uuid string, name string) {
+	for _, thumbnail := range []bool{false, true} {
+		if err := os.Remove(photoPath(user_uuid, name, thumbnail)); err != nil && !os.IsNotExist(err) {
+			custom_log.NewCustomLog("profile_photo_remove_failed", err.Error(), "warn")
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package profile
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"tarantool-admin-api/internal/front/auth"
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/imaging"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ProfileRepo interface {
+	Show() (*ProfileResponse, *responses.ErrorResponse)
+	Update(update_req ProfileUpdateRequest) (*ProfileResponse, *responses.ErrorResponse)
+	ChangePassword(password_req PasswordChangeRequest) (*PasswordChangeResponse, *responses.ErrorResponse)
+	UpdateLanguage(language_req LanguageRequest) (*ProfileResponse, *responses.ErrorResponse)
+	UploadPhoto(file *multipart.FileHeader) (*ProfileResponse, *responses.ErrorResponse)
+	DeletePhoto() (*ProfileResponse, *responses.ErrorResponse)
+	Photo(user_uuid string, thumbnail bool) (*ProfilePhotoFile, *responses.ErrorResponse)
+}
+
+type ProfileRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewProfileRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *ProfileRepoImpl {
+	return &ProfileRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+func (p *ProfileRepoImpl) Show() (*ProfileResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			id, user_uuid, first_name, last_name, user_name, email, email_verified_at,
+			profile_photo, language, created_at, updated_at
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND id = $1
+	`
+
+	// execute query
+	var profile Profile
+	if err := p.DBPool.Get(&profile, query, p.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("profile_show_failed", fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog("profile_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_show_failed", fmt.Errorf("get_user_error"))
+	}
+
+	return &ProfileResponse{
+		Profile: profile,
+	}, nil
+}
+
+// Update changes the names and the email of the user, a new email is no
+// longer verified and a verification link is mailed to it
+func (p *ProfileRepoImpl) Update(update_req ProfileUpdateRequest) (*ProfileResponse, *responses.ErrorResponse) {
+	current, err_resp := p.Show()
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	email_changed := update_req.Email != nil && !strings.EqualFold(*update_req.Email, current.Profile.Email)
+	if email_changed {
+		// a stolen api key must not take the account over
+		if err_resp := p.interactive("profile_update_failed"); err_resp != nil {
+			return nil, err_resp
+		}
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			first_name = COALESCE($1, first_name),
+			last_name = COALESCE($2, last_name),
+			email = CASE WHEN $3 THEN $4 ELSE email END,
+			email_verified_at = CASE WHEN $3 THEN NULL ELSE email_verified_at END,
+			updated_by = $5, updated_at = $6
+		WHERE id = $5
+	`
+
+	// execute query
+	if _, err := p.DBPool.Exec(query, update_req.FirstName, update_req.LastName, email_changed, update_req.Email, p.UserContext.Id, utils.Now()); err != nil {
+		custom_log.NewCustomLog("profile_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_update_failed", fmt.Errorf("error_database"))
+	}
+
+	desc := fmt.Sprintf("User %s updated its profile", p.UserContext.UserName)
+	if email_changed {
+		desc = fmt.Sprintf("User %s updated its profile and changed its email from %s to %s", p.UserContext.UserName, current.Profile.Email, *update_req.Email)
+
+		// the email is changed either way, the link can be sent again
+		if err := auth.NewAuthRepoImpl(p.UserContext, p.DBPool).SendEmailVerification(p.UserContext.Id); err != nil {
+			custom_log.NewCustomLog("profile_update_failed", err.Error(), "error")
+		}
+	}
+	p.audit("profile_update", desc)
+
+	return p.Show()
+}
+
+// ChangePassword replaces the password of the user once the current one is
+// confirmed, every other session is signed out
+func (p *ProfileRepoImpl) ChangePassword(password_req PasswordChangeRequest) (*PasswordChangeResponse, *responses.ErrorResponse) {
+	if err_resp := p.interactive("password_change_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	tx, err := p.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("password_change_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_change_failed", fmt.Errorf("error_database"))
+	}
+	defer tx.Rollback()
+
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			password = $1, updated_by = $2, updated_at = $3
+		WHERE deleted_at IS NULL
+		AND id = $2
+		AND password = $4
+	`
+
+	// execute query
+	now := utils.Now()
+	result, err := tx.Exec(query, password_req.NewPassword, p.UserContext.Id, now, password_req.CurrentPassword)
+	if err != nil {
+		custom_log.NewCustomLog("password_change_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_change_failed", fmt.Errorf("error_database"))
+	}
+	if changed, _ := result.RowsAffected(); changed == 0 {
+		p.audit("password_change_refused", fmt.Sprintf("User %s failed to confirm its current password", p.UserContext.UserName))
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_change_failed", fmt.Errorf("current_password_invalid"))
+	}
+
+	// prepare query, the session in use stays signed in
+	revoke_query := `
+		UPDATE tbl_users_sessions SET
+			revoked_by = $1, revoked_at = $2, revoked_reason = 'password_changed', updated_at = $2
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+		AND session_uuid <> $3
+	`
+
+	// execute query
+	result, err = tx.Exec(revoke_query, p.UserContext.Id, now, p.UserContext.LoginSession)
+	if err != nil {
+		custom_log.NewCustomLog("password_change_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_change_failed", fmt.Errorf("error_database"))
+	}
+	revoked, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("password_change_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("password_change_failed", fmt.Errorf("error_database"))
+	}
+
+	p.audit("password_change", fmt.Sprintf("User %s changed its password and signed out %d other session(s)", p.UserContext.UserName, revoked))
+
+	return &PasswordChangeResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+func (p *ProfileRepoImpl) UpdateLanguage(language_req LanguageRequest) (*ProfileResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			language = $1, updated_by = $2, updated_at = $3
+		WHERE id = $2
+	`
+
+	// execute query
+	if _, err := p.DBPool.Exec(query, language_req.Language, p.UserContext.Id, utils.Now()); err != nil {
+		custom_log.NewCustomLog("language_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("language_update_failed", fmt.Errorf("error_database"))
+	}
+
+	return p.Show()
+}
+
+// UploadPhoto stores a new photo with its thumbnail and replaces the former
+// one, the content decides whether it is a picture, not its name
+func (p *ProfileRepoImpl) UploadPhoto(file *multipart.FileHeader) (*ProfileResponse, *responses.ErrorResponse) {
+	if file == nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("profile_photo_required"))
+	}
+
+	max_bytes := photoMaxBytes()
+	if file.Size > max_bytes {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("profile_photo_too_large"))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		custom_log.NewCustomLog("profile_photo_upload_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("error_read_file"))
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, max_bytes+1))
+	if err != nil {
+		custom_log.NewCustomLog("profile_photo_upload_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("error_read_file"))
+	}
+	if int64(len(data)) > max_bytes {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("profile_photo_too_large"))
+	}
+
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		err_msg := &responses.ErrorResponse{}
+		if errors.Is(err, imaging.ErrTooLarge) {
+			return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("profile_photo_dimensions_too_large"))
+		}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("profile_photo_type_invalid"))
+	}
+
+	photo_uuid, err := uuid.NewV7()
+	if err != nil {
+		custom_log.NewCustomLog("profile_photo_upload_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("error_save_file"))
+	}
+	name := photo_uuid.String() + imaging.Extension(format)
+
+	if err := imaging.Save(img, format, photoPath(p.UserContext.UserUuid, name, false)); err != nil {
+		custom_log.NewCustomLog("profile_photo_upload_failed", err.Error(), "error")
+		removePhoto(p.UserContext.UserUuid, name)
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("error_save_file"))
+	}
+	if err := imaging.Save(imaging.Thumbnail(img, thumbnailSize()), format, photoPath(p.UserContext.UserUuid, name, true)); err != nil {
+		custom_log.NewCustomLog("profile_photo_upload_failed", err.Error(), "error")
+		removePhoto(p.UserContext.UserUuid, name)
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("error_save_file"))
+	}
+
+	previous, err := p.replacePhoto(&name)
+	if err != nil {
+		custom_log.NewCustomLog("profile_photo_upload_failed", err.Error(), "error")
+		removePhoto(p.UserContext.UserUuid, name)
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_upload_failed", fmt.Errorf("error_database"))
+	}
+	if previous != nil {
+		removePhoto(p.UserContext.UserUuid, *previous)
+	}
+
+	p.audit("profile_photo_upload", fmt.Sprintf("User %s uploaded a new profile photo", p.UserContext.UserName))
+
+	return p.Show()
+}
+
+func (p *ProfileRepoImpl) DeletePhoto() (*ProfileResponse, *responses.ErrorResponse) {
+	previous, err := p.replacePhoto(nil)
+	if err != nil {
+		custom_log.NewCustomLog("profile_photo_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_delete_failed", fmt.Errorf("error_database"))
+	}
+	if previous == nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_delete_failed", fmt.Errorf("profile_photo_not_found"))
+	}
+	removePhoto(p.UserContext.UserUuid, *previous)
+
+	p.audit("profile_photo_delete", fmt.Sprintf("User %s deleted its profile photo", p.UserContext.UserName))
+
+	return p.Show()
+}
+
+// Photo returns the stored photo of any user, or its thumbnail
+func (p *ProfileRepoImpl) Photo(user_uuid string, thumbnail bool) (*ProfilePhotoFile, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT profile_photo
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND user_uuid = $1
+	`
+
+	// execute query
+	var name *string
+	if err := p.DBPool.Get(&name, query, user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("profile_photo_show_failed", fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog("profile_photo_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_show_failed", fmt.Errorf("get_user_error"))
+	}
+	if name == nil {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("profile_photo_show_failed", fmt.Errorf("profile_photo_not_found"))
+	}
+
+	return &ProfilePhotoFile{
+		FilePath: photoPath(user_uuid, *name, thumbnail),
+	}, nil
+}
+
+// replacePhoto stores the name of the new photo of the user and returns the
+// name of the one it replaces
+func (p *ProfileRepoImpl) replacePhoto(name *string) (*string, error) {
+	// prepare query, the row is locked so two uploads do not race
+	query := `
+		WITH previous AS (
+			SELECT id, profile_photo
+			FROM tbl_users
+			WHERE id = $2
+			FOR UPDATE
+		)
+		UPDATE tbl_users us SET
+			profile_photo = $1, updated_by = $2, updated_at = $3
+		FROM previous
+		WHERE us.id = previous.id
+		RETURNING previous.profile_photo
+	`
+
+	// execute query
+	var previous *string
+	if err := p.DBPool.Get(&previous, query, name, p.UserContext.Id, utils.Now()); err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// interactive refuses the changes an api key must not make
+func (p *ProfileRepoImpl) interactive(message_id string) *responses.ErrorResponse {
+	if p.UserContext.ApiKey != "" {
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("profile_api_key_not_allowed"))
+	}
+	return nil
+}
+
+func (p *ProfileRepoImpl) audit(context string, desc string) {
+	utils.AuditUserAction(p.UserContext, context, desc, constants.AuditTypeUser, nil, p.DBPool)
+}
@@ -0,0 +1,34 @@
+package profile
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type ProfileRoute struct {
+	App            *fiber.App
+	DBPool         *sqlx.DB
+	ProfileHandler *ProfileHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *ProfileRoute {
+	return &ProfileRoute{
+		App:            app,
+		DBPool:         db_pool,
+		ProfileHandler: NewProfileHandler(db_pool),
+	}
+}
+
+func (p *ProfileRoute) RegisterProfileRoute() *ProfileRoute {
+	profile := p.App.Group("/api/v1/front/profile")
+
+	profile.Get("/", p.ProfileHandler.Show)
+	profile.Put("/", p.ProfileHandler.Update)
+	profile.Put("/password", p.ProfileHandler.ChangePassword)
+	profile.Put("/language", p.ProfileHandler.UpdateLanguage)
+	profile.Post("/photo", p.ProfileHandler.UploadPhoto)
+	profile.Delete("/photo", p.ProfileHandler.DeletePhoto)
+	profile.Get("/photo/:user_uuid", p.ProfileHandler.Photo)
+
+	return p
+}
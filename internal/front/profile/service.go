@@ -0,0 +1,61 @@
+package profile
+
+import (
+	"mime/multipart"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ProfileServiceCreator interface {
+	Show() (*ProfileResponse, *responses.ErrorResponse)
+	Update(update_req ProfileUpdateRequest) (*ProfileResponse, *responses.ErrorResponse)
+	ChangePassword(password_req PasswordChangeRequest) (*PasswordChangeResponse, *responses.ErrorResponse)
+	UpdateLanguage(language_req LanguageRequest) (*ProfileResponse, *responses.ErrorResponse)
+	UploadPhoto(file *multipart.FileHeader) (*ProfileResponse, *responses.ErrorResponse)
+	DeletePhoto() (*ProfileResponse, *responses.ErrorResponse)
+	Photo(user_uuid string, thumbnail bool) (*ProfilePhotoFile, *responses.ErrorResponse)
+}
+
+type ProfileService struct {
+	DBPool      *sqlx.DB
+	ProfileRepo *ProfileRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewProfileService(us_ctx *types.UserContext, db_pool *sqlx.DB) *ProfileService {
+	return &ProfileService{
+		DBPool:      db_pool,
+		ProfileRepo: NewProfileRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (p *ProfileService) Show() (*ProfileResponse, *responses.ErrorResponse) {
+	return p.ProfileRepo.Show()
+}
+
+func (p *ProfileService) Update(update_req ProfileUpdateRequest) (*ProfileResponse, *responses.ErrorResponse) {
+	return p.ProfileRepo.Update(update_req)
+}
+
+func (p *ProfileService) ChangePassword(password_req PasswordChangeRequest) (*PasswordChangeResponse, *responses.ErrorResponse) {
+	return p.ProfileRepo.ChangePassword(password_req)
+}
+
+func (p *ProfileService) UpdateLanguage(language_req LanguageRequest) (*ProfileResponse, *responses.ErrorResponse) {
+	return p.ProfileRepo.UpdateLanguage(language_req)
+}
+
+func (p *ProfileService) UploadPhoto(file *multipart.FileHeader) (*ProfileResponse, *responses.ErrorResponse) {
+	return p.ProfileRepo.UploadPhoto(file)
+}
+
+func (p *ProfileService) DeletePhoto() (*ProfileResponse, *responses.ErrorResponse) {
+	return p.ProfileRepo.DeletePhoto()
+}
+
+func (p *ProfileService) Photo(user_uuid string, thumbnail bool) (*ProfilePhotoFile, *responses.ErrorResponse) {
+	return p.ProfileRepo.Photo(user_uuid, thumbnail)
+}
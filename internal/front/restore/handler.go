@@ -0,0 +1,158 @@
+package restore
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type RestoreHandler struct {
+	DBPool         *sqlx.DB
+	RestoreService func(c *fiber.Ctx) *RestoreService
+}
+
+func NewRestoreHandler(db_pool *sqlx.DB) *RestoreHandler {
+	return &RestoreHandler{
+		DBPool: db_pool,
+		RestoreService: func(c *fiber.Ctx) *RestoreService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewRestoreService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (r *RestoreHandler) Create(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var restore_req RestoreNewRequest
+	v := utils.NewValidator()
+	if err := restore_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("restore_plan_failed", nil, c),
+				-5000,
+				err,
+			),
+		)
+	}
+
+	// the archive is optional when restoring from an existing backup
+	file, _ := c.FormFile("archive")
+
+	resp, err := r.RestoreService(c).Create(db_uuid, restore_req, file)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-5000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("restore_plan_success", nil, c),
+			5000,
+			resp,
+		),
+	)
+}
+
+func (r *RestoreHandler) Run(c *fiber.Ctx) error {
+	restore_uuid := c.Params("restore_uuid")
+
+	resp, err := r.RestoreService(c).Run(restore_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-5001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("restore_run_success", nil, c),
+			5001,
+			resp,
+		),
+	)
+}
+
+func (r *RestoreHandler) List(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, restoreListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("restore_list_failed", nil, c),
+				-5002,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := r.RestoreService(c).List(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-5002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("restore_list_success", nil, c),
+			5002,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (r *RestoreHandler) ShowOne(c *fiber.Ctx) error {
+	restore_uuid := c.Params("restore_uuid")
+
+	resp, err := r.RestoreService(c).ShowOne(restore_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-5003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("restore_show_success", nil, c),
+			5003,
+			resp,
+		),
+	)
+}
@@ -0,0 +1,187 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+const (
+	RestoreModeFail    = "fail"
+	RestoreModeReplace = "replace"
+	RestoreModeSkip    = "skip"
+
+	// space actions of a restore plan
+	ActionCreate   = "create"
+	ActionRecreate = "recreate"
+	ActionMerge    = "merge"
+	ActionSkip     = "skip"
+	ActionConflict = "conflict"
+)
+
+type Restore struct {
+	ID             uint64              `json:"-" db:"id"`
+	RestoreUUID    string              `json:"restore_uuid" db:"restore_uuid"`
+	UserID         uint64              `json:"-" db:"user_id"`
+	DBID           uint64              `json:"-" db:"db_id"`
+	DBUUID         string              `json:"db_uuid" db:"db_uuid"`
+	BackupUUID     *string             `json:"backup_uuid" db:"backup_uuid"`
+	FilePath       string              `json:"-" db:"file_path"`
+	Mode           string              `json:"mode" db:"mode"`
+	BatchSize      int                 `json:"batch_size" db:"batch_size"`
+	Plan           sqlx_types.JSONText `json:"plan" db:"plan"`
+	Progress       sqlx_types.JSONText `json:"progress" db:"progress"`
+	TotalTuples    int64               `json:"total_tuples" db:"total_tuples"`
+	RestoredTuples int64               `json:"restored_tuples" db:"restored_tuples"`
+	JobStatus      string              `json:"job_status" db:"job_status"`
+	ErrorMessage   *string             `json:"error_message" db:"error_message"`
+	StartedAt      *time.Time          `json:"started_at" db:"started_at"`
+	FinishedAt     *time.Time          `json:"finished_at" db:"finished_at"`
+	CreatedBy      uint64              `json:"-" db:"created_by"`
+	CreatedAt      time.Time           `json:"created_at" db:"created_at"`
+}
+
+// restoreListFields are the columns the restore list filters and sorts on
+var restoreListFields = postgres.ListFields{
+	Filter:      []string{"restore_uuid", "backup_uuid", "mode", "job_status", "total_tuples", "restored_tuples", "started_at", "finished_at", "created_at"},
+	Sort:        []string{"mode", "job_status", "total_tuples", "restored_tuples", "started_at", "finished_at", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type RestoreResponse struct {
+	Restore Restore `json:"restore"`
+}
+
+type RestorePlan struct {
+	Mode        string             `json:"mode"`
+	TotalTuples uint64             `json:"total_tuples"`
+	HasConflict bool               `json:"has_conflict"`
+	Spaces      []RestorePlanSpace `json:"spaces"`
+}
+
+type RestorePlanSpace struct {
+	Name               string   `json:"name"`
+	Action             string   `json:"action"`
+	TupleCount         uint64   `json:"tuple_count"`
+	ExistingTupleCount uint64   `json:"existing_tuple_count"`
+	Conflicts          []string `json:"conflicts"`
+}
+
+type RestoreSpaceProgress struct {
+	Name       string `json:"name"`
+	Action     string `json:"action"`
+	TupleCount uint64 `json:"tuple_count"`
+	Written    uint64 `json:"written"`
+	Skipped    uint64 `json:"skipped"`
+	Done       bool   `json:"done"`
+}
+
+type RestoreNewRequest struct {
+	BackupUUID string `json:"backup_uuid" form:"backup_uuid" validate:"omitempty,uuid"`
+	Mode       string `json:"mode" form:"mode" validate:"required,oneof=fail replace skip"`
+	Spaces     string `json:"spaces" form:"spaces" validate:"omitempty"`
+	BatchSize  int    `json:"batch_size" form:"batch_size" validate:"omitempty,min=1,max=10000"`
+}
+
+func (r *RestoreNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(r); err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(r, c); err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		return err
+	}
+
+	if r.BatchSize == 0 {
+		r.BatchSize = 500
+	}
+
+	return nil
+}
+
+// SpaceNames returns the comma separated list of spaces to restore
+func (r *RestoreNewRequest) SpaceNames() []string {
+	var names []string
+	for _, name := range strings.Split(r.Spaces, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type RestoreNewModel struct {
+	ID          uint64              `db:"id"`
+	RestoreUUID string              `db:"restore_uuid"`
+	UserID      uint64              `db:"user_id"`
+	DBID        uint64              `db:"db_id"`
+	BackupID    *uint64             `db:"backup_id"`
+	FilePath    string              `db:"file_path"`
+	Mode        string              `db:"mode"`
+	BatchSize   int                 `db:"batch_size"`
+	Plan        sqlx_types.JSONText `db:"plan"`
+	TotalTuples uint64              `db:"total_tuples"`
+	JobStatus   string              `db:"job_status"`
+	CreatedBy   uint64              `db:"created_by"`
+	CreatedAt   time.Time           `db:"created_at"`
+}
+
+func (r *RestoreNewModel) new(db_id uint64, restore_req RestoreNewRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_restores_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	// get current os time
+	time_zone := os.Getenv("APP_TIMEZONE")
+	location, err := time.LoadLocation(time_zone)
+	if err != nil {
+		return fmt.Errorf("error load location : %w", err)
+	}
+	now := time.Now().In(location)
+
+	r.ID = uint64(*id)
+	r.RestoreUUID = uuid.String()
+	r.UserID = uint64(us_ctx.Id)
+	r.DBID = db_id
+	r.FilePath = filepath.Join(RestoreDir(), fmt.Sprintf("%s.tar.gz", r.RestoreUUID))
+	r.Mode = restore_req.Mode
+	r.BatchSize = restore_req.BatchSize
+	r.Plan = sqlx_types.JSONText("{}")
+	r.JobStatus = constants.JobStatusPlanned
+	r.CreatedBy = uint64(us_ctx.Id)
+	r.CreatedAt = now
+
+	return nil
+}
+
+// RestoreDir returns the local directory where uploaded archives are stored
+func RestoreDir() string {
+	dir := os.Getenv("RESTORE_DIR")
+	if dir == "" {
+		dir = "./storage/restores"
+	}
+	return dir
+}
@@ -0,0 +1,710 @@
+package restore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"tarantool-admin-api/internal/front/backup"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/archive"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+type RestoreRepo interface {
+	Create(db_uuid string, restore_req RestoreNewRequest, file *multipart.FileHeader) (*RestoreResponse, *responses.ErrorResponse)
+	Run(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]Restore, int, *responses.ErrorResponse)
+	ShowOne(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse)
+}
+
+type RestoreRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewRestoreRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *RestoreRepoImpl {
+	return &RestoreRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+func (r *RestoreRepoImpl) Create(db_uuid string, restore_req RestoreNewRequest, file *multipart.FileHeader) (*RestoreResponse, *responses.ErrorResponse) {
+	// get database info, a restore overwrites the data of the database
+	db_resp, err_resp := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).Accessible(db_uuid, constants.AccessLevelWrite, "restore_plan_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// create insert model
+	var restore_new_model RestoreNewModel
+	if err := restore_new_model.new(db_resp.Database.ID, restore_req, r.UserContext, r.DBPool); err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("invalid_info_to_restore"))
+	}
+
+	// resolve the archive, either an existing backup or an uploaded file
+	uploaded := false
+	if restore_req.BackupUUID != "" {
+		backup_resp, err_resp := backup.NewBackupRepoImpl(r.UserContext, r.DBPool).ShowOne(restore_req.BackupUUID)
+		if err_resp != nil {
+			return nil, err_resp
+		}
+
+		if backup_resp.Backup.JobStatus != constants.JobStatusCompleted || backup_resp.Backup.FilePath == nil {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("backup_not_completed"))
+		}
+
+		restore_new_model.BackupID = &backup_resp.Backup.ID
+		restore_new_model.FilePath = *backup_resp.Backup.FilePath
+	} else if file != nil {
+		if err := saveUpload(file, restore_new_model.FilePath); err != nil {
+			custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("failed_to_save_archive"))
+		}
+		uploaded = true
+	} else {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("archive_or_backup_required"))
+	}
+
+	// drop the uploaded archive when the restore cannot be planned
+	defer func() {
+		if uploaded {
+			os.Remove(restore_new_model.FilePath)
+		}
+	}()
+
+	// read the archive manifest
+	manifest, err := archive.ReadManifest(restore_new_model.FilePath)
+	if err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("invalid_archive"))
+	}
+
+	// connect the target database to compare schemas
+	conn, err := tarantool_utils.ConnectTarantool(
+		db_resp.Database.Host,
+		int(db_resp.Database.Port),
+		db_resp.Database.Username,
+		db_resp.Database.Password,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("failed_connect_to_target_db"))
+	}
+
+	// close connection after function end
+	defer conn.Close()
+
+	plan, err := buildPlan(conn, manifest, restore_req.Mode, restore_req.SpaceNames())
+	if err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("failed_to_build_restore_plan"))
+	}
+
+	// refuse a plan the user could not run
+	if err_resp := r.authorize(*plan, db_resp.Database, "restore_plan_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	plan_json, err := json.Marshal(plan)
+	if err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("failed_to_build_restore_plan"))
+	}
+	restore_new_model.Plan = sqlx_types.JSONText(plan_json)
+	restore_new_model.TotalTuples = plan.TotalTuples
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_restores (
+			id, restore_uuid, user_id, db_id, backup_id, file_path, mode,
+			batch_size, plan, total_tuples, job_status, created_by, created_at
+		) VALUES (
+			:id, :restore_uuid, :user_id, :db_id, :backup_id, :file_path, :mode,
+			:batch_size, :plan, :total_tuples, :job_status, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := r.DBPool.NamedExec(query, restore_new_model); err != nil {
+		custom_log.NewCustomLog("restore_plan_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_plan_failed", fmt.Errorf("error_create_restore"))
+	}
+	uploaded = false
+
+	return r.ShowOne(restore_new_model.RestoreUUID)
+}
+
+func (r *RestoreRepoImpl) Run(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	// get restore info
+	restore_resp, err_resp := r.ShowOne(restore_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	if restore_resp.Restore.JobStatus != constants.JobStatusPlanned {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("restore_already_started"))
+	}
+
+	var plan RestorePlan
+	if err := json.Unmarshal(restore_resp.Restore.Plan, &plan); err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("invalid_restore_plan"))
+	}
+
+	if plan.HasConflict {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("restore_plan_has_conflict"))
+	}
+
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).Accessible(restore_resp.Restore.DBUUID, constants.AccessLevelWrite, "restore_run_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// the access or the mode of the user may have changed since the plan
+	if err_resp := r.authorize(plan, db_resp.Database, "restore_run_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	// a restore on a database requiring approval waits for a second user
+	if db_resp.Database.RequiresApproval {
+		return r.queue(restore_resp.Restore, db_resp.Database)
+	}
+
+	return r.start(restore_resp.Restore, db_resp.Database, constants.JobStatusPlanned)
+}
+
+// Approve starts a restore that was waiting for approval, the checks were
+// made when it was queued
+func (r *RestoreRepoImpl) Approve(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	// get restore info
+	restore_resp, err_resp := r.load(restore_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).ShowOne(restore_resp.Restore.DBUUID)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	return r.start(restore_resp.Restore, db_resp.Database, constants.JobStatusAwaitingApproval)
+}
+
+// Reject closes a restore that was waiting for approval without running it
+func (r *RestoreRepoImpl) Reject(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		UPDATE tbl_restores SET
+			job_status = $1, updated_by = $2, updated_at = $3
+		WHERE deleted_at IS NULL
+		AND restore_uuid = $4
+		AND job_status = $5
+	`
+
+	// execute request
+	result, err := r.DBPool.Exec(query, constants.JobStatusRejected, r.UserContext.Id, utils.Now(), restore_uuid, constants.JobStatusAwaitingApproval)
+	if err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("error_database"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("restore_not_awaiting_approval"))
+	}
+
+	return r.load(restore_uuid)
+}
+
+// authorize checks the current user may run the plan on the database, a
+// restore writes tuples and the spaces it creates or recreates change the
+// schema
+func (r *RestoreRepoImpl) authorize(plan RestorePlan, db database.Database, message_id string) *responses.ErrorResponse {
+	statements := []tarantool_utils.Statement{{Keyword: "RESTORE", Kind: tarantool_utils.StatementDML}}
+	for _, space := range plan.Spaces {
+		if space.Action == ActionCreate || space.Action == ActionRecreate {
+			statements = append(statements, tarantool_utils.Statement{Keyword: "RESTORE", Kind: tarantool_utils.StatementDDL})
+			break
+		}
+	}
+
+	if err_detail := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).Authorize(db, statements); err_detail != nil {
+		custom_log.NewCustomLog(message_id, err_detail.Detail.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, err_detail.Err)
+	}
+
+	return nil
+}
+
+// queue marks the restore as awaiting approval and stores it as a pending
+// change of the database
+func (r *RestoreRepoImpl) queue(restore Restore, db database.Database) (*RestoreResponse, *responses.ErrorResponse) {
+	tx, err := r.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("error_database"))
+	}
+	defer tx.Rollback()
+
+	// guard against a concurrent run of the same restore
+	update_awaiting := `
+		UPDATE tbl_restores SET
+			job_status = $1, updated_by = $2, updated_at = $3
+		WHERE id = $4 AND job_status = $5
+	`
+	result, err := tx.Exec(update_awaiting, constants.JobStatusAwaitingApproval, r.UserContext.Id, utils.Now(), restore.ID, constants.JobStatusPlanned)
+	if err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("error_database"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("restore_already_started"))
+	}
+
+	statement := fmt.Sprintf("restore %s in %s mode", restore.RestoreUUID, restore.Mode)
+	if restore.BackupUUID != nil {
+		statement = fmt.Sprintf("%s from backup %s", statement, *restore.BackupUUID)
+	}
+
+	if _, err := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).QueueChange(db, database.ChangeKindRestore, statement, nil, &restore.ID, nil); err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("error_queue_change"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("error_database"))
+	}
+
+	return r.ShowOne(restore.RestoreUUID)
+}
+
+// start marks the restore as pending when it is still in from_status and
+// runs it in the background
+func (r *RestoreRepoImpl) start(restore Restore, db database.Database, from_status string) (*RestoreResponse, *responses.ErrorResponse) {
+	// mark as pending, guarding against a concurrent run of the same restore
+	update_pending := `
+		UPDATE tbl_restores SET
+			job_status = $1, updated_by = $2, updated_at = $3
+		WHERE id = $4 AND job_status = $5
+	`
+	result, err := r.DBPool.Exec(
+		update_pending,
+		constants.JobStatusPending,
+		r.UserContext.Id,
+		utils.Now(),
+		restore.ID,
+		from_status,
+	)
+	if err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("error_database"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_run_failed", fmt.Errorf("restore_already_started"))
+	}
+
+	// run the restore job in the background
+	go r.run(restore, db)
+
+	return r.load(restore.RestoreUUID)
+}
+
+func (r *RestoreRepoImpl) List(db_uuid string, list_req types.ListRequest) ([]Restore, int, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).Accessible(db_uuid, constants.AccessLevelWrite, "restore_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// prepare query
+	query := `
+		SELECT
+			rs.id, rs.restore_uuid, rs.user_id, rs.db_id, db.db_uuid, bk.backup_uuid,
+			rs.file_path, rs.mode, rs.batch_size, rs.plan, rs.progress, rs.total_tuples,
+			rs.restored_tuples, rs.job_status, rs.error_message, rs.started_at,
+			rs.finished_at, rs.created_by, rs.created_at
+		FROM tbl_restores rs
+		INNER JOIN tbl_users_databases db ON db.id = rs.db_id
+		LEFT JOIN tbl_backups bk ON bk.id = rs.backup_id
+		WHERE rs.deleted_at IS NULL
+		AND rs.db_id = $1
+	`
+
+	// execute query
+	var restores []Restore
+	total, err := postgres.SelectList(r.DBPool, &restores, query, []interface{}{db_resp.Database.ID}, list_req, restoreListFields)
+	if err != nil {
+		custom_log.NewCustomLog("restore_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("restore_list_failed", fmt.Errorf("get_restore_error"))
+	}
+
+	if restores == nil {
+		restores = []Restore{}
+	}
+
+	return restores, total, nil
+}
+
+func (r *RestoreRepoImpl) ShowOne(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	restore_resp, err_resp := r.load(restore_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// the restores of a database are for the users who may write to it
+	if _, err_resp := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).Accessible(restore_resp.Restore.DBUUID, constants.AccessLevelWrite, "restore_show_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return restore_resp, nil
+}
+
+// load returns the restore without checking the access of the current user,
+// an approved restore is started by its reviewer
+func (r *RestoreRepoImpl) load(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			rs.id, rs.restore_uuid, rs.user_id, rs.db_id, db.db_uuid, bk.backup_uuid,
+			rs.file_path, rs.mode, rs.batch_size, rs.plan, rs.progress, rs.total_tuples,
+			rs.restored_tuples, rs.job_status, rs.error_message, rs.started_at,
+			rs.finished_at, rs.created_by, rs.created_at
+		FROM tbl_restores rs
+		INNER JOIN tbl_users_databases db ON db.id = rs.db_id
+		LEFT JOIN tbl_backups bk ON bk.id = rs.backup_id
+		WHERE rs.deleted_at IS NULL
+		AND rs.restore_uuid = $1
+	`
+
+	// execute query
+	var restore Restore
+	if err := r.DBPool.Get(&restore, query, restore_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("restore_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("restore_show_failed", fmt.Errorf("no_restore_found"))
+		}
+		custom_log.NewCustomLog("restore_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("restore_show_failed", fmt.Errorf("get_restore_error"))
+	}
+
+	return &RestoreResponse{
+		Restore: restore,
+	}, nil
+}
+
+// run replays schema and data of the archive and records progress
+func (r *RestoreRepoImpl) run(restore Restore, db database.Database) {
+	update_running := `
+		UPDATE tbl_restores SET
+			job_status = $1, started_at = $2
+		WHERE id = $3
+	`
+	if _, err := r.DBPool.Exec(update_running, constants.JobStatusRunning, utils.Now(), restore.ID); err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+	}
+
+	restored, err := r.replay(restore, db)
+
+	// spaces created by the restore are expected changes, record them
+	if _, err := database.NewDatabaseRepoImpl(r.UserContext, r.DBPool).RecordSchema(db, database.SchemaSourceAPI); err != nil {
+		custom_log.NewCustomLog("schema_record_failed", err.Error(), "error")
+	}
+	if err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+
+		update_failed := `
+			UPDATE tbl_restores SET
+				job_status = $1, error_message = $2, restored_tuples = $3, finished_at = $4
+			WHERE id = $5
+		`
+		if _, err := r.DBPool.Exec(update_failed, constants.JobStatusFailed, err.Error(), restored, utils.Now(), restore.ID); err != nil {
+			custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+		}
+		return
+	}
+
+	update_completed := `
+		UPDATE tbl_restores SET
+			job_status = $1, restored_tuples = $2, finished_at = $3
+		WHERE id = $4
+	`
+	if _, err := r.DBPool.Exec(update_completed, constants.JobStatusCompleted, restored, utils.Now(), restore.ID); err != nil {
+		custom_log.NewCustomLog("restore_run_failed", err.Error(), "error")
+	}
+}
+
+func (r *RestoreRepoImpl) replay(restore Restore, db database.Database) (uint64, error) {
+	manifest, err := archive.ReadManifest(restore.FilePath)
+	if err != nil {
+		return 0, fmt.Errorf("read manifest: %w", err)
+	}
+
+	conn, err := tarantool_utils.ConnectTarantool(db.Host, int(db.Port), db.Username, db.Password)
+	if err != nil {
+		return 0, fmt.Errorf("connect target database: %w", err)
+	}
+	defer conn.Close()
+
+	var stored_plan RestorePlan
+	if err := json.Unmarshal(restore.Plan, &stored_plan); err != nil {
+		return 0, err
+	}
+
+	var selected []string
+	for _, space := range stored_plan.Spaces {
+		selected = append(selected, space.Name)
+	}
+
+	// the target may have changed since the plan was made, so plan again
+	plan, err := buildPlan(conn, manifest, restore.Mode, selected)
+	if err != nil {
+		return 0, fmt.Errorf("build plan: %w", err)
+	}
+	if plan.HasConflict {
+		return 0, fmt.Errorf("target schema conflicts with the archive")
+	}
+
+	manifest_spaces := make(map[string]archive.ManifestSpace, len(manifest.Spaces))
+	for _, space := range manifest.Spaces {
+		manifest_spaces[space.Schema.Name] = space
+	}
+
+	progress := make([]RestoreSpaceProgress, 0, len(plan.Spaces))
+	for _, space := range plan.Spaces {
+		progress = append(progress, RestoreSpaceProgress{
+			Name:       space.Name,
+			Action:     space.Action,
+			TupleCount: space.TupleCount,
+		})
+	}
+
+	write_mode := tarantool_utils.WriteModeInsert
+	switch restore.Mode {
+	case RestoreModeReplace:
+		write_mode = tarantool_utils.WriteModeReplace
+	case RestoreModeSkip:
+		write_mode = tarantool_utils.WriteModeSkip
+	}
+
+	var restored uint64
+	for i, space := range plan.Spaces {
+		manifest_space := manifest_spaces[space.Name]
+
+		switch space.Action {
+		case ActionSkip:
+			progress[i].Done = true
+			r.saveProgress(restore.ID, progress, restored)
+			continue
+		case ActionCreate, ActionRecreate:
+			if err := tarantool_utils.CreateSpace(conn, manifest_space.Schema, space.Action == ActionRecreate); err != nil {
+				return restored, fmt.Errorf("create space %s: %w", space.Name, err)
+			}
+		}
+
+		// replay tuples in batches
+		batch := make([][]interface{}, 0, restore.BatchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+
+			result, err := tarantool_utils.WriteTuples(conn, space.Name, batch, write_mode, true)
+			if err != nil {
+				return err
+			}
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("row %d: %s", result.Errors[0].Row, result.Errors[0].Error)
+			}
+
+			progress[i].Written += uint64(result.Written)
+			progress[i].Skipped += uint64(result.Skipped)
+			restored += uint64(result.Written)
+			r.saveProgress(restore.ID, progress, restored)
+
+			batch = batch[:0]
+			return nil
+		}
+
+		err := archive.ReadSpace(restore.FilePath, manifest, manifest_space, func(tuple []interface{}) error {
+			coerced, err := tarantool_utils.CoerceTuple(tuple, manifest_space.Schema.Format)
+			if err != nil {
+				return err
+			}
+
+			batch = append(batch, coerced)
+			if len(batch) >= restore.BatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			return restored, fmt.Errorf("restore space %s: %w", space.Name, err)
+		}
+
+		progress[i].Done = true
+		r.saveProgress(restore.ID, progress, restored)
+	}
+
+	return restored, nil
+}
+
+func (r *RestoreRepoImpl) saveProgress(restore_id uint64, progress []RestoreSpaceProgress, restored uint64) {
+	progress_json, err := json.Marshal(progress)
+	if err != nil {
+		custom_log.NewCustomLog("restore_progress_failed", err.Error(), "error")
+		return
+	}
+
+	update_progress := `
+		UPDATE tbl_restores SET
+			progress = $1, restored_tuples = $2
+		WHERE id = $3
+	`
+	if _, err := r.DBPool.Exec(update_progress, sqlx_types.JSONText(progress_json), restored, restore_id); err != nil {
+		custom_log.NewCustomLog("restore_progress_failed", err.Error(), "error")
+	}
+}
+
+// buildPlan compares the archive with the target database and decides what
+// to do with every space for the given mode
+func buildPlan(conn *pool.ConnectionPool, manifest *archive.Manifest, mode string, selected []string) (*RestorePlan, error) {
+	existing_spaces, err := tarantool_utils.GetUserSpaces(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]tarantool_utils.SpaceSchema, len(existing_spaces))
+	for _, space := range existing_spaces {
+		existing[space.Name] = space
+	}
+
+	wanted := make(map[string]bool, len(selected))
+	for _, name := range selected {
+		wanted[name] = true
+	}
+
+	plan := &RestorePlan{
+		Mode:   mode,
+		Spaces: []RestorePlanSpace{},
+	}
+
+	found := make(map[string]bool, len(selected))
+	for _, space := range manifest.Spaces {
+		if len(wanted) > 0 && !wanted[space.Schema.Name] {
+			continue
+		}
+		found[space.Schema.Name] = true
+
+		plan_space := RestorePlanSpace{
+			Name:       space.Schema.Name,
+			TupleCount: space.TupleCount,
+			Conflicts:  []string{},
+		}
+
+		target, ok := existing[space.Schema.Name]
+		if !ok {
+			plan_space.Action = ActionCreate
+		} else {
+			plan_space.ExistingTupleCount = target.TupleCount
+
+			diffs := tarantool_utils.CompareSchema(space.Schema, target)
+			switch {
+			case len(diffs) == 0:
+				plan_space.Action = ActionMerge
+			case mode == RestoreModeReplace:
+				plan_space.Action = ActionRecreate
+			case mode == RestoreModeSkip:
+				plan_space.Action = ActionSkip
+			default:
+				plan_space.Action = ActionConflict
+				plan.HasConflict = true
+			}
+
+			if diffs != nil {
+				plan_space.Conflicts = diffs
+			}
+		}
+
+		if plan_space.Action != ActionSkip && plan_space.Action != ActionConflict {
+			plan.TotalTuples += space.TupleCount
+		}
+
+		plan.Spaces = append(plan.Spaces, plan_space)
+	}
+
+	for _, name := range selected {
+		if !found[name] {
+			return nil, fmt.Errorf("space %s is not in the archive", name)
+		}
+	}
+
+	return plan, nil
+}
+
+// saveUpload stores an uploaded archive on the local disk
+func saveUpload(file *multipart.FileHeader, file_path string) error {
+	if err := os.MkdirAll(filepath.Dir(file_path), 0755); err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(file_path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,34 @@
+package restore
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type RestoreRoute struct {
+	App            *fiber.App
+	DBPool         *sqlx.DB
+	RestoreHandler *RestoreHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *RestoreRoute {
+	return &RestoreRoute{
+		App:            app,
+		DBPool:         db_pool,
+		RestoreHandler: NewRestoreHandler(db_pool),
+	}
+}
+
+func (r *RestoreRoute) RegisterRestoreRoute() *RestoreRoute {
+	restore := r.App.Group("/api/v1/front/restore")
+
+	restore.Post("/:db_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), r.RestoreHandler.Create)
+	restore.Get("/:db_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), r.RestoreHandler.List)
+	restore.Get("/job/:restore_uuid", middlewares.RequirePermission(constants.PermissionManageConnections), r.RestoreHandler.ShowOne)
+	restore.Post("/job/:restore_uuid/run", middlewares.RequirePermission(constants.PermissionManageConnections), r.RestoreHandler.Run)
+
+	return r
+}
@@ -0,0 +1,46 @@
+package restore
+
+import (
+	"mime/multipart"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RestoreServiceCreator interface {
+	Create(db_uuid string, restore_req RestoreNewRequest, file *multipart.FileHeader) (*RestoreResponse, *responses.ErrorResponse)
+	Run(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]Restore, int, *responses.ErrorResponse)
+	ShowOne(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse)
+}
+
+type RestoreService struct {
+	DBPool      *sqlx.DB
+	RestoreRepo *RestoreRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewRestoreService(us_ctx *types.UserContext, db_pool *sqlx.DB) *RestoreService {
+	return &RestoreService{
+		DBPool:      db_pool,
+		RestoreRepo: NewRestoreRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (r *RestoreService) Create(db_uuid string, restore_req RestoreNewRequest, file *multipart.FileHeader) (*RestoreResponse, *responses.ErrorResponse) {
+	return r.RestoreRepo.Create(db_uuid, restore_req, file)
+}
+
+func (r *RestoreService) Run(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	return r.RestoreRepo.Run(restore_uuid)
+}
+
+func (r *RestoreService) List(db_uuid string, list_req types.ListRequest) ([]Restore, int, *responses.ErrorResponse) {
+	return r.RestoreRepo.List(db_uuid, list_req)
+}
+
+func (r *RestoreService) ShowOne(restore_uuid string) (*RestoreResponse, *responses.ErrorResponse) {
+	return r.RestoreRepo.ShowOne(restore_uuid)
+}
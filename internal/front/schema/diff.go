@@ -0,0 +1,498 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+)
+
+const (
+	ObjectSpace     = "space"
+	ObjectIndex     = "index"
+	ObjectSequence  = "sequence"
+	ObjectFunction  = "function"
+	ObjectUser      = "user"
+	ObjectPrivilege = "privilege"
+)
+
+type SchemaChange struct {
+	ObjectType string   `json:"object_type"`
+	Name       string   `json:"name"`
+	Details    []string `json:"details,omitempty"`
+}
+
+type SchemaDiff struct {
+	Additions []SchemaChange `json:"additions"`
+	Removals  []SchemaChange `json:"removals"`
+	Changes   []SchemaChange `json:"changes"`
+	Script    string         `json:"script"`
+}
+
+// migration script statements are grouped so objects are created before
+// they are referenced and dropped after their references are gone
+type migrationScript struct {
+	create   []string
+	alter    []string
+	grant    []string
+	revoke   []string
+	drop     []string
+	comments []string
+}
+
+func (m *migrationScript) String() string {
+	var lines []string
+	lines = append(lines, m.comments...)
+	for _, section := range [][]string{m.revoke, m.create, m.alter, m.grant, m.drop} {
+		lines = append(lines, section...)
+	}
+	if len(lines) == 0 {
+		return "-- schemas are identical, nothing to migrate\n"
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// diffSchema lists what changed from the base schema to the desired one and
+// builds the lua script that brings a database at base in line with desired
+func diffSchema(base *tarantool_utils.SchemaSnapshot, desired *tarantool_utils.SchemaSnapshot) SchemaDiff {
+	diff := SchemaDiff{
+		Additions: []SchemaChange{},
+		Removals:  []SchemaChange{},
+		Changes:   []SchemaChange{},
+	}
+	script := &migrationScript{}
+
+	diffSpaces(base.Spaces, desired.Spaces, &diff, script)
+	diffSequences(base.Sequences, desired.Sequences, &diff, script)
+	diffFunctions(base.Functions, desired.Functions, &diff, script)
+	diffUsers(base.Users, desired.Users, &diff, script)
+
+	diff.Script = script.String()
+	return diff
+}
+
+func diffSpaces(base []tarantool_utils.SpaceSchema, desired []tarantool_utils.SpaceSchema, diff *SchemaDiff, script *migrationScript) {
+	base_by_name := make(map[string]tarantool_utils.SpaceSchema, len(base))
+	for _, space := range base {
+		base_by_name[space.Name] = space
+	}
+	desired_by_name := make(map[string]bool, len(desired))
+
+	for _, space := range desired {
+		desired_by_name[space.Name] = true
+
+		existing, ok := base_by_name[space.Name]
+		if !ok {
+			diff.Additions = append(diff.Additions, SchemaChange{ObjectType: ObjectSpace, Name: space.Name})
+			script.create = append(script.create, createSpaceLua(space))
+			continue
+		}
+
+		var details []string
+		if existing.Engine != space.Engine {
+			details = append(details, fmt.Sprintf("engine %s -> %s", existing.Engine, space.Engine))
+			script.comments = append(script.comments, fmt.Sprintf(
+				"-- space %s: changing the engine from %s to %s requires recreating the space",
+				space.Name, existing.Engine, space.Engine,
+			))
+		}
+		if !reflect.DeepEqual(nonNilFields(existing.Format), nonNilFields(space.Format)) {
+			details = append(details, "format changed")
+			script.alter = append(script.alter, fmt.Sprintf("box.space[%s]:format(%s)", luaString(space.Name), luaFormat(space.Format)))
+		}
+		if len(details) > 0 {
+			diff.Changes = append(diff.Changes, SchemaChange{ObjectType: ObjectSpace, Name: space.Name, Details: details})
+		}
+
+		diffIndexes(space.Name, existing.Indexes, space.Indexes, diff, script)
+	}
+
+	for _, space := range base {
+		if !desired_by_name[space.Name] {
+			diff.Removals = append(diff.Removals, SchemaChange{ObjectType: ObjectSpace, Name: space.Name})
+			script.drop = append(script.drop, fmt.Sprintf("box.space[%s]:drop()", luaString(space.Name)))
+		}
+	}
+}
+
+func diffIndexes(space_name string, base []tarantool_utils.IndexSchema, desired []tarantool_utils.IndexSchema, diff *SchemaDiff, script *migrationScript) {
+	base_by_name := make(map[string]tarantool_utils.IndexSchema, len(base))
+	for _, index := range base {
+		base_by_name[index.Name] = index
+	}
+	desired_by_name := make(map[string]bool, len(desired))
+
+	for _, index := range desired {
+		desired_by_name[index.Name] = true
+		name := space_name + "." + index.Name
+
+		existing, ok := base_by_name[index.Name]
+		if !ok {
+			diff.Additions = append(diff.Additions, SchemaChange{ObjectType: ObjectIndex, Name: name})
+			script.alter = append(script.alter, fmt.Sprintf(
+				"box.space[%s]:create_index(%s, %s)",
+				luaString(space_name), luaString(index.Name), luaIndexOptions(index),
+			))
+			continue
+		}
+
+		var details []string
+		if existing.Type != index.Type {
+			details = append(details, fmt.Sprintf("type %s -> %s", existing.Type, index.Type))
+		}
+		if existing.Unique != index.Unique {
+			details = append(details, fmt.Sprintf("unique %t -> %t", existing.Unique, index.Unique))
+		}
+		if !reflect.DeepEqual(nonNilParts(existing.Parts), nonNilParts(index.Parts)) {
+			details = append(details, "parts changed")
+		}
+		if len(details) > 0 {
+			diff.Changes = append(diff.Changes, SchemaChange{ObjectType: ObjectIndex, Name: name, Details: details})
+			script.alter = append(script.alter, fmt.Sprintf(
+				"box.space[%s].index[%s]:alter(%s)",
+				luaString(space_name), luaString(index.Name), luaIndexOptions(index),
+			))
+		}
+	}
+
+	// secondary indexes are dropped before the primary one
+	for i := len(base) - 1; i >= 0; i-- {
+		index := base[i]
+		if !desired_by_name[index.Name] {
+			diff.Removals = append(diff.Removals, SchemaChange{ObjectType: ObjectIndex, Name: space_name + "." + index.Name})
+			script.alter = append(script.alter, fmt.Sprintf(
+				"box.space[%s].index[%s]:drop()",
+				luaString(space_name), luaString(index.Name),
+			))
+		}
+	}
+}
+
+func diffSequences(base []tarantool_utils.SequenceSchema, desired []tarantool_utils.SequenceSchema, diff *SchemaDiff, script *migrationScript) {
+	base_by_name := make(map[string]tarantool_utils.SequenceSchema, len(base))
+	for _, sequence := range base {
+		base_by_name[sequence.Name] = sequence
+	}
+	desired_by_name := make(map[string]bool, len(desired))
+
+	for _, sequence := range desired {
+		desired_by_name[sequence.Name] = true
+
+		existing, ok := base_by_name[sequence.Name]
+		if !ok {
+			diff.Additions = append(diff.Additions, SchemaChange{ObjectType: ObjectSequence, Name: sequence.Name})
+			script.create = append(script.create, fmt.Sprintf(
+				"box.schema.sequence.create(%s, %s)",
+				luaString(sequence.Name), luaSequenceOptions(sequence),
+			))
+			continue
+		}
+
+		if existing != sequence {
+			diff.Changes = append(diff.Changes, SchemaChange{ObjectType: ObjectSequence, Name: sequence.Name, Details: []string{"options changed"}})
+			script.alter = append(script.alter, fmt.Sprintf(
+				"box.sequence[%s]:alter(%s)",
+				luaString(sequence.Name), luaSequenceOptions(sequence),
+			))
+		}
+	}
+
+	for _, sequence := range base {
+		if !desired_by_name[sequence.Name] {
+			diff.Removals = append(diff.Removals, SchemaChange{ObjectType: ObjectSequence, Name: sequence.Name})
+			script.drop = append(script.drop, fmt.Sprintf("box.sequence[%s]:drop()", luaString(sequence.Name)))
+		}
+	}
+}
+
+func diffFunctions(base []tarantool_utils.FunctionSchema, desired []tarantool_utils.FunctionSchema, diff *SchemaDiff, script *migrationScript) {
+	base_by_name := make(map[string]tarantool_utils.FunctionSchema, len(base))
+	for _, function := range base {
+		base_by_name[function.Name] = function
+	}
+	desired_by_name := make(map[string]bool, len(desired))
+
+	for _, function := range desired {
+		desired_by_name[function.Name] = true
+
+		existing, ok := base_by_name[function.Name]
+		if !ok {
+			diff.Additions = append(diff.Additions, SchemaChange{ObjectType: ObjectFunction, Name: function.Name})
+			script.create = append(script.create, fmt.Sprintf(
+				"box.schema.func.create(%s, %s)",
+				luaString(function.Name), luaFunctionOptions(function),
+			))
+			continue
+		}
+
+		// functions cannot be altered, they are dropped and created again
+		if !reflect.DeepEqual(nonNilFunction(existing), nonNilFunction(function)) {
+			diff.Changes = append(diff.Changes, SchemaChange{ObjectType: ObjectFunction, Name: function.Name, Details: []string{"definition changed"}})
+			script.alter = append(script.alter,
+				fmt.Sprintf("box.schema.func.drop(%s)", luaString(function.Name)),
+				fmt.Sprintf("box.schema.func.create(%s, %s)", luaString(function.Name), luaFunctionOptions(function)),
+			)
+		}
+	}
+
+	for _, function := range base {
+		if !desired_by_name[function.Name] {
+			diff.Removals = append(diff.Removals, SchemaChange{ObjectType: ObjectFunction, Name: function.Name})
+			script.drop = append(script.drop, fmt.Sprintf("box.schema.func.drop(%s, {if_exists = true})", luaString(function.Name)))
+		}
+	}
+}
+
+func diffUsers(base []tarantool_utils.UserSchema, desired []tarantool_utils.UserSchema, diff *SchemaDiff, script *migrationScript) {
+	base_by_name := make(map[string]tarantool_utils.UserSchema, len(base))
+	for _, user := range base {
+		base_by_name[user.Name] = user
+	}
+	desired_by_name := make(map[string]bool, len(desired))
+
+	for _, user := range desired {
+		desired_by_name[user.Name] = true
+
+		existing, ok := base_by_name[user.Name]
+		if !ok || existing.Type != user.Type {
+			if ok {
+				diff.Changes = append(diff.Changes, SchemaChange{ObjectType: ObjectUser, Name: user.Name, Details: []string{fmt.Sprintf("type %s -> %s", existing.Type, user.Type)}})
+				script.revoke = append(script.revoke, fmt.Sprintf("box.schema.%s.drop(%s)", existing.Type, luaString(user.Name)))
+			} else {
+				diff.Additions = append(diff.Additions, SchemaChange{ObjectType: ObjectUser, Name: user.Name})
+			}
+
+			script.create = append(script.create, fmt.Sprintf("box.schema.%s.create(%s, {if_not_exists = true})", user.Type, luaString(user.Name)))
+			if user.Type == "user" {
+				script.comments = append(script.comments, fmt.Sprintf("-- user %s: passwords are not part of the schema, set it with box.schema.user.passwd", user.Name))
+			}
+			existing = tarantool_utils.UserSchema{Name: user.Name, Type: user.Type}
+		}
+
+		diffPrivileges(user, existing.Privileges, user.Privileges, diff, script)
+	}
+
+	for _, user := range base {
+		if !desired_by_name[user.Name] {
+			diff.Removals = append(diff.Removals, SchemaChange{ObjectType: ObjectUser, Name: user.Name})
+			script.drop = append(script.drop, fmt.Sprintf("box.schema.%s.drop(%s, {if_exists = true})", user.Type, luaString(user.Name)))
+		}
+	}
+}
+
+func diffPrivileges(user tarantool_utils.UserSchema, base []tarantool_utils.PrivilegeSchema, desired []tarantool_utils.PrivilegeSchema, diff *SchemaDiff, script *migrationScript) {
+	key := func(privilege tarantool_utils.PrivilegeSchema) string {
+		return privilege.ObjectType + ":" + privilege.ObjectName
+	}
+
+	base_by_key := make(map[string]tarantool_utils.PrivilegeSchema, len(base))
+	for _, privilege := range base {
+		base_by_key[key(privilege)] = privilege
+	}
+	desired_by_key := make(map[string]tarantool_utils.PrivilegeSchema, len(desired))
+	for _, privilege := range desired {
+		desired_by_key[key(privilege)] = privilege
+	}
+
+	var keys []string
+	for k := range base_by_key {
+		keys = append(keys, k)
+	}
+	for k := range desired_by_key {
+		if _, ok := base_by_key[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		granted := setOf(base_by_key[k].Privileges)
+		wanted := setOf(desired_by_key[k].Privileges)
+
+		var grant, revoke []string
+		for privilege := range wanted {
+			if !granted[privilege] {
+				grant = append(grant, privilege)
+			}
+		}
+		for privilege := range granted {
+			if !wanted[privilege] {
+				revoke = append(revoke, privilege)
+			}
+		}
+		if len(grant) == 0 && len(revoke) == 0 {
+			continue
+		}
+		sort.Strings(grant)
+		sort.Strings(revoke)
+
+		privilege := desired_by_key[k]
+		if _, ok := desired_by_key[k]; !ok {
+			privilege = base_by_key[k]
+		}
+		change := SchemaChange{ObjectType: ObjectPrivilege, Name: strings.TrimSuffix(user.Name+":"+k, ":")}
+
+		if len(grant) > 0 {
+			change.Details = append(change.Details, "grant "+strings.Join(grant, ","))
+			script.grant = append(script.grant, fmt.Sprintf(
+				"box.schema.%s.grant(%s, %s, %s, %s, {if_not_exists = true})",
+				user.Type, luaString(user.Name), luaString(strings.Join(grant, ",")),
+				luaString(privilege.ObjectType), luaObjectName(privilege),
+			))
+		}
+		if len(revoke) > 0 {
+			change.Details = append(change.Details, "revoke "+strings.Join(revoke, ","))
+			script.revoke = append(script.revoke, fmt.Sprintf(
+				"box.schema.%s.revoke(%s, %s, %s, %s, {if_exists = true})",
+				user.Type, luaString(user.Name), luaString(strings.Join(revoke, ",")),
+				luaString(privilege.ObjectType), luaObjectName(privilege),
+			))
+		}
+
+		switch {
+		case len(base_by_key[k].Privileges) == 0:
+			diff.Additions = append(diff.Additions, change)
+		case len(desired_by_key[k].Privileges) == 0:
+			diff.Removals = append(diff.Removals, change)
+		default:
+			diff.Changes = append(diff.Changes, change)
+		}
+	}
+}
+
+func setOf(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func nonNilFields(fields []tarantool_utils.SpaceField) []tarantool_utils.SpaceField {
+	if fields == nil {
+		return []tarantool_utils.SpaceField{}
+	}
+	return fields
+}
+
+func nonNilParts(parts []tarantool_utils.IndexPart) []tarantool_utils.IndexPart {
+	if parts == nil {
+		return []tarantool_utils.IndexPart{}
+	}
+	return parts
+}
+
+func nonNilFunction(function tarantool_utils.FunctionSchema) tarantool_utils.FunctionSchema {
+	if function.ParamList == nil {
+		function.ParamList = []string{}
+	}
+	if function.Exports == nil {
+		function.Exports = []string{}
+	}
+	return function
+}
+
+// lua literal helpers used to build the migration script
+
+func luaString(value string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				b.WriteString(`\` + strconv.Itoa(int(c)))
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+func luaStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = luaString(value)
+	}
+	return "{" + strings.Join(quoted, ", ") + "}"
+}
+
+func luaObjectName(privilege tarantool_utils.PrivilegeSchema) string {
+	if privilege.ObjectType == "universe" || privilege.ObjectName == "" {
+		return "nil"
+	}
+	return luaString(privilege.ObjectName)
+}
+
+func luaFormat(fields []tarantool_utils.SpaceField) string {
+	entries := make([]string, len(fields))
+	for i, field := range fields {
+		entries[i] = fmt.Sprintf("{name = %s, type = %s, is_nullable = %t}", luaString(field.Name), luaString(field.Type), field.IsNullable)
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func luaIndexOptions(index tarantool_utils.IndexSchema) string {
+	parts := make([]string, len(index.Parts))
+	for i, part := range index.Parts {
+		entry := fmt.Sprintf("{field = %d, type = %s, is_nullable = %t", part.Field, luaString(part.Type), part.IsNullable)
+		if part.Path != "" {
+			entry += ", path = " + luaString(part.Path)
+		}
+		parts[i] = entry + "}"
+	}
+	return fmt.Sprintf("{type = %s, unique = %t, parts = {%s}}", luaString(index.Type), index.Unique, strings.Join(parts, ", "))
+}
+
+func createSpaceLua(space tarantool_utils.SpaceSchema) string {
+	lines := []string{fmt.Sprintf(
+		"box.schema.space.create(%s, {engine = %s, format = %s})",
+		luaString(space.Name), luaString(space.Engine), luaFormat(space.Format),
+	)}
+	for _, index := range space.Indexes {
+		lines = append(lines, fmt.Sprintf(
+			"box.space[%s]:create_index(%s, %s)",
+			luaString(space.Name), luaString(index.Name), luaIndexOptions(index),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func luaSequenceOptions(sequence tarantool_utils.SequenceSchema) string {
+	return fmt.Sprintf(
+		"{step = %d, min = %d, max = %d, start = %d, cache = %d, cycle = %t}",
+		sequence.Step, sequence.Min, sequence.Max, sequence.Start, sequence.Cache, sequence.Cycle,
+	)
+}
+
+func luaFunctionOptions(function tarantool_utils.FunctionSchema) string {
+	options := []string{
+		"language = " + luaString(function.Language),
+		fmt.Sprintf("setuid = %t", function.Setuid),
+		fmt.Sprintf("is_deterministic = %t", function.IsDeterministic),
+		fmt.Sprintf("is_sandboxed = %t", function.IsSandboxed),
+		"returns = " + luaString(function.Returns),
+	}
+	if function.Body != "" {
+		options = append(options, "body = "+luaString(function.Body))
+	}
+	if len(function.ParamList) > 0 {
+		options = append(options, "param_list = "+luaStringList(function.ParamList))
+	}
+	if len(function.Exports) > 0 {
+		options = append(options, "exports = "+luaStringList(function.Exports))
+	}
+	return "{" + strings.Join(options, ", ") + "}"
+}
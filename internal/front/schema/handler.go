@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"errors"
+	"net/http"
+	"tarantool-admin-api/internal/front/database"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type SchemaHandler struct {
+	DBPool        *sqlx.DB
+	SchemaService func(c *fiber.Ctx) *SchemaService
+}
+
+func NewSchemaHandler(db_pool *sqlx.DB) *SchemaHandler {
+	return &SchemaHandler{
+		DBPool: db_pool,
+		SchemaService: func(c *fiber.Ctx) *SchemaService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewSchemaService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (s *SchemaHandler) Snapshot(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	resp, err := s.SchemaService(c).Snapshot(db_uuid, database.SchemaSourceManual)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-10000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("schema_snapshot_success", nil, c),
+			10000,
+			resp,
+		),
+	)
+}
+
+func (s *SchemaHandler) List(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, schemaListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("schema_snapshot_list_failed", nil, c),
+				-10001,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := s.SchemaService(c).List(db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-10001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("schema_snapshot_list_success", nil, c),
+			10001,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (s *SchemaHandler) ShowOne(c *fiber.Ctx) error {
+	snapshot_uuid := c.Params("snapshot_uuid")
+
+	resp, err := s.SchemaService(c).ShowOne(snapshot_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-10002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("schema_snapshot_show_success", nil, c),
+			10002,
+			resp,
+		),
+	)
+}
+
+func (s *SchemaHandler) Diff(c *fiber.Ctx) error {
+	db_uuid := c.Params("db_uuid")
+
+	var diff_req SchemaDiffRequest
+	v := utils.NewValidator()
+	if err := diff_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("schema_diff_failed", nil, c),
+				-10003,
+				err,
+			),
+		)
+	}
+
+	resp, err := s.SchemaService(c).Diff(db_uuid, diff_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-10003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("schema_diff_success", nil, c),
+			10003,
+			resp,
+		),
+	)
+}
@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"errors"
+	custom_log "tarantool-admin-api/pkg/logs"
+	"tarantool-admin-api/pkg/postgres"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+type SchemaSnapshot struct {
+	ID            uint64              `json:"-" db:"id"`
+	SnapshotUUID  string              `json:"snapshot_uuid" db:"snapshot_uuid"`
+	UserID        uint64              `json:"-" db:"user_id"`
+	DBID          uint64              `json:"-" db:"db_id"`
+	DBUUID        string              `json:"db_uuid" db:"db_uuid"`
+	Version       int                 `json:"version" db:"version"`
+	Source        string              `json:"source" db:"source"`
+	Drift         bool                `json:"drift" db:"drift"`
+	Checksum      string              `json:"checksum" db:"checksum"`
+	SchemaData    sqlx_types.JSONText `json:"schema" db:"schema_data"`
+	CheckedAt     *time.Time          `json:"checked_at" db:"checked_at"`
+	CreatedBy     uint64              `json:"-" db:"created_by"`
+	CreatedByName *string             `json:"created_by" db:"created_by_name"`
+	CreatedAt     time.Time           `json:"created_at" db:"created_at"`
+}
+
+// schemaListFields are the columns the schema snapshot list filters and sorts on
+var schemaListFields = postgres.ListFields{
+	Filter:      []string{"snapshot_uuid", "version", "source", "drift", "created_by", "checked_at", "created_at"},
+	Sort:        []string{"version", "source", "checked_at", "created_at"},
+	Columns:     map[string]string{"created_by": "created_by_name"},
+	DefaultSort: "version DESC",
+}
+
+// schema decodes the stored snapshot
+func (s *SchemaSnapshot) schema() (*tarantool_utils.SchemaSnapshot, error) {
+	var schema tarantool_utils.SchemaSnapshot
+	if err := s.SchemaData.Unmarshal(&schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+type SchemaSnapshotResponse struct {
+	Snapshot SchemaSnapshot `json:"snapshot"`
+}
+
+type SchemaDiffRequest struct {
+	AgainstDBUUID       string `json:"against_db_uuid" validate:"required_without=AgainstSnapshotUUID,excluded_with=AgainstSnapshotUUID,omitempty,uuid"`
+	AgainstSnapshotUUID string `json:"against_snapshot_uuid" validate:"required_without=AgainstDBUUID,omitempty,uuid"`
+}
+
+func (s *SchemaDiffRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(s); err != nil {
+		custom_log.NewCustomLog("schema_diff_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(s, c); err != nil {
+		custom_log.NewCustomLog("schema_diff_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type SchemaDiffResult struct {
+	DBUUID              string     `json:"db_uuid"`
+	AgainstDBUUID       *string    `json:"against_db_uuid"`
+	AgainstSnapshotUUID *string    `json:"against_snapshot_uuid"`
+	Identical           bool       `json:"identical"`
+	Diff                SchemaDiff `json:"diff"`
+}
+
+type SchemaDiffResponse struct {
+	SchemaDiff SchemaDiffResult `json:"schema_diff"`
+}
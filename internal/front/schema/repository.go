@@ -0,0 +1,199 @@
+package schema
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SchemaRepo interface {
+	Snapshot(db_uuid string, source string) (*SchemaSnapshotResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]SchemaSnapshot, int, *responses.ErrorResponse)
+	ShowOne(snapshot_uuid string) (*SchemaSnapshotResponse, *responses.ErrorResponse)
+	Diff(db_uuid string, diff_req SchemaDiffRequest) (*SchemaDiffResponse, *responses.ErrorResponse)
+}
+
+type SchemaRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewSchemaRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *SchemaRepoImpl {
+	return &SchemaRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectSnapshotQuery = `
+	SELECT
+		ss.id, ss.snapshot_uuid, ss.user_id, ss.db_id, db.db_uuid, ss.version, ss.source,
+		ss.drift, ss.checksum, ss.schema_data, ss.checked_at, ss.created_by,
+		us.user_name AS created_by_name, ss.created_at
+	FROM tbl_schema_snapshots ss
+	INNER JOIN tbl_users_databases db ON db.id = ss.db_id
+	LEFT JOIN tbl_users us ON us.id = ss.created_by
+	WHERE ss.deleted_at IS NULL
+	AND db.deleted_at IS NULL
+`
+
+// Snapshot records the current schema of the database, a new version is only
+// stored when the schema changed since the latest snapshot
+func (s *SchemaRepoImpl) Snapshot(db_uuid string, source string) (*SchemaSnapshotResponse, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(s.UserContext, s.DBPool).Accessible(db_uuid, constants.AccessLevelRead, "schema_snapshot_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	snapshot_uuid, err := database.NewDatabaseRepoImpl(s.UserContext, s.DBPool).RecordSchema(db_resp.Database, source)
+	if err != nil {
+		custom_log.NewCustomLog("schema_snapshot_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("schema_snapshot_failed", fmt.Errorf("error_create_snapshot"))
+	}
+
+	return s.ShowOne(snapshot_uuid)
+}
+
+func (s *SchemaRepoImpl) List(db_uuid string, list_req types.ListRequest) ([]SchemaSnapshot, int, *responses.ErrorResponse) {
+	// get database info
+	db_resp, err_resp := database.NewDatabaseRepoImpl(s.UserContext, s.DBPool).Accessible(db_uuid, constants.AccessLevelRead, "schema_snapshot_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// prepare query
+	query := selectSnapshotQuery + `
+		AND ss.db_id = $1
+	`
+
+	// execute query
+	var snapshots []SchemaSnapshot
+	total, err := postgres.SelectList(s.DBPool, &snapshots, query, []interface{}{db_resp.Database.ID}, list_req, schemaListFields)
+	if err != nil {
+		custom_log.NewCustomLog("schema_snapshot_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("schema_snapshot_list_failed", fmt.Errorf("get_snapshot_error"))
+	}
+
+	if snapshots == nil {
+		snapshots = []SchemaSnapshot{}
+	}
+
+	return snapshots, total, nil
+}
+
+func (s *SchemaRepoImpl) ShowOne(snapshot_uuid string) (*SchemaSnapshotResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectSnapshotQuery + `
+		AND ss.snapshot_uuid = $1
+	`
+
+	// execute query
+	var snapshot SchemaSnapshot
+	if err := s.DBPool.Get(&snapshot, query, snapshot_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("schema_snapshot_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("schema_snapshot_show_failed", fmt.Errorf("no_snapshot_found"))
+		}
+		custom_log.NewCustomLog("schema_snapshot_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("schema_snapshot_show_failed", fmt.Errorf("get_snapshot_error"))
+	}
+
+	// the snapshot holds the schema of its database
+	if _, err_resp := database.NewDatabaseRepoImpl(s.UserContext, s.DBPool).Accessible(snapshot.DBUUID, constants.AccessLevelRead, "schema_snapshot_show_failed"); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return &SchemaSnapshotResponse{
+		Snapshot: snapshot,
+	}, nil
+}
+
+// Diff compares the live schema of the database with another database or a
+// stored snapshot, the generated script brings the other side in line
+func (s *SchemaRepoImpl) Diff(db_uuid string, diff_req SchemaDiffRequest) (*SchemaDiffResponse, *responses.ErrorResponse) {
+	// get database info
+	db_repo := database.NewDatabaseRepoImpl(s.UserContext, s.DBPool)
+	db_resp, err_resp := db_repo.Accessible(db_uuid, constants.AccessLevelRead, "schema_diff_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	desired, err_resp := liveSchema(db_resp.Database, "schema_diff_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	result := SchemaDiffResult{
+		DBUUID: db_uuid,
+	}
+
+	var base *tarantool_utils.SchemaSnapshot
+	if diff_req.AgainstSnapshotUUID != "" {
+		snapshot_resp, err_resp := s.ShowOne(diff_req.AgainstSnapshotUUID)
+		if err_resp != nil {
+			return nil, err_resp
+		}
+
+		schema, err := snapshot_resp.Snapshot.schema()
+		if err != nil {
+			custom_log.NewCustomLog("schema_diff_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("schema_diff_failed", fmt.Errorf("get_snapshot_error"))
+		}
+		base = schema
+		result.AgainstSnapshotUUID = &diff_req.AgainstSnapshotUUID
+	} else {
+		against_resp, err_resp := db_repo.Accessible(diff_req.AgainstDBUUID, constants.AccessLevelRead, "schema_diff_failed")
+		if err_resp != nil {
+			return nil, err_resp
+		}
+
+		schema, err_resp := liveSchema(against_resp.Database, "schema_diff_failed")
+		if err_resp != nil {
+			return nil, err_resp
+		}
+		base = schema
+		result.AgainstDBUUID = &diff_req.AgainstDBUUID
+	}
+
+	result.Diff = diffSchema(base, desired)
+	result.Identical = len(result.Diff.Additions) == 0 && len(result.Diff.Removals) == 0 && len(result.Diff.Changes) == 0
+
+	return &SchemaDiffResponse{
+		SchemaDiff: result,
+	}, nil
+}
+
+// liveSchema connects the database and reads its current schema
+func liveSchema(db database.Database, message_id string) (*tarantool_utils.SchemaSnapshot, *responses.ErrorResponse) {
+	conn, err := tarantool_utils.ConnectTarantool(db.Host, int(db.Port), db.Username, db.Password)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("failed_connect_to_target_db"))
+	}
+	defer conn.Close()
+
+	schema, err := tarantool_utils.GetSchema(conn)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("failed_to_read_schema"))
+	}
+
+	return schema, nil
+}
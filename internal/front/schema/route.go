@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type SchemaRoute struct {
+	App           *fiber.App
+	DBPool        *sqlx.DB
+	SchemaHandler *SchemaHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *SchemaRoute {
+	return &SchemaRoute{
+		App:           app,
+		DBPool:        db_pool,
+		SchemaHandler: NewSchemaHandler(db_pool),
+	}
+}
+
+func (s *SchemaRoute) RegisterSchemaRoute() *SchemaRoute {
+	schema := s.App.Group("/api/v1/front/schema")
+
+	schema.Get("/snapshot/:snapshot_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), s.SchemaHandler.ShowOne)
+	schema.Post("/:db_uuid/snapshot", middlewares.RequirePermission(constants.PermissionRunSelect), s.SchemaHandler.Snapshot)
+	schema.Get("/:db_uuid/snapshot", middlewares.RequirePermission(constants.PermissionRunSelect), s.SchemaHandler.List)
+	schema.Post("/:db_uuid/diff", middlewares.RequirePermission(constants.PermissionRunSelect), s.SchemaHandler.Diff)
+
+	return s
+}
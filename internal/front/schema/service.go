@@ -0,0 +1,45 @@
+package schema
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SchemaServiceCreator interface {
+	Snapshot(db_uuid string, source string) (*SchemaSnapshotResponse, *responses.ErrorResponse)
+	List(db_uuid string, list_req types.ListRequest) ([]SchemaSnapshot, int, *responses.ErrorResponse)
+	ShowOne(snapshot_uuid string) (*SchemaSnapshotResponse, *responses.ErrorResponse)
+	Diff(db_uuid string, diff_req SchemaDiffRequest) (*SchemaDiffResponse, *responses.ErrorResponse)
+}
+
+type SchemaService struct {
+	DBPool      *sqlx.DB
+	SchemaRepo  *SchemaRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewSchemaService(us_ctx *types.UserContext, db_pool *sqlx.DB) *SchemaService {
+	return &SchemaService{
+		DBPool:      db_pool,
+		SchemaRepo:  NewSchemaRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (s *SchemaService) Snapshot(db_uuid string, source string) (*SchemaSnapshotResponse, *responses.ErrorResponse) {
+	return s.SchemaRepo.Snapshot(db_uuid, source)
+}
+
+func (s *SchemaService) List(db_uuid string, list_req types.ListRequest) ([]SchemaSnapshot, int, *responses.ErrorResponse) {
+	return s.SchemaRepo.List(db_uuid, list_req)
+}
+
+func (s *SchemaService) ShowOne(snapshot_uuid string) (*SchemaSnapshotResponse, *responses.ErrorResponse) {
+	return s.SchemaRepo.ShowOne(snapshot_uuid)
+}
+
+func (s *SchemaService) Diff(db_uuid string, diff_req SchemaDiffRequest) (*SchemaDiffResponse, *responses.ErrorResponse) {
+	return s.SchemaRepo.Diff(db_uuid, diff_req)
+}
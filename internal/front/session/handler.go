@@ -0,0 +1,137 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionHandler struct {
+	DBPool         *sqlx.DB
+	SessionService func(c *fiber.Ctx) *SessionService
+}
+
+func NewSessionHandler(db_pool *sqlx.DB) *SessionHandler {
+	return &SessionHandler{
+		DBPool: db_pool,
+		SessionService: func(c *fiber.Ctx) *SessionService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewSessionService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (s *SessionHandler) List(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, sessionListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("session_list_failed", nil, c),
+				-15000,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := s.SessionService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-15000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("session_list_success", nil, c),
+			15000,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (s *SessionHandler) Revoke(c *fiber.Ctx) error {
+	session_uuid := c.Params("session_uuid")
+
+	resp, err := s.SessionService(c).Revoke(session_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-15001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("session_revoke_success", nil, c),
+			15001,
+			resp,
+		),
+	)
+}
+
+func (s *SessionHandler) Logout(c *fiber.Ctx) error {
+	resp, err := s.SessionService(c).Logout()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-15002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("logout_success", nil, c),
+			15002,
+			resp,
+		),
+	)
+}
+
+func (s *SessionHandler) LogoutAll(c *fiber.Ctx) error {
+	resp, err := s.SessionService(c).LogoutAll()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-15003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("logout_all_success", nil, c),
+			15003,
+			resp,
+		),
+	)
+}
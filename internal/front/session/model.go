@@ -0,0 +1,29 @@
+package session
+
+import (
+	"tarantool-admin-api/pkg/postgres"
+	"time"
+)
+
+type Session struct {
+	ID               uint64    `json:"-" db:"id"`
+	SessionUUID      string    `json:"session_uuid" db:"session_uuid"`
+	Device           string    `json:"device" db:"device"`
+	UserAgent        *string   `json:"user_agent" db:"user_agent"`
+	IP               *string   `json:"ip" db:"ip"`
+	Current          bool      `json:"current" db:"-"`
+	LastSeenAt       time.Time `json:"last_seen_at" db:"last_seen_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at" db:"refresh_expires_at"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// sessionListFields are the columns the session list filters and sorts on
+var sessionListFields = postgres.ListFields{
+	Filter:      []string{"device", "ip", "last_seen_at", "created_at"},
+	Sort:        []string{"device", "last_seen_at", "created_at"},
+	DefaultSort: "last_seen_at DESC",
+}
+
+type SessionRevokeResponse struct {
+	Revoked int64 `json:"revoked"`
+}
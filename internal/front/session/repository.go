@@ -0,0 +1,142 @@
+package session
+
+import (
+	"fmt"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionRepo interface {
+	List(list_req types.ListRequest) ([]Session, int, *responses.ErrorResponse)
+	Revoke(session_uuid string) (*SessionRevokeResponse, *responses.ErrorResponse)
+	Logout() (*SessionRevokeResponse, *responses.ErrorResponse)
+	LogoutAll() (*SessionRevokeResponse, *responses.ErrorResponse)
+}
+
+type SessionRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewSessionRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *SessionRepoImpl {
+	return &SessionRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+// List returns the sessions of the current user that can still be used,
+// most recently seen first
+func (s *SessionRepoImpl) List(list_req types.ListRequest) ([]Session, int, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			id, session_uuid, device, user_agent, ip, last_seen_at,
+			refresh_expires_at, created_at
+		FROM tbl_users_sessions
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+		AND refresh_expires_at > $2
+	`
+
+	// execute query
+	var sessions []Session
+	total, err := postgres.SelectList(s.DBPool, &sessions, query, []interface{}{s.UserContext.Id, utils.Now()}, list_req, sessionListFields)
+	if err != nil {
+		custom_log.NewCustomLog("session_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("session_list_failed", fmt.Errorf("get_session_error"))
+	}
+
+	if sessions == nil {
+		sessions = []Session{}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionUUID == s.UserContext.LoginSession
+	}
+
+	return sessions, total, nil
+}
+
+// Revoke signs one of the current user's devices out
+func (s *SessionRepoImpl) Revoke(session_uuid string) (*SessionRevokeResponse, *responses.ErrorResponse) {
+	revoked, err := s.revoke("session_uuid = $4", session_uuid)
+	if err != nil {
+		custom_log.NewCustomLog("session_revoke_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("session_revoke_failed", fmt.Errorf("error_revoke_session"))
+	}
+	if revoked == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("session_revoke_failed", fmt.Errorf("no_session_found"))
+	}
+
+	s.audit("session_revoke", fmt.Sprintf("User %s revoked session %s", s.UserContext.UserName, session_uuid))
+
+	return &SessionRevokeResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+// Logout signs the current device out
+func (s *SessionRepoImpl) Logout() (*SessionRevokeResponse, *responses.ErrorResponse) {
+	revoked, err := s.revoke("session_uuid = $4", s.UserContext.LoginSession)
+	if err != nil {
+		custom_log.NewCustomLog("logout_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("logout_failed", fmt.Errorf("error_revoke_session"))
+	}
+
+	s.audit("logout", fmt.Sprintf("User %s logged out", s.UserContext.UserName))
+
+	return &SessionRevokeResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+// LogoutAll signs every device of the current user out, the current one
+// included
+func (s *SessionRepoImpl) LogoutAll() (*SessionRevokeResponse, *responses.ErrorResponse) {
+	revoked, err := s.revoke("TRUE")
+	if err != nil {
+		custom_log.NewCustomLog("logout_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("logout_failed", fmt.Errorf("error_revoke_session"))
+	}
+
+	s.audit("logout_all", fmt.Sprintf("User %s logged out of %d sessions", s.UserContext.UserName, revoked))
+
+	return &SessionRevokeResponse{
+		Revoked: revoked,
+	}, nil
+}
+
+// revoke closes the active sessions of the current user matching the
+// condition, the condition arguments start at $4
+func (s *SessionRepoImpl) revoke(condition string, args ...interface{}) (int64, error) {
+	// prepare query
+	query := `
+		UPDATE tbl_users_sessions SET
+			revoked_by = $1, revoked_at = $2, revoked_reason = 'logout', updated_at = $2
+		WHERE user_id = $3
+		AND revoked_at IS NULL
+		AND ` + condition
+
+	// execute query
+	result, err := s.DBPool.Exec(query, append([]interface{}{s.UserContext.Id, utils.Now(), s.UserContext.Id}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (s *SessionRepoImpl) audit(context string, desc string) {
+	utils.AuditUserAction(s.UserContext, context, desc, constants.AuditTypeAuth, nil, s.DBPool)
+}
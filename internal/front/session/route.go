@@ -0,0 +1,31 @@
+package session
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionRoute struct {
+	App            *fiber.App
+	DBPool         *sqlx.DB
+	SessionHandler *SessionHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *SessionRoute {
+	return &SessionRoute{
+		App:            app,
+		DBPool:         db_pool,
+		SessionHandler: NewSessionHandler(db_pool),
+	}
+}
+
+func (s *SessionRoute) RegisterSessionRoute() *SessionRoute {
+	session := s.App.Group("/api/v1/front/session")
+
+	session.Get("/", s.SessionHandler.List)
+	session.Post("/logout", s.SessionHandler.Logout)
+	session.Post("/logout-all", s.SessionHandler.LogoutAll)
+	session.Delete("/:session_uuid", s.SessionHandler.Revoke)
+
+	return s
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionServiceCreator interface {
+	List(list_req types.ListRequest) ([]Session, int, *responses.ErrorResponse)
+	Revoke(session_uuid string) (*SessionRevokeResponse, *responses.ErrorResponse)
+	Logout() (*SessionRevokeResponse, *responses.ErrorResponse)
+	LogoutAll() (*SessionRevokeResponse, *responses.ErrorResponse)
+}
+
+type SessionService struct {
+	DBPool      *sqlx.DB
+	SessionRepo *SessionRepoImpl
+	UserContext *types.UserContext
+}
+
+func NewSessionService(us_ctx *types.UserContext, db_pool *sqlx.DB) *SessionService {
+	return &SessionService{
+		DBPool:      db_pool,
+		SessionRepo: NewSessionRepoImpl(us_ctx, db_pool),
+		UserContext: us_ctx,
+	}
+}
+
+func (s *SessionService) List(list_req types.ListRequest) ([]Session, int, *responses.ErrorResponse) {
+	return s.SessionRepo.List(list_req)
+}
+
+func (s *SessionService) Revoke(session_uuid string) (*SessionRevokeResponse, *responses.ErrorResponse) {
+	return s.SessionRepo.Revoke(session_uuid)
+}
+
+func (s *SessionService) Logout() (*SessionRevokeResponse, *responses.ErrorResponse) {
+	return s.SessionRepo.Logout()
+}
+
+func (s *SessionService) LogoutAll() (*SessionRevokeResponse, *responses.ErrorResponse) {
+	return s.SessionRepo.LogoutAll()
+}
@@ -0,0 +1,140 @@
+package transfer
+
+import (
+	"errors"
+	"net/http"
+	"tarantool-admin-api/pkg/constants"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type TransferHandler struct {
+	DBPool          *sqlx.DB
+	TransferService func(c *fiber.Ctx) *TransferService
+}
+
+func NewTransferHandler(db_pool *sqlx.DB) *TransferHandler {
+	return &TransferHandler{
+		DBPool: db_pool,
+		TransferService: func(c *fiber.Ctx) *TransferService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewTransferService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (t *TransferHandler) Create(c *fiber.Ctx) error {
+	var transfer_req TransferNewRequest
+	v := utils.NewValidator()
+	if err := transfer_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("transfer_create_failed", nil, c),
+				-9000,
+				err,
+			),
+		)
+	}
+
+	resp, err := t.TransferService(c).Create(transfer_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-9000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	// the transfer waits for approval instead of running
+	if resp.Transfer.JobStatus == constants.JobStatusAwaitingApproval {
+		return c.Status(http.StatusAccepted).JSON(
+			response.NewResponse(
+				utils.Translate("transfer_pending_approval", nil, c),
+				9000,
+				resp,
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("transfer_create_success", nil, c),
+			9000,
+			resp,
+		),
+	)
+}
+
+func (t *TransferHandler) List(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, transferListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("transfer_list_failed", nil, c),
+				-9001,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := t.TransferService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-9001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("transfer_list_success", nil, c),
+			9001,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (t *TransferHandler) ShowOne(c *fiber.Ctx) error {
+	transfer_uuid := c.Params("transfer_uuid")
+
+	resp, err := t.TransferService(c).ShowOne(transfer_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-9002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("transfer_show_success", nil, c),
+			9002,
+			resp,
+		),
+	)
+}
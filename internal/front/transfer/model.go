@@ -0,0 +1,167 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+)
+
+const (
+	// schema actions applied on the target space
+	SchemaCreated   = "created"
+	SchemaRecreated = "recreated"
+	SchemaKept      = "kept"
+)
+
+type Transfer struct {
+	ID           uint64              `json:"-" db:"id"`
+	TransferUUID string              `json:"transfer_uuid" db:"transfer_uuid"`
+	UserID       uint64              `json:"-" db:"user_id"`
+	SourceDBID   uint64              `json:"-" db:"source_db_id"`
+	SourceDBUUID string              `json:"source_db_uuid" db:"source_db_uuid"`
+	TargetDBID   uint64              `json:"-" db:"target_db_id"`
+	TargetDBUUID string              `json:"target_db_uuid" db:"target_db_uuid"`
+	Options      sqlx_types.JSONText `json:"options" db:"options"`
+	Progress     sqlx_types.JSONText `json:"progress" db:"progress"`
+	CopiedTuples int64               `json:"copied_tuples" db:"copied_tuples"`
+	Verified     *bool               `json:"verified" db:"verified"`
+	JobStatus    string              `json:"job_status" db:"job_status"`
+	ErrorMessage *string             `json:"error_message" db:"error_message"`
+	StartedAt    *time.Time          `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time          `json:"finished_at" db:"finished_at"`
+	CreatedBy    uint64              `json:"-" db:"created_by"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+}
+
+// transferListFields are the columns the transfer list filters and sorts on
+var transferListFields = postgres.ListFields{
+	Filter:      []string{"transfer_uuid", "source_db_uuid", "target_db_uuid", "job_status", "verified", "copied_tuples", "started_at", "finished_at", "created_at"},
+	Sort:        []string{"job_status", "copied_tuples", "started_at", "finished_at", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type TransferResponse struct {
+	Transfer Transfer `json:"transfer"`
+}
+
+// TransferPayload is what a queued transfer runs with once it is approved
+type TransferPayload struct {
+	TransferUUID string `json:"transfer_uuid"`
+}
+
+type TransferSpaceRequest struct {
+	Name    string        `json:"name" validate:"required"`
+	KeyFrom []interface{} `json:"key_from"`
+	KeyTo   []interface{} `json:"key_to"`
+}
+
+type TransferNewRequest struct {
+	SourceDBUUID string                 `json:"source_db_uuid" validate:"required,uuid"`
+	TargetDBUUID string                 `json:"target_db_uuid" validate:"required,uuid,nefield=SourceDBUUID"`
+	Spaces       []TransferSpaceRequest `json:"spaces" validate:"required,min=1,dive"`
+	CopySchema   bool                   `json:"copy_schema"`
+	CopyData     bool                   `json:"copy_data"`
+	DropExisting bool                   `json:"drop_existing"`
+	WriteMode    string                 `json:"write_mode" validate:"omitempty,oneof=insert replace upsert"`
+	BatchSize    int                    `json:"batch_size" validate:"omitempty,min=1,max=10000"`
+	ThrottleMs   int                    `json:"throttle_ms" validate:"omitempty,min=0,max=60000"`
+	Verify       *bool                  `json:"verify"`
+}
+
+func (t *TransferNewRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(t); err != nil {
+		custom_log.NewCustomLog("transfer_create_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(t, c); err != nil {
+		custom_log.NewCustomLog("transfer_create_failed", err.Error(), "error")
+		return err
+	}
+
+	if !t.CopySchema && !t.CopyData {
+		return errors.New(utils.Translate("nothing_to_transfer", nil, c))
+	}
+
+	if t.WriteMode == "" {
+		t.WriteMode = tarantool_utils.WriteModeReplace
+	}
+	if t.BatchSize == 0 {
+		t.BatchSize = 500
+	}
+	if t.Verify == nil {
+		verify := true
+		t.Verify = &verify
+	}
+
+	return nil
+}
+
+type TransferSpaceProgress struct {
+	Name         string             `json:"name"`
+	SchemaAction string             `json:"schema_action"`
+	Copied       uint64             `json:"copied"`
+	Done         bool               `json:"done"`
+	Verification *SpaceVerification `json:"verification"`
+}
+
+type SpaceVerification struct {
+	Source tarantool_utils.SpaceChecksum `json:"source"`
+	Target tarantool_utils.SpaceChecksum `json:"target"`
+	Match  bool                          `json:"match"`
+}
+
+type TransferNewModel struct {
+	ID           uint64              `db:"id"`
+	TransferUUID string              `db:"transfer_uuid"`
+	UserID       uint64              `db:"user_id"`
+	SourceDBID   uint64              `db:"source_db_id"`
+	TargetDBID   uint64              `db:"target_db_id"`
+	Options      sqlx_types.JSONText `db:"options"`
+	JobStatus    string              `db:"job_status"`
+	CreatedBy    uint64              `db:"created_by"`
+	CreatedAt    time.Time           `db:"created_at"`
+}
+
+func (t *TransferNewModel) new(source_db_id uint64, target_db_id uint64, transfer_req TransferNewRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_transfers_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	options, err := json.Marshal(transfer_req)
+	if err != nil {
+		return fmt.Errorf("error encode options : %w", err)
+	}
+
+	t.ID = uint64(*id)
+	t.TransferUUID = uuid.String()
+	t.UserID = uint64(us_ctx.Id)
+	t.SourceDBID = source_db_id
+	t.TargetDBID = target_db_id
+	t.Options = sqlx_types.JSONText(options)
+	t.JobStatus = constants.JobStatusPending
+	t.CreatedBy = uint64(us_ctx.Id)
+	t.CreatedAt = utils.Now()
+
+	return nil
+}
@@ -0,0 +1,553 @@
+package transfer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/internal/front/database"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	sqlx_types "github.com/jmoiron/sqlx/types"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+type TransferRepo interface {
+	Create(transfer_req TransferNewRequest) (*TransferResponse, *responses.ErrorResponse)
+	List(list_req types.ListRequest) ([]Transfer, int, *responses.ErrorResponse)
+	ShowOne(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse)
+	Approve(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse)
+	Reject(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse)
+}
+
+type TransferRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewTransferRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *TransferRepoImpl {
+	return &TransferRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectTransfer = `
+	SELECT
+		tf.id, tf.transfer_uuid, tf.user_id, tf.source_db_id, src.db_uuid AS source_db_uuid,
+		tf.target_db_id, tgt.db_uuid AS target_db_uuid, tf.options, tf.progress,
+		tf.copied_tuples, tf.verified, tf.job_status, tf.error_message,
+		tf.started_at, tf.finished_at, tf.created_by, tf.created_at
+	FROM tbl_transfers tf
+	INNER JOIN tbl_users_databases src ON src.id = tf.source_db_id
+	INNER JOIN tbl_users_databases tgt ON tgt.id = tf.target_db_id
+	WHERE tf.deleted_at IS NULL
+`
+
+const selectTransferQuery = selectTransfer + `
+	AND tf.user_id = $1
+`
+
+func (t *TransferRepoImpl) Create(transfer_req TransferNewRequest) (*TransferResponse, *responses.ErrorResponse) {
+	// the transfer reads the source and writes the target
+	db_repo := database.NewDatabaseRepoImpl(t.UserContext, t.DBPool)
+	source_resp, err_resp := db_repo.Accessible(transfer_req.SourceDBUUID, constants.AccessLevelRead, "transfer_create_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	target_resp, err_resp := db_repo.Accessible(transfer_req.TargetDBUUID, constants.AccessLevelWrite, "transfer_create_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	source, target := &source_resp.Database, &target_resp.Database
+
+	// the writes on the target get the checks of a query
+	statements := transferStatements(transfer_req)
+	if err_detail := db_repo.Authorize(*target, statements); err_detail != nil {
+		custom_log.NewCustomLog("transfer_create_failed", err_detail.Detail.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_create_failed", err_detail.Err)
+	}
+
+	// create insert model
+	var transfer_new_model TransferNewModel
+	if err := transfer_new_model.new(source.ID, target.ID, transfer_req, t.UserContext, t.DBPool); err != nil {
+		custom_log.NewCustomLog("transfer_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_create_failed", fmt.Errorf("invalid_info_to_create_transfer"))
+	}
+
+	// a transfer into a database requiring approval waits for a second user
+	if target.RequiresApproval {
+		transfer_new_model.JobStatus = constants.JobStatusAwaitingApproval
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_transfers (
+			id, transfer_uuid, user_id, source_db_id, target_db_id, options,
+			job_status, created_by, created_at
+		) VALUES (
+			:id, :transfer_uuid, :user_id, :source_db_id, :target_db_id, :options,
+			:job_status, :created_by, :created_at
+		)
+	`
+
+	// execute request
+	if _, err := t.DBPool.NamedExec(query, transfer_new_model); err != nil {
+		custom_log.NewCustomLog("transfer_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_create_failed", fmt.Errorf("error_create_transfer"))
+	}
+
+	if target.RequiresApproval {
+		return t.queue(transfer_new_model, *source, *target, statements)
+	}
+
+	// run the transfer job in the background
+	go t.run(transfer_new_model.ID, *source, *target, transfer_req)
+
+	return t.ShowOne(transfer_new_model.TransferUUID)
+}
+
+// Approve starts a transfer that was waiting for approval, the checks were
+// made when it was queued
+func (t *TransferRepoImpl) Approve(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse) {
+	// get transfer info
+	transfer_resp, err_resp := t.load(transfer_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	transfer := transfer_resp.Transfer
+
+	var transfer_req TransferNewRequest
+	if err := json.Unmarshal(transfer.Options, &transfer_req); err != nil {
+		custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_run_failed", fmt.Errorf("invalid_info_to_create_transfer"))
+	}
+
+	// get database info
+	db_repo := database.NewDatabaseRepoImpl(t.UserContext, t.DBPool)
+	source_resp, err_resp := db_repo.ShowOne(transfer.SourceDBUUID)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	target_resp, err_resp := db_repo.ShowOne(transfer.TargetDBUUID)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// guard against a concurrent review of the same transfer
+	query := `
+		UPDATE tbl_transfers SET
+			job_status = $1
+		WHERE id = $2 AND job_status = $3
+	`
+	result, err := t.DBPool.Exec(query, constants.JobStatusPending, transfer.ID, constants.JobStatusAwaitingApproval)
+	if err != nil {
+		custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_run_failed", fmt.Errorf("error_database"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_run_failed", fmt.Errorf("transfer_not_awaiting_approval"))
+	}
+
+	// run the transfer job in the background
+	go t.run(transfer.ID, source_resp.Database, target_resp.Database, transfer_req)
+
+	return t.load(transfer_uuid)
+}
+
+// Reject closes a transfer that was waiting for approval without running it
+func (t *TransferRepoImpl) Reject(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		UPDATE tbl_transfers SET
+			job_status = $1, finished_at = $2
+		WHERE deleted_at IS NULL
+		AND transfer_uuid = $3
+		AND job_status = $4
+	`
+
+	// execute request
+	result, err := t.DBPool.Exec(query, constants.JobStatusRejected, utils.Now(), transfer_uuid, constants.JobStatusAwaitingApproval)
+	if err != nil {
+		custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_run_failed", fmt.Errorf("error_database"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_run_failed", fmt.Errorf("transfer_not_awaiting_approval"))
+	}
+
+	return t.load(transfer_uuid)
+}
+
+// queue stores the transfer as a pending change of the target database
+func (t *TransferRepoImpl) queue(transfer TransferNewModel, source database.Database, target database.Database, statements []tarantool_utils.Statement) (*TransferResponse, *responses.ErrorResponse) {
+	statement := fmt.Sprintf("transfer %s from %s into %s", transfer.TransferUUID, source.DBName, target.DBName)
+	payload := TransferPayload{TransferUUID: transfer.TransferUUID}
+
+	pending_change, err := database.NewDatabaseRepoImpl(t.UserContext, t.DBPool).QueueChange(target, database.ChangeKindTransfer, statement, statements, nil, payload)
+	if err != nil {
+		custom_log.NewCustomLog("transfer_create_failed", err.Error(), "error")
+
+		// the transfer cannot be approved anymore, close it
+		update_failed := `
+			UPDATE tbl_transfers SET
+				job_status = $1, error_message = $2, finished_at = $3
+			WHERE id = $4
+		`
+		if _, err := t.DBPool.Exec(update_failed, constants.JobStatusFailed, err.Error(), utils.Now(), transfer.ID); err != nil {
+			custom_log.NewCustomLog("transfer_create_failed", err.Error(), "error")
+		}
+
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_create_failed", fmt.Errorf("error_queue_change"))
+	}
+
+	utils.AuditUserAction(
+		t.UserContext,
+		"transfer_queued",
+		fmt.Sprintf("Queued change %s on %s for approval: %s", pending_change.ChangeUUID, target.DBName, statement),
+		constants.AuditTypeApproval,
+		&target.ID,
+		t.DBPool,
+	)
+
+	return t.ShowOne(transfer.TransferUUID)
+}
+
+// load returns the transfer whoever created it, it is meant for the reviewer
+// of a transfer waiting for approval
+func (t *TransferRepoImpl) load(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectTransfer + `
+		AND tf.transfer_uuid = $1
+	`
+
+	// execute query
+	var transfer Transfer
+	if err := t.DBPool.Get(&transfer, query, transfer_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("transfer_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("transfer_show_failed", fmt.Errorf("no_transfer_found"))
+		}
+		custom_log.NewCustomLog("transfer_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_show_failed", fmt.Errorf("get_transfer_error"))
+	}
+
+	return &TransferResponse{
+		Transfer: transfer,
+	}, nil
+}
+
+func (t *TransferRepoImpl) List(list_req types.ListRequest) ([]Transfer, int, *responses.ErrorResponse) {
+	// execute query
+	var transfers []Transfer
+	total, err := postgres.SelectList(t.DBPool, &transfers, selectTransferQuery, []interface{}{t.UserContext.Id}, list_req, transferListFields)
+	if err != nil {
+		custom_log.NewCustomLog("transfer_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("transfer_list_failed", fmt.Errorf("get_transfer_error"))
+	}
+
+	if transfers == nil {
+		transfers = []Transfer{}
+	}
+
+	return transfers, total, nil
+}
+
+func (t *TransferRepoImpl) ShowOne(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectTransferQuery + `
+		AND tf.transfer_uuid = $2
+	`
+
+	// execute query
+	var transfer Transfer
+	if err := t.DBPool.Get(&transfer, query, t.UserContext.Id, transfer_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			custom_log.NewCustomLog("transfer_show_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("transfer_show_failed", fmt.Errorf("no_transfer_found"))
+		}
+		custom_log.NewCustomLog("transfer_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("transfer_show_failed", fmt.Errorf("get_transfer_error"))
+	}
+
+	return &TransferResponse{
+		Transfer: transfer,
+	}, nil
+}
+
+// transferStatements describes the writes of the transfer on the target, the
+// copied tuples are dml and the copied schema is ddl
+func transferStatements(transfer_req TransferNewRequest) []tarantool_utils.Statement {
+	var statements []tarantool_utils.Statement
+	if transfer_req.CopyData {
+		statements = append(statements, tarantool_utils.Statement{Keyword: "TRANSFER", Kind: tarantool_utils.StatementDML})
+	}
+	if transfer_req.CopySchema {
+		statements = append(statements, tarantool_utils.Statement{Keyword: "TRANSFER", Kind: tarantool_utils.StatementDDL})
+	}
+	return statements
+}
+
+// run executes the transfer job and records its outcome in tbl_transfers
+func (t *TransferRepoImpl) run(transfer_id uint64, source database.Database, target database.Database, transfer_req TransferNewRequest) {
+	// mark the job as running
+	update_running := `
+		UPDATE tbl_transfers SET
+			job_status = $1, started_at = $2
+		WHERE id = $3
+	`
+	if _, err := t.DBPool.Exec(update_running, constants.JobStatusRunning, utils.Now(), transfer_id); err != nil {
+		custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+	}
+
+	progress, err := t.transfer(transfer_id, source, target, transfer_req)
+
+	// schema copies are expected changes on the target, record them
+	if transfer_req.CopySchema {
+		if _, err := database.NewDatabaseRepoImpl(t.UserContext, t.DBPool).RecordSchema(target, database.SchemaSourceAPI); err != nil {
+			custom_log.NewCustomLog("schema_record_failed", err.Error(), "error")
+		}
+	}
+	if err != nil {
+		custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+		t.saveProgress(transfer_id, progress)
+
+		update_failed := `
+			UPDATE tbl_transfers SET
+				job_status = $1, error_message = $2, finished_at = $3
+			WHERE id = $4
+		`
+		if _, err := t.DBPool.Exec(update_failed, constants.JobStatusFailed, err.Error(), utils.Now(), transfer_id); err != nil {
+			custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+		}
+		return
+	}
+
+	t.saveProgress(transfer_id, progress)
+
+	// the transfer is verified when every space matched
+	var verified *bool
+	if *transfer_req.Verify && transfer_req.CopyData {
+		all_match := true
+		for _, space := range progress {
+			if space.Verification == nil || !space.Verification.Match {
+				all_match = false
+			}
+		}
+		verified = &all_match
+	}
+
+	update_completed := `
+		UPDATE tbl_transfers SET
+			job_status = $1, verified = $2, finished_at = $3
+		WHERE id = $4
+	`
+	if _, err := t.DBPool.Exec(update_completed, constants.JobStatusCompleted, verified, utils.Now(), transfer_id); err != nil {
+		custom_log.NewCustomLog("transfer_run_failed", err.Error(), "error")
+	}
+}
+
+// transfer copies every requested space and verifies the copied ranges
+func (t *TransferRepoImpl) transfer(transfer_id uint64, source database.Database, target database.Database, transfer_req TransferNewRequest) ([]TransferSpaceProgress, error) {
+	progress := make([]TransferSpaceProgress, len(transfer_req.Spaces))
+	for i, space := range transfer_req.Spaces {
+		progress[i] = TransferSpaceProgress{Name: space.Name}
+	}
+
+	// connect both databases
+	source_conn, err := tarantool_utils.ConnectTarantool(source.Host, int(source.Port), source.Username, source.Password)
+	if err != nil {
+		return progress, fmt.Errorf("connect source database: %w", err)
+	}
+	defer source_conn.Close()
+
+	target_conn, err := tarantool_utils.ConnectTarantool(target.Host, int(target.Port), target.Username, target.Password)
+	if err != nil {
+		return progress, fmt.Errorf("connect target database: %w", err)
+	}
+	defer target_conn.Close()
+
+	source_spaces, err := tarantool_utils.GetUserSpaces(source_conn)
+	if err != nil {
+		return progress, fmt.Errorf("read source spaces: %w", err)
+	}
+	target_spaces, err := tarantool_utils.GetUserSpaces(target_conn)
+	if err != nil {
+		return progress, fmt.Errorf("read target spaces: %w", err)
+	}
+
+	source_by_name := make(map[string]tarantool_utils.SpaceSchema, len(source_spaces))
+	for _, space := range source_spaces {
+		source_by_name[space.Name] = space
+	}
+	target_by_name := make(map[string]tarantool_utils.SpaceSchema, len(target_spaces))
+	for _, space := range target_spaces {
+		target_by_name[space.Name] = space
+	}
+
+	for i, space_req := range transfer_req.Spaces {
+		schema, ok := source_by_name[space_req.Name]
+		if !ok {
+			return progress, fmt.Errorf("space %s does not exist in the source database", space_req.Name)
+		}
+
+		// the key bounds come from json, coerce them to the primary key types
+		key_from, err := coerceKey(space_req.KeyFrom, schema)
+		if err != nil {
+			return progress, fmt.Errorf("space %s key_from: %w", space_req.Name, err)
+		}
+		key_to, err := coerceKey(space_req.KeyTo, schema)
+		if err != nil {
+			return progress, fmt.Errorf("space %s key_to: %w", space_req.Name, err)
+		}
+
+		// prepare the target space
+		existing, exists := target_by_name[space_req.Name]
+		switch {
+		case transfer_req.CopySchema && !exists:
+			if err := tarantool_utils.CreateSpace(target_conn, schema, false); err != nil {
+				return progress, fmt.Errorf("create space %s: %w", space_req.Name, err)
+			}
+			progress[i].SchemaAction = SchemaCreated
+		case transfer_req.CopySchema && transfer_req.DropExisting:
+			if err := tarantool_utils.CreateSpace(target_conn, schema, true); err != nil {
+				return progress, fmt.Errorf("recreate space %s: %w", space_req.Name, err)
+			}
+			progress[i].SchemaAction = SchemaRecreated
+		case transfer_req.CopySchema:
+			if diffs := tarantool_utils.CompareSchema(schema, existing); len(diffs) > 0 {
+				return progress, fmt.Errorf("space %s differs in the target database: %v", space_req.Name, diffs)
+			}
+			progress[i].SchemaAction = SchemaKept
+		case !exists:
+			return progress, fmt.Errorf("space %s does not exist in the target database", space_req.Name)
+		default:
+			progress[i].SchemaAction = SchemaKept
+		}
+
+		if transfer_req.CopyData {
+			err := tarantool_utils.ScanSpaceRange(source_conn, space_req.Name, key_from, key_to, transfer_req.BatchSize, func(tuples [][]interface{}) error {
+				result, err := tarantool_utils.WriteTuples(target_conn, space_req.Name, tuples, transfer_req.WriteMode, true)
+				if err != nil {
+					return err
+				}
+				if len(result.Errors) > 0 {
+					return fmt.Errorf("write space %s: %s", space_req.Name, result.Errors[0].Error)
+				}
+
+				progress[i].Copied += uint64(result.Written)
+				t.saveProgress(transfer_id, progress)
+
+				// give the target some room between batches
+				if transfer_req.ThrottleMs > 0 {
+					time.Sleep(time.Duration(transfer_req.ThrottleMs) * time.Millisecond)
+				}
+				return nil
+			})
+			if err != nil {
+				return progress, fmt.Errorf("copy space %s: %w", space_req.Name, err)
+			}
+
+			if *transfer_req.Verify {
+				verification, err := verifySpace(source_conn, target_conn, space_req.Name, key_from, key_to)
+				if err != nil {
+					return progress, fmt.Errorf("verify space %s: %w", space_req.Name, err)
+				}
+				progress[i].Verification = verification
+			}
+		}
+
+		progress[i].Done = true
+		t.saveProgress(transfer_id, progress)
+	}
+
+	return progress, nil
+}
+
+// verifySpace compares the count and checksum of the key range on both sides
+func verifySpace(source_conn *pool.ConnectionPool, target_conn *pool.ConnectionPool, space_name string, key_from []interface{}, key_to []interface{}) (*SpaceVerification, error) {
+	source_sum, err := tarantool_utils.ChecksumSpace(source_conn, space_name, key_from, key_to)
+	if err != nil {
+		return nil, err
+	}
+	target_sum, err := tarantool_utils.ChecksumSpace(target_conn, space_name, key_from, key_to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SpaceVerification{
+		Source: *source_sum,
+		Target: *target_sum,
+		Match:  source_sum.Count == target_sum.Count && source_sum.Checksum == target_sum.Checksum,
+	}, nil
+}
+
+// coerceKey converts the parts of a key to the types of the primary index
+func coerceKey(key []interface{}, schema tarantool_utils.SpaceSchema) ([]interface{}, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(schema.Indexes) == 0 {
+		return nil, fmt.Errorf("space %s has no primary index", schema.Name)
+	}
+
+	parts := schema.Indexes[0].Parts
+	if len(key) > len(parts) {
+		return nil, fmt.Errorf("key has more parts than the primary index")
+	}
+
+	coerced := make([]interface{}, len(key))
+	for i, value := range key {
+		converted, err := tarantool_utils.CoerceValue(value, parts[i].Type)
+		if err != nil {
+			return nil, err
+		}
+		coerced[i] = converted
+	}
+	return coerced, nil
+}
+
+// saveProgress stores the progress of every space and the copied total
+func (t *TransferRepoImpl) saveProgress(transfer_id uint64, progress []TransferSpaceProgress) {
+	progress_json, err := json.Marshal(progress)
+	if err != nil {
+		custom_log.NewCustomLog("transfer_progress_failed", err.Error(), "error")
+		return
+	}
+
+	var copied uint64
+	for _, space := range progress {
+		copied += space.Copied
+	}
+
+	update_progress := `
+		UPDATE tbl_transfers SET
+			progress = $1, copied_tuples = $2
+		WHERE id = $3
+	`
+	if _, err := t.DBPool.Exec(update_progress, sqlx_types.JSONText(progress_json), copied, transfer_id); err != nil {
+		custom_log.NewCustomLog("transfer_progress_failed", err.Error(), "error")
+	}
+}
@@ -0,0 +1,33 @@
+package transfer
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type TransferRoute struct {
+	App             *fiber.App
+	DBPool          *sqlx.DB
+	TransferHandler *TransferHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *TransferRoute {
+	return &TransferRoute{
+		App:             app,
+		DBPool:          db_pool,
+		TransferHandler: NewTransferHandler(db_pool),
+	}
+}
+
+func (t *TransferRoute) RegisterTransferRoute() *TransferRoute {
+	transfer := t.App.Group("/api/v1/front/transfer")
+
+	transfer.Post("/", middlewares.RequirePermission(constants.PermissionRunDML), t.TransferHandler.Create)
+	transfer.Get("/", middlewares.RequirePermission(constants.PermissionRunSelect), t.TransferHandler.List)
+	transfer.Get("/:transfer_uuid", middlewares.RequirePermission(constants.PermissionRunSelect), t.TransferHandler.ShowOne)
+
+	return t
+}
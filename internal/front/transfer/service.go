@@ -0,0 +1,40 @@
+package transfer
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TransferServiceCreator interface {
+	Create(transfer_req TransferNewRequest) (*TransferResponse, *responses.ErrorResponse)
+	List(list_req types.ListRequest) ([]Transfer, int, *responses.ErrorResponse)
+	ShowOne(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse)
+}
+
+type TransferService struct {
+	DBPool       *sqlx.DB
+	TransferRepo *TransferRepoImpl
+	UserContext  *types.UserContext
+}
+
+func NewTransferService(us_ctx *types.UserContext, db_pool *sqlx.DB) *TransferService {
+	return &TransferService{
+		DBPool:       db_pool,
+		TransferRepo: NewTransferRepoImpl(us_ctx, db_pool),
+		UserContext:  us_ctx,
+	}
+}
+
+func (t *TransferService) Create(transfer_req TransferNewRequest) (*TransferResponse, *responses.ErrorResponse) {
+	return t.TransferRepo.Create(transfer_req)
+}
+
+func (t *TransferService) List(list_req types.ListRequest) ([]Transfer, int, *responses.ErrorResponse) {
+	return t.TransferRepo.List(list_req)
+}
+
+func (t *TransferService) ShowOne(transfer_uuid string) (*TransferResponse, *responses.ErrorResponse) {
+	return t.TransferRepo.ShowOne(transfer_uuid)
+}
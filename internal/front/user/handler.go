@@ -1,7 +1,7 @@
 package user
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	response "tarantool-admin-api/pkg/http/response"
 	custom_log "tarantool-admin-api/pkg/logs"
@@ -43,7 +43,7 @@ func (u *UserHandler) Info(c *fiber.Ctx) error {
 			response.NewResponseError(
 				utils.Translate(err.MessageID, nil, c),
 				-3000,
-				fmt.Errorf(utils.Translate(err.Err.Error(), nil, c)),
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
 			),
 		)
 	}
@@ -56,3 +56,284 @@ func (u *UserHandler) Info(c *fiber.Ctx) error {
 		),
 	)
 }
+
+func (u *UserHandler) UpdateMFA(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	var mfa_req UserMFARequest
+	v := utils.NewValidator()
+
+	if err := mfa_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("user_mfa_update_failed", nil, c),
+				-3001,
+				err,
+			),
+		)
+	}
+
+	resp, err := u.UserService(c).UpdateMFA(user_uuid, mfa_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_mfa_update_success", nil, c),
+			3001,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) Unlock(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := u.UserService(c).Unlock(user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_unlock_success", nil, c),
+			3002,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) List(c *fiber.Ctx) error {
+	var list_req UserListRequest
+	v := utils.NewValidator()
+
+	if err := list_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("user_list_failed", nil, c),
+				-3003,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := u.UserService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("user_list_success", nil, c),
+			3003,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (u *UserHandler) ShowOne(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := u.UserService(c).ShowOne(user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_show_success", nil, c),
+			3004,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) Create(c *fiber.Ctx) error {
+	var create_req UserCreateRequest
+	v := utils.NewValidator()
+
+	if err := create_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("user_create_failed", nil, c),
+				-3005,
+				err,
+			),
+		)
+	}
+
+	resp, err := u.UserService(c).Create(create_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("user_create_success", nil, c),
+			3005,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) UpdateStatus(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	var status_req UserStatusRequest
+	v := utils.NewValidator()
+
+	if err := status_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("user_status_update_failed", nil, c),
+				-3006,
+				err,
+			),
+		)
+	}
+
+	resp, err := u.UserService(c).UpdateStatus(user_uuid, status_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3006,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_status_update_success", nil, c),
+			3006,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) UpdateRole(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	var role_req UserRoleRequest
+	v := utils.NewValidator()
+
+	if err := role_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("user_role_update_failed", nil, c),
+				-3007,
+				err,
+			),
+		)
+	}
+
+	resp, err := u.UserService(c).UpdateRole(user_uuid, role_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3007,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_role_update_success", nil, c),
+			3007,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := u.UserService(c).ForcePasswordReset(user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3008,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_password_reset_success", nil, c),
+			3008,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) RevokeSessions(c *fiber.Ctx) error {
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := u.UserService(c).RevokeSessions(user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-3009,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_session_revoke_success", nil, c),
+			3009,
+			resp,
+		),
+	)
+}
+
+func (u *UserHandler) Statuses(c *fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("user_status_list_success", nil, c),
+			3010,
+			u.UserService(c).Statuses(),
+		),
+	)
+}
@@ -1,26 +1,44 @@
 package user
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 type User struct {
-	ID            int            `db:"id" json:"-"`
-	UserUUID      string         `db:"user_uuid" json:"user_uuid"`
-	FirstName     *string        `db:"first_name" json:"first_name"`
-	LastName      *string        `db:"last_name" json:"last_name"`
-	UserName      string         `db:"user_name" json:"user_name"`
-	Password      string         `db:"password" json:"-"`
-	Email         string         `db:"email" json:"email"`
-	LoginSession  *string        `db:"login_session" json:"-"`
-	ProfilePhoto  *string        `db:"profile_photo" json:"profile_photo"`
-	StatusID      int            `db:"status_id" json:"-"`
-	Order         *int           `db:"order" json:"-"`
-	CreatedBy     int            `db:"created_by" json:"-"`
-	CreatedAt     time.Time      `db:"created_at" json:"-"`
-	UpdatedBy     *int           `db:"updated_by" json:"-"`
-	UpdatedAt     *time.Time     `db:"updated_at" json:"-"`
-	DeletedBy     *int           `db:"deleted_by" json:"-"`
-	DeletedAt     *time.Time     `db:"deleted_at" json:"-"`
-	UserDatabases []UserDatabase `json:"user_databases"`
+	ID                 int                 `db:"id" json:"-"`
+	UserUUID           string              `db:"user_uuid" json:"user_uuid"`
+	FirstName          *string             `db:"first_name" json:"first_name"`
+	LastName           *string             `db:"last_name" json:"last_name"`
+	UserName           string              `db:"user_name" json:"user_name"`
+	Password           string              `db:"password" json:"-"`
+	Email              string              `db:"email" json:"email"`
+	EmailVerifiedAt    *time.Time          `db:"email_verified_at" json:"email_verified_at"`
+	LoginSession       *string             `db:"login_session" json:"-"`
+	ProfilePhoto       *string             `db:"profile_photo" json:"profile_photo"`
+	Language           *string             `db:"language" json:"language"`
+	StatusID           int                 `db:"status_id" json:"-"`
+	Order              *int                `db:"order" json:"-"`
+	CreatedBy          int                 `db:"created_by" json:"-"`
+	CreatedAt          time.Time           `db:"created_at" json:"-"`
+	UpdatedBy          *int                `db:"updated_by" json:"-"`
+	UpdatedAt          *time.Time          `db:"updated_at" json:"-"`
+	DeletedBy          *int                `db:"deleted_by" json:"-"`
+	DeletedAt          *time.Time          `db:"deleted_at" json:"-"`
+	Role               string              `db:"-" json:"role"`
+	Permissions        []string            `db:"-" json:"permissions"`
+	UserDatabases      []UserDatabase      `json:"user_databases"`
+	WorkspaceDatabases []WorkspaceDatabase `json:"workspace_databases"`
 }
 
 type UserDatabase struct {
@@ -30,6 +48,243 @@ type UserDatabase struct {
 	Port   string `db:"port" json:"port"`
 }
 
+// WorkspaceDatabase is a database shared with the user through a workspace
+type WorkspaceDatabase struct {
+	DBUUID        string `db:"db_uuid" json:"db_uuid"`
+	DBName        string `db:"db_name" json:"db_name"`
+	Host          string `db:"host" json:"host"`
+	Port          string `db:"port" json:"port"`
+	WorkspaceUUID string `db:"workspace_uuid" json:"workspace_uuid"`
+	WorkspaceName string `db:"workspace_name" json:"workspace_name"`
+	MemberRole    string `db:"member_role" json:"member_role"`
+}
+
 type UserInfoResponse struct {
 	UserInfo User `json:"user_info"`
 }
+
+// UserMFARequest makes a user set up two-factor authentication on its next
+// login, or lifts that requirement
+type UserMFARequest struct {
+	Required *bool `json:"required" validate:"required"`
+}
+
+func (u *UserMFARequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(u); err != nil {
+		custom_log.NewCustomLog("user_mfa_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(u, c); err != nil {
+		custom_log.NewCustomLog("user_mfa_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type UserMFAResponse struct {
+	UserUUID    string `json:"user_uuid"`
+	MFARequired bool   `json:"mfa_required"`
+}
+
+type UserUnlockResponse struct {
+	UserUUID string `json:"user_uuid"`
+	UserName string `json:"user_name"`
+}
+
+// ManagedUser is a user as the administrators see it
+type ManagedUser struct {
+	ID              int        `db:"id" json:"-"`
+	UserUUID        string     `db:"user_uuid" json:"user_uuid"`
+	FirstName       *string    `db:"first_name" json:"first_name"`
+	LastName        *string    `db:"last_name" json:"last_name"`
+	UserName        string     `db:"user_name" json:"user_name"`
+	Email           string     `db:"email" json:"email"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at"`
+	StatusID        int        `db:"status_id" json:"status_id"`
+	StatusName      string     `db:"-" json:"status_name"`
+	Role            string     `db:"role_name" json:"role"`
+	MFARequired     bool       `db:"mfa_required" json:"mfa_required"`
+	MFAEnabled      bool       `db:"mfa_enabled" json:"mfa_enabled"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       *time.Time `db:"updated_at" json:"updated_at"`
+}
+
+var userListFields = postgres.ListFields{
+	Filter:      []string{"user_uuid", "user_name", "email", "status_id", "role", "mfa_required", "created_at"},
+	Sort:        []string{"user_name", "email", "status_id", "role", "created_at"},
+	Columns:     map[string]string{"role": "role_name"},
+	DefaultSort: "id",
+}
+
+type ManagedUserResponse struct {
+	User ManagedUser `json:"user"`
+}
+
+type UserStatusesResponse struct {
+	Statuses []types.Status `json:"statuses"`
+}
+
+// statusName returns the name of a status_id of share.StatusData
+func statusName(status_id int) string {
+	for _, status := range types.StatusData {
+		if status.Id == status_id {
+			return status.StatusName
+		}
+	}
+	return ""
+}
+
+// UserListRequest filters the users, search matches the user name, names
+// and email
+type UserListRequest struct {
+	Search   string `query:"search"`
+	StatusID int    `query:"status_id" validate:"omitempty,oneof=1 2 3"`
+	Role     string `query:"role" validate:"omitempty,oneof=owner admin operator analyst viewer"`
+
+	types.ListRequest `query:"-"`
+}
+
+func (u *UserListRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.QueryParser(u); err != nil {
+		custom_log.NewCustomLog("user_list_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(u, c); err != nil {
+		custom_log.NewCustomLog("user_list_failed", err.Error(), "error")
+		return err
+	}
+
+	return utils.BindList(c, &u.ListRequest, userListFields)
+}
+
+// where builds the filter of the request, deleted users are gone
+func (u *UserListRequest) where() (string, []interface{}) {
+	conditions := []string{
+		"us.deleted_at IS NULL",
+	}
+	args := []interface{}{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if u.StatusID != 0 {
+		add("us.status_id = $%d", u.StatusID)
+	}
+	if u.Role != "" {
+		add("ro.role_name = $%d", u.Role)
+	}
+	if u.Search != "" {
+		args = append(args, "%"+u.Search+"%")
+		conditions = append(conditions, fmt.Sprintf(
+			"(us.user_name ILIKE $%[1]d OR us.first_name ILIKE $%[1]d OR us.last_name ILIKE $%[1]d OR us.email ILIKE $%[1]d)",
+			len(args),
+		))
+	}
+
+	return "WHERE " + strings.Join(conditions, "\n\t\tAND "), args
+}
+
+type UserCreateRequest struct {
+	FirstName string `json:"first_name" validate:"required,min=2,max=100"`
+	LastName  string `json:"last_name" validate:"required,min=2,max=100"`
+	UserName  string `json:"user_name" validate:"required,min=3,max=50,alphanum"`
+	Password  string `json:"password" validate:"required,min=6,max=100"`
+	Email     string `json:"email" validate:"required,email"`
+	Role      string `json:"role" validate:"omitempty,oneof=admin operator analyst viewer"`
+	StatusID  int    `json:"status_id" validate:"omitempty,oneof=1 2 3"`
+}
+
+func (u *UserCreateRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(u); err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(u, c); err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+		return err
+	}
+
+	u.UserName = strings.TrimSpace(strings.ToUpper(u.UserName))
+
+	return nil
+}
+
+// UserStatusRequest moves a user to another status of share.StatusData, a
+// deleted user is gone for good
+type UserStatusRequest struct {
+	StatusID int `json:"status_id" validate:"required,oneof=1 2 3 4"`
+}
+
+func (u *UserStatusRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(u); err != nil {
+		custom_log.NewCustomLog("user_status_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(u, c); err != nil {
+		custom_log.NewCustomLog("user_status_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type UserStatusResponse struct {
+	UserUUID   string `json:"user_uuid"`
+	StatusID   int    `json:"status_id"`
+	StatusName string `json:"status_name"`
+	Revoked    int64  `json:"revoked_sessions"`
+}
+
+type UserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin operator analyst viewer"`
+}
+
+func (u *UserRoleRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(u); err != nil {
+		custom_log.NewCustomLog("user_role_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(u, c); err != nil {
+		custom_log.NewCustomLog("user_role_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+type UserSessionsResponse struct {
+	UserUUID string `json:"user_uuid"`
+	Revoked  int64  `json:"revoked_sessions"`
+}
+
+type UserPasswordResetResponse struct {
+	UserUUID string `json:"user_uuid"`
+	Email    string `json:"email"`
+	Revoked  int64  `json:"revoked_sessions"`
+}
+
+// managedTarget is the user an administrator acts on
+type managedTarget struct {
+	ID       int    `db:"id"`
+	UserName string `db:"user_name"`
+	Email    string `db:"email"`
+	StatusID int    `db:"status_id"`
+	Role     string `db:"role_name"`
+}
+
+// randomPassword replaces a password nobody may use anymore
+func randomPassword() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -4,15 +4,30 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"tarantool-admin-api/internal/front/auth"
+	"tarantool-admin-api/pkg/constants"
 	custom_log "tarantool-admin-api/pkg/logs"
 	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
 	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
 type UserRepo interface {
 	Info() (*UserInfoResponse, *responses.ErrorResponse)
+	UpdateMFA(user_uuid string, mfa_req UserMFARequest) (*UserMFAResponse, *responses.ErrorResponse)
+	Unlock(user_uuid string) (*UserUnlockResponse, *responses.ErrorResponse)
+	List(list_req UserListRequest) ([]ManagedUser, int, *responses.ErrorResponse)
+	ShowOne(user_uuid string) (*ManagedUserResponse, *responses.ErrorResponse)
+	Create(create_req UserCreateRequest) (*ManagedUserResponse, *responses.ErrorResponse)
+	UpdateStatus(user_uuid string, status_req UserStatusRequest) (*UserStatusResponse, *responses.ErrorResponse)
+	UpdateRole(user_uuid string, role_req UserRoleRequest) (*ManagedUserResponse, *responses.ErrorResponse)
+	ForcePasswordReset(user_uuid string) (*UserPasswordResetResponse, *responses.ErrorResponse)
+	RevokeSessions(user_uuid string) (*UserSessionsResponse, *responses.ErrorResponse)
+	Statuses() *UserStatusesResponse
 }
 
 type UserRepoImpl struct {
@@ -31,8 +46,8 @@ func (u *UserRepoImpl) Info() (*UserInfoResponse, *responses.ErrorResponse) {
 	// prepare query
 	query := `
 		SELECT
-			id, user_uuid, first_name, last_name, user_name, password, email, 
-			login_session, profile_photo, status_id, "order", created_by, created_at,
+			id, user_uuid, first_name, last_name, user_name, password, email, email_verified_at,
+			login_session, profile_photo, language, status_id, "order", created_by, created_at,
 			updated_by, updated_at, deleted_by, deleted_at
 		FROM tbl_users
 		WHERE deleted_at IS NULL AND id = $1
@@ -62,12 +77,60 @@ func (u *UserRepoImpl) Info() (*UserInfoResponse, *responses.ErrorResponse) {
 
 	user.UserDatabases = user_databases
 
+	// get the databases shared through workspaces
+	workspace_databases, err := u.getWorkspaceDatabases(user.ID)
+	if err != nil {
+		custom_log.NewCustomLog("userinfo_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("userinfo_show_failed", fmt.Errorf("error_get_user_database"))
+	}
+
+	user.WorkspaceDatabases = workspace_databases
+	user.Role = u.UserContext.Role
+	user.Permissions = u.UserContext.Permissions
+
 	return &UserInfoResponse{
 		UserInfo: user,
 	}, nil
 
 }
 
+// UpdateMFA requires a user to sign in with a second factor, a user without
+// one is asked to set it up on its next login
+func (u *UserRepoImpl) UpdateMFA(user_uuid string, mfa_req UserMFARequest) (*UserMFAResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			mfa_required = $1, updated_by = $2, updated_at = $3
+		WHERE deleted_at IS NULL
+		AND user_uuid = $4
+		RETURNING user_name
+	`
+
+	// execute request
+	var user_name string
+	if err := u.DBPool.Get(&user_name, query, *mfa_req.Required, u.UserContext.Id, utils.Now(), user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("user_mfa_update_failed", fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog("user_mfa_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_mfa_update_failed", fmt.Errorf("error_update_mfa"))
+	}
+
+	desc := fmt.Sprintf("User %s required two-factor authentication for %s", u.UserContext.UserName, user_name)
+	if !*mfa_req.Required {
+		desc = fmt.Sprintf("User %s lifted the two-factor authentication requirement of %s", u.UserContext.UserName, user_name)
+	}
+	utils.AuditUserAction(u.UserContext, "mfa_require", desc, constants.AuditTypeUser, nil, u.DBPool)
+
+	return &UserMFAResponse{
+		UserUUID:    user_uuid,
+		MFARequired: *mfa_req.Required,
+	}, nil
+}
+
 func (u *UserRepoImpl) getUserDatabases(user_id int) ([]UserDatabase, error) {
 	// prepare query
 	query := `
@@ -75,6 +138,7 @@ func (u *UserRepoImpl) getUserDatabases(user_id int) ([]UserDatabase, error) {
 			db_uuid, db_name, host, port
 		FROM tbl_users_databases
 		WHERE deleted_at IS NULL
+		AND workspace_id IS NULL
 		AND user_id = $1
 	`
 
@@ -90,3 +154,449 @@ func (u *UserRepoImpl) getUserDatabases(user_id int) ([]UserDatabase, error) {
 
 	return databases, nil
 }
+
+func (u *UserRepoImpl) getWorkspaceDatabases(user_id int) ([]WorkspaceDatabase, error) {
+	// prepare query
+	query := `
+		SELECT
+			db.db_uuid, db.db_name, db.host, db.port, ws.workspace_uuid,
+			ws.workspace_name, wm.member_role
+		FROM tbl_users_databases db
+		INNER JOIN tbl_workspaces ws ON ws.id = db.workspace_id
+		INNER JOIN tbl_workspaces_members wm ON wm.workspace_id = ws.id
+		WHERE db.deleted_at IS NULL
+		AND ws.deleted_at IS NULL
+		AND wm.deleted_at IS NULL
+		AND wm.user_id = $1
+		ORDER BY ws.id, db.id
+	`
+
+	// execute query
+	var databases []WorkspaceDatabase
+	if err := u.DBPool.Select(&databases, query, user_id); err != nil {
+		return nil, err
+	}
+
+	if databases == nil {
+		databases = []WorkspaceDatabase{}
+	}
+
+	return databases, nil
+}
+
+// Unlock lifts the lockout a user got from too many failed logins
+func (u *UserRepoImpl) Unlock(user_uuid string) (*UserUnlockResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT user_name
+		FROM tbl_users
+		WHERE deleted_at IS NULL
+		AND user_uuid = $1
+	`
+
+	// execute request
+	var user_name string
+	if err := u.DBPool.Get(&user_name, query, user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("user_unlock_failed", fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog("user_unlock_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_unlock_failed", fmt.Errorf("get_user_error"))
+	}
+
+	if err := auth.UnlockLogin(user_name); err != nil {
+		custom_log.NewCustomLog("user_unlock_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_unlock_failed", fmt.Errorf("error_unlock_user"))
+	}
+
+	utils.AuditUserAction(u.UserContext, "account_unlock", fmt.Sprintf("User %s unlocked the account of %s", u.UserContext.UserName, user_name), constants.AuditTypeUser, nil, u.DBPool)
+
+	return &UserUnlockResponse{
+		UserUUID: user_uuid,
+		UserName: user_name,
+	}, nil
+}
+
+const selectManagedUser = `
+	SELECT
+		us.id, us.user_uuid, us.first_name, us.last_name, us.user_name, us.email,
+		us.email_verified_at, us.status_id, ro.role_name, us.mfa_required,
+		EXISTS (
+			SELECT 1 FROM tbl_users_totp tt
+			WHERE tt.user_id = us.id
+			AND tt.confirmed_at IS NOT NULL
+		) AS mfa_enabled,
+		us.created_at, us.updated_at
+	FROM tbl_users us
+	INNER JOIN tbl_roles ro ON ro.id = us.role_id
+`
+
+func (u *UserRepoImpl) List(list_req UserListRequest) ([]ManagedUser, int, *responses.ErrorResponse) {
+	where, args := list_req.where()
+
+	// execute query
+	var users []ManagedUser
+	total, err := postgres.SelectList(u.DBPool, &users, selectManagedUser+where, args, list_req.ListRequest, userListFields)
+	if err != nil {
+		custom_log.NewCustomLog("user_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("user_list_failed", fmt.Errorf("get_user_error"))
+	}
+
+	if users == nil {
+		users = []ManagedUser{}
+	}
+	for i := range users {
+		users[i].StatusName = statusName(users[i].StatusID)
+	}
+
+	return users, total, nil
+}
+
+func (u *UserRepoImpl) ShowOne(user_uuid string) (*ManagedUserResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectManagedUser + `
+		WHERE us.deleted_at IS NULL
+		AND us.user_uuid = $1
+	`
+
+	// execute query
+	var user ManagedUser
+	if err := u.DBPool.Get(&user, query, user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("user_show_failed", fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog("user_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_show_failed", fmt.Errorf("get_user_error"))
+	}
+	user.StatusName = statusName(user.StatusID)
+
+	return &ManagedUserResponse{
+		User: user,
+	}, nil
+}
+
+// Create adds a user on behalf of an administrator, nobody hands out a role
+// as high as its own, the new user is asked to confirm its email
+func (u *UserRepoImpl) Create(create_req UserCreateRequest) (*ManagedUserResponse, *responses.ErrorResponse) {
+	if create_req.Role == "" {
+		create_req.Role = constants.RoleViewer
+	}
+	if create_req.StatusID == 0 {
+		create_req.StatusID = constants.UserStatusActive
+	}
+
+	if constants.RoleRank[create_req.Role] >= constants.RoleRank[u.UserContext.Role] {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_create_failed", fmt.Errorf("role_not_assignable"))
+	}
+
+	exists, err := postgres.IsExists("tbl_users", "user_name", create_req.UserName, u.DBPool)
+	if err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_create_failed", fmt.Errorf("error_database"))
+	}
+	if exists {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_create_failed", fmt.Errorf("username_already_exists"))
+	}
+
+	id, err := postgres.GetSeqNextVal("tbl_users_id_seq", u.DBPool)
+	if err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_create_failed", fmt.Errorf("error_database"))
+	}
+
+	user_uuid, err := uuid.NewV7()
+	if err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_create_failed", fmt.Errorf("error_database"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_users (
+			id, user_uuid, first_name, last_name, user_name, password, email,
+			status_id, role_id, "order", created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7,
+			$8, (SELECT id FROM tbl_roles WHERE role_name = $9), $1, $10, $11
+		)
+	`
+
+	// execute query
+	if _, err := u.DBPool.Exec(
+		query,
+		*id, user_uuid, create_req.FirstName, create_req.LastName, create_req.UserName, create_req.Password, create_req.Email,
+		create_req.StatusID, create_req.Role, u.UserContext.Id, utils.Now(),
+	); err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_create_failed", fmt.Errorf("error_database"))
+	}
+
+	// the user exists either way, an unsent mail can be sent again later
+	if err := auth.NewAuthRepoImpl(u.UserContext, u.DBPool).SendEmailVerification(int(*id)); err != nil {
+		custom_log.NewCustomLog("user_create_failed", err.Error(), "error")
+	}
+
+	utils.AuditUserAction(u.UserContext, "user_create", fmt.Sprintf("User %s created the user %s with the role %s", u.UserContext.UserName, create_req.UserName, create_req.Role), constants.AuditTypeUser, nil, u.DBPool)
+
+	return u.ShowOne(user_uuid.String())
+}
+
+// UpdateStatus moves a user among the statuses of share.StatusData, a user
+// who is no longer active is signed out at once
+func (u *UserRepoImpl) UpdateStatus(user_uuid string, status_req UserStatusRequest) (*UserStatusResponse, *responses.ErrorResponse) {
+	target, err_resp := u.target(user_uuid, "user_status_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	tx, err := u.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("user_status_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_status_update_failed", fmt.Errorf("error_database"))
+	}
+	defer tx.Rollback()
+
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			status_id = $1, updated_by = $2, updated_at = $3,
+			deleted_by = CASE WHEN $4 THEN $2 END,
+			deleted_at = CASE WHEN $4 THEN $3::TIMESTAMP END
+		WHERE id = $5
+	`
+
+	// execute query
+	now := utils.Now()
+	deleted := status_req.StatusID == constants.UserStatusDeleted
+	if _, err := tx.Exec(query, status_req.StatusID, u.UserContext.Id, now, deleted, target.ID); err != nil {
+		custom_log.NewCustomLog("user_status_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_status_update_failed", fmt.Errorf("error_database"))
+	}
+
+	var revoked int64
+	if status_req.StatusID != constants.UserStatusActive {
+		if revoked, err = revokeSessions(tx, target.ID, u.UserContext.Id, "status_changed"); err != nil {
+			custom_log.NewCustomLog("user_status_update_failed", err.Error(), "error")
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("user_status_update_failed", fmt.Errorf("error_database"))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("user_status_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_status_update_failed", fmt.Errorf("error_database"))
+	}
+
+	utils.AuditUserAction(u.UserContext, "user_status", fmt.Sprintf("User %s changed the status of %s from %s to %s", u.UserContext.UserName, target.UserName, statusName(target.StatusID), statusName(status_req.StatusID)), constants.AuditTypeUser, nil, u.DBPool)
+
+	return &UserStatusResponse{
+		UserUUID:   user_uuid,
+		StatusID:   status_req.StatusID,
+		StatusName: statusName(status_req.StatusID),
+		Revoked:    revoked,
+	}, nil
+}
+
+// UpdateRole assigns a role below the one of the caller, the new role takes
+// effect on the next request since the middleware reads it every time
+func (u *UserRepoImpl) UpdateRole(user_uuid string, role_req UserRoleRequest) (*ManagedUserResponse, *responses.ErrorResponse) {
+	target, err_resp := u.target(user_uuid, "user_role_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	if constants.RoleRank[role_req.Role] >= constants.RoleRank[u.UserContext.Role] {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_role_update_failed", fmt.Errorf("role_not_assignable"))
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			role_id = (SELECT id FROM tbl_roles WHERE role_name = $1),
+			updated_by = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	// execute query
+	if _, err := u.DBPool.Exec(query, role_req.Role, u.UserContext.Id, utils.Now(), target.ID); err != nil {
+		custom_log.NewCustomLog("user_role_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_role_update_failed", fmt.Errorf("error_database"))
+	}
+
+	utils.AuditUserAction(u.UserContext, "user_role", fmt.Sprintf("User %s changed the role of %s from %s to %s", u.UserContext.UserName, target.UserName, target.Role, role_req.Role), constants.AuditTypeUser, nil, u.DBPool)
+
+	return u.ShowOne(user_uuid)
+}
+
+// ForcePasswordReset throws away the password of a user, signs it out and
+// mails it a reset link, until then nobody can sign in with a password
+func (u *UserRepoImpl) ForcePasswordReset(user_uuid string) (*UserPasswordResetResponse, *responses.ErrorResponse) {
+	target, err_resp := u.target(user_uuid, "user_password_reset_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	tx, err := u.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_password_reset_failed", fmt.Errorf("error_database"))
+	}
+	defer tx.Rollback()
+
+	// prepare query
+	query := `
+		UPDATE tbl_users SET
+			password = $1, updated_by = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	// execute query
+	if _, err := tx.Exec(query, password, u.UserContext.Id, utils.Now(), target.ID); err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	revoked, err := revokeSessions(tx, target.ID, u.UserContext.Id, "password_reset_forced")
+	if err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_password_reset_failed", fmt.Errorf("error_database"))
+	}
+
+	utils.AuditUserAction(u.UserContext, "password_reset_forced", fmt.Sprintf("User %s forced a password reset of %s", u.UserContext.UserName, target.UserName), constants.AuditTypeUser, nil, u.DBPool)
+
+	// a lockout would keep the user from the new password
+	if err := auth.UnlockLogin(target.UserName); err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "warn")
+	}
+
+	// the user can still ask for a link itself when this mail is lost
+	if err := auth.NewAuthRepoImpl(u.UserContext, u.DBPool).SendPasswordReset(target.ID); err != nil {
+		custom_log.NewCustomLog("user_password_reset_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_password_reset_failed", fmt.Errorf("error_send_mail"))
+	}
+
+	return &UserPasswordResetResponse{
+		UserUUID: user_uuid,
+		Email:    target.Email,
+		Revoked:  revoked,
+	}, nil
+}
+
+// RevokeSessions signs a user out of every device
+func (u *UserRepoImpl) RevokeSessions(user_uuid string) (*UserSessionsResponse, *responses.ErrorResponse) {
+	target, err_resp := u.target(user_uuid, "user_session_revoke_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	revoked, err := revokeSessions(u.DBPool, target.ID, u.UserContext.Id, "revoked_by_admin")
+	if err != nil {
+		custom_log.NewCustomLog("user_session_revoke_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("user_session_revoke_failed", fmt.Errorf("error_database"))
+	}
+
+	utils.AuditUserAction(u.UserContext, "session_revoke", fmt.Sprintf("User %s revoked %d session(s) of %s", u.UserContext.UserName, revoked, target.UserName), constants.AuditTypeUser, nil, u.DBPool)
+
+	return &UserSessionsResponse{
+		UserUUID: user_uuid,
+		Revoked:  revoked,
+	}, nil
+}
+
+// Statuses lists the statuses a user can be moved to
+func (u *UserRepoImpl) Statuses() *UserStatusesResponse {
+	return &UserStatusesResponse{
+		Statuses: types.StatusData,
+	}
+}
+
+// target loads the user an administrator acts on, nobody manages itself or a
+// user whose role is as high as its own
+func (u *UserRepoImpl) target(user_uuid string, message_id string) (*managedTarget, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT us.id, us.user_name, us.email, us.status_id, ro.role_name
+		FROM tbl_users us
+		INNER JOIN tbl_roles ro ON ro.id = us.role_id
+		WHERE us.deleted_at IS NULL
+		AND us.user_uuid = $1
+	`
+
+	// execute query
+	var target managedTarget
+	if err := u.DBPool.Get(&target, query, user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_user_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_user_error"))
+	}
+
+	if target.ID == u.UserContext.Id {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("cannot_manage_self"))
+	}
+
+	if constants.RoleRank[target.Role] >= constants.RoleRank[u.UserContext.Role] {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("cannot_manage_user"))
+	}
+
+	return &target, nil
+}
+
+// revokeSessions closes every open session of a user
+func revokeSessions(exec sqlx.Execer, user_id int, revoked_by int, reason string) (int64, error) {
+	// prepare query
+	query := `
+		UPDATE tbl_users_sessions SET
+			revoked_by = $1, revoked_at = $2, revoked_reason = $3, updated_at = $2
+		WHERE user_id = $4
+		AND revoked_at IS NULL
+	`
+
+	// execute query
+	result, err := exec.Exec(query, revoked_by, utils.Now(), reason, user_id)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -1,6 +1,9 @@
 package user
 
 import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 )
@@ -24,5 +27,18 @@ func (u *UserRoute) RegisterUserRoute() *UserRoute {
 
 	user.Get("/info", u.UserHandler.Info)
 
+	// administration of the other users
+	manage := middlewares.RequirePermission(constants.PermissionManageUsers)
+	user.Get("/", manage, u.UserHandler.List)
+	user.Post("/", manage, u.UserHandler.Create)
+	user.Get("/status", manage, u.UserHandler.Statuses)
+	user.Get("/:user_uuid", manage, u.UserHandler.ShowOne)
+	user.Put("/:user_uuid/status", manage, u.UserHandler.UpdateStatus)
+	user.Put("/:user_uuid/role", manage, u.UserHandler.UpdateRole)
+	user.Post("/:user_uuid/password-reset", manage, u.UserHandler.ForcePasswordReset)
+	user.Delete("/:user_uuid/session", manage, u.UserHandler.RevokeSessions)
+	user.Put("/:user_uuid/mfa", manage, u.UserHandler.UpdateMFA)
+	user.Post("/:user_uuid/unlock", manage, u.UserHandler.Unlock)
+
 	return u
 }
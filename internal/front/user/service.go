@@ -9,6 +9,16 @@ import (
 
 type UserServiceCreator interface {
 	Info() (*UserInfoResponse, *responses.ErrorResponse)
+	UpdateMFA(user_uuid string, mfa_req UserMFARequest) (*UserMFAResponse, *responses.ErrorResponse)
+	Unlock(user_uuid string) (*UserUnlockResponse, *responses.ErrorResponse)
+	List(list_req UserListRequest) ([]ManagedUser, int, *responses.ErrorResponse)
+	ShowOne(user_uuid string) (*ManagedUserResponse, *responses.ErrorResponse)
+	Create(create_req UserCreateRequest) (*ManagedUserResponse, *responses.ErrorResponse)
+	UpdateStatus(user_uuid string, status_req UserStatusRequest) (*UserStatusResponse, *responses.ErrorResponse)
+	UpdateRole(user_uuid string, role_req UserRoleRequest) (*ManagedUserResponse, *responses.ErrorResponse)
+	ForcePasswordReset(user_uuid string) (*UserPasswordResetResponse, *responses.ErrorResponse)
+	RevokeSessions(user_uuid string) (*UserSessionsResponse, *responses.ErrorResponse)
+	Statuses() *UserStatusesResponse
 }
 
 type UserService struct {
@@ -28,3 +38,43 @@ func NewUserService(us_ctx *types.UserContext, db_pool *sqlx.DB) *UserService {
 func (u *UserService) Info() (*UserInfoResponse, *responses.ErrorResponse) {
 	return u.UserRepo.Info()
 }
+
+func (u *UserService) UpdateMFA(user_uuid string, mfa_req UserMFARequest) (*UserMFAResponse, *responses.ErrorResponse) {
+	return u.UserRepo.UpdateMFA(user_uuid, mfa_req)
+}
+
+func (u *UserService) Unlock(user_uuid string) (*UserUnlockResponse, *responses.ErrorResponse) {
+	return u.UserRepo.Unlock(user_uuid)
+}
+
+func (u *UserService) List(list_req UserListRequest) ([]ManagedUser, int, *responses.ErrorResponse) {
+	return u.UserRepo.List(list_req)
+}
+
+func (u *UserService) ShowOne(user_uuid string) (*ManagedUserResponse, *responses.ErrorResponse) {
+	return u.UserRepo.ShowOne(user_uuid)
+}
+
+func (u *UserService) Create(create_req UserCreateRequest) (*ManagedUserResponse, *responses.ErrorResponse) {
+	return u.UserRepo.Create(create_req)
+}
+
+func (u *UserService) UpdateStatus(user_uuid string, status_req UserStatusRequest) (*UserStatusResponse, *responses.ErrorResponse) {
+	return u.UserRepo.UpdateStatus(user_uuid, status_req)
+}
+
+func (u *UserService) UpdateRole(user_uuid string, role_req UserRoleRequest) (*ManagedUserResponse, *responses.ErrorResponse) {
+	return u.UserRepo.UpdateRole(user_uuid, role_req)
+}
+
+func (u *UserService) ForcePasswordReset(user_uuid string) (*UserPasswordResetResponse, *responses.ErrorResponse) {
+	return u.UserRepo.ForcePasswordReset(user_uuid)
+}
+
+func (u *UserService) RevokeSessions(user_uuid string) (*UserSessionsResponse, *responses.ErrorResponse) {
+	return u.UserRepo.RevokeSessions(user_uuid)
+}
+
+func (u *UserService) Statuses() *UserStatusesResponse {
+	return u.UserRepo.Statuses()
+}
@@ -0,0 +1,528 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkspaceHandler struct {
+	DBPool           *sqlx.DB
+	WorkspaceService func(c *fiber.Ctx) *WorkspaceService
+}
+
+func NewWorkspaceHandler(db_pool *sqlx.DB) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		DBPool: db_pool,
+		WorkspaceService: func(c *fiber.Ctx) *WorkspaceService {
+			user_context := c.Locals("UserContext")
+
+			var us_ctx types.UserContext
+			if context_map, ok := user_context.(types.UserContext); ok {
+				us_ctx = context_map
+			} else {
+				custom_log.NewCustomLog("user_context_failed", "Failed to cast UserContext to map[string]interface{}", "warn")
+				us_ctx = types.UserContext{}
+			}
+
+			return NewWorkspaceService(&us_ctx, db_pool)
+		},
+	}
+}
+
+func (w *WorkspaceHandler) Create(c *fiber.Ctx) error {
+	var workspace_req WorkspaceRequest
+	v := utils.NewValidator()
+
+	if err := workspace_req.bind(c, v, "workspace_create_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_create_failed", nil, c),
+				-16000,
+				err,
+			),
+		)
+	}
+
+	resp, err := w.WorkspaceService(c).Create(workspace_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16000,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_create_success", nil, c),
+			16000,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) List(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, workspaceListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_list_failed", nil, c),
+				-16001,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := w.WorkspaceService(c).List(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16001,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("workspace_list_success", nil, c),
+			16001,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) ShowOne(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+
+	resp, err := w.WorkspaceService(c).ShowOne(workspace_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16002,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_show_success", nil, c),
+			16002,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) Update(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+
+	var workspace_req WorkspaceRequest
+	v := utils.NewValidator()
+
+	if err := workspace_req.bind(c, v, "workspace_update_failed"); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_update_failed", nil, c),
+				-16003,
+				err,
+			),
+		)
+	}
+
+	resp, err := w.WorkspaceService(c).Update(workspace_uuid, workspace_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16003,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_update_success", nil, c),
+			16003,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) Delete(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+
+	resp, err := w.WorkspaceService(c).Delete(workspace_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16004,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_delete_success", nil, c),
+			16004,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) Invite(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+
+	var invite_req WorkspaceInviteRequest
+	v := utils.NewValidator()
+
+	if err := invite_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_invite_failed", nil, c),
+				-16005,
+				err,
+			),
+		)
+	}
+
+	resp, err := w.WorkspaceService(c).Invite(workspace_uuid, invite_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16005,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusCreated).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_invite_success", nil, c),
+			16005,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) ListInvitations(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, invitationListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_invitation_list_failed", nil, c),
+				-16006,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := w.WorkspaceService(c).ListInvitations(workspace_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16006,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("workspace_invitation_list_success", nil, c),
+			16006,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) RevokeInvitation(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+	invitation_uuid := c.Params("invitation_uuid")
+
+	resp, err := w.WorkspaceService(c).RevokeInvitation(workspace_uuid, invitation_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16007,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_invitation_revoke_success", nil, c),
+			16007,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) MyInvitations(c *fiber.Ctx) error {
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, invitationListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_invitation_list_failed", nil, c),
+				-16008,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := w.WorkspaceService(c).MyInvitations(list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16008,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("workspace_invitation_list_success", nil, c),
+			16008,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) AcceptInvitation(c *fiber.Ctx) error {
+	invitation_uuid := c.Params("invitation_uuid")
+
+	resp, err := w.WorkspaceService(c).AcceptInvitation(invitation_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16009,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_invitation_accept_success", nil, c),
+			16009,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) DeclineInvitation(c *fiber.Ctx) error {
+	invitation_uuid := c.Params("invitation_uuid")
+
+	resp, err := w.WorkspaceService(c).DeclineInvitation(invitation_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16010,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_invitation_decline_success", nil, c),
+			16010,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) UpdateMember(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+	user_uuid := c.Params("user_uuid")
+
+	var member_req WorkspaceMemberRequest
+	v := utils.NewValidator()
+
+	if err := member_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_member_update_failed", nil, c),
+				-16011,
+				err,
+			),
+		)
+	}
+
+	resp, err := w.WorkspaceService(c).UpdateMember(workspace_uuid, user_uuid, member_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16011,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_member_update_success", nil, c),
+			16011,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) RemoveMember(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := w.WorkspaceService(c).RemoveMember(workspace_uuid, user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16012,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_member_remove_success", nil, c),
+			16012,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) UpdateAccess(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+	db_uuid := c.Params("db_uuid")
+	user_uuid := c.Params("user_uuid")
+
+	var access_req WorkspaceAccessRequest
+	v := utils.NewValidator()
+
+	if err := access_req.bind(c, v); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_access_update_failed", nil, c),
+				-16013,
+				err,
+			),
+		)
+	}
+
+	resp, err := w.WorkspaceService(c).UpdateAccess(workspace_uuid, db_uuid, user_uuid, access_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16013,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_access_update_success", nil, c),
+			16013,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) DeleteAccess(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+	db_uuid := c.Params("db_uuid")
+	user_uuid := c.Params("user_uuid")
+
+	resp, err := w.WorkspaceService(c).DeleteAccess(workspace_uuid, db_uuid, user_uuid)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16014,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponse(
+			utils.Translate("workspace_access_delete_success", nil, c),
+			16014,
+			resp,
+		),
+	)
+}
+
+func (w *WorkspaceHandler) ListAccess(c *fiber.Ctx) error {
+	workspace_uuid := c.Params("workspace_uuid")
+	db_uuid := c.Params("db_uuid")
+
+	var list_req types.ListRequest
+	if err := utils.BindList(c, &list_req, accessListFields); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate("workspace_access_list_failed", nil, c),
+				-16015,
+				err,
+			),
+		)
+	}
+
+	resp, total, err := w.WorkspaceService(c).ListAccess(workspace_uuid, db_uuid, list_req)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(
+			response.NewResponseError(
+				utils.Translate(err.MessageID, nil, c),
+				-16015,
+				errors.New(utils.Translate(err.Err.Error(), nil, c)),
+			),
+		)
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		response.NewResponseWithPaging(
+			utils.Translate("workspace_access_list_success", nil, c),
+			16015,
+			resp,
+			list_req.Page,
+			list_req.PerPage,
+			total,
+		),
+	)
+}
@@ -0,0 +1,263 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Workspace struct {
+	ID            uint64            `json:"-" db:"id"`
+	WorkspaceUUID string            `json:"workspace_uuid" db:"workspace_uuid"`
+	WorkspaceName string            `json:"workspace_name" db:"workspace_name"`
+	WorkspaceDesc *string           `json:"workspace_desc" db:"workspace_desc"`
+	MemberRole    string            `json:"member_role" db:"member_role"`
+	MemberCount   int               `json:"member_count" db:"member_count"`
+	DatabaseCount int               `json:"database_count" db:"database_count"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	Members       []WorkspaceMember `json:"members,omitempty" db:"-"`
+}
+
+// workspaceListFields are the columns the workspace list filters and sorts on
+var workspaceListFields = postgres.ListFields{
+	Filter:      []string{"workspace_uuid", "workspace_name", "member_role", "member_count", "database_count", "created_at"},
+	Sort:        []string{"workspace_name", "member_role", "member_count", "database_count", "created_at"},
+	DefaultSort: "id",
+}
+
+type WorkspaceResponse struct {
+	Workspace Workspace `json:"workspace"`
+}
+
+type WorkspaceMember struct {
+	ID         uint64    `json:"-" db:"id"`
+	UserID     uint64    `json:"-" db:"user_id"`
+	UserUUID   string    `json:"user_uuid" db:"user_uuid"`
+	UserName   string    `json:"user_name" db:"user_name"`
+	Email      string    `json:"email" db:"email"`
+	MemberRole string    `json:"member_role" db:"member_role"`
+	CreatedAt  time.Time `json:"joined_at" db:"created_at"`
+}
+
+type WorkspaceRequest struct {
+	WorkspaceName string `json:"workspace_name" validate:"required,max=100"`
+	WorkspaceDesc string `json:"workspace_desc" validate:"omitempty,max=500"`
+}
+
+func (w *WorkspaceRequest) bind(c *fiber.Ctx, v *utils.Validator, message_id string) error {
+	if err := c.BodyParser(w); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(w, c); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		return err
+	}
+
+	w.WorkspaceName = strings.TrimSpace(w.WorkspaceName)
+
+	return nil
+}
+
+type WorkspaceNewModel struct {
+	ID            uint64    `db:"id"`
+	WorkspaceUUID string    `db:"workspace_uuid"`
+	WorkspaceName string    `db:"workspace_name"`
+	WorkspaceDesc *string   `db:"workspace_desc"`
+	CreatedBy     int       `db:"created_by"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (w *WorkspaceNewModel) new(workspace_req WorkspaceRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_workspaces_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	w.ID = uint64(*id)
+	w.WorkspaceUUID = uuid.String()
+	w.WorkspaceName = workspace_req.WorkspaceName
+	if workspace_req.WorkspaceDesc != "" {
+		w.WorkspaceDesc = &workspace_req.WorkspaceDesc
+	}
+	w.CreatedBy = us_ctx.Id
+	w.CreatedAt = utils.Now()
+
+	return nil
+}
+
+type WorkspaceInvitation struct {
+	ID               uint64     `json:"-" db:"id"`
+	InvitationUUID   string     `json:"invitation_uuid" db:"invitation_uuid"`
+	WorkspaceID      uint64     `json:"-" db:"workspace_id"`
+	WorkspaceUUID    string     `json:"workspace_uuid" db:"workspace_uuid"`
+	WorkspaceName    string     `json:"workspace_name" db:"workspace_name"`
+	Email            string     `json:"email" db:"email"`
+	MemberRole       string     `json:"member_role" db:"member_role"`
+	InvitationStatus string     `json:"invitation_status" db:"invitation_status"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	InvitedBy        *string    `json:"invited_by" db:"invited_by"`
+	RespondedAt      *time.Time `json:"responded_at" db:"responded_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+type WorkspaceInvitationResponse struct {
+	Invitation WorkspaceInvitation `json:"invitation"`
+}
+
+// invitationListFields are the columns the invitation lists filter and sort on
+var invitationListFields = postgres.ListFields{
+	Filter:      []string{"invitation_uuid", "workspace_uuid", "workspace_name", "email", "member_role", "invitation_status", "expires_at", "invited_by", "responded_at", "created_at"},
+	Sort:        []string{"workspace_name", "email", "member_role", "invitation_status", "expires_at", "invited_by", "responded_at", "created_at"},
+	DefaultSort: "id DESC",
+}
+
+type WorkspaceInviteRequest struct {
+	Email      string `json:"email" validate:"required,email"`
+	MemberRole string `json:"member_role" validate:"required,oneof=admin member"`
+}
+
+func (w *WorkspaceInviteRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(w); err != nil {
+		custom_log.NewCustomLog("workspace_invite_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(w, c); err != nil {
+		custom_log.NewCustomLog("workspace_invite_failed", err.Error(), "error")
+		return err
+	}
+
+	w.Email = strings.ToLower(strings.TrimSpace(w.Email))
+
+	return nil
+}
+
+type WorkspaceInvitationNewModel struct {
+	ID               uint64    `db:"id"`
+	InvitationUUID   string    `db:"invitation_uuid"`
+	WorkspaceID      uint64    `db:"workspace_id"`
+	Email            string    `db:"email"`
+	MemberRole       string    `db:"member_role"`
+	InvitationStatus string    `db:"invitation_status"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	CreatedBy        int       `db:"created_by"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+func (w *WorkspaceInvitationNewModel) new(workspace_id uint64, invite_req WorkspaceInviteRequest, us_ctx *types.UserContext, conn *sqlx.DB) error {
+	// get sequence next value
+	id, err := postgres.GetSeqNextVal("tbl_workspaces_invitations_id_seq", conn)
+	if err != nil {
+		return fmt.Errorf("error get seq : %w", err)
+	}
+
+	// generate new uuid
+	uuid, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("error generate new uuid : %w", err)
+	}
+
+	now := utils.Now()
+
+	w.ID = uint64(*id)
+	w.InvitationUUID = uuid.String()
+	w.WorkspaceID = workspace_id
+	w.Email = invite_req.Email
+	w.MemberRole = invite_req.MemberRole
+	w.InvitationStatus = constants.InvitationStatusPending
+	w.ExpiresAt = now.Add(time.Duration(utils.GetenvInt("WORKSPACE_INVITATION_EXP_DAYS", 7)) * 24 * time.Hour)
+	w.CreatedBy = us_ctx.Id
+	w.CreatedAt = now
+
+	return nil
+}
+
+type WorkspaceMemberRequest struct {
+	MemberRole string `json:"member_role" validate:"required,oneof=admin member"`
+}
+
+func (w *WorkspaceMemberRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(w); err != nil {
+		custom_log.NewCustomLog("workspace_member_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(w, c); err != nil {
+		custom_log.NewCustomLog("workspace_member_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// WorkspaceAccess is the access level of a member on a workspace database,
+// access_override is only set when the level differs from the member role
+type WorkspaceAccess struct {
+	ID             uint64  `json:"-" db:"id"`
+	UserID         uint64  `json:"-" db:"user_id"`
+	UserUUID       string  `json:"user_uuid" db:"user_uuid"`
+	UserName       string  `json:"user_name" db:"user_name"`
+	MemberRole     string  `json:"member_role" db:"member_role"`
+	AccessOverride *string `json:"access_override" db:"access_override"`
+	AccessLevel    string  `json:"access_level" db:"-"`
+}
+
+// accessListFields are the columns the access list filters and sorts on
+var accessListFields = postgres.ListFields{
+	Filter:      []string{"user_uuid", "user_name", "member_role", "access_override"},
+	Sort:        []string{"user_name", "member_role", "access_override"},
+	DefaultSort: "id",
+}
+
+type WorkspaceAccessResponse struct {
+	DBUUID string            `json:"db_uuid"`
+	Access []WorkspaceAccess `json:"access"`
+}
+
+type WorkspaceAccessRequest struct {
+	AccessLevel string `json:"access_level" validate:"required,oneof=read write admin"`
+}
+
+func (w *WorkspaceAccessRequest) bind(c *fiber.Ctx, v *utils.Validator) error {
+	if err := c.BodyParser(w); err != nil {
+		custom_log.NewCustomLog("workspace_access_update_failed", err.Error(), "error")
+		return errors.New(utils.Translate("invalid_body", nil, c))
+	}
+
+	if err := v.Validate(w, c); err != nil {
+		custom_log.NewCustomLog("workspace_access_update_failed", err.Error(), "error")
+		return err
+	}
+
+	return nil
+}
+
+// membership is the current user's place in a workspace
+type membership struct {
+	WorkspaceID   uint64 `db:"workspace_id"`
+	WorkspaceName string `db:"workspace_name"`
+	MemberRole    string `db:"member_role"`
+}
+
+func (m membership) isAdmin() bool {
+	return m.MemberRole == constants.WorkspaceRoleOwner || m.MemberRole == constants.WorkspaceRoleAdmin
+}
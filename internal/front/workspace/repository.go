@@ -0,0 +1,950 @@
+package workspace
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"tarantool-admin-api/pkg/constants"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+	"tarantool-admin-api/pkg/responses"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkspaceRepo interface {
+	Create(workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse)
+	List(list_req types.ListRequest) ([]Workspace, int, *responses.ErrorResponse)
+	ShowOne(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	Update(workspace_uuid string, workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse)
+	Delete(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	Invite(workspace_uuid string, invite_req WorkspaceInviteRequest) (*WorkspaceInvitationResponse, *responses.ErrorResponse)
+	ListInvitations(workspace_uuid string, list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse)
+	RevokeInvitation(workspace_uuid string, invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse)
+	MyInvitations(list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse)
+	AcceptInvitation(invitation_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	DeclineInvitation(invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse)
+	UpdateMember(workspace_uuid string, user_uuid string, member_req WorkspaceMemberRequest) (*WorkspaceResponse, *responses.ErrorResponse)
+	RemoveMember(workspace_uuid string, user_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	ListAccess(workspace_uuid string, db_uuid string, list_req types.ListRequest) ([]WorkspaceAccess, int, *responses.ErrorResponse)
+	UpdateAccess(workspace_uuid string, db_uuid string, user_uuid string, access_req WorkspaceAccessRequest) (*WorkspaceAccessResponse, *responses.ErrorResponse)
+	DeleteAccess(workspace_uuid string, db_uuid string, user_uuid string) (*WorkspaceAccessResponse, *responses.ErrorResponse)
+}
+
+type WorkspaceRepoImpl struct {
+	DBPool      *sqlx.DB
+	UserContext *types.UserContext
+}
+
+func NewWorkspaceRepoImpl(us_ctx *types.UserContext, db_pool *sqlx.DB) *WorkspaceRepoImpl {
+	return &WorkspaceRepoImpl{
+		DBPool:      db_pool,
+		UserContext: us_ctx,
+	}
+}
+
+const selectWorkspace = `
+	SELECT
+		ws.id, ws.workspace_uuid, ws.workspace_name, ws.workspace_desc, wm.member_role,
+		(
+			SELECT COUNT(*) FROM tbl_workspaces_members
+			WHERE workspace_id = ws.id AND deleted_at IS NULL
+		) AS member_count,
+		(
+			SELECT COUNT(*) FROM tbl_users_databases
+			WHERE workspace_id = ws.id AND deleted_at IS NULL
+		) AS database_count,
+		ws.created_at
+	FROM tbl_workspaces ws
+	INNER JOIN tbl_workspaces_members wm ON wm.workspace_id = ws.id
+	WHERE ws.deleted_at IS NULL
+	AND wm.deleted_at IS NULL
+`
+
+const selectInvitation = `
+	SELECT
+		wi.id, wi.invitation_uuid, wi.workspace_id, ws.workspace_uuid, ws.workspace_name,
+		wi.email, wi.member_role, wi.invitation_status, wi.expires_at,
+		us.user_name AS invited_by, wi.responded_at, wi.created_at
+	FROM tbl_workspaces_invitations wi
+	INNER JOIN tbl_workspaces ws ON ws.id = wi.workspace_id
+	LEFT JOIN tbl_users us ON us.id = wi.created_by
+	WHERE ws.deleted_at IS NULL
+`
+
+const selectAccess = `
+	SELECT
+		wm.id, wm.user_id, us.user_uuid, us.user_name, wm.member_role,
+		da.access_level AS access_override
+	FROM tbl_workspaces_members wm
+	INNER JOIN tbl_users us ON us.id = wm.user_id
+	LEFT JOIN tbl_workspaces_databases_access da ON da.db_id = $1 AND da.user_id = wm.user_id AND da.deleted_at IS NULL
+	WHERE wm.deleted_at IS NULL
+	AND wm.workspace_id = $2
+`
+
+// Create opens a workspace with the current user as its owner
+func (w *WorkspaceRepoImpl) Create(workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse) {
+	var workspace WorkspaceNewModel
+	if err := workspace.new(workspace_req, w.UserContext, w.DBPool); err != nil {
+		custom_log.NewCustomLog("workspace_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_create_failed", fmt.Errorf("error_create_workspace"))
+	}
+
+	tx, err := w.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("workspace_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_create_failed", fmt.Errorf("error_create_workspace"))
+	}
+	defer tx.Rollback()
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_workspaces (
+			id, workspace_uuid, workspace_name, workspace_desc, created_by, created_at
+		) VALUES (
+			:id, :workspace_uuid, :workspace_name, :workspace_desc, :created_by, :created_at
+		)
+	`
+
+	// execute query
+	if _, err := tx.NamedExec(query, workspace); err != nil {
+		custom_log.NewCustomLog("workspace_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_create_failed", fmt.Errorf("error_create_workspace"))
+	}
+
+	if err := addMember(tx, workspace.ID, uint64(w.UserContext.Id), constants.WorkspaceRoleOwner, w.UserContext.Id); err != nil {
+		custom_log.NewCustomLog("workspace_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_create_failed", fmt.Errorf("error_create_workspace"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("workspace_create_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_create_failed", fmt.Errorf("error_create_workspace"))
+	}
+
+	w.audit("workspace_create", fmt.Sprintf("Created workspace %s", workspace.WorkspaceName))
+
+	return w.ShowOne(workspace.WorkspaceUUID)
+}
+
+// List returns the workspaces the current user is a member of
+func (w *WorkspaceRepoImpl) List(list_req types.ListRequest) ([]Workspace, int, *responses.ErrorResponse) {
+	// prepare query
+	query := selectWorkspace + `
+		AND wm.user_id = $1
+	`
+
+	// execute query
+	var workspaces []Workspace
+	total, err := postgres.SelectList(w.DBPool, &workspaces, query, []interface{}{w.UserContext.Id}, list_req, workspaceListFields)
+	if err != nil {
+		custom_log.NewCustomLog("workspace_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("workspace_list_failed", fmt.Errorf("get_workspace_error"))
+	}
+
+	if workspaces == nil {
+		workspaces = []Workspace{}
+	}
+
+	return workspaces, total, nil
+}
+
+// ShowOne returns the workspace with its members, only members can see it
+func (w *WorkspaceRepoImpl) ShowOne(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectWorkspace + `
+		AND ws.workspace_uuid = $1
+		AND wm.user_id = $2
+	`
+
+	// execute query
+	var workspace Workspace
+	if err := w.DBPool.Get(&workspace, query, workspace_uuid, w.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse("workspace_show_failed", fmt.Errorf("no_workspace_found"))
+		}
+		custom_log.NewCustomLog("workspace_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_show_failed", fmt.Errorf("get_workspace_error"))
+	}
+
+	// prepare query
+	member_query := `
+		SELECT
+			wm.id, wm.user_id, us.user_uuid, us.user_name, us.email, wm.member_role, wm.created_at
+		FROM tbl_workspaces_members wm
+		INNER JOIN tbl_users us ON us.id = wm.user_id
+		WHERE wm.deleted_at IS NULL
+		AND wm.workspace_id = $1
+		ORDER BY wm.id
+	`
+
+	// execute query
+	if err := w.DBPool.Select(&workspace.Members, member_query, workspace.ID); err != nil {
+		custom_log.NewCustomLog("workspace_show_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_show_failed", fmt.Errorf("get_workspace_error"))
+	}
+
+	return &WorkspaceResponse{
+		Workspace: workspace,
+	}, nil
+}
+
+func (w *WorkspaceRepoImpl) Update(workspace_uuid string, workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	var workspace_desc *string
+	if workspace_req.WorkspaceDesc != "" {
+		workspace_desc = &workspace_req.WorkspaceDesc
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces SET
+			workspace_name = $1, workspace_desc = $2, updated_by = $3, updated_at = $4
+		WHERE id = $5
+	`
+
+	// execute query
+	if _, err := w.DBPool.Exec(query, workspace_req.WorkspaceName, workspace_desc, w.UserContext.Id, utils.Now(), member.WorkspaceID); err != nil {
+		custom_log.NewCustomLog("workspace_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_update_failed", fmt.Errorf("error_update_workspace"))
+	}
+
+	return w.ShowOne(workspace_uuid)
+}
+
+// Delete removes the workspace, its connections must be removed first so
+// no database is left without an owner
+func (w *WorkspaceRepoImpl) Delete(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	workspace_resp, err_resp := w.ShowOne(workspace_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	workspace := workspace_resp.Workspace
+
+	if workspace.MemberRole != constants.WorkspaceRoleOwner {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_delete_failed", fmt.Errorf("not_workspace_owner"))
+	}
+	if workspace.DatabaseCount > 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_delete_failed", fmt.Errorf("workspace_has_databases"))
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND id = $3
+	`
+
+	// execute query
+	result, err := w.DBPool.Exec(query, w.UserContext.Id, utils.Now(), workspace.ID)
+	if err != nil {
+		custom_log.NewCustomLog("workspace_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_delete_failed", fmt.Errorf("error_delete_workspace"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_delete_failed", fmt.Errorf("no_workspace_found"))
+	}
+
+	w.audit("workspace_delete", fmt.Sprintf("Deleted workspace %s", workspace.WorkspaceName))
+
+	return workspace_resp, nil
+}
+
+// Invite invites an email address to the workspace, the invitation is
+// accepted by the user registered with that email
+func (w *WorkspaceRepoImpl) Invite(workspace_uuid string, invite_req WorkspaceInviteRequest) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_invite_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	check_query := `
+		SELECT
+			EXISTS (
+				SELECT 1
+				FROM tbl_workspaces_members wm
+				INNER JOIN tbl_users us ON us.id = wm.user_id
+				WHERE wm.deleted_at IS NULL
+				AND wm.workspace_id = $1
+				AND LOWER(us.email) = $2
+			) AS is_member,
+			EXISTS (
+				SELECT 1
+				FROM tbl_workspaces_invitations
+				WHERE workspace_id = $1
+				AND LOWER(email) = $2
+				AND invitation_status = $3
+				AND expires_at > $4
+			) AS is_invited
+	`
+
+	// execute query
+	var check struct {
+		IsMember  bool `db:"is_member"`
+		IsInvited bool `db:"is_invited"`
+	}
+	if err := w.DBPool.Get(&check, check_query, member.WorkspaceID, invite_req.Email, constants.InvitationStatusPending, utils.Now()); err != nil {
+		custom_log.NewCustomLog("workspace_invite_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invite_failed", fmt.Errorf("get_workspace_error"))
+	}
+	if check.IsMember {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invite_failed", fmt.Errorf("already_workspace_member"))
+	}
+	if check.IsInvited {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invite_failed", fmt.Errorf("invitation_already_pending"))
+	}
+
+	var invitation WorkspaceInvitationNewModel
+	if err := invitation.new(member.WorkspaceID, invite_req, w.UserContext, w.DBPool); err != nil {
+		custom_log.NewCustomLog("workspace_invite_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invite_failed", fmt.Errorf("error_invite_member"))
+	}
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_workspaces_invitations (
+			id, invitation_uuid, workspace_id, email, member_role, invitation_status,
+			expires_at, created_by, created_at
+		) VALUES (
+			:id, :invitation_uuid, :workspace_id, :email, :member_role, :invitation_status,
+			:expires_at, :created_by, :created_at
+		)
+	`
+
+	// execute query
+	if _, err := w.DBPool.NamedExec(query, invitation); err != nil {
+		custom_log.NewCustomLog("workspace_invite_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invite_failed", fmt.Errorf("error_invite_member"))
+	}
+
+	w.audit("workspace_invite", fmt.Sprintf("Invited %s to workspace %s as %s", invitation.Email, member.WorkspaceName, invitation.MemberRole))
+
+	return w.invitation(invitation.InvitationUUID, "workspace_invite_failed")
+}
+
+// ListInvitations returns every invitation of the workspace, newest first
+func (w *WorkspaceRepoImpl) ListInvitations(workspace_uuid string, list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_invitation_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	return w.invitations(list_req, "workspace_invitation_list_failed", `
+		AND wi.workspace_id = $1
+	`, member.WorkspaceID)
+}
+
+func (w *WorkspaceRepoImpl) RevokeInvitation(workspace_uuid string, invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_invitation_revoke_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces_invitations SET
+			invitation_status = $1, updated_by = $2, updated_at = $3
+		WHERE invitation_uuid = $4
+		AND workspace_id = $5
+		AND invitation_status = $6
+	`
+
+	// execute query
+	if err_resp := w.respond(query, "workspace_invitation_revoke_failed", constants.InvitationStatusRevoked, w.UserContext.Id, utils.Now(), invitation_uuid, member.WorkspaceID, constants.InvitationStatusPending); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return w.invitation(invitation_uuid, "workspace_invitation_revoke_failed")
+}
+
+// MyInvitations returns the pending invitations sent to the email of the
+// current user
+func (w *WorkspaceRepoImpl) MyInvitations(list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse) {
+	return w.invitations(list_req, "workspace_invitation_list_failed", `
+		AND LOWER(wi.email) = (SELECT LOWER(email) FROM tbl_users WHERE id = $1)
+		AND wi.invitation_status = $2
+		AND wi.expires_at > $3
+	`, w.UserContext.Id, constants.InvitationStatusPending, utils.Now())
+}
+
+// AcceptInvitation makes the current user a member of the workspace with
+// the role given in the invitation
+func (w *WorkspaceRepoImpl) AcceptInvitation(invitation_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	invitation, err_resp := w.received(invitation_uuid, "workspace_invitation_accept_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	tx, err := w.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("workspace_invitation_accept_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invitation_accept_failed", fmt.Errorf("error_respond_invitation"))
+	}
+	defer tx.Rollback()
+
+	// prepare query, the status guards against accepting twice
+	query := `
+		UPDATE tbl_workspaces_invitations SET
+			invitation_status = $1, responded_by = $2, responded_at = $3
+		WHERE id = $4
+		AND invitation_status = $5
+	`
+
+	// execute query
+	result, err := tx.Exec(query, constants.InvitationStatusAccepted, w.UserContext.Id, utils.Now(), invitation.ID, constants.InvitationStatusPending)
+	if err != nil {
+		custom_log.NewCustomLog("workspace_invitation_accept_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invitation_accept_failed", fmt.Errorf("error_respond_invitation"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invitation_accept_failed", fmt.Errorf("invitation_not_pending"))
+	}
+
+	if err := addMember(tx, invitation.WorkspaceID, uint64(w.UserContext.Id), invitation.MemberRole, w.UserContext.Id); err != nil {
+		custom_log.NewCustomLog("workspace_invitation_accept_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invitation_accept_failed", fmt.Errorf("error_respond_invitation"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("workspace_invitation_accept_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_invitation_accept_failed", fmt.Errorf("error_respond_invitation"))
+	}
+
+	w.audit("workspace_join", fmt.Sprintf("Joined workspace %s as %s", invitation.WorkspaceName, invitation.MemberRole))
+
+	return w.ShowOne(invitation.WorkspaceUUID)
+}
+
+func (w *WorkspaceRepoImpl) DeclineInvitation(invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	invitation, err_resp := w.received(invitation_uuid, "workspace_invitation_decline_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces_invitations SET
+			invitation_status = $1, responded_by = $2, responded_at = $3
+		WHERE id = $4
+		AND invitation_status = $5
+	`
+
+	// execute query
+	if err_resp := w.respond(query, "workspace_invitation_decline_failed", constants.InvitationStatusDeclined, w.UserContext.Id, utils.Now(), invitation.ID, constants.InvitationStatusPending); err_resp != nil {
+		return nil, err_resp
+	}
+
+	return w.invitation(invitation_uuid, "workspace_invitation_decline_failed")
+}
+
+// UpdateMember changes the role of a member, the owner keeps its role
+func (w *WorkspaceRepoImpl) UpdateMember(workspace_uuid string, user_uuid string, member_req WorkspaceMemberRequest) (*WorkspaceResponse, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_member_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	target, err_resp := w.member(member.WorkspaceID, user_uuid, "workspace_member_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	if target.MemberRole == constants.WorkspaceRoleOwner {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_update_failed", fmt.Errorf("cannot_change_owner"))
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces_members SET
+			member_role = $1, updated_by = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	// execute query
+	if _, err := w.DBPool.Exec(query, member_req.MemberRole, w.UserContext.Id, utils.Now(), target.ID); err != nil {
+		custom_log.NewCustomLog("workspace_member_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_update_failed", fmt.Errorf("error_update_member"))
+	}
+
+	w.audit("workspace_member_update", fmt.Sprintf("Changed the role of %s in workspace %s from %s to %s", target.UserName, member.WorkspaceName, target.MemberRole, member_req.MemberRole))
+
+	return w.ShowOne(workspace_uuid)
+}
+
+// RemoveMember takes a member out of the workspace, admins remove others
+// and every member can leave, the owner cannot leave its workspace
+func (w *WorkspaceRepoImpl) RemoveMember(workspace_uuid string, user_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	workspace_resp, err_resp := w.ShowOne(workspace_uuid)
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	workspace := workspace_resp.Workspace
+
+	target, err_resp := w.member(workspace.ID, user_uuid, "workspace_member_remove_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+	if target.MemberRole == constants.WorkspaceRoleOwner {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_remove_failed", fmt.Errorf("cannot_remove_owner"))
+	}
+
+	leaving := target.UserID == uint64(w.UserContext.Id)
+	if !leaving && !(membership{MemberRole: workspace.MemberRole}).isAdmin() {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_remove_failed", fmt.Errorf("not_workspace_admin"))
+	}
+
+	tx, err := w.DBPool.Beginx()
+	if err != nil {
+		custom_log.NewCustomLog("workspace_member_remove_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_remove_failed", fmt.Errorf("error_remove_member"))
+	}
+	defer tx.Rollback()
+
+	now := utils.Now()
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces_members SET
+			deleted_by = $1, deleted_at = $2
+		WHERE id = $3
+	`
+
+	// execute query
+	if _, err := tx.Exec(query, w.UserContext.Id, now, target.ID); err != nil {
+		custom_log.NewCustomLog("workspace_member_remove_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_remove_failed", fmt.Errorf("error_remove_member"))
+	}
+
+	// prepare query, the access set on the workspace connections goes with it
+	access_query := `
+		UPDATE tbl_workspaces_databases_access SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND user_id = $3
+		AND db_id IN (SELECT id FROM tbl_users_databases WHERE workspace_id = $4)
+	`
+
+	// execute query
+	if _, err := tx.Exec(access_query, w.UserContext.Id, now, target.UserID, workspace.ID); err != nil {
+		custom_log.NewCustomLog("workspace_member_remove_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_remove_failed", fmt.Errorf("error_remove_member"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		custom_log.NewCustomLog("workspace_member_remove_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_member_remove_failed", fmt.Errorf("error_remove_member"))
+	}
+
+	if leaving {
+		w.audit("workspace_leave", fmt.Sprintf("Left workspace %s", workspace.WorkspaceName))
+		workspace.Members = nil
+		return &WorkspaceResponse{
+			Workspace: workspace,
+		}, nil
+	}
+
+	w.audit("workspace_member_remove", fmt.Sprintf("Removed %s from workspace %s", target.UserName, workspace.WorkspaceName))
+
+	return w.ShowOne(workspace_uuid)
+}
+
+// ListAccess returns the access level of every member on a workspace
+// database
+func (w *WorkspaceRepoImpl) ListAccess(workspace_uuid string, db_uuid string, list_req types.ListRequest) ([]WorkspaceAccess, int, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_access_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	db_id, err_resp := w.database(member.WorkspaceID, db_uuid, "workspace_access_list_failed")
+	if err_resp != nil {
+		return nil, 0, err_resp
+	}
+
+	// execute query
+	var access []WorkspaceAccess
+	total, err := postgres.SelectList(w.DBPool, &access, selectAccess, []interface{}{db_id, member.WorkspaceID}, list_req, accessListFields)
+	if err != nil {
+		custom_log.NewCustomLog("workspace_access_list_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse("workspace_access_list_failed", fmt.Errorf("get_access_error"))
+	}
+
+	if access == nil {
+		access = []WorkspaceAccess{}
+	}
+
+	return accessLevels(access), total, nil
+}
+
+// UpdateAccess sets the access level of a member on a workspace database
+func (w *WorkspaceRepoImpl) UpdateAccess(workspace_uuid string, db_uuid string, user_uuid string, access_req WorkspaceAccessRequest) (*WorkspaceAccessResponse, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_access_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	db_id, err_resp := w.database(member.WorkspaceID, db_uuid, "workspace_access_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	target, err_resp := w.member(member.WorkspaceID, user_uuid, "workspace_access_update_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	now := utils.Now()
+
+	// prepare query
+	query := `
+		INSERT INTO tbl_workspaces_databases_access (
+			db_id, user_id, access_level, created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (db_id, user_id) WHERE deleted_at IS NULL DO UPDATE SET
+			access_level = EXCLUDED.access_level, updated_by = EXCLUDED.created_by,
+			updated_at = EXCLUDED.created_at
+	`
+
+	// execute query
+	if _, err := w.DBPool.Exec(query, db_id, target.UserID, access_req.AccessLevel, w.UserContext.Id, now); err != nil {
+		custom_log.NewCustomLog("workspace_access_update_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_access_update_failed", fmt.Errorf("error_update_access"))
+	}
+
+	utils.AuditUserAction(
+		w.UserContext,
+		"workspace_access_update",
+		fmt.Sprintf("Set the access of %s on a database of workspace %s to %s", target.UserName, member.WorkspaceName, access_req.AccessLevel),
+		constants.AuditTypeConnection,
+		&db_id,
+		w.DBPool,
+	)
+
+	return w.access(db_id, db_uuid, member.WorkspaceID, "workspace_access_update_failed")
+}
+
+// DeleteAccess removes the access level set for a member so the member role
+// applies again
+func (w *WorkspaceRepoImpl) DeleteAccess(workspace_uuid string, db_uuid string, user_uuid string) (*WorkspaceAccessResponse, *responses.ErrorResponse) {
+	member, err_resp := w.admin(workspace_uuid, "workspace_access_delete_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	db_id, err_resp := w.database(member.WorkspaceID, db_uuid, "workspace_access_delete_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	target, err_resp := w.member(member.WorkspaceID, user_uuid, "workspace_access_delete_failed")
+	if err_resp != nil {
+		return nil, err_resp
+	}
+
+	// prepare query
+	query := `
+		UPDATE tbl_workspaces_databases_access SET
+			deleted_by = $1, deleted_at = $2
+		WHERE deleted_at IS NULL
+		AND db_id = $3
+		AND user_id = $4
+	`
+
+	// execute query
+	result, err := w.DBPool.Exec(query, w.UserContext.Id, utils.Now(), db_id, target.UserID)
+	if err != nil {
+		custom_log.NewCustomLog("workspace_access_delete_failed", err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_access_delete_failed", fmt.Errorf("error_update_access"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse("workspace_access_delete_failed", fmt.Errorf("no_access_found"))
+	}
+
+	utils.AuditUserAction(
+		w.UserContext,
+		"workspace_access_delete",
+		fmt.Sprintf("Reset the access of %s on a database of workspace %s to its member role", target.UserName, member.WorkspaceName),
+		constants.AuditTypeConnection,
+		&db_id,
+		w.DBPool,
+	)
+
+	return w.access(db_id, db_uuid, member.WorkspaceID, "workspace_access_delete_failed")
+}
+
+// admin returns the membership of the current user when it administers the
+// workspace
+func (w *WorkspaceRepoImpl) admin(workspace_uuid string, message_id string) (*membership, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT wm.workspace_id, ws.workspace_name, wm.member_role
+		FROM tbl_workspaces ws
+		INNER JOIN tbl_workspaces_members wm ON wm.workspace_id = ws.id
+		WHERE ws.deleted_at IS NULL
+		AND wm.deleted_at IS NULL
+		AND ws.workspace_uuid = $1
+		AND wm.user_id = $2
+	`
+
+	// execute query
+	var member membership
+	if err := w.DBPool.Get(&member, query, workspace_uuid, w.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_workspace_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_workspace_error"))
+	}
+
+	if !member.isAdmin() {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("not_workspace_admin"))
+	}
+
+	return &member, nil
+}
+
+// member returns a member of the workspace
+func (w *WorkspaceRepoImpl) member(workspace_id uint64, user_uuid string, message_id string) (*WorkspaceMember, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT
+			wm.id, wm.user_id, us.user_uuid, us.user_name, us.email, wm.member_role, wm.created_at
+		FROM tbl_workspaces_members wm
+		INNER JOIN tbl_users us ON us.id = wm.user_id
+		WHERE wm.deleted_at IS NULL
+		AND wm.workspace_id = $1
+		AND us.user_uuid = $2
+	`
+
+	// execute query
+	var member WorkspaceMember
+	if err := w.DBPool.Get(&member, query, workspace_id, user_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_member_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_workspace_error"))
+	}
+
+	return &member, nil
+}
+
+// database returns the id of a database owned by the workspace
+func (w *WorkspaceRepoImpl) database(workspace_id uint64, db_uuid string, message_id string) (uint64, *responses.ErrorResponse) {
+	// prepare query
+	query := `
+		SELECT id
+		FROM tbl_users_databases
+		WHERE deleted_at IS NULL
+		AND workspace_id = $1
+		AND db_uuid = $2
+	`
+
+	// execute query
+	var db_id uint64
+	if err := w.DBPool.Get(&db_id, query, workspace_id, db_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_db_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_db_error"))
+	}
+
+	return db_id, nil
+}
+
+// access lists the access level of every member on the database
+func (w *WorkspaceRepoImpl) access(db_id uint64, db_uuid string, workspace_id uint64, message_id string) (*WorkspaceAccessResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectAccess + `
+		ORDER BY wm.id
+	`
+
+	// execute query
+	var access []WorkspaceAccess
+	if err := w.DBPool.Select(&access, query, db_id, workspace_id); err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_access_error"))
+	}
+
+	return &WorkspaceAccessResponse{
+		DBUUID: db_uuid,
+		Access: accessLevels(access),
+	}, nil
+}
+
+// accessLevels resolves the access level of every member, the override wins
+// over the level of the member role
+func accessLevels(access []WorkspaceAccess) []WorkspaceAccess {
+	for i := range access {
+		access[i].AccessLevel = constants.WorkspaceRoleAccess[access[i].MemberRole]
+		if access[i].AccessOverride != nil {
+			access[i].AccessLevel = *access[i].AccessOverride
+		}
+	}
+
+	return access
+}
+
+func (w *WorkspaceRepoImpl) invitation(invitation_uuid string, message_id string) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	// prepare query
+	query := selectInvitation + `
+		AND wi.invitation_uuid = $1
+	`
+
+	// execute query
+	var invitation WorkspaceInvitation
+	if err := w.DBPool.Get(&invitation, query, invitation_uuid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_invitation_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_invitation_error"))
+	}
+
+	return &WorkspaceInvitationResponse{
+		Invitation: invitation,
+	}, nil
+}
+
+func (w *WorkspaceRepoImpl) invitations(list_req types.ListRequest, message_id string, condition string, args ...interface{}) ([]WorkspaceInvitation, int, *responses.ErrorResponse) {
+	// execute query
+	var invitations []WorkspaceInvitation
+	total, err := postgres.SelectList(w.DBPool, &invitations, selectInvitation+condition, args, list_req, invitationListFields)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, 0, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_invitation_error"))
+	}
+
+	if invitations == nil {
+		invitations = []WorkspaceInvitation{}
+	}
+
+	return invitations, total, nil
+}
+
+// received returns a pending invitation sent to the email of the current user
+func (w *WorkspaceRepoImpl) received(invitation_uuid string, message_id string) (*WorkspaceInvitation, *responses.ErrorResponse) {
+	// prepare query
+	query := selectInvitation + `
+		AND wi.invitation_uuid = $1
+		AND LOWER(wi.email) = (SELECT LOWER(email) FROM tbl_users WHERE id = $2)
+	`
+
+	// execute query
+	var invitation WorkspaceInvitation
+	if err := w.DBPool.Get(&invitation, query, invitation_uuid, w.UserContext.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err_msg := &responses.ErrorResponse{}
+			return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("no_invitation_found"))
+		}
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("get_invitation_error"))
+	}
+
+	if invitation.InvitationStatus != constants.InvitationStatusPending {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("invitation_not_pending"))
+	}
+	if !utils.Now().Before(invitation.ExpiresAt) {
+		err_msg := &responses.ErrorResponse{}
+		return nil, err_msg.NewErrorResponse(message_id, fmt.Errorf("invitation_expired"))
+	}
+
+	return &invitation, nil
+}
+
+// respond moves a pending invitation to another status
+func (w *WorkspaceRepoImpl) respond(query string, message_id string, args ...interface{}) *responses.ErrorResponse {
+	result, err := w.DBPool.Exec(query, args...)
+	if err != nil {
+		custom_log.NewCustomLog(message_id, err.Error(), "error")
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("error_respond_invitation"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		err_msg := &responses.ErrorResponse{}
+		return err_msg.NewErrorResponse(message_id, fmt.Errorf("invitation_not_pending"))
+	}
+
+	return nil
+}
+
+func (w *WorkspaceRepoImpl) audit(context string, desc string) {
+	utils.AuditUserAction(w.UserContext, context, desc, constants.AuditTypeUser, nil, w.DBPool)
+}
+
+// addMember adds a user to the workspace, a user already in it keeps its role
+func addMember(tx *sqlx.Tx, workspace_id uint64, user_id uint64, member_role string, created_by int) error {
+	// prepare query
+	query := `
+		INSERT INTO tbl_workspaces_members (
+			workspace_id, user_id, member_role, created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (workspace_id, user_id) WHERE deleted_at IS NULL DO NOTHING
+	`
+
+	// execute query
+	_, err := tx.Exec(query, workspace_id, user_id, member_role, created_by, utils.Now())
+	return err
+}
@@ -0,0 +1,55 @@
+package workspace
+
+import (
+	"tarantool-admin-api/pkg/constants"
+	"tarantool-admin-api/pkg/middlewares"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkspaceRoute struct {
+	App              *fiber.App
+	DBPool           *sqlx.DB
+	WorkspaceHandler *WorkspaceHandler
+}
+
+func NewRoute(db_pool *sqlx.DB, app *fiber.App) *WorkspaceRoute {
+	return &WorkspaceRoute{
+		App:              app,
+		DBPool:           db_pool,
+		WorkspaceHandler: NewWorkspaceHandler(db_pool),
+	}
+}
+
+func (w *WorkspaceRoute) RegisterWorkspaceRoute() *WorkspaceRoute {
+	workspace := w.App.Group("/api/v1/front/workspace")
+
+	view := middlewares.RequirePermission(constants.PermissionViewConnections)
+	manage := middlewares.RequirePermission(constants.PermissionManageConnections)
+
+	workspace.Post("/", manage, w.WorkspaceHandler.Create)
+	workspace.Get("/", view, w.WorkspaceHandler.List)
+
+	// invitations received by the current user
+	workspace.Get("/invitation", w.WorkspaceHandler.MyInvitations)
+	workspace.Post("/invitation/:invitation_uuid/accept", w.WorkspaceHandler.AcceptInvitation)
+	workspace.Post("/invitation/:invitation_uuid/decline", w.WorkspaceHandler.DeclineInvitation)
+
+	workspace.Get("/:workspace_uuid", view, w.WorkspaceHandler.ShowOne)
+	workspace.Put("/:workspace_uuid", manage, w.WorkspaceHandler.Update)
+	workspace.Delete("/:workspace_uuid", manage, w.WorkspaceHandler.Delete)
+
+	workspace.Post("/:workspace_uuid/invitation", manage, w.WorkspaceHandler.Invite)
+	workspace.Get("/:workspace_uuid/invitation", manage, w.WorkspaceHandler.ListInvitations)
+	workspace.Delete("/:workspace_uuid/invitation/:invitation_uuid", manage, w.WorkspaceHandler.RevokeInvitation)
+
+	workspace.Put("/:workspace_uuid/member/:user_uuid", manage, w.WorkspaceHandler.UpdateMember)
+	workspace.Delete("/:workspace_uuid/member/:user_uuid", manage, w.WorkspaceHandler.RemoveMember)
+
+	workspace.Get("/:workspace_uuid/database/:db_uuid/access", manage, w.WorkspaceHandler.ListAccess)
+	workspace.Put("/:workspace_uuid/database/:db_uuid/access/:user_uuid", manage, w.WorkspaceHandler.UpdateAccess)
+	workspace.Delete("/:workspace_uuid/database/:db_uuid/access/:user_uuid", manage, w.WorkspaceHandler.DeleteAccess)
+
+	return w
+}
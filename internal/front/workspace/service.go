@@ -0,0 +1,105 @@
+package workspace
+
+import (
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/responses"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WorkspaceServiceCreator interface {
+	Create(workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse)
+	List(list_req types.ListRequest) ([]Workspace, int, *responses.ErrorResponse)
+	ShowOne(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	Update(workspace_uuid string, workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse)
+	Delete(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	Invite(workspace_uuid string, invite_req WorkspaceInviteRequest) (*WorkspaceInvitationResponse, *responses.ErrorResponse)
+	ListInvitations(workspace_uuid string, list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse)
+	RevokeInvitation(workspace_uuid string, invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse)
+	MyInvitations(list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse)
+	AcceptInvitation(invitation_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	DeclineInvitation(invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse)
+	UpdateMember(workspace_uuid string, user_uuid string, member_req WorkspaceMemberRequest) (*WorkspaceResponse, *responses.ErrorResponse)
+	RemoveMember(workspace_uuid string, user_uuid string) (*WorkspaceResponse, *responses.ErrorResponse)
+	UpdateAccess(workspace_uuid string, db_uuid string, user_uuid string, access_req WorkspaceAccessRequest) (*WorkspaceAccessResponse, *responses.ErrorResponse)
+	DeleteAccess(workspace_uuid string, db_uuid string, user_uuid string) (*WorkspaceAccessResponse, *responses.ErrorResponse)
+	ListAccess(workspace_uuid string, db_uuid string, list_req types.ListRequest) ([]WorkspaceAccess, int, *responses.ErrorResponse)
+}
+
+type WorkspaceService struct {
+	DBPool        *sqlx.DB
+	WorkspaceRepo *WorkspaceRepoImpl
+	UserContext   *types.UserContext
+}
+
+func NewWorkspaceService(us_ctx *types.UserContext, db_pool *sqlx.DB) *WorkspaceService {
+	return &WorkspaceService{
+		DBPool:        db_pool,
+		WorkspaceRepo: NewWorkspaceRepoImpl(us_ctx, db_pool),
+		UserContext:   us_ctx,
+	}
+}
+
+func (w *WorkspaceService) Create(workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.Create(workspace_req)
+}
+
+func (w *WorkspaceService) List(list_req types.ListRequest) ([]Workspace, int, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.List(list_req)
+}
+
+func (w *WorkspaceService) ShowOne(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.ShowOne(workspace_uuid)
+}
+
+func (w *WorkspaceService) Update(workspace_uuid string, workspace_req WorkspaceRequest) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.Update(workspace_uuid, workspace_req)
+}
+
+func (w *WorkspaceService) Delete(workspace_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.Delete(workspace_uuid)
+}
+
+func (w *WorkspaceService) Invite(workspace_uuid string, invite_req WorkspaceInviteRequest) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.Invite(workspace_uuid, invite_req)
+}
+
+func (w *WorkspaceService) ListInvitations(workspace_uuid string, list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.ListInvitations(workspace_uuid, list_req)
+}
+
+func (w *WorkspaceService) RevokeInvitation(workspace_uuid string, invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.RevokeInvitation(workspace_uuid, invitation_uuid)
+}
+
+func (w *WorkspaceService) MyInvitations(list_req types.ListRequest) ([]WorkspaceInvitation, int, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.MyInvitations(list_req)
+}
+
+func (w *WorkspaceService) AcceptInvitation(invitation_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.AcceptInvitation(invitation_uuid)
+}
+
+func (w *WorkspaceService) DeclineInvitation(invitation_uuid string) (*WorkspaceInvitationResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.DeclineInvitation(invitation_uuid)
+}
+
+func (w *WorkspaceService) UpdateMember(workspace_uuid string, user_uuid string, member_req WorkspaceMemberRequest) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.UpdateMember(workspace_uuid, user_uuid, member_req)
+}
+
+func (w *WorkspaceService) RemoveMember(workspace_uuid string, user_uuid string) (*WorkspaceResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.RemoveMember(workspace_uuid, user_uuid)
+}
+
+func (w *WorkspaceService) UpdateAccess(workspace_uuid string, db_uuid string, user_uuid string, access_req WorkspaceAccessRequest) (*WorkspaceAccessResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.UpdateAccess(workspace_uuid, db_uuid, user_uuid, access_req)
+}
+
+func (w *WorkspaceService) DeleteAccess(workspace_uuid string, db_uuid string, user_uuid string) (*WorkspaceAccessResponse, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.DeleteAccess(workspace_uuid, db_uuid, user_uuid)
+}
+
+func (w *WorkspaceService) ListAccess(workspace_uuid string, db_uuid string, list_req types.ListRequest) ([]WorkspaceAccess, int, *responses.ErrorResponse) {
+	return w.WorkspaceRepo.ListAccess(workspace_uuid, db_uuid, list_req)
+}
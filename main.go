@@ -4,6 +4,8 @@ import (
 	"tarantool-admin-api/configs"
 	"tarantool-admin-api/db/postgresql"
 	"tarantool-admin-api/handler"
+	"tarantool-admin-api/internal/front/audit"
+	"tarantool-admin-api/internal/front/job"
 	"tarantool-admin-api/pkg/logs"
 	"tarantool-admin-api/pkg/redis"
 	"tarantool-admin-api/pkg/swagger"
@@ -43,6 +45,12 @@ func main() {
 	// init router
 	handler.NewServiceHandlers(apps, pool)
 
+	// start scheduled jobs runner
+	job.NewRunner(pool).Start()
+
+	// start periodic audit checkpoints
+	audit.NewCheckpointer(pool).Start()
+
 	// http server
 	err = apps.Listen(fmt.Sprintf("%s:%d", app_configs.AppHost, app_configs.AppPort))
 	if err != nil {
@@ -0,0 +1,344 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	ManifestName    = "manifest.json"
+	ManifestVersion = 1
+
+	FormatNDJSON  = "ndjson"
+	FormatMsgpack = "msgpack"
+)
+
+type Manifest struct {
+	Version   int             `json:"version"`
+	DBUUID    string          `json:"db_uuid"`
+	DBName    string          `json:"db_name"`
+	Format    string          `json:"format"`
+	CreatedAt time.Time       `json:"created_at"`
+	Spaces    []ManifestSpace `json:"spaces"`
+}
+
+type ManifestSpace struct {
+	Schema     tarantool_utils.SpaceSchema `json:"schema"`
+	File       string                      `json:"file"`
+	TupleCount uint64                      `json:"tuple_count"`
+	Checksum   string                      `json:"checksum"`
+}
+
+// DataFileName returns the archive entry name holding the tuples of a space
+func DataFileName(space_name string, format string) string {
+	return path.Join("data", fmt.Sprintf("%s.%s", space_name, format))
+}
+
+// TupleWriter encodes tuples of one space into a data file
+type TupleWriter struct {
+	format string
+	buffer *bufio.Writer
+	digest hash.Hash
+	json   *json.Encoder
+	mp     *msgpack.Encoder
+	count  uint64
+}
+
+func NewTupleWriter(w io.Writer, format string) (*TupleWriter, error) {
+	digest := sha256.New()
+	buffer := bufio.NewWriter(io.MultiWriter(w, digest))
+
+	tw := &TupleWriter{
+		format: format,
+		buffer: buffer,
+		digest: digest,
+	}
+
+	switch format {
+	case FormatNDJSON:
+		tw.json = json.NewEncoder(buffer)
+	case FormatMsgpack:
+		tw.mp = msgpack.NewEncoder(buffer)
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	return tw, nil
+}
+
+func (tw *TupleWriter) Write(tuple []interface{}) error {
+	var err error
+	if tw.json != nil {
+		err = tw.json.Encode(tarantool_utils.ToJSONValue(tuple))
+	} else {
+		err = tw.mp.Encode(tuple)
+	}
+	if err != nil {
+		return err
+	}
+
+	tw.count++
+	return nil
+}
+
+// Close flushes pending data and returns the tuple count and sha256 checksum
+func (tw *TupleWriter) Close() (uint64, string, error) {
+	if err := tw.buffer.Flush(); err != nil {
+		return 0, "", err
+	}
+
+	return tw.count, hex.EncodeToString(tw.digest.Sum(nil)), nil
+}
+
+// TupleReader decodes tuples from a data file written by TupleWriter
+type TupleReader struct {
+	json *json.Decoder
+	mp   *msgpack.Decoder
+}
+
+func NewTupleReader(r io.Reader, format string) (*TupleReader, error) {
+	buffer := bufio.NewReader(r)
+
+	switch format {
+	case FormatNDJSON:
+		decoder := json.NewDecoder(buffer)
+		decoder.UseNumber()
+		return &TupleReader{json: decoder}, nil
+	case FormatMsgpack:
+		return &TupleReader{mp: msgpack.NewDecoder(buffer)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+// Read returns the next tuple or io.EOF when the data file is exhausted
+func (tr *TupleReader) Read() ([]interface{}, error) {
+	var tuple []interface{}
+
+	if tr.json != nil {
+		if err := tr.json.Decode(&tuple); err != nil {
+			return nil, err
+		}
+		return tuple, nil
+	}
+
+	if err := tr.mp.Decode(&tuple); err != nil {
+		return nil, err
+	}
+	return tarantool_utils.NormalizeValue(tuple).([]interface{}), nil
+}
+
+// Writer builds a gzip compressed tar archive
+type Writer struct {
+	file   *os.File
+	gz     *gzip.Writer
+	tw     *tar.Writer
+	digest hash.Hash
+}
+
+func NewWriter(file_path string) (*Writer, error) {
+	file, err := os.Create(file_path)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, digest))
+
+	return &Writer{
+		file:   file,
+		gz:     gz,
+		tw:     tar.NewWriter(gz),
+		digest: digest,
+	}, nil
+}
+
+// AddFile copies a file from the local disk into the archive under name
+func (w *Writer) AddFile(name string, src_path string) error {
+	src, err := os.Open(src_path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w.tw, src)
+	return err
+}
+
+func (w *Writer) WriteManifest(manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    ManifestName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+
+	_, err = w.tw.Write(data)
+	return err
+}
+
+// Close finishes the archive and returns its size and sha256 checksum
+func (w *Writer) Close() (int64, string, error) {
+	defer w.file.Close()
+
+	if err := w.tw.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := w.gz.Close(); err != nil {
+		return 0, "", err
+	}
+
+	info, err := w.file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	return info.Size(), hex.EncodeToString(w.digest.Sum(nil)), nil
+}
+
+// Walk iterates every entry of an archive, stopping early when fn returns io.EOF
+func Walk(file_path string, fn func(header *tar.Header, r io.Reader) error) error {
+	file, err := os.Open(file_path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(header, tr); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ReadManifest loads the manifest of an archive
+func ReadManifest(file_path string) (*Manifest, error) {
+	var manifest *Manifest
+
+	err := Walk(file_path, func(header *tar.Header, r io.Reader) error {
+		if header.Name != ManifestName {
+			return nil
+		}
+
+		manifest = &Manifest{}
+		if err := json.NewDecoder(r).Decode(manifest); err != nil {
+			return err
+		}
+		return io.EOF
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no %s", ManifestName)
+	}
+
+	return manifest, nil
+}
+
+// ReadSpace streams the tuples of one space from an archive, verifying the
+// checksum recorded in the manifest once the data file is fully read
+func ReadSpace(file_path string, manifest *Manifest, space ManifestSpace, fn func(tuple []interface{}) error) error {
+	found := false
+
+	err := Walk(file_path, func(header *tar.Header, r io.Reader) error {
+		if header.Name != space.File {
+			return nil
+		}
+		found = true
+
+		digest := sha256.New()
+		reader, err := NewTupleReader(io.TeeReader(r, digest), manifest.Format)
+		if err != nil {
+			return err
+		}
+
+		for {
+			tuple, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := fn(tuple); err != nil {
+				return err
+			}
+		}
+
+		// drain the rest of the entry so the checksum covers the whole file
+		if _, err := io.Copy(io.Discard, io.TeeReader(r, digest)); err != nil {
+			return err
+		}
+
+		if checksum := hex.EncodeToString(digest.Sum(nil)); checksum != space.Checksum {
+			return fmt.Errorf("checksum mismatch for %s", space.File)
+		}
+		return io.EOF
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("archive has no %s", space.File)
+	}
+
+	return nil
+}
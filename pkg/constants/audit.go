@@ -0,0 +1,11 @@
+package constants
+
+const (
+	// audit_type_id of tbl_users_audits
+	AuditTypeUser       = 1
+	AuditTypeApproval   = 2
+	AuditTypeAuth       = 3
+	AuditTypeConnection = 4
+	AuditTypeQuery      = 5
+	AuditTypeDDL        = 6
+)
@@ -0,0 +1,13 @@
+package constants
+
+const (
+	JobStatusPlanned   = "planned"
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+
+	// a restore on a database requiring approval waits in these
+	JobStatusAwaitingApproval = "awaiting_approval"
+	JobStatusRejected         = "rejected"
+)
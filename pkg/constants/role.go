@@ -0,0 +1,31 @@
+package constants
+
+const (
+	// role_name of tbl_roles
+	RoleOwner    = "owner"
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleAnalyst  = "analyst"
+	RoleViewer   = "viewer"
+)
+
+// RoleRank orders the roles from the least to the most privileged
+var RoleRank = map[string]int{
+	RoleViewer:   1,
+	RoleAnalyst:  2,
+	RoleOperator: 3,
+	RoleAdmin:    4,
+	RoleOwner:    5,
+}
+
+const (
+	// permission_name of tbl_permissions
+	PermissionViewConnections   = "view_connections"
+	PermissionManageConnections = "manage_connections"
+	PermissionRunSelect         = "run_select"
+	PermissionRunDML            = "run_dml"
+	PermissionRunDDL            = "run_ddl"
+	PermissionLuaEval           = "lua_eval"
+	PermissionManageUsers       = "manage_users"
+	PermissionViewAudit         = "view_audit"
+)
@@ -22,3 +22,11 @@ const (
 	UserGetLoginSessionSuccess   = 14018
 	UserGetLoginSessionFailed    = 14019
 )
+
+const (
+	// status_id of tbl_users, see share.StatusData
+	UserStatusActive    = 1
+	UserStatusInactive  = 2
+	UserStatusSuspended = 3
+	UserStatusDeleted   = 4
+)
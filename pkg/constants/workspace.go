@@ -0,0 +1,39 @@
+package constants
+
+const (
+	// member_role of tbl_workspaces_members
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleMember = "member"
+)
+
+const (
+	// invitation_status of tbl_workspaces_invitations
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	InvitationStatusRevoked  = "revoked"
+)
+
+const (
+	// access level of a user on a connection, each level includes the ones
+	// before it
+	AccessLevelRead  = "read"
+	AccessLevelWrite = "write"
+	AccessLevelAdmin = "admin"
+)
+
+// AccessLevelRank orders the access levels
+var AccessLevelRank = map[string]int{
+	AccessLevelRead:  1,
+	AccessLevelWrite: 2,
+	AccessLevelAdmin: 3,
+}
+
+// WorkspaceRoleAccess is the access level a member has on the connections of
+// the workspace when none is set for the connection
+var WorkspaceRoleAccess = map[string]string{
+	WorkspaceRoleOwner:  AccessLevelAdmin,
+	WorkspaceRoleAdmin:  AccessLevelAdmin,
+	WorkspaceRoleMember: AccessLevelRead,
+}
@@ -0,0 +1,234 @@
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	tarantool_utils "tarantool-admin-api/pkg/tarantool"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// ContentType returns the http content type of an export
+func ContentType(format string, compressed bool) string {
+	if compressed {
+		return "application/gzip"
+	}
+
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// FileName returns the file name of an export, e.g. users.csv.gz
+func FileName(name string, format string, compressed bool) string {
+	file_name := fmt.Sprintf("%s.%s", name, format)
+	if compressed {
+		file_name += ".gz"
+	}
+	return file_name
+}
+
+// RowWriter encodes rows one by one as csv, a json array or ndjson, rows
+// are written as soon as they are received so nothing is buffered
+type RowWriter struct {
+	format  string
+	columns []string
+	count   uint64
+	gz      *gzip.Writer
+	dest    io.Writer
+	out     io.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+// NewRowWriter writes the csv header or the json array opening right away,
+// the output is gzip compressed when compressed is set
+func NewRowWriter(w io.Writer, format string, columns []string, compressed bool) (*RowWriter, error) {
+	r := &RowWriter{
+		format:  format,
+		columns: columns,
+		dest:    w,
+		out:     w,
+	}
+
+	if compressed {
+		r.gz = gzip.NewWriter(w)
+		r.out = r.gz
+	}
+
+	switch format {
+	case FormatCSV:
+		r.csv = csv.NewWriter(r.out)
+		if err := r.csv.Write(columns); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		r.json = json.NewEncoder(r.out)
+		if _, err := io.WriteString(r.out, "["); err != nil {
+			return nil, err
+		}
+	case FormatNDJSON:
+		r.json = json.NewEncoder(r.out)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	return r, nil
+}
+
+// Write encodes one row, json rows are objects keyed by the column names
+func (r *RowWriter) Write(row []interface{}) error {
+	switch r.format {
+	case FormatCSV:
+		record := make([]string, len(row))
+		for i, value := range row {
+			cell, err := csvCell(value)
+			if err != nil {
+				return err
+			}
+			record[i] = cell
+		}
+		if err := r.csv.Write(record); err != nil {
+			return err
+		}
+	case FormatJSON:
+		if r.count > 0 {
+			if _, err := io.WriteString(r.out, ","); err != nil {
+				return err
+			}
+		}
+		if err := r.json.Encode(r.object(row)); err != nil {
+			return err
+		}
+	default:
+		if err := r.json.Encode(r.object(row)); err != nil {
+			return err
+		}
+	}
+
+	r.count++
+	return nil
+}
+
+// Flush pushes the buffered output to the underlying writer, and flushes
+// the underlying writer too when it is buffered (e.g. a streamed response)
+func (r *RowWriter) Flush() error {
+	if r.csv != nil {
+		r.csv.Flush()
+		if err := r.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if r.gz != nil {
+		if err := r.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := r.dest.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close terminates the output and returns the number of rows written
+func (r *RowWriter) Close() (uint64, error) {
+	if r.format == FormatJSON {
+		if _, err := io.WriteString(r.out, "]\n"); err != nil {
+			return r.count, err
+		}
+	}
+
+	if r.csv != nil {
+		r.csv.Flush()
+		if err := r.csv.Error(); err != nil {
+			return r.count, err
+		}
+	}
+
+	if r.gz != nil {
+		if err := r.gz.Close(); err != nil {
+			return r.count, err
+		}
+	}
+
+	return r.count, nil
+}
+
+// object maps a row to its column names, fields beyond the known columns
+// are named after their position
+func (r *RowWriter) object(row []interface{}) orderedObject {
+	object := make(orderedObject, len(row))
+	for i, value := range row {
+		name := fmt.Sprintf("field_%d", i+1)
+		if i < len(r.columns) {
+			name = r.columns[i]
+		}
+		object[i] = field{Name: name, Value: tarantool_utils.ToJSONValue(value)}
+	}
+	return object
+}
+
+type field struct {
+	Name  string
+	Value interface{}
+}
+
+// orderedObject encodes as a json object keeping the column order
+type orderedObject []field
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, f := range o {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, name...)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+	return append(buf, '}'), nil
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch val := tarantool_utils.ToJSONValue(value).(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprintf("%v", val), nil
+	case fmt.Stringer:
+		return val.String(), nil
+	default:
+		// nested maps and arrays are kept as json
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
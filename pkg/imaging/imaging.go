@@ -0,0 +1,130 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// MaxSide keeps a small file from decoding into a huge picture
+const MaxSide = 4096
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions too large")
+)
+
+// formats maps the sniffed content types to the format a picture is stored
+// in, a gif keeps its first frame only
+var formats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "png",
+}
+
+// Decode reads a picture after checking its content rather than its name,
+// it also returns the format to store it in
+func Decode(data []byte) (image.Image, string, error) {
+	format, ok := formats[http.DetectContentType(data)]
+	if !ok {
+		return nil, "", ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedType
+	}
+	if config.Width > MaxSide || config.Height > MaxSide {
+		return nil, "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedType
+	}
+
+	return img, format, nil
+}
+
+// Extension returns the file extension of a format of Decode
+func Extension(format string) string {
+	if format == "jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// Thumbnail crops the middle square of a picture and scales it down to
+// size x size, every pixel of the result averages the pixels it covers
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	if side < size {
+		size = side
+	}
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for ty := 0; ty < size; ty++ {
+		sy0, sy1 := y0+ty*side/size, y0+(ty+1)*side/size
+		for tx := 0; tx < size; tx++ {
+			sx0, sx1 := x0+tx*side/size, x0+(tx+1)*side/size
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			thumb.SetNRGBA(tx, ty, color.NRGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return thumb
+}
+
+// Save encodes a picture to file_path in a format of Decode, encoding it
+// again drops whatever metadata the upload carried
+func Save(img image.Image, format string, file_path string) error {
+	if err := os.MkdirAll(filepath.Dir(file_path), 0755); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(file_path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if format == "jpeg" {
+		err = jpeg.Encode(dst, img, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(dst, img)
+	}
+	if err != nil {
+		return err
+	}
+
+	return dst.Close()
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	custom_log "tarantool-admin-api/pkg/logs"
+	"time"
+)
+
+// LogSender keeps mails for local development, every mail is written to
+// Dir as an .eml file or logged when there is no Dir
+type LogSender struct {
+	Dir  string
+	From string
+}
+
+func (s *LogSender) Send(mail Mail) error {
+	if err := checkHeader(s.From, mail.To, mail.Subject); err != nil {
+		return err
+	}
+
+	from := s.From
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	if s.Dir == "" {
+		custom_log.NewCustomLog("mail_sent", fmt.Sprintf("to: %s, subject: %s\n%s", mail.To, mail.Subject, mail.Body), "info")
+		return nil
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("error create mail dir : %w", err)
+	}
+
+	name := filepath.Join(s.Dir, fmt.Sprintf("%s.eml", time.Now().Format("20060102T150405.000000000")))
+	if err := os.WriteFile(name, message(from, mail), 0o644); err != nil {
+		return fmt.Errorf("error write mail : %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail is a plain text email
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers mails, SMTPSender sends them for real and LogSender keeps
+// them on the machine for local development
+type Sender interface {
+	Send(mail Mail) error
+}
+
+var (
+	once   sync.Once
+	sender Sender
+)
+
+// Default returns the sender picked by MAIL_DRIVER, smtp or log, mails are
+// only logged when it is not set
+func Default() Sender {
+	once.Do(func() {
+		switch os.Getenv("MAIL_DRIVER") {
+		case "smtp":
+			sender = &SMTPSender{
+				Host:     os.Getenv("SMTP_HOST"),
+				Port:     os.Getenv("SMTP_PORT"),
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+				From:     os.Getenv("MAIL_FROM"),
+			}
+		default:
+			sender = &LogSender{
+				Dir:  os.Getenv("MAIL_LOG_DIR"),
+				From: os.Getenv("MAIL_FROM"),
+			}
+		}
+	})
+
+	return sender
+}
+
+// message renders the mail with its headers, the subject is encoded so any
+// language fits in it
+func message(from string, mail Mail) []byte {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
+
+// checkHeader refuses line breaks that would smuggle headers into a mail
+func checkHeader(values ...string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid mail header %q", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPSender sends mails through an SMTP server, the connection is upgraded
+// with STARTTLS when the server offers it
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(mail Mail) error {
+	if s.Host == "" || s.From == "" {
+		return fmt.Errorf("smtp sender is not configured")
+	}
+	if err := checkHeader(s.From, mail.To, mail.Subject); err != nil {
+		return err
+	}
+
+	port := s.Port
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	if err := smtp.SendMail(net.JoinHostPort(s.Host, port), auth, s.From, []string{mail.To}, message(s.From, mail)); err != nil {
+		return fmt.Errorf("error send mail : %w", err)
+	}
+	return nil
+}
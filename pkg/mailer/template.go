@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+var (
+	bundle_once sync.Once
+	bundle      *i18n.Bundle
+	bundle_err  error
+)
+
+// localizeBundle loads the translations the api answers with, mails are
+// written in the same languages
+func localizeBundle() (*i18n.Bundle, error) {
+	bundle_once.Do(func() {
+		root := os.Getenv("I18N_PATH")
+		if root == "" {
+			root = "./pkg/i18n/localize"
+		}
+
+		bundle = i18n.NewBundle(language.Khmer)
+		bundle.RegisterUnmarshalFunc("json", json.Unmarshal)
+
+		files, err := filepath.Glob(filepath.Join(root, "*.json"))
+		if err != nil {
+			bundle_err = err
+			return
+		}
+		for _, file := range files {
+			if _, err := bundle.LoadMessageFile(file); err != nil {
+				bundle_err = err
+				return
+			}
+		}
+	})
+
+	return bundle, bundle_err
+}
+
+// Render writes the mail of a template in the language the user asked for,
+// lang takes an Accept-Language value and falls back on Khmer like the api,
+// the subject and body are the <template>_subject and <template>_body
+// messages
+func Render(to string, template string, lang string, data map[string]interface{}) (*Mail, error) {
+	bundle, err := localizeBundle()
+	if err != nil {
+		return nil, fmt.Errorf("error load mail templates : %w", err)
+	}
+
+	localizer := i18n.NewLocalizer(bundle, lang)
+
+	subject, err := localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    template + "_subject",
+		TemplateData: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error render mail subject : %w", err)
+	}
+
+	body, err := localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    template + "_body",
+		TemplateData: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error render mail body : %w", err)
+	}
+
+	return &Mail{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	}, nil
+}
@@ -3,10 +3,10 @@ package middlewares
 import (
 	// "tarantool-admin-api/internal/front/auth"
 	"tarantool-admin-api/internal/front/auth"
+	"tarantool-admin-api/pkg/constants"
 	types "tarantool-admin-api/pkg/model"
 	"tarantool-admin-api/pkg/utils"
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -32,6 +32,18 @@ func NewJwtMinddleWare(app *fiber.App, DBPool *sqlx.DB) {
 
 	// JWT middleware
 	app.Use(func(c *fiber.Ctx) error {
+		// scripts authenticate with an api key instead of a JWT
+		if api_key := apiKeyOf(c); api_key != "" {
+			key, err := auth.NewAuthRepoImpl(nil, DBPool).CheckApiKey(api_key, c.IP())
+			if err != nil {
+				return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+					"error": utils.Translate("invalid_api_key", nil, c),
+				})
+			}
+			c.Locals("api_key", key)
+			return c.Next()
+		}
+
 		// check if the request is upgrading to WebSocket
 		if websocketUpgrade := c.Get("Upgrade"); websocketUpgrade == "websocket" {
 			// extract Bearer token from Sec-WebSocket-Protocol
@@ -88,6 +100,10 @@ func NewJwtMinddleWare(app *fiber.App, DBPool *sqlx.DB) {
 
 	// user context middleware
 	app.Use(func(c *fiber.Ctx) error {
+		if key, ok := c.Locals("api_key").(*auth.ApiKey); ok {
+			return handleApiKeyContext(c, key, DBPool)
+		}
+
 		// extract the JWT token data
 		user_token := c.Locals("jwt_data").(*jwt.Token)
 		pclaim := user_token.Claims.(jwt.MapClaims)
@@ -111,7 +127,7 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
 			utils.Translate("access_denied", nil, c),
 			-500,
-			fmt.Errorf(
+			errors.New(
 				utils.Translate(
 					"missing_or_invalid_key",
 					map[string]interface{}{
@@ -129,7 +145,7 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
 			utils.Translate("access_denied", nil, c),
 			-500,
-			fmt.Errorf(
+			errors.New(
 				utils.Translate(
 					"missing_or_invalid_key",
 					map[string]interface{}{
@@ -147,7 +163,7 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
 			utils.Translate("access_denied", nil, c),
 			-500,
-			fmt.Errorf(
+			errors.New(
 				utils.Translate(
 					"missing_or_invalid_key",
 					map[string]interface{}{
@@ -160,12 +176,12 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 	}
 
 	// get user info for context
-	user_info, err := auth.NewAuthRepoImpl(DBPool).GetUserByUUID(user_uuid)
+	user_info, err := auth.NewAuthRepoImpl(nil, DBPool).GetUserByUUID(user_uuid)
 	if err != nil {
 		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
 			utils.Translate("access_denied", nil, c),
 			-500,
-			fmt.Errorf(
+			errors.New(
 				utils.Translate(
 					"get_userinfo_failed",
 					nil,
@@ -175,12 +191,17 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 		))
 	}
 
-	// check login session
-	if login_session != user_info.LoginSession {
+	// only active users may use the api
+	if user_info.StatusID != constants.UserStatusActive {
+		return inactiveUser(c, user_info.StatusID)
+	}
+
+	// check login session, it is revoked on logout
+	if err := auth.NewAuthRepoImpl(nil, DBPool).CheckSession(login_session, user_info.ID); err != nil {
 		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
 			utils.Translate("access_denied", nil, c),
 			-500,
-			fmt.Errorf(
+			errors.New(
 				utils.Translate(
 					"session_expired",
 					nil,
@@ -190,6 +211,22 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 		))
 	}
 
+	// get the role of the user, routes check its permissions
+	user_role, err := auth.NewAuthRepoImpl(nil, DBPool).GetUserRole(user_info.ID)
+	if err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
+			utils.Translate("access_denied", nil, c),
+			-500,
+			errors.New(
+				utils.Translate(
+					"get_user_role_failed",
+					nil,
+					c,
+				),
+			),
+		))
+	}
+
 	// Create and populate PlayerContext struct
 	uCtx := types.UserContext{
 		Id:           user_info.ID,
@@ -200,8 +237,104 @@ func handlePlayerContext(c *fiber.Ctx, pclaim jwt.MapClaims, DBPool *sqlx.DB) er
 		UserAgent:    string(c.Context().UserAgent()),
 		Ip:           string(c.Context().RemoteIP().String()),
 		StatusId:     user_info.StatusID,
+		Role:         user_role.RoleName,
+		Permissions:  user_role.Permissions,
+		Language:     utils.Language(c),
+	}
+	c.Locals("UserContext", uCtx)
+
+	return c.Next()
+}
+
+// apiKeyOf returns the api key sent in the X-API-Key header or as an
+// "Authorization: ApiKey <key>" header
+func apiKeyOf(c *fiber.Ctx) string {
+	if api_key := strings.TrimSpace(c.Get("X-API-Key")); api_key != "" {
+		return api_key
+	}
+
+	scheme, api_key, ok := strings.Cut(c.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(api_key)
+	}
+
+	return ""
+}
+
+// helper function to create the user context of a request made with an api
+// key, the key only keeps the permissions of the user that its scopes allow
+func handleApiKeyContext(c *fiber.Ctx, key *auth.ApiKey, DBPool *sqlx.DB) error {
+	// get user info for context
+	user_info, err := auth.NewAuthRepoImpl(nil, DBPool).GetUserByUUID(key.UserUUID)
+	if err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
+			utils.Translate("access_denied", nil, c),
+			-500,
+			errors.New(
+				utils.Translate(
+					"get_userinfo_failed",
+					nil,
+					c,
+				),
+			),
+		))
+	}
+
+	// only active users may use the api
+	if user_info.StatusID != constants.UserStatusActive {
+		return inactiveUser(c, user_info.StatusID)
+	}
+
+	// get the role of the user, routes check its permissions
+	user_role, err := auth.NewAuthRepoImpl(nil, DBPool).GetUserRole(user_info.ID)
+	if err != nil {
+		return c.Status(http.StatusUnprocessableEntity).JSON(response.NewResponseError(
+			utils.Translate("access_denied", nil, c),
+			-500,
+			errors.New(
+				utils.Translate(
+					"get_user_role_failed",
+					nil,
+					c,
+				),
+			),
+		))
+	}
+
+	uCtx := types.UserContext{
+		Id:          user_info.ID,
+		UserUuid:    user_info.UserUUID,
+		UserName:    user_info.UserName,
+		Exp:         key.ExpiresAt,
+		UserAgent:   string(c.Context().UserAgent()),
+		Ip:          string(c.Context().RemoteIP().String()),
+		StatusId:    user_info.StatusID,
+		Role:        user_role.RoleName,
+		Permissions: user_role.Scoped(key.Scopes),
+		ApiKey:      key.KeyUUID,
+		Language:    utils.Language(c),
 	}
 	c.Locals("UserContext", uCtx)
 
 	return c.Next()
 }
+
+// inactiveUser answers the request of a user that is not active
+func inactiveUser(c *fiber.Ctx, status_id int) error {
+	reason := "account_inactive"
+	if status_id == constants.UserStatusSuspended {
+		reason = "account_suspended"
+	}
+
+	return c.Status(http.StatusForbidden).JSON(response.NewResponseError(
+		utils.Translate("access_denied", nil, c),
+		-403,
+		errors.New(
+			utils.Translate(
+				reason,
+				nil,
+				c,
+			),
+		),
+	))
+}
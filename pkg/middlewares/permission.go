@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	response "tarantool-admin-api/pkg/http/response"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission lets the request through only when the role of the
+// user grants the permission, it runs after the jwt middleware
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		us_ctx, ok := c.Locals("UserContext").(types.UserContext)
+		if !ok || !us_ctx.HasPermission(permission) {
+			return c.Status(http.StatusForbidden).JSON(response.NewResponseError(
+				utils.Translate("access_denied", nil, c),
+				-403,
+				errors.New(
+					utils.Translate(
+						"permission_required",
+						map[string]interface{}{
+							"permission": permission,
+						},
+						c,
+					),
+				),
+			))
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireLogin lets the request through only when it is made with a login,
+// the routes without a permission act on the account of the user and an api
+// key, whatever its scopes, cannot use them
+func RequireLogin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		us_ctx, ok := c.Locals("UserContext").(types.UserContext)
+		if !ok || us_ctx.ApiKey != "" {
+			return c.Status(http.StatusForbidden).JSON(response.NewResponseError(
+				utils.Translate("access_denied", nil, c),
+				-403,
+				errors.New(utils.Translate("login_required", nil, c)),
+			))
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	response "tarantool-admin-api/pkg/http/response"
+	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/redis"
+	"tarantool-admin-api/pkg/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit lets at most limit requests through per window for every key
+// returned by key_of, requests without a key are not limited, a limit of 0
+// turns the limiter off
+func RateLimit(name string, limit int, window time.Duration, key_of func(c *fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := key_of(c)
+		if limit <= 0 || key == "" {
+			return c.Next()
+		}
+
+		rate, err := redis.AllowRate(context.Background(), fmt.Sprintf("rate:%s:%s", name, key), limit, window)
+		if err != nil {
+			// a redis outage must not take the api down with it
+			custom_log.NewCustomLog("rate_limit_failed", err.Error(), "error")
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(rate.Remaining))
+
+		if !rate.Allowed {
+			retry_after := retryAfterSeconds(rate.RetryAfter)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retry_after))
+
+			return c.Status(http.StatusTooManyRequests).JSON(response.NewResponseError(
+				utils.Translate("too_many_requests", nil, c),
+				-429,
+				errors.New(
+					utils.Translate(
+						"rate_limit_exceeded",
+						map[string]interface{}{
+							"seconds": retry_after,
+						},
+						c,
+					),
+				),
+			))
+		}
+
+		return c.Next()
+	}
+}
+
+// RateLimitByIP limits every client address on its own
+func RateLimitByIP(c *fiber.Ctx) string {
+	return c.IP()
+}
+
+// RateLimitByUser limits every user on its own whatever the device, it runs
+// after the jwt middleware
+func RateLimitByUser(c *fiber.Ctx) string {
+	us_ctx, ok := c.Locals("UserContext").(types.UserContext)
+	if !ok || us_ctx.Id == 0 {
+		return ""
+	}
+	return strconv.Itoa(us_ctx.Id)
+}
+
+// NewAuthRateLimit limits the requests every client address sends to the
+// public auth endpoints, it has to be registered before the auth routes
+func NewAuthRateLimit(app *fiber.App) {
+	app.Use("/api/v1/front/auth", RateLimit(
+		"auth",
+		utils.GetenvInt("AUTH_RATE_LIMIT", 20),
+		time.Duration(utils.GetenvInt("AUTH_RATE_WINDOW_SECONDS", 60))*time.Second,
+		RateLimitByIP,
+	))
+}
+
+// QueryRateLimit limits how many queries every user runs against its
+// databases
+func QueryRateLimit() fiber.Handler {
+	return RateLimit(
+		"query",
+		utils.GetenvInt("QUERY_RATE_LIMIT", 120),
+		time.Duration(utils.GetenvInt("QUERY_RATE_WINDOW_SECONDS", 60))*time.Second,
+		RateLimitByUser,
+	)
+}
+
+// retryAfterSeconds rounds a wait up to the whole seconds of a Retry-After
+// header
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
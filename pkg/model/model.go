@@ -18,7 +18,22 @@ type UserContext struct {
 	UserAgent    string
 	Ip           string
 	StatusId     int
+	Role         string
+	Permissions  []string
+	ApiKey       string
+	Language     string
 }
+
+// HasPermission reports whether the role of the user grants the permission
+func (u UserContext) HasPermission(permission string) bool {
+	for _, granted := range u.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
 type Paging struct {
 	Page    int `json:"page" query:"page" validate:"required,min=1"`
 	Perpage int `json:"per_page" query:"per_page" validate:"required,min=1"`
@@ -33,6 +48,14 @@ type Filter struct {
 	Value    interface{} `json:"value" validate:"required"`
 }
 
+// ListRequest is the paging, the sorts and the filters of a list endpoint
+type ListRequest struct {
+	Page    int
+	PerPage int
+	Sorts   []Sort
+	Filters []Filter
+}
+
 type FieldUuid struct {
 	Uuid uuid.UUID `json:"id"`
 }
@@ -0,0 +1,353 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	custom_log "tarantool-admin-api/pkg/logs"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrNotConfigured is returned when no identity provider is set up
+var ErrNotConfigured = errors.New("oidc provider is not configured")
+
+// signing algorithms accepted for id tokens, symmetric ones are left out
+// since the client secret must not be enough to forge a token
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+}
+
+// ConfigFromEnv reads the provider settings from the OIDC_* variables
+func ConfigFromEnv() Config {
+	scopes := strings.Fields(os.Getenv("OIDC_SCOPES"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email", "groups"}
+	}
+
+	groups_claim := os.Getenv("OIDC_GROUPS_CLAIM")
+	if groups_claim == "" {
+		groups_claim = "groups"
+	}
+
+	return Config{
+		Issuer:       os.Getenv("OIDC_ISSUER"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       scopes,
+		GroupsClaim:  groups_claim,
+	}
+}
+
+func (c Config) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != "" && c.RedirectURL != ""
+}
+
+// Discovery is the part of the provider metadata the login flow needs
+type Discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Claims are the verified claims of an id token
+type Claims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	GivenName         string
+	FamilyName        string
+	PreferredUsername string
+	Groups            []string
+}
+
+type Provider struct {
+	config    Config
+	discovery Discovery
+	jwks      *keyfunc.JWKS
+	client    *http.Client
+}
+
+// NewProvider loads the provider metadata and its signing keys, the keys
+// are fetched again when a token is signed with an unknown key until the
+// context is done
+func NewProvider(ctx context.Context, config Config, client *http.Client) (*Provider, error) {
+	if !config.Enabled() {
+		return nil, ErrNotConfigured
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	discovery_url := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery_url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error create discovery request : %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error get discovery document : %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error get discovery document : status %d", resp.StatusCode)
+	}
+
+	var discovery Discovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("error decode discovery document : %w", err)
+	}
+
+	// the metadata must come from the issuer it is configured for
+	if discovery.Issuer != config.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing an endpoint")
+	}
+	if len(discovery.CodeChallengeMethods) > 0 && !contains(discovery.CodeChallengeMethods, "S256") {
+		return nil, errors.New("provider does not support S256 code challenges")
+	}
+
+	jwks, err := keyfunc.Get(discovery.JWKSURI, keyfunc.Options{
+		Client:            client,
+		Ctx:               ctx,
+		RefreshUnknownKID: true,
+		RefreshRateLimit:  time.Minute,
+		RefreshTimeout:    10 * time.Second,
+		RefreshErrorHandler: func(err error) {
+			custom_log.NewCustomLog("oidc_jwks_refresh_failed", err.Error(), "error")
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error get jwks : %w", err)
+	}
+
+	return &Provider{
+		config:    config,
+		discovery: discovery,
+		jwks:      jwks,
+		client:    client,
+	}, nil
+}
+
+// Close stops the background refresh of the signing keys
+func (p *Provider) Close() {
+	p.jwks.EndBackground()
+}
+
+// AuthCodeURL returns the url the user signs in at, the code challenge is
+// derived from the verifier kept for the callback
+func (p *Provider) AuthCodeURL(state string, nonce string, code_verifier string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(code_verifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return p.discovery.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange trades the authorization code for the tokens of the user
+func (p *Provider) Exchange(ctx context.Context, code string, code_verifier string) (*Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", code_verifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error create token request : %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// public clients only rely on pkce
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error exchange code : %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var token_err tokenError
+		_ = json.NewDecoder(resp.Body).Decode(&token_err)
+		return nil, fmt.Errorf("error exchange code : status %d %s %s", resp.StatusCode, token_err.Error, token_err.ErrorDescription)
+	}
+
+	var token Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("error decode token response : %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return &token, nil
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of an id
+// token and returns its claims
+func (p *Provider) Verify(raw_id_token string, nonce string) (*Claims, error) {
+	map_claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(
+		raw_id_token,
+		map_claims,
+		p.jwks.Keyfunc,
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error verify id token : %w", err)
+	}
+
+	// a token issued to several clients must name us as its party
+	audience, _ := map_claims.GetAudience()
+	if len(audience) > 1 {
+		if azp, _ := map_claims["azp"].(string); azp != p.config.ClientID {
+			return nil, errors.New("id token is not authorized for this client")
+		}
+	}
+
+	if token_nonce, _ := map_claims["nonce"].(string); token_nonce == "" || token_nonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+
+	subject, _ := map_claims.GetSubject()
+	if subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	claims := Claims{
+		Issuer:            p.config.Issuer,
+		Subject:           subject,
+		Email:             stringClaim(map_claims, "email"),
+		EmailVerified:     boolClaim(map_claims, "email_verified"),
+		Name:              stringClaim(map_claims, "name"),
+		GivenName:         stringClaim(map_claims, "given_name"),
+		FamilyName:        stringClaim(map_claims, "family_name"),
+		PreferredUsername: stringClaim(map_claims, "preferred_username"),
+		Groups:            listClaim(map_claims, p.config.GroupsClaim),
+	}
+
+	return &claims, nil
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultProvider *Provider
+)
+
+// Default returns the provider configured by the environment, it is loaded
+// on first use and loaded again after a failure
+func Default() (*Provider, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultProvider != nil {
+		return defaultProvider, nil
+	}
+
+	provider, err := NewProvider(context.Background(), ConfigFromEnv(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defaultProvider = provider
+
+	return defaultProvider, nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// boolClaim reads a boolean claim, some providers send it as a string
+func boolClaim(claims jwt.MapClaims, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// listClaim reads a claim holding either a list of strings or one string
+func listClaim(claims jwt.MapClaims, name string) []string {
+	values := []string{}
+	switch value := claims[name].(type) {
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case string:
+		values = append(values, strings.Fields(strings.ReplaceAll(value, ",", " "))...)
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
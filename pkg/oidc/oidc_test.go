@@ -0,0 +1,412 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tarantool-admin-api/pkg/constants"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "tarantool-admin"
+	testClientSecret = "s3cret"
+	testRedirectURL  = "https://admin.example.com/sso/callback"
+)
+
+// stubIdP is a minimal identity provider serving discovery, jwks and the
+// token endpoint of the authorization code flow
+type stubIdP struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu    sync.Mutex
+	key   *rsa.PrivateKey
+	kid   string
+	codes map[string]stubGrant
+}
+
+type stubGrant struct {
+	challenge   string
+	redirectURI string
+	nonce       string
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+
+	idp := &stubIdP{
+		t:     t,
+		codes: map[string]stubGrant{},
+	}
+	idp.rotate("key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *stubIdP) issuer() string {
+	return idp.server.URL
+}
+
+func (idp *stubIdP) config() Config {
+	return Config{
+		Issuer:       idp.issuer(),
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "groups"},
+		GroupsClaim:  "groups",
+	}
+}
+
+// rotate replaces the signing key, the old key disappears from the jwks
+func (idp *stubIdP) rotate(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		idp.t.Fatalf("generate key: %v", err)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key = key
+	idp.kid = kid
+}
+
+func (idp *stubIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                           idp.issuer(),
+		"authorization_endpoint":           idp.issuer() + "/authorize",
+		"token_endpoint":                   idp.issuer() + "/token",
+		"jwks_uri":                         idp.issuer() + "/jwks",
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+func (idp *stubIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": idp.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize stands in for the user signing in at the provider, it returns
+// the code the provider would redirect back with
+func (idp *stubIdP) authorize(auth_url string) (code string, state string) {
+	idp.t.Helper()
+
+	parsed, err := url.Parse(auth_url)
+	if err != nil {
+		idp.t.Fatalf("parse auth url: %v", err)
+	}
+	query := parsed.Query()
+
+	if query.Get("response_type") != "code" || query.Get("client_id") != testClientID {
+		idp.t.Fatalf("unexpected authorization request %s", auth_url)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		idp.t.Fatalf("authorization request without pkce %s", auth_url)
+	}
+
+	code, _ = RandomString()
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.codes[code] = stubGrant{
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		nonce:       query.Get("nonce"),
+	}
+
+	return code, query.Get("state")
+}
+
+func (idp *stubIdP) token(w http.ResponseWriter, r *http.Request) {
+	fail := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	client_id, client_secret, ok := r.BasicAuth()
+	if !ok || client_id != testClientID || client_secret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		fail("unsupported_grant_type")
+		return
+	}
+
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	if !ok || grant.redirectURI != r.PostForm.Get("redirect_uri") {
+		fail("invalid_grant")
+		return
+	}
+	if CodeChallenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+		fail("invalid_grant")
+		return
+	}
+
+	claims := idp.idClaims(grant.nonce)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idp.sign(claims),
+	})
+}
+
+func (idp *stubIdP) idClaims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":                idp.issuer(),
+		"sub":                "user-123",
+		"aud":                testClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              nonce,
+		"email":              "Jane.Doe@example.com",
+		"email_verified":     true,
+		"preferred_username": "jane.doe",
+		"groups":             []string{"engineering", "dba"},
+	}
+}
+
+func (idp *stubIdP) sign(claims jwt.MapClaims) string {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.kid
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		idp.t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func newTestProvider(t *testing.T, idp *stubIdP) *Provider {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	provider, err := NewProvider(ctx, idp.config(), idp.server.Client())
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	return provider
+}
+
+func TestLoginFlow(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := newTestProvider(t, idp)
+
+	verifier, _ := RandomString()
+	auth_url := provider.AuthCodeURL("state-1", "nonce-1", verifier)
+	if !strings.HasPrefix(auth_url, idp.issuer()+"/authorize?") {
+		t.Fatalf("auth url %s does not point at the provider", auth_url)
+	}
+
+	code, state := idp.authorize(auth_url)
+	if state != "state-1" {
+		t.Fatalf("state = %q, want state-1", state)
+	}
+
+	token, err := provider.Exchange(context.Background(), code, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	claims, err := provider.Verify(token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "Jane.Doe@example.com" || !claims.EmailVerified {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if strings.Join(claims.Groups, ",") != "engineering,dba" {
+		t.Fatalf("groups = %v", claims.Groups)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := newTestProvider(t, idp)
+
+	verifier, _ := RandomString()
+	code, _ := idp.authorize(provider.AuthCodeURL("state", "nonce", verifier))
+
+	if _, err := provider.Exchange(context.Background(), code, "another-verifier"); err == nil {
+		t.Fatal("exchange with the wrong code verifier succeeded")
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := newTestProvider(t, idp)
+
+	verifier, _ := RandomString()
+	code, _ := idp.authorize(provider.AuthCodeURL("state", "nonce", verifier))
+
+	if _, err := provider.Exchange(context.Background(), code, verifier); err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if _, err := provider.Exchange(context.Background(), code, verifier); err == nil {
+		t.Fatal("exchange with a used code succeeded")
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := newTestProvider(t, idp)
+
+	other_key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"wrong nonce", func() string {
+			return idp.sign(idp.idClaims("other-nonce"))
+		}},
+		{"wrong audience", func() string {
+			claims := idp.idClaims("nonce")
+			claims["aud"] = "another-client"
+			return idp.sign(claims)
+		}},
+		{"wrong issuer", func() string {
+			claims := idp.idClaims("nonce")
+			claims["iss"] = "https://evil.example.com"
+			return idp.sign(claims)
+		}},
+		{"expired", func() string {
+			claims := idp.idClaims("nonce")
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return idp.sign(claims)
+		}},
+		{"no expiry", func() string {
+			claims := idp.idClaims("nonce")
+			delete(claims, "exp")
+			return idp.sign(claims)
+		}},
+		{"other audience as authorized party", func() string {
+			claims := idp.idClaims("nonce")
+			claims["aud"] = []string{testClientID, "another-client"}
+			claims["azp"] = "another-client"
+			return idp.sign(claims)
+		}},
+		{"unknown key", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.idClaims("nonce"))
+			token.Header["kid"] = "key-1"
+			signed, _ := token.SignedString(other_key)
+			return signed
+		}},
+		{"signed with the client secret", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, idp.idClaims("nonce"))
+			token.Header["kid"] = "key-1"
+			signed, _ := token.SignedString([]byte(testClientSecret))
+			return signed
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.Verify(tt.token(), "nonce"); err == nil {
+				t.Fatal("invalid id token was accepted")
+			}
+		})
+	}
+}
+
+func TestVerifyFollowsKeyRotation(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := newTestProvider(t, idp)
+
+	idp.rotate("key-2")
+
+	if _, err := provider.Verify(idp.sign(idp.idClaims("nonce")), "nonce"); err != nil {
+		t.Fatalf("verify after rotation: %v", err)
+	}
+}
+
+func TestNewProviderRejectsIssuerMismatch(t *testing.T) {
+	idp := newStubIdP(t)
+
+	config := idp.config()
+	config.Issuer = idp.issuer() + "/"
+
+	if _, err := NewProvider(context.Background(), config, idp.server.Client()); err == nil {
+		t.Fatal("provider with a mismatched issuer was accepted")
+	}
+}
+
+func TestNewProviderNotConfigured(t *testing.T) {
+	if _, err := NewProvider(context.Background(), Config{}, nil); err != ErrNotConfigured {
+		t.Fatalf("err = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestRoleMapping(t *testing.T) {
+	mapping := ParseRoleMapping("dba=operator, admins=admin,analysts=analyst,root=owner,bad=superuser,broken")
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   string
+	}{
+		{"no group", nil, constants.RoleViewer},
+		{"unmapped group", []string{"engineering"}, constants.RoleViewer},
+		{"one group", []string{"analysts"}, constants.RoleAnalyst},
+		{"highest role wins", []string{"analysts", "admins", "dba"}, constants.RoleAdmin},
+		{"owner is never mapped", []string{"root"}, constants.RoleViewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapping.Role(tt.groups, constants.RoleViewer); got != tt.want {
+				t.Fatalf("Role(%v) = %q, want %q", tt.groups, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := mapping["bad"]; ok {
+		t.Fatal("mapping to an unknown role was kept")
+	}
+}
@@ -0,0 +1,23 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// RandomString returns a url safe random value for states, nonces and code
+// verifiers
+func RandomString() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// CodeChallenge returns the S256 challenge of a pkce code verifier
+func CodeChallenge(code_verifier string) string {
+	sum := sha256.Sum256([]byte(code_verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
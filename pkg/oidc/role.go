@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+	"tarantool-admin-api/pkg/constants"
+)
+
+// RoleMapping maps a group of the identity provider to a role
+type RoleMapping map[string]string
+
+// ParseRoleMapping reads a mapping written as "group=role,group=role",
+// entries naming an unknown role are left out
+func ParseRoleMapping(value string) RoleMapping {
+	mapping := RoleMapping{}
+	for _, entry := range strings.Split(value, ",") {
+		group, role, ok := strings.Cut(entry, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			continue
+		}
+		if _, known := constants.RoleRank[role]; !known {
+			continue
+		}
+		mapping[group] = role
+	}
+	return mapping
+}
+
+// RoleMappingFromEnv reads the mapping from OIDC_ROLE_MAPPING
+func RoleMappingFromEnv() RoleMapping {
+	return ParseRoleMapping(os.Getenv("OIDC_ROLE_MAPPING"))
+}
+
+// DefaultRoleFromEnv returns the role of users in no mapped group
+func DefaultRoleFromEnv() string {
+	role := os.Getenv("OIDC_DEFAULT_ROLE")
+	if _, known := constants.RoleRank[role]; !known || role == constants.RoleOwner {
+		return constants.RoleViewer
+	}
+	return role
+}
+
+// Role returns the highest role the groups are mapped to, the owner role is
+// never handed out by the provider
+func (m RoleMapping) Role(groups []string, default_role string) string {
+	role := default_role
+	for _, group := range groups {
+		mapped, ok := m[group]
+		if !ok || mapped == constants.RoleOwner {
+			continue
+		}
+		if constants.RoleRank[mapped] > constants.RoleRank[role] {
+			role = mapped
+		}
+	}
+	return role
+}
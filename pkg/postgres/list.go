@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	types "tarantool-admin-api/pkg/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrFieldNotAllowed    = errors.New("field not allowed")
+	ErrOperatorNotAllowed = errors.New("operator not allowed")
+)
+
+// Operators are the filter operators BuildSQLFilter understands
+var Operators = []string{"eq", "neq", "lt", "lte", "gt", "gte", "like", "ilike", "in", "between"}
+
+// ListFields whitelists the fields of a list a client may filter and sort
+// on, a field is a column the list query returns unless Columns renames it
+type ListFields struct {
+	Filter  []string
+	Sort    []string
+	Columns map[string]string
+	// DefaultSort orders the rows after the sorts of the client, it keeps
+	// the pages stable, e.g. "id DESC". It applies to the columns the list
+	// query returns, a query sorted on id selects exactly one id column
+	DefaultSort string
+}
+
+// AllowsFilter reports whether a client may filter on a field
+func (l ListFields) AllowsFilter(field string) bool {
+	_, ok := l.column(field, l.Filter)
+	return ok
+}
+
+// AllowsSort reports whether a client may sort on a field
+func (l ListFields) AllowsSort(field string) bool {
+	_, ok := l.column(field, l.Sort)
+	return ok
+}
+
+// column returns the quoted column of an allowed field
+func (l ListFields) column(field string, allowed []string) (string, bool) {
+	for _, name := range allowed {
+		if name != field {
+			continue
+		}
+		if column, ok := l.Columns[name]; ok {
+			name = column
+		}
+		return `"` + name + `"`, true
+	}
+	return "", false
+}
+
+// SelectList runs a list query one page at a time and returns the number of
+// rows of every page, the query must not be ordered, the filters and the
+// sorts of the request apply to the columns it returns. The query is wrapped
+// in a subquery so its column names must be unique, joins select one id and
+// alias the others
+func SelectList(db sqlx.Queryer, dest interface{}, query string, args []interface{}, list_req types.ListRequest, fields ListFields) (int, error) {
+	where, filter_args, err := BuildSQLFilter(list_req.Filters, fields, len(args)+1)
+	if err != nil {
+		return 0, err
+	}
+	if where != "" {
+		where = "WHERE " + where
+	}
+
+	order, err := BuildSQLSort(list_req.Sorts, fields)
+	if err != nil {
+		return 0, err
+	}
+	if fields.DefaultSort != "" {
+		if order == "" {
+			order = "ORDER BY " + fields.DefaultSort
+		} else {
+			order += ", " + fields.DefaultSort
+		}
+	}
+
+	params := make([]interface{}, 0, len(args)+len(filter_args))
+	params = append(params, args...)
+	params = append(params, filter_args...)
+
+	// count the matching rows
+	count_query := fmt.Sprintf(`SELECT COUNT(*) FROM (%s) list_rows %s`, query, where)
+
+	var total int
+	if err := sqlx.Get(db, &total, count_query, params...); err != nil {
+		return 0, err
+	}
+
+	// prepare query
+	list_query := fmt.Sprintf(`SELECT * FROM (%s) list_rows %s %s %s`, query, where, order, BuildPaging(list_req.Page, list_req.PerPage))
+
+	// execute query
+	if err := sqlx.Select(db, dest, list_query, params...); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
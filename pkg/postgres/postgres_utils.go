@@ -11,11 +11,11 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-// built sql filter
-func BuildSQLFilter(filters []types.Filter) (string, []interface{}) {
+// built sql filter, a property must be one the list allows since it ends up
+// in the sql, the placeholders start at placeholder
+func BuildSQLFilter(filters []types.Filter, fields ListFields, placeholder int) (string, []interface{}, error) {
 	var clauses []string
 	var params []interface{}
-	placeholder := 1
 
 	// Get current OS time
 	app_timezone := os.Getenv("APP_TIMEZONE")
@@ -24,7 +24,7 @@ func BuildSQLFilter(filters []types.Filter) (string, []interface{}) {
 	}
 	location, err := time.LoadLocation(app_timezone)
 	if err != nil {
-		return "", nil
+		return "", nil, err
 	}
 
 	// convert value types
@@ -56,7 +56,10 @@ func BuildSQLFilter(filters []types.Filter) (string, []interface{}) {
 	}
 
 	for _, f := range filters {
-		field := f.Property
+		field, ok := fields.column(f.Property, fields.Filter)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s", ErrFieldNotAllowed, f.Property)
+		}
 		op := strings.ToLower(f.Operator)
 
 		switch op {
@@ -81,8 +84,8 @@ func BuildSQLFilter(filters []types.Filter) (string, []interface{}) {
 			params = append(params, f.Value)
 			placeholder++
 
-		case "like":
-			clauses = append(clauses, fmt.Sprintf("%s LIKE $%d", field, placeholder))
+		case "like", "ilike":
+			clauses = append(clauses, fmt.Sprintf("%s::TEXT %s $%d", field, strings.ToUpper(op), placeholder))
 			params = append(params, f.Value)
 			placeholder++
 
@@ -120,21 +123,27 @@ func BuildSQLFilter(filters []types.Filter) (string, []interface{}) {
 				params = append(params, vals[0], vals[1])
 				placeholder += 2
 			}
+
+		default:
+			return "", nil, fmt.Errorf("%w: %s", ErrOperatorNotAllowed, f.Operator)
 		}
 	}
 
 	if len(clauses) == 0 {
-		return "", nil
+		return "", nil, nil
 	}
-	return strings.Join(clauses, " AND "), params
+	return strings.Join(clauses, " AND "), params, nil
 }
 
-// built sql sort
-func BuildSQLSort(sorts []types.Sort) string {
+// built sql sort, a property must be one the list allows
+func BuildSQLSort(sorts []types.Sort, fields ListFields) (string, error) {
 	var orderClauses []string
 
 	for _, sort := range sorts {
-		field := sort.Property
+		field, ok := fields.column(sort.Property, fields.Sort)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrFieldNotAllowed, sort.Property)
+		}
 		direction := strings.ToUpper(sort.Direction)
 
 		// ensure the direction is either ASC or DESC
@@ -147,11 +156,11 @@ func BuildSQLSort(sorts []types.Sort) string {
 	}
 
 	if len(orderClauses) == 0 {
-		return ""
+		return "", nil
 	}
 
 	// join the clauses with commas and return the final order by string
-	return "ORDER BY " + strings.Join(orderClauses, ", ")
+	return "ORDER BY " + strings.Join(orderClauses, ", "), nil
 }
 
 // built sql paging
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// extend the lock only while it is still held by the same owner
+var extendLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	end
+	return 0
+`)
+
+// release the lock only while it is still held by the same owner
+var releaseLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0
+`)
+
+// AcquireLock takes the lock for owner or extends it when owner already holds
+// it, it returns false when another owner holds the lock
+func AcquireLock(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	client := NewRedis()
+
+	ok, err := client.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+
+	extended, err := extendLockScript.Run(ctx, client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return extended == 1, nil
+}
+
+// ReleaseLock drops the lock when it is held by owner
+func ReleaseLock(ctx context.Context, key string, owner string) error {
+	return releaseLockScript.Run(ctx, NewRedis(), []string{key}, owner).Err()
+}
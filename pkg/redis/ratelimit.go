@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// count the hits of the last window and record the new one when it fits,
+// every hit is kept in a sorted set scored by its time in milliseconds
+var slidingWindowScript = redis.NewScript(`
+	local now = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local limit = tonumber(ARGV[3])
+
+	redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+	local count = redis.call("ZCARD", KEYS[1])
+	if count < limit then
+		redis.call("ZADD", KEYS[1], now, ARGV[4])
+		redis.call("PEXPIRE", KEYS[1], window)
+		return {1, limit - count - 1, 0}
+	end
+
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// Rate is the outcome of a hit against a sliding window
+type Rate struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// AllowRate records a hit on key when fewer than limit hits happened during
+// the last window, a refused hit tells how long until the oldest one expires
+func AllowRate(ctx context.Context, key string, limit int, window time.Duration) (*Rate, error) {
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+
+	values, err := slidingWindowScript.Run(ctx, NewRedis(), []string{key}, now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rate{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
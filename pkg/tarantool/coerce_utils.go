@@ -0,0 +1,220 @@
+package tarantool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tarantool/go-tarantool/v2/datetime"
+	"github.com/tarantool/go-tarantool/v2/decimal"
+)
+
+// CoerceValue converts a decoded json/csv value into the go type matching
+// a tarantool field type so it is encoded with the right msgpack type
+func CoerceValue(value interface{}, field_type string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch strings.ToLower(field_type) {
+	case "unsigned":
+		return toUnsigned(value)
+	case "integer":
+		return toInteger(value)
+	case "number", "double":
+		return toNumber(value)
+	case "string":
+		return toString(value), nil
+	case "boolean":
+		return toBoolean(value)
+	case "varbinary":
+		return []byte(toString(value)), nil
+	case "uuid":
+		if id, ok := value.(uuid.UUID); ok {
+			return id, nil
+		}
+		return uuid.Parse(toString(value))
+	case "decimal":
+		if dec, ok := value.(decimal.Decimal); ok {
+			return dec, nil
+		}
+		return decimal.MakeDecimalFromString(toString(value))
+	case "datetime":
+		switch val := value.(type) {
+		case datetime.Datetime, *datetime.Datetime:
+			return val, nil
+		case time.Time:
+			return datetime.MakeDatetime(val)
+		}
+		t, err := time.Parse(time.RFC3339Nano, toString(value))
+		if err != nil {
+			return nil, err
+		}
+		return datetime.MakeDatetime(t)
+	default:
+		return normalizeNumbers(value), nil
+	}
+}
+
+// ToJSONValue converts tarantool extension types without a json
+// representation (datetime) into strings, recursively
+func ToJSONValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case datetime.Datetime:
+		return val.ToTime().Format(time.RFC3339Nano)
+	case *datetime.Datetime:
+		return val.ToTime().Format(time.RFC3339Nano)
+	case []interface{}:
+		converted := make([]interface{}, len(val))
+		for i, elem := range val {
+			converted[i] = ToJSONValue(elem)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(val))
+		for k, elem := range val {
+			converted[k] = ToJSONValue(elem)
+		}
+		return converted
+	}
+	return value
+}
+
+func toString(value interface{}) string {
+	switch val := value.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case json.Number:
+		return val.String()
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func toUnsigned(value interface{}) (interface{}, error) {
+	switch val := value.(type) {
+	case uint64:
+		return val, nil
+	case int64:
+		if val < 0 {
+			return nil, fmt.Errorf("value %d is negative", val)
+		}
+		return uint64(val), nil
+	case float64:
+		if val < 0 || val != math.Trunc(val) {
+			return nil, fmt.Errorf("value %v is not an unsigned integer", val)
+		}
+		return uint64(val), nil
+	}
+
+	parsed, err := strconv.ParseUint(strings.TrimSpace(toString(value)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("value %v is not an unsigned integer", value)
+	}
+	return parsed, nil
+}
+
+func toInteger(value interface{}) (interface{}, error) {
+	switch val := value.(type) {
+	case int64, uint64:
+		return val, nil
+	case float64:
+		if val != math.Trunc(val) {
+			return nil, fmt.Errorf("value %v is not an integer", val)
+		}
+		return int64(val), nil
+	}
+
+	str := strings.TrimSpace(toString(value))
+	if parsed, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := strconv.ParseUint(str, 10, 64); err == nil {
+		return parsed, nil
+	}
+	return nil, fmt.Errorf("value %v is not an integer", value)
+}
+
+func toNumber(value interface{}) (interface{}, error) {
+	switch val := value.(type) {
+	case int64, uint64, float64:
+		return val, nil
+	}
+
+	str := strings.TrimSpace(toString(value))
+	if parsed, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := strconv.ParseFloat(str, 64); err == nil {
+		return parsed, nil
+	}
+	return nil, fmt.Errorf("value %v is not a number", value)
+}
+
+func toBoolean(value interface{}) (interface{}, error) {
+	if val, ok := value.(bool); ok {
+		return val, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(toString(value))) {
+	case "true", "1", "yes":
+		return true, nil
+	case "false", "0", "no":
+		return false, nil
+	}
+	return nil, fmt.Errorf("value %v is not a boolean", value)
+}
+
+// normalizeNumbers turns json.Number into int64/uint64/float64, recursively
+func normalizeNumbers(value interface{}) interface{} {
+	switch val := value.(type) {
+	case json.Number:
+		if parsed, err := val.Int64(); err == nil {
+			return parsed
+		}
+		if parsed, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return parsed
+		}
+		if parsed, err := val.Float64(); err == nil {
+			return parsed
+		}
+		return val.String()
+	case []interface{}:
+		for i, elem := range val {
+			val[i] = normalizeNumbers(elem)
+		}
+	case map[string]interface{}:
+		for k, elem := range val {
+			val[k] = normalizeNumbers(elem)
+		}
+	}
+	return value
+}
+
+// CoerceTuple coerces every field of a tuple according to the space format,
+// fields beyond the format are kept with numbers normalized
+func CoerceTuple(tuple []interface{}, format []SpaceField) ([]interface{}, error) {
+	coerced := make([]interface{}, len(tuple))
+	for i, value := range tuple {
+		field_type := ""
+		if i < len(format) {
+			field_type = format[i].Type
+		}
+
+		converted, err := CoerceValue(value, field_type)
+		if err != nil {
+			if i < len(format) {
+				return nil, fmt.Errorf("field %s: %w", format[i].Name, err)
+			}
+			return nil, fmt.Errorf("field %d: %w", i+1, err)
+		}
+		coerced[i] = converted
+	}
+	return coerced, nil
+}
@@ -0,0 +1,135 @@
+package tarantool
+
+import (
+	"encoding/json"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+// LuaValue is one value returned by a lua chunk, value holds its json encoding
+type LuaValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type LuaEvalResult struct {
+	Results   []LuaValue `json:"results"`
+	Error     *string    `json:"error"`
+	Traceback *string    `json:"traceback"`
+}
+
+// lua chunk to run user code in protected mode, every result is json encoded
+// on the server so values msgpack cannot carry (functions, cycles, cdata)
+// still come back, errors are returned with their traceback
+const evalLua = `
+	local code, args = ...
+
+	local serializer = require('json').new()
+	serializer.cfg({
+		encode_use_tostring = true,
+		encode_invalid_as_nil = true,
+		encode_deep_as_nil = true,
+		encode_max_depth = 32,
+		encode_sparse_convert = true,
+	})
+
+	local decimal = require('decimal')
+	local uuid = require('uuid')
+	local ok_datetime, datetime = pcall(require, 'datetime')
+
+	local function type_of(value)
+		if box.tuple.is(value) then
+			return 'tuple'
+		elseif decimal.is_decimal(value) then
+			return 'decimal'
+		elseif uuid.is_uuid(value) then
+			return 'uuid'
+		elseif ok_datetime and datetime.is_datetime(value) then
+			return 'datetime'
+		elseif value == box.NULL and type(value) == 'cdata' then
+			return 'nil'
+		end
+		return type(value)
+	end
+
+	local chunk, err = load(code, '=eval')
+	if chunk == nil then
+		return {ok = false, results = {}, error = tostring(err), traceback = ''}
+	end
+
+	local n = args.n or #args
+	local packed = {xpcall(function()
+		return chunk(unpack(args, 1, n))
+	end, function(e)
+		return {message = tostring(e), traceback = debug.traceback(tostring(e), 2)}
+	end)}
+
+	if not packed[1] then
+		local failure = packed[2]
+		return {ok = false, results = {}, error = failure.message, traceback = failure.traceback}
+	end
+
+	local results = {}
+	for i = 2, table.maxn(packed) do
+		local value = packed[i]
+		local encoded_ok, encoded = pcall(serializer.encode, value)
+		if not encoded_ok then
+			encoded = serializer.encode(tostring(value))
+		end
+		table.insert(results, {type = type_of(value), value = encoded})
+	end
+
+	return {ok = true, results = results, error = '', traceback = ''}
+`
+
+type evalResponse struct {
+	OK      bool `msgpack:"ok"`
+	Results []struct {
+		Type  string `msgpack:"type"`
+		Value string `msgpack:"value"`
+	} `msgpack:"results"`
+	Error     string `msgpack:"error"`
+	Traceback string `msgpack:"traceback"`
+}
+
+// EvalLua runs a lua chunk with arguments, json.Number arguments are turned
+// into integers or floats, a lua error is returned in the result and only
+// transport errors are returned as error
+func EvalLua(conn *pool.ConnectionPool, code string, args []interface{}) (*LuaEvalResult, error) {
+	normalized := make([]interface{}, len(args))
+	for i, arg := range args {
+		normalized[i] = normalizeNumbers(arg)
+	}
+
+	var response []evalResponse
+	err := conn.Do(
+		tarantool.NewEvalRequest(evalLua).Args([]interface{}{code, normalized}),
+		pool.RW,
+	).GetTyped(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LuaEvalResult{
+		Results: []LuaValue{},
+	}
+	if len(response) == 0 {
+		return result, nil
+	}
+
+	if !response[0].OK {
+		result.Error = &response[0].Error
+		result.Traceback = &response[0].Traceback
+		return result, nil
+	}
+
+	for _, value := range response[0].Results {
+		result.Results = append(result.Results, LuaValue{
+			Type:  value.Type,
+			Value: json.RawMessage(value.Value),
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package tarantool
+
+import (
+	"fmt"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+// MigrationsSpace is the space tracking the migrations applied on a database
+const MigrationsSpace = "_migrations"
+
+type AppliedMigration struct {
+	Version   uint64 `msgpack:"version" json:"version"`
+	Name      string `msgpack:"name" json:"name"`
+	Checksum  string `msgpack:"checksum" json:"checksum"`
+	AppliedAt string `msgpack:"applied_at" json:"applied_at"`
+	AppliedBy string `msgpack:"applied_by" json:"applied_by"`
+}
+
+// lua chunk to list the applied migrations by version
+const appliedMigrationsLua = `
+	local space = box.space._migrations
+	local result = {}
+	if space == nil then
+		return result
+	end
+	for _, t in space:pairs() do
+		table.insert(result, {
+			version = t[1], name = t[2], checksum = t[3],
+			applied_at = t[4], applied_by = t[5],
+		})
+	end
+	return result
+`
+
+// lua chunk to run the up script of a migration and record it, the
+// _migrations space is created on first use
+const applyMigrationLua = `
+	local version, name, checksum, script, applied_by = ...
+
+	local space = box.schema.space.create('_migrations', {
+		if_not_exists = true,
+		format = {
+			{name = 'version', type = 'unsigned'},
+			{name = 'name', type = 'string'},
+			{name = 'checksum', type = 'string'},
+			{name = 'applied_at', type = 'string'},
+			{name = 'applied_by', type = 'string'},
+		},
+	})
+	space:create_index('primary', {parts = {'version'}, if_not_exists = true})
+
+	if space:get(version) ~= nil then
+		error('migration ' .. version .. ' is already applied')
+	end
+
+	local chunk, err = load(script, '=' .. name)
+	if chunk == nil then
+		error(err)
+	end
+	local ok, err = xpcall(chunk, debug.traceback)
+	if not ok then
+		error(err)
+	end
+
+	space:insert({version, name, checksum, os.date('!%Y-%m-%dT%H:%M:%SZ'), applied_by})
+	return true
+`
+
+// lua chunk to run the down script of an applied migration and forget it
+const rollbackMigrationLua = `
+	local version, name, script = ...
+
+	local space = box.space._migrations
+	if space == nil or space:get(version) == nil then
+		error('migration ' .. version .. ' is not applied')
+	end
+
+	local chunk, err = load(script, '=' .. name)
+	if chunk == nil then
+		error(err)
+	end
+	local ok, err = xpcall(chunk, debug.traceback)
+	if not ok then
+		error(err)
+	end
+
+	space:delete(version)
+	return true
+`
+
+// GetAppliedMigrations returns the migrations recorded in the _migrations
+// space, empty when the space does not exist yet
+func GetAppliedMigrations(conn *pool.ConnectionPool) ([]AppliedMigration, error) {
+	var result [][]AppliedMigration
+	if err := conn.Do(tarantool.NewEvalRequest(appliedMigrationsLua), pool.RW).GetTyped(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 || result[0] == nil {
+		return []AppliedMigration{}, nil
+	}
+
+	return result[0], nil
+}
+
+// ApplyMigration runs an up script on the database and records its version
+func ApplyMigration(conn *pool.ConnectionPool, version uint64, name string, checksum string, script string, applied_by string) error {
+	_, err := conn.Do(
+		tarantool.NewEvalRequest(applyMigrationLua).Args([]interface{}{version, name, checksum, script, applied_by}),
+		pool.RW,
+	).Get()
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", version, name, err)
+	}
+
+	return nil
+}
+
+// RollbackMigration runs a down script on the database and removes the
+// version from the applied migrations
+func RollbackMigration(conn *pool.ConnectionPool, version uint64, name string, script string) error {
+	_, err := conn.Do(
+		tarantool.NewEvalRequest(rollbackMigrationLua).Args([]interface{}{version, name, script}),
+		pool.RW,
+	).Get()
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", version, name, err)
+	}
+
+	return nil
+}
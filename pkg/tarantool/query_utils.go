@@ -0,0 +1,61 @@
+package tarantool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+// lua chunk to read one page of an sql select by wrapping it in a sub query
+const queryPageLua = `
+	local sql, limit, offset = ...
+	local res, err = box.execute('SELECT * FROM (' .. sql .. ') LIMIT ? OFFSET ?', {limit, offset})
+	if res == nil then
+		error(tostring(err))
+	end
+	return res.metadata or {}, res.rows or {}
+`
+
+// QueryPage runs one page of an sql select and returns its columns and rows
+func QueryPage(conn *pool.ConnectionPool, sql string, limit int, offset int) ([]ColumnMeta, [][]interface{}, error) {
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+
+	data, err := conn.Do(
+		tarantool.NewEvalRequest(queryPageLua).Args([]interface{}{sql, limit, offset}),
+		pool.ANY,
+	).Get()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(data) < 2 {
+		return nil, nil, fmt.Errorf("unexpected query page result")
+	}
+
+	var columns []ColumnMeta
+	metadata, _ := NormalizeValue(data[0]).([]interface{})
+	for _, meta := range metadata {
+		meta_map, ok := meta.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		columns = append(columns, ColumnMeta{
+			Name: getString(meta_map, "name"),
+			Type: getString(meta_map, "type"),
+		})
+	}
+
+	raw_rows, _ := data[1].([]interface{})
+	rows := make([][]interface{}, 0, len(raw_rows))
+	for _, raw_row := range raw_rows {
+		row, ok := raw_row.([]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected row format in query result")
+		}
+		rows = append(rows, NormalizeValue(row).([]interface{}))
+	}
+
+	return columns, rows, nil
+}
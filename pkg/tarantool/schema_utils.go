@@ -0,0 +1,196 @@
+package tarantool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+type SequenceSchema struct {
+	Name  string `msgpack:"name" json:"name"`
+	Step  int64  `msgpack:"step" json:"step"`
+	Min   int64  `msgpack:"min" json:"min"`
+	Max   int64  `msgpack:"max" json:"max"`
+	Start int64  `msgpack:"start" json:"start"`
+	Cache int64  `msgpack:"cache" json:"cache"`
+	Cycle bool   `msgpack:"cycle" json:"cycle"`
+}
+
+type FunctionSchema struct {
+	Name            string   `msgpack:"name" json:"name"`
+	Language        string   `msgpack:"language" json:"language"`
+	Body            string   `msgpack:"body" json:"body"`
+	Setuid          bool     `msgpack:"setuid" json:"setuid"`
+	IsDeterministic bool     `msgpack:"is_deterministic" json:"is_deterministic"`
+	IsSandboxed     bool     `msgpack:"is_sandboxed" json:"is_sandboxed"`
+	Returns         string   `msgpack:"returns" json:"returns"`
+	ParamList       []string `msgpack:"param_list" json:"param_list"`
+	Exports         []string `msgpack:"exports" json:"exports"`
+}
+
+type PrivilegeSchema struct {
+	ObjectType string   `msgpack:"object_type" json:"object_type"`
+	ObjectName string   `msgpack:"object_name" json:"object_name"`
+	Privileges []string `msgpack:"privileges" json:"privileges"`
+}
+
+type UserSchema struct {
+	Name       string            `msgpack:"name" json:"name"`
+	Type       string            `msgpack:"type" json:"type"`
+	Privileges []PrivilegeSchema `msgpack:"privileges" json:"privileges"`
+}
+
+// SchemaSnapshot is the full user defined schema of a database
+type SchemaSnapshot struct {
+	Spaces    []SpaceSchema    `msgpack:"spaces" json:"spaces"`
+	Sequences []SequenceSchema `msgpack:"sequences" json:"sequences"`
+	Functions []FunctionSchema `msgpack:"functions" json:"functions"`
+	Users     []UserSchema     `msgpack:"users" json:"users"`
+}
+
+// lua chunk to describe sequences, functions, users and their privileges,
+// system objects are left out
+const schemaObjectsLua = `
+	local function sorted(list)
+		table.sort(list, function(a, b) return a.name < b.name end)
+		return list
+	end
+
+	local sequences = {}
+	for _, t in box.space._vsequence:pairs() do
+		table.insert(sequences, {
+			name = t[3], step = t[4], min = t[5], max = t[6],
+			start = t[7], cache = t[8], cycle = t[9],
+		})
+	end
+
+	local functions = {}
+	local function_names = {}
+	for _, t in box.space._vfunc:pairs() do
+		local name, language = t[3], t[5]
+		function_names[t[1]] = name
+		if name:sub(1, 4) ~= 'box.' and name ~= 'LUA' and language ~= 'SQL_BUILTIN' then
+			local opts = t:tomap({names_only = true})
+			table.insert(functions, {
+				name = name,
+				language = language,
+				body = opts.body or '',
+				setuid = opts.setuid == 1 or opts.setuid == true,
+				is_deterministic = opts.is_deterministic or false,
+				is_sandboxed = opts.is_sandboxed or false,
+				returns = opts.returns or 'any',
+				param_list = opts.param_list or {},
+				exports = opts.exports or {},
+			})
+		end
+	end
+
+	-- guest, admin, public, replication and super are built in
+	local system_users = {[0] = true, [1] = true, [2] = true, [3] = true, [31] = true}
+	local user_names = {}
+	for _, t in box.space._vuser:pairs() do
+		user_names[t[1]] = t[3]
+	end
+
+	local band = require('bit').band
+	local privilege_bits = {
+		{1, 'read'}, {2, 'write'}, {4, 'execute'}, {8, 'session'},
+		{16, 'usage'}, {32, 'create'}, {64, 'drop'}, {128, 'alter'},
+		{256, 'reference'}, {512, 'trigger'}, {1024, 'insert'},
+		{2048, 'update'}, {4096, 'delete'},
+	}
+
+	local function object_name(object_type, object_id)
+		if object_type == 'space' then
+			local space = box.space[object_id]
+			return space and space.name or tostring(object_id)
+		elseif object_type == 'function' then
+			return function_names[object_id] or tostring(object_id)
+		elseif object_type == 'sequence' then
+			local sequence = box.space._vsequence:get(object_id)
+			return sequence and sequence[3] or tostring(object_id)
+		elseif object_type == 'role' or object_type == 'user' then
+			return user_names[object_id] or tostring(object_id)
+		end
+		return ''
+	end
+
+	local users = {}
+	for _, t in box.space._vuser:pairs() do
+		if not system_users[t[1]] then
+			local privileges = {}
+			for _, p in box.space._vpriv.index.primary:pairs({t[1]}) do
+				local names = {}
+				for _, bit in ipairs(privilege_bits) do
+					if band(p[5], bit[1]) ~= 0 then
+						table.insert(names, bit[2])
+					end
+				end
+				table.insert(privileges, {
+					object_type = p[3],
+					object_name = object_name(p[3], p[4]),
+					privileges = names,
+				})
+			end
+			table.sort(privileges, function(a, b)
+				return a.object_type .. ':' .. a.object_name < b.object_type .. ':' .. b.object_name
+			end)
+			table.insert(users, {name = t[3], type = t[4], privileges = privileges})
+		end
+	end
+
+	return {
+		sequences = sorted(sequences),
+		functions = sorted(functions),
+		users = sorted(users),
+	}
+`
+
+// GetSchema reads the full user defined schema of a database
+func GetSchema(conn *pool.ConnectionPool) (*SchemaSnapshot, error) {
+	spaces, err := GetUserSpaces(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []SchemaSnapshot
+	if err := conn.Do(tarantool.NewEvalRequest(schemaObjectsLua), pool.ANY).GetTyped(&result); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("unexpected schema result")
+	}
+
+	snapshot := result[0]
+	snapshot.Spaces = spaces
+	if snapshot.Sequences == nil {
+		snapshot.Sequences = []SequenceSchema{}
+	}
+	if snapshot.Functions == nil {
+		snapshot.Functions = []FunctionSchema{}
+	}
+	if snapshot.Users == nil {
+		snapshot.Users = []UserSchema{}
+	}
+
+	// tuple counts change all the time and are not part of the schema
+	for i := range snapshot.Spaces {
+		snapshot.Spaces[i].TupleCount = 0
+	}
+
+	return &snapshot, nil
+}
+
+// Checksum returns a sha256 of the snapshot, equal schemas have equal checksums
+func (s *SchemaSnapshot) Checksum() (string, error) {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
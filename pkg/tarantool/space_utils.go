@@ -0,0 +1,294 @@
+package tarantool
+
+import (
+	"fmt"
+
+	"github.com/tarantool/go-tarantool/v2"
+	_ "github.com/tarantool/go-tarantool/v2/datetime"
+	_ "github.com/tarantool/go-tarantool/v2/decimal"
+	"github.com/tarantool/go-tarantool/v2/pool"
+	_ "github.com/tarantool/go-tarantool/v2/uuid"
+)
+
+type SpaceField struct {
+	Name       string `msgpack:"name" json:"name"`
+	Type       string `msgpack:"type" json:"type"`
+	IsNullable bool   `msgpack:"is_nullable" json:"is_nullable"`
+}
+
+type IndexPart struct {
+	Field      uint32 `msgpack:"field" json:"field"`
+	Type       string `msgpack:"type" json:"type"`
+	IsNullable bool   `msgpack:"is_nullable" json:"is_nullable"`
+	Path       string `msgpack:"path" json:"path,omitempty"`
+}
+
+type IndexSchema struct {
+	ID     uint32      `msgpack:"id" json:"id"`
+	Name   string      `msgpack:"name" json:"name"`
+	Type   string      `msgpack:"type" json:"type"`
+	Unique bool        `msgpack:"unique" json:"unique"`
+	Parts  []IndexPart `msgpack:"parts" json:"parts"`
+}
+
+type SpaceSchema struct {
+	ID          uint32        `msgpack:"id" json:"id"`
+	Name        string        `msgpack:"name" json:"name"`
+	Engine      string        `msgpack:"engine" json:"engine"`
+	FieldCount  uint32        `msgpack:"field_count" json:"field_count"`
+	IsTemporary bool          `msgpack:"is_temporary" json:"is_temporary"`
+	Format      []SpaceField  `msgpack:"format" json:"format"`
+	Indexes     []IndexSchema `msgpack:"indexes" json:"indexes"`
+	TupleCount  uint64        `msgpack:"tuple_count" json:"tuple_count"`
+}
+
+// lua chunk to describe every user space (name not starting with "_")
+const userSpacesLua = `
+	local result = {}
+	for name, space in pairs(box.space) do
+		if type(name) == 'string' and name:sub(1, 1) ~= '_' then
+			local format = {}
+			for _, field in ipairs(space:format()) do
+				table.insert(format, {
+					name = field.name,
+					type = field.type,
+					is_nullable = field.is_nullable or false,
+				})
+			end
+
+			local indexes = {}
+			for id, index in pairs(space.index) do
+				if type(id) == 'number' then
+					local parts = {}
+					for _, part in ipairs(index.parts) do
+						table.insert(parts, {
+							field = part.fieldno,
+							type = part.type,
+							is_nullable = part.is_nullable or false,
+							path = part.path,
+						})
+					end
+					table.insert(indexes, {
+						id = id,
+						name = index.name,
+						type = index.type,
+						unique = index.unique or false,
+						parts = parts,
+					})
+				end
+			end
+			table.sort(indexes, function(a, b) return a.id < b.id end)
+
+			local tuple_count = 0
+			if space.index[0] ~= nil then
+				tuple_count = space:len()
+			end
+
+			table.insert(result, {
+				id = space.id,
+				name = name,
+				engine = space.engine,
+				field_count = space.field_count,
+				is_temporary = space.temporary or false,
+				format = format,
+				indexes = indexes,
+				tuple_count = tuple_count,
+			})
+		end
+	end
+	table.sort(result, function(a, b) return a.id < b.id end)
+	return result
+`
+
+// lua chunk to read one page of a space by its primary index, optionally
+// bounded by a key range, returning the tuples, the primary key of the last
+// tuple and whether the scan is over
+const scanSpaceLua = `
+	local space_name, after, limit, from, to = ...
+	local space = box.space[space_name]
+	if space == nil then
+		error('space ' .. space_name .. ' does not exist')
+	end
+
+	local index = space.index[0]
+	if index == nil then
+		return {}, box.NULL, true
+	end
+
+	local tuples
+	if after ~= nil then
+		tuples = index:select(after, {iterator = 'GT', limit = limit})
+	elseif from ~= nil then
+		tuples = index:select(from, {iterator = 'GE', limit = limit})
+	else
+		tuples = index:select({}, {iterator = 'ALL', limit = limit})
+	end
+
+	local key_def = require('key_def').new(index.parts)
+	local rows, done = {}, #tuples < limit
+	for _, tuple in ipairs(tuples) do
+		if to ~= nil and key_def:compare_with_key(tuple, to) > 0 then
+			done = true
+			break
+		end
+		table.insert(rows, tuple:totable())
+	end
+
+	local last_key = box.NULL
+	if #tuples > 0 then
+		last_key = key_def:extract_key(tuples[#tuples]):totable()
+	end
+
+	return rows, last_key, done
+`
+
+// lua chunk to count a key range of a space and checksum its tuples, the
+// checksum is a crc32 over the msgpack encoding of every tuple in key order
+const checksumSpaceLua = `
+	local space_name, from, to = ...
+	local space = box.space[space_name]
+	if space == nil then
+		error('space ' .. space_name .. ' does not exist')
+	end
+
+	local index = space.index[0]
+	if index == nil then
+		return 0, '00000000'
+	end
+
+	local msgpack = require('msgpack')
+	local fiber = require('fiber')
+	local key_def = require('key_def').new(index.parts)
+	local crc = require('digest').crc32.new()
+	local count = 0
+
+	local iterator, key = 'ALL', {}
+	if from ~= nil then
+		iterator, key = 'GE', from
+	end
+
+	for _, tuple in index:pairs(key, {iterator = iterator}) do
+		if to ~= nil and key_def:compare_with_key(tuple, to) > 0 then
+			break
+		end
+		crc:update(msgpack.encode(tuple:totable()))
+		count = count + 1
+		if count % 1000 == 0 then
+			fiber.yield()
+		end
+	end
+
+	return count, string.format('%08x', crc:result())
+`
+
+// GetUserSpaces returns the schema of every non-system space
+func GetUserSpaces(conn *pool.ConnectionPool) ([]SpaceSchema, error) {
+	var result [][]SpaceSchema
+	err := conn.Do(tarantool.NewEvalRequest(userSpacesLua), pool.ANY).GetTyped(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 || result[0] == nil {
+		return []SpaceSchema{}, nil
+	}
+
+	return result[0], nil
+}
+
+// ScanSpace walks a space by its primary index in pages of batch_size tuples
+// and calls fn for every non-empty page until the space is exhausted
+func ScanSpace(conn *pool.ConnectionPool, space_name string, batch_size int, fn func(tuples [][]interface{}) error) error {
+	return ScanSpaceRange(conn, space_name, nil, nil, batch_size, fn)
+}
+
+// ScanSpaceRange is ScanSpace limited to the primary keys between from and
+// to (both included), a nil bound leaves that side of the range open
+func ScanSpaceRange(conn *pool.ConnectionPool, space_name string, from []interface{}, to []interface{}, batch_size int, fn func(tuples [][]interface{}) error) error {
+	if batch_size < 1 {
+		batch_size = 1000
+	}
+
+	var after interface{}
+	for {
+		data, err := conn.Do(
+			tarantool.NewEvalRequest(scanSpaceLua).Args([]interface{}{space_name, after, batch_size, bound(from), bound(to)}),
+			pool.ANY,
+		).Get()
+		if err != nil {
+			return err
+		}
+
+		if len(data) < 3 {
+			return fmt.Errorf("unexpected scan result for space %s", space_name)
+		}
+
+		rows, _ := data[0].([]interface{})
+		tuples := make([][]interface{}, 0, len(rows))
+		for _, row := range rows {
+			tuple, ok := row.([]interface{})
+			if !ok {
+				return fmt.Errorf("unexpected tuple format in space %s", space_name)
+			}
+			tuples = append(tuples, NormalizeValue(tuple).([]interface{}))
+		}
+
+		if len(tuples) > 0 {
+			if err := fn(tuples); err != nil {
+				return err
+			}
+		}
+
+		if done, _ := data[2].(bool); done || len(rows) == 0 {
+			return nil
+		}
+		after = data[1]
+	}
+}
+
+type SpaceChecksum struct {
+	Count    uint64 `json:"count"`
+	Checksum string `json:"checksum"`
+}
+
+// ChecksumSpace counts the tuples of a primary key range and checksums them
+// on the server, two ranges holding the same tuples get the same checksum
+func ChecksumSpace(conn *pool.ConnectionPool, space_name string, from []interface{}, to []interface{}) (*SpaceChecksum, error) {
+	var result []interface{}
+	err := conn.Do(
+		tarantool.NewEvalRequest(checksumSpaceLua).Args([]interface{}{space_name, bound(from), bound(to)}),
+		pool.ANY,
+	).GetTyped(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) < 2 {
+		return nil, fmt.Errorf("unexpected checksum result for space %s", space_name)
+	}
+
+	count, err := toUnsigned(result[0])
+	if err != nil {
+		return nil, err
+	}
+	checksum, _ := result[1].(string)
+
+	return &SpaceChecksum{
+		Count:    count.(uint64),
+		Checksum: checksum,
+	}, nil
+}
+
+// bound turns an empty key into nil so lua sees an open range
+func bound(key []interface{}) interface{} {
+	if len(key) == 0 {
+		return nil
+	}
+	return key
+}
+
+// NormalizeValue converts msgpack maps with interface keys into
+// map[string]interface{} recursively so the value can be encoded to json
+func NormalizeValue(value interface{}) interface{} {
+	return convertMapInterfaceToString(value)
+}
@@ -0,0 +1,146 @@
+package tarantool
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// kinds of sql statements
+	StatementRead        = "read"
+	StatementDML         = "dml"
+	StatementDDL         = "ddl"
+	StatementTransaction = "transaction"
+	StatementPragma      = "pragma"
+	StatementUnknown     = "unknown"
+)
+
+var statementKinds = map[string]string{
+	"SELECT":    StatementRead,
+	"VALUES":    StatementRead,
+	"EXPLAIN":   StatementRead,
+	"INSERT":    StatementDML,
+	"UPDATE":    StatementDML,
+	"DELETE":    StatementDML,
+	"REPLACE":   StatementDML,
+	"CREATE":    StatementDDL,
+	"ALTER":     StatementDDL,
+	"DROP":      StatementDDL,
+	"TRUNCATE":  StatementDDL,
+	"START":     StatementTransaction,
+	"BEGIN":     StatementTransaction,
+	"COMMIT":    StatementTransaction,
+	"ROLLBACK":  StatementTransaction,
+	"SAVEPOINT": StatementTransaction,
+	"RELEASE":   StatementTransaction,
+	"PRAGMA":    StatementPragma,
+}
+
+type Statement struct {
+	SQL     string `json:"sql"`
+	Keyword string `json:"keyword"`
+	Kind    string `json:"kind"`
+}
+
+// ClassifyStatements splits sql into statements on top level semicolons and
+// classifies each one by its leading keyword, strings, quoted identifiers and
+// comments are skipped so their content never changes the classification
+func ClassifyStatements(sql string) ([]Statement, error) {
+	var statements []Statement
+	var keywords []string
+	start := 0
+
+	flush := func(end int) {
+		text := strings.TrimSpace(sql[start:end])
+		if len(keywords) > 0 {
+			statements = append(statements, Statement{
+				SQL:     text,
+				Keyword: keywords[0],
+				Kind:    classify(keywords),
+			})
+		}
+		keywords = nil
+		start = end + 1
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at position %d", i)
+			}
+			i += end + 4
+		case c == '\'' || c == '"':
+			end, err := skipQuoted(sql, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case c == ';':
+			flush(i)
+			i++
+		case isWordByte(c):
+			end := i
+			for end < len(sql) && (isWordByte(sql[end]) || unicode.IsDigit(rune(sql[end]))) {
+				end++
+			}
+			keywords = append(keywords, strings.ToUpper(sql[i:end]))
+			i = end
+		default:
+			i++
+		}
+	}
+	flush(len(sql))
+
+	return statements, nil
+}
+
+// classify returns the kind of a statement from its words, a WITH clause
+// takes the kind of the statement it introduces
+func classify(keywords []string) string {
+	if keywords[0] == "WITH" {
+		for _, keyword := range keywords[1:] {
+			switch keyword {
+			case "INSERT", "UPDATE", "DELETE", "REPLACE":
+				return StatementDML
+			}
+		}
+		return StatementRead
+	}
+
+	if kind, ok := statementKinds[keywords[0]]; ok {
+		return kind
+	}
+	return StatementUnknown
+}
+
+// skipQuoted returns the position after the quoted string or identifier
+// starting at i, a doubled quote is an escaped quote
+func skipQuoted(sql string, i int) (int, error) {
+	quote := sql[i]
+	for j := i + 1; j < len(sql); j++ {
+		if sql[j] != quote {
+			continue
+		}
+		if j+1 < len(sql) && sql[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1, nil
+	}
+	return 0, fmt.Errorf("unterminated quote at position %d", i)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
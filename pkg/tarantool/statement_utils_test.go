@@ -0,0 +1,182 @@
+package tarantool
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []Statement
+	}{
+		{
+			name: "single select",
+			sql:  "SELECT * FROM users",
+			want: []Statement{
+				{SQL: "SELECT * FROM users", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "leading whitespace and lowercase keyword",
+			sql:  "\n\t  select id from users",
+			want: []Statement{
+				{SQL: "select id from users", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "mixed case dml",
+			sql:  "  Delete FROM users WHERE id = 1",
+			want: []Statement{
+				{SQL: "Delete FROM users WHERE id = 1", Keyword: "DELETE", Kind: StatementDML},
+			},
+		},
+		{
+			name: "semicolon inside single quotes",
+			sql:  "SELECT * FROM users WHERE name = 'a; DROP TABLE users'",
+			want: []Statement{
+				{SQL: "SELECT * FROM users WHERE name = 'a; DROP TABLE users'", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "semicolon inside an escaped quote",
+			sql:  "SELECT 'it''s; fine'",
+			want: []Statement{
+				{SQL: "SELECT 'it''s; fine'", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "semicolon inside a quoted identifier",
+			sql:  `SELECT "a;b" FROM t`,
+			want: []Statement{
+				{SQL: `SELECT "a;b" FROM t`, Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "semicolon inside a line comment",
+			sql:  "SELECT 1 -- ; DROP TABLE users\nFROM t",
+			want: []Statement{
+				{SQL: "SELECT 1 -- ; DROP TABLE users\nFROM t", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "semicolon inside a block comment",
+			sql:  "SELECT /* ; DROP TABLE users; */ 1",
+			want: []Statement{
+				{SQL: "SELECT /* ; DROP TABLE users; */ 1", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "leading comments do not give the keyword",
+			sql:  "-- DROP TABLE users\n/* DELETE */ SELECT 1",
+			want: []Statement{
+				{SQL: "-- DROP TABLE users\n/* DELETE */ SELECT 1", Keyword: "SELECT", Kind: StatementRead},
+			},
+		},
+		{
+			name: "with select is read",
+			sql:  "WITH recent AS (SELECT * FROM users) SELECT * FROM recent",
+			want: []Statement{
+				{SQL: "WITH recent AS (SELECT * FROM users) SELECT * FROM recent", Keyword: "WITH", Kind: StatementRead},
+			},
+		},
+		{
+			name: "with insert is dml",
+			sql:  "WITH src AS (SELECT 1 AS id) INSERT INTO users SELECT id FROM src",
+			want: []Statement{
+				{SQL: "WITH src AS (SELECT 1 AS id) INSERT INTO users SELECT id FROM src", Keyword: "WITH", Kind: StatementDML},
+			},
+		},
+		{
+			name: "with update is dml",
+			sql:  "with ids as (select id from users) update users set active = false",
+			want: []Statement{
+				{SQL: "with ids as (select id from users) update users set active = false", Keyword: "WITH", Kind: StatementDML},
+			},
+		},
+		{
+			name: "with delete is dml",
+			sql:  "WITH old AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM old)",
+			want: []Statement{
+				{SQL: "WITH old AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM old)", Keyword: "WITH", Kind: StatementDML},
+			},
+		},
+		{
+			name: "dml keyword quoted in a with select stays read",
+			sql:  "WITH t AS (SELECT 'DELETE' AS word) SELECT word FROM t",
+			want: []Statement{
+				{SQL: "WITH t AS (SELECT 'DELETE' AS word) SELECT word FROM t", Keyword: "WITH", Kind: StatementRead},
+			},
+		},
+		{
+			name: "select and ddl mixed",
+			sql:  "SELECT 1; CREATE TABLE t (id INTEGER PRIMARY KEY);\n drop table t;",
+			want: []Statement{
+				{SQL: "SELECT 1", Keyword: "SELECT", Kind: StatementRead},
+				{SQL: "CREATE TABLE t (id INTEGER PRIMARY KEY)", Keyword: "CREATE", Kind: StatementDDL},
+				{SQL: "drop table t", Keyword: "DROP", Kind: StatementDDL},
+			},
+		},
+		{
+			name: "transaction and pragma",
+			sql:  "START TRANSACTION; PRAGMA table_info(t); COMMIT",
+			want: []Statement{
+				{SQL: "START TRANSACTION", Keyword: "START", Kind: StatementTransaction},
+				{SQL: "PRAGMA table_info(t)", Keyword: "PRAGMA", Kind: StatementPragma},
+				{SQL: "COMMIT", Keyword: "COMMIT", Kind: StatementTransaction},
+			},
+		},
+		{
+			name: "unknown keyword",
+			sql:  "GRANT READ ON SPACE users TO guest",
+			want: []Statement{
+				{SQL: "GRANT READ ON SPACE users TO guest", Keyword: "GRANT", Kind: StatementUnknown},
+			},
+		},
+		{
+			name: "unknown keyword after a select",
+			sql:  "SELECT 1; call box.schema.user.grant()",
+			want: []Statement{
+				{SQL: "SELECT 1", Keyword: "SELECT", Kind: StatementRead},
+				{SQL: "call box.schema.user.grant()", Keyword: "CALL", Kind: StatementUnknown},
+			},
+		},
+		{
+			name: "empty statements are dropped",
+			sql:  " ; ;-- nothing\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClassifyStatements(tt.sql)
+			if err != nil {
+				t.Fatalf("ClassifyStatements(%q) failed: %v", tt.sql, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyStatements(%q)\n got  %+v\n want %+v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyStatementsRejectsUnterminated(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{name: "single quote", sql: "SELECT 'abc; DROP TABLE users"},
+		{name: "double quote", sql: `SELECT "abc FROM t`},
+		{name: "block comment", sql: "SELECT 1 /* DROP TABLE users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ClassifyStatements(tt.sql); err == nil {
+				t.Errorf("ClassifyStatements(%q) = %+v, want an error", tt.sql, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package tarantool
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+const (
+	WriteModeInsert  = "insert"
+	WriteModeReplace = "replace"
+	WriteModeUpsert  = "upsert"
+	WriteModeSkip    = "skip"
+)
+
+type RowError struct {
+	Row   int    `msgpack:"row" json:"row"`
+	Error string `msgpack:"error" json:"error"`
+}
+
+type WriteResult struct {
+	Written int        `msgpack:"written" json:"written"`
+	Skipped int        `msgpack:"skipped" json:"skipped"`
+	Errors  []RowError `msgpack:"errors" json:"errors"`
+}
+
+// lua chunk to write a batch of tuples in one transaction
+const writeTuplesLua = `
+	local space_name, tuples, mode, atomic = ...
+	local space = box.space[space_name]
+	if space == nil then
+		error('space ' .. space_name .. ' does not exist')
+	end
+
+	local primary = {}
+	if space.index[0] ~= nil then
+		for _, part in ipairs(space.index[0].parts) do
+			primary[part.fieldno] = true
+		end
+	end
+
+	local written, skipped, errors = 0, 0, {}
+	box.begin()
+	for i, tuple in ipairs(tuples) do
+		local ok, err
+		if mode == 'replace' then
+			ok, err = pcall(space.replace, space, tuple)
+		elseif mode == 'upsert' then
+			local ops = {}
+			for field = 1, #tuple do
+				if not primary[field] then
+					table.insert(ops, {'=', field, tuple[field]})
+				end
+			end
+			ok, err = pcall(space.upsert, space, tuple, ops)
+		else
+			ok, err = pcall(space.insert, space, tuple)
+		end
+
+		if ok then
+			written = written + 1
+		elseif mode == 'skip' and type(err) == 'cdata' and err.code == box.error.TUPLE_FOUND then
+			skipped = skipped + 1
+		else
+			table.insert(errors, {row = i, error = tostring(err)})
+			if atomic then
+				box.rollback()
+				return {written = 0, skipped = 0, errors = errors}
+			end
+		end
+	end
+	box.commit()
+
+	return {written = written, skipped = skipped, errors = errors}
+`
+
+// lua chunk to create a space with its format and indexes
+const createSpaceLua = `
+	local schema, drop_existing = ...
+	if drop_existing and box.space[schema.name] ~= nil then
+		box.space[schema.name]:drop()
+	end
+
+	local format = {}
+	for _, field in ipairs(schema.format or {}) do
+		table.insert(format, {name = field.name, type = field.type, is_nullable = field.is_nullable})
+	end
+
+	local space = box.schema.space.create(schema.name, {
+		engine = schema.engine ~= '' and schema.engine or nil,
+		format = format,
+	})
+
+	for _, index in ipairs(schema.indexes or {}) do
+		local parts = {}
+		for _, part in ipairs(index.parts) do
+			table.insert(parts, {
+				field = part.field,
+				type = part.type,
+				is_nullable = part.is_nullable,
+				path = part.path ~= '' and part.path or nil,
+			})
+		end
+		space:create_index(index.name, {type = index.type, unique = index.unique, parts = parts})
+	end
+
+	return true
+`
+
+// WriteTuples writes a batch of tuples in a single transaction using mode
+// (insert, replace, upsert or skip). When atomic is set the whole batch is
+// rolled back on the first failing tuple, otherwise failing rows are reported
+// and the rest of the batch is committed
+func WriteTuples(conn *pool.ConnectionPool, space_name string, tuples [][]interface{}, mode string, atomic bool) (*WriteResult, error) {
+	switch mode {
+	case WriteModeInsert, WriteModeReplace, WriteModeUpsert, WriteModeSkip:
+	default:
+		return nil, fmt.Errorf("unsupported write mode: %s", mode)
+	}
+
+	var result []WriteResult
+	err := conn.Do(
+		tarantool.NewEvalRequest(writeTuplesLua).Args([]interface{}{space_name, tuples, mode, atomic}),
+		pool.RW,
+	).GetTyped(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("unexpected write result for space %s", space_name)
+	}
+
+	return &result[0], nil
+}
+
+// CreateSpace creates a space from its schema, dropping an existing space
+// with the same name first when drop_existing is set
+func CreateSpace(conn *pool.ConnectionPool, schema SpaceSchema, drop_existing bool) error {
+	_, err := conn.Do(
+		tarantool.NewEvalRequest(createSpaceLua).Args([]interface{}{schema, drop_existing}),
+		pool.RW,
+	).Get()
+	return err
+}
+
+// CompareSchema lists the differences in format and indexes between the
+// expected schema and the actual one, an empty result means they match
+func CompareSchema(expected SpaceSchema, actual SpaceSchema) []string {
+	var diffs []string
+
+	if expected.Engine != actual.Engine {
+		diffs = append(diffs, fmt.Sprintf("engine differs: %s != %s", expected.Engine, actual.Engine))
+	}
+
+	if !reflect.DeepEqual(nonNilFields(expected.Format), nonNilFields(actual.Format)) {
+		diffs = append(diffs, "format differs")
+	}
+
+	actual_indexes := make(map[string]IndexSchema, len(actual.Indexes))
+	for _, index := range actual.Indexes {
+		actual_indexes[index.Name] = index
+	}
+
+	expected_names := make(map[string]bool, len(expected.Indexes))
+	for _, index := range expected.Indexes {
+		expected_names[index.Name] = true
+
+		existing, ok := actual_indexes[index.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("index %s is missing", index.Name))
+			continue
+		}
+
+		if existing.ID != index.ID ||
+			existing.Type != index.Type ||
+			existing.Unique != index.Unique ||
+			!reflect.DeepEqual(nonNilParts(existing.Parts), nonNilParts(index.Parts)) {
+			diffs = append(diffs, fmt.Sprintf("index %s differs", index.Name))
+		}
+	}
+
+	for _, index := range actual.Indexes {
+		if !expected_names[index.Name] {
+			diffs = append(diffs, fmt.Sprintf("index %s is not expected", index.Name))
+		}
+	}
+
+	return diffs
+}
+
+func nonNilFields(fields []SpaceField) []SpaceField {
+	if fields == nil {
+		return []SpaceField{}
+	}
+	return fields
+}
+
+func nonNilParts(parts []IndexPart) []IndexPart {
+	if parts == nil {
+		return []IndexPart{}
+	}
+	return parts
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// key of the postgres advisory lock serializing the sealing of audit rows
+const auditChainLockKey = 7310390
+
+// AuditChainRow is a tbl_users_audits row as it is hashed into the chain
+type AuditChainRow struct {
+	ID            uint64     `db:"id"`
+	UserAuditUUID string     `db:"user_audit_uuid"`
+	UserID        int        `db:"user_id"`
+	AuditContext  string     `db:"user_audit_context"`
+	AuditDesc     string     `db:"user_audit_desc"`
+	AuditTypeID   int        `db:"audit_type_id"`
+	DBID          *uint64    `db:"db_id"`
+	UserAgent     string     `db:"user_agent"`
+	Operator      string     `db:"operator"`
+	IP            string     `db:"ip"`
+	StatusID      int        `db:"status_id"`
+	Order         *int       `db:"order"`
+	CreatedBy     int        `db:"created_by"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedBy     *int       `db:"updated_by"`
+	UpdatedAt     *time.Time `db:"updated_at"`
+	DeletedBy     *int       `db:"deleted_by"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+	ChainSeq      *uint64    `db:"chain_seq"`
+	PrevHash      *string    `db:"prev_hash"`
+	RowHash       *string    `db:"row_hash"`
+}
+
+// SelectAuditChainRow selects the columns of AuditChainRow
+const SelectAuditChainRow = `
+	SELECT
+		id, user_audit_uuid, user_id, user_audit_context, user_audit_desc,
+		audit_type_id, db_id, user_agent, operator, ip, status_id, "order",
+		created_by, created_at, updated_by, updated_at, deleted_by, deleted_at,
+		chain_seq, prev_hash, row_hash
+	FROM tbl_users_audits
+`
+
+// Hash returns the hash of the row chained on prev_hash, every column but
+// row_hash is part of it so editing any of them breaks the chain
+func (r AuditChainRow) Hash(chain_seq uint64, prev_hash string) string {
+	h := sha256.New()
+
+	write := func(value string) {
+		fmt.Fprintf(h, "%d:%s;", len(value), value)
+	}
+
+	write(strconv.FormatUint(chain_seq, 10))
+	write(prev_hash)
+	write(strconv.FormatUint(r.ID, 10))
+	write(r.UserAuditUUID)
+	write(strconv.Itoa(r.UserID))
+	write(r.AuditContext)
+	write(r.AuditDesc)
+	write(strconv.Itoa(r.AuditTypeID))
+	writeNullable(h, write, r.DBID)
+	write(r.UserAgent)
+	write(r.Operator)
+	write(r.IP)
+	write(strconv.Itoa(r.StatusID))
+	writeNullable(h, write, r.Order)
+	write(strconv.Itoa(r.CreatedBy))
+	write(chainTime(r.CreatedAt))
+	writeNullable(h, write, r.UpdatedBy)
+	writeNullable(h, write, r.UpdatedAt)
+	writeNullable(h, write, r.DeletedBy)
+	writeNullable(h, write, r.DeletedAt)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SealUserAudits links every audit row that is not in the chain yet, in
+// insertion order, rows are sealed one writer at a time so the chain never
+// forks
+func SealUserAudits(dbPool *sqlx.DB) error {
+	tx, err := dbPool.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, auditChainLockKey); err != nil {
+		return err
+	}
+
+	// get the end of the chain
+	var last struct {
+		ChainSeq uint64 `db:"chain_seq"`
+		RowHash  string `db:"row_hash"`
+	}
+	err = tx.Get(&last, `
+		SELECT chain_seq, row_hash
+		FROM tbl_users_audits
+		WHERE chain_seq IS NOT NULL
+		ORDER BY chain_seq DESC
+		LIMIT 1
+	`)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// get the rows waiting to be sealed
+	var rows []AuditChainRow
+	if err := tx.Select(&rows, SelectAuditChainRow+`
+		WHERE chain_seq IS NULL
+		ORDER BY id
+	`); err != nil {
+		return err
+	}
+
+	chain_seq, prev_hash := last.ChainSeq, last.RowHash
+	for _, row := range rows {
+		chain_seq++
+		row_hash := row.Hash(chain_seq, prev_hash)
+
+		if _, err := tx.Exec(`
+			UPDATE tbl_users_audits SET
+				chain_seq = $1, prev_hash = $2, row_hash = $3
+			WHERE id = $4
+		`, chain_seq, prev_hash, row_hash, row.ID); err != nil {
+			return err
+		}
+		prev_hash = row_hash
+	}
+
+	return tx.Commit()
+}
+
+// writeNullable writes a nullable column, null and zero values hash
+// differently
+func writeNullable[T any](h hash.Hash, write func(string), value *T) {
+	if value == nil {
+		h.Write([]byte("-;"))
+		return
+	}
+
+	switch v := any(*value).(type) {
+	case time.Time:
+		write(chainTime(v))
+	default:
+		write(fmt.Sprint(v))
+	}
+}
+
+// chainTime formats a timestamp column the way postgres stores it, without
+// a time zone
+func chainTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.999999")
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	custom_log "tarantool-admin-api/pkg/logs"
+	types "tarantool-admin-api/pkg/model"
 	postgres "tarantool-admin-api/pkg/postgres"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ func AddUserAuditLog(
 	auditContext string,
 	auditDesc string,
 	auditTypeID int,
+	dbID *uint64,
 	userAgent string,
 	userName string,
 	ip string,
@@ -45,12 +47,12 @@ func AddUserAuditLog(
 	// 4. Build and execute insert query
 	query := `INSERT INTO tbl_users_audits (
 		id, user_audit_uuid, user_id, user_audit_context, user_audit_desc,
-		audit_type_id, user_agent, operator, ip, status_id,
+		audit_type_id, db_id, user_agent, operator, ip, status_id,
 		"order", created_by, created_at
 	) VALUES (
 		$1, $2, $3, $4, $5,
-		$6, $7, $8, $9, $10,
-		$11, $12, $13
+		$6, $7, $8, $9, $10, $11,
+		$12, $13, $14
 	)`
 
 	_, err = dbPool.Exec(
@@ -61,6 +63,7 @@ func AddUserAuditLog(
 		auditContext,
 		auditDesc,
 		auditTypeID,
+		dbID,
 		userAgent,
 		userName,
 		ip,
@@ -74,6 +77,37 @@ func AddUserAuditLog(
 		return nil, err
 	}
 
+	// 5. Link the row into the audit chain, a row left unsealed is picked up
+	// by the next seal
+	if err := SealUserAudits(dbPool); err != nil {
+		custom_log.NewCustomLog("user_audit_seal_failed", err.Error(), "error")
+	}
+
 	success := true
 	return &success, nil
 }
+
+// AuditUserAction records an action of the user in the context along with the
+// ip and user agent of the request, db_id is set when the action targets a
+// database, a failure is only logged so it never breaks the action itself
+func AuditUserAction(
+	usCtx *types.UserContext,
+	auditContext string,
+	auditDesc string,
+	auditTypeID int,
+	dbID *uint64,
+	dbPool *sqlx.DB,
+) {
+	_, _ = AddUserAuditLog(
+		usCtx.Id,
+		auditContext,
+		auditDesc,
+		auditTypeID,
+		dbID,
+		usCtx.UserAgent,
+		usCtx.UserName,
+		usCtx.Ip,
+		usCtx.Id,
+		dbPool,
+	)
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	types "tarantool-admin-api/pkg/model"
+	"tarantool-admin-api/pkg/postgres"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// list paging when the client asks for none, and the largest page it gets
+const (
+	ListPerPage    = 50
+	ListMaxPerPage = 500
+)
+
+// BindList reads the paging, the sorts and the filters of a list from the
+// query string, e.g.
+//
+//	?page=2&per_page=20&sort=-created_at,db_name
+//	&job_status[eq]=completed&created_at[between]=2025-07-01,2025-07-31
+//
+// a sort on -field is descending, the values of in and between are comma
+// separated, a field must be one the list allows and other parameters are
+// left to the endpoint
+func BindList(c *fiber.Ctx, list_req *types.ListRequest, fields postgres.ListFields) error {
+	list_req.Page = 1
+	list_req.PerPage = ListPerPage
+
+	var err error
+	c.Context().QueryArgs().VisitAll(func(key []byte, value []byte) {
+		if err != nil {
+			return
+		}
+		name, val := string(key), string(value)
+
+		switch {
+		case name == "page":
+			list_req.Page, err = listNumber(c, name, val, 1<<31-1)
+		case name == "per_page":
+			list_req.PerPage, err = listNumber(c, name, val, ListMaxPerPage)
+		case name == "sort":
+			for _, property := range strings.Split(val, ",") {
+				property = strings.TrimSpace(property)
+				if property == "" {
+					continue
+				}
+				direction := "asc"
+				if strings.HasPrefix(property, "-") {
+					property, direction = property[1:], "desc"
+				}
+				if !fields.AllowsSort(property) {
+					err = errors.New(Translate("list_sort_not_allowed", map[string]interface{}{"field": property}, c))
+					return
+				}
+				list_req.Sorts = append(list_req.Sorts, types.Sort{Property: property, Direction: direction})
+			}
+		case strings.HasSuffix(name, "]") && strings.Contains(name, "["):
+			open := strings.Index(name, "[")
+			property, operator := name[:open], strings.ToLower(name[open+1:len(name)-1])
+			if !fields.AllowsFilter(property) {
+				err = errors.New(Translate("list_filter_not_allowed", map[string]interface{}{"field": property}, c))
+				return
+			}
+			if !listOperator(operator) {
+				err = errors.New(Translate("list_operator_not_allowed", map[string]interface{}{"operator": operator}, c))
+				return
+			}
+
+			var filter_value interface{} = val
+			if operator == "in" || operator == "between" {
+				values := []interface{}{}
+				for _, v := range strings.Split(val, ",") {
+					values = append(values, strings.TrimSpace(v))
+				}
+				if operator == "between" && len(values) != 2 {
+					err = errors.New(Translate("invalid", map[string]interface{}{"field": name}, c))
+					return
+				}
+				filter_value = values
+			}
+			list_req.Filters = append(list_req.Filters, types.Filter{Property: property, Operator: operator, Value: filter_value})
+		}
+	})
+
+	return err
+}
+
+func listNumber(c *fiber.Ctx, name string, value string, max int) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 || number > max {
+		return 0, errors.New(Translate("invalid", map[string]interface{}{"field": name}, c))
+	}
+	return number, nil
+}
+
+func listOperator(operator string) bool {
+	for _, allowed := range postgres.Operators {
+		if allowed == operator {
+			return true
+		}
+	}
+	return false
+}
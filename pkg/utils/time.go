@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"os"
+	"time"
+)
+
+// Now returns the current time in the APP_TIMEZONE location,
+// falling back to the local time when the timezone cannot be loaded
+func Now() time.Time {
+	location, err := time.LoadLocation(os.Getenv("APP_TIMEZONE"))
+	if err != nil {
+		return time.Now()
+	}
+	return time.Now().In(location)
+}
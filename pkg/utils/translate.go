@@ -23,3 +23,12 @@ func Translate(messageID string, params map[string]interface{}, c *fiber.Ctx) st
 	}
 	return translate
 }
+
+// Language returns the language the client asked for, the same way the
+// translations of the response pick it
+func Language(c *fiber.Ctx) string {
+	if lang := c.Query("lang"); lang != "" {
+		return lang
+	}
+	return c.Get(fiber.HeaderAcceptLanguage)
+}
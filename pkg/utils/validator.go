@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"fmt"
+	"errors"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -35,7 +35,7 @@ func (v *Validator) Validate(i interface{}, c *fiber.Ctx) error {
 
 	error_string := strings.Join(error_messages, ", ")
 
-	return fmt.Errorf(strings.ToLower(error_string))
+	return errors.New(strings.ToLower(error_string))
 }
 
 func formatErrorMessage(e validator.FieldError, c *fiber.Ctx) string {
@@ -15,6 +15,8 @@ func New() *fiber.App {
 	f := fiber.New(fiber.Config{
 		// EnablePrintRoutes: true,
 		ErrorHandler: utils.GlobalErrorHandler,
+		// allow archive uploads larger than the default 4MB body limit
+		BodyLimit: utils.GetenvInt("BODY_LIMIT_MB", 100) * 1024 * 1024,
 	})
 	f.Use(logger.New())
 	f.Use("/ws", func(c *fiber.Ctx) error {